@@ -0,0 +1,29 @@
+// ==========================================================================================
+// Embeds a gzipped copy of the frontend folder, making the binary smaller
+// Build with `-tags compressfrontend` after running `go generate`, which creates dist/frontend
+// ==========================================================================================
+
+//go:build compressfrontend
+
+package kubeview
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed all:dist/frontend
+var compressedFiles embed.FS
+
+// FrontendFS holds the frontend files, under the frontend directory, decompressed as they are read
+var FrontendFS fs.FS = GunzipFS(mustSub(compressedFiles, "dist"))
+
+// mustSub is fs.Sub for a directory known to be valid, which can't fail
+func mustSub(fsys fs.FS, dir string) fs.FS {
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+
+	return sub
+}
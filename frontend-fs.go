@@ -3,11 +3,24 @@
 //
 // It's at the root of the repo because it needs to be here, asking further questions
 //  - about this is not going to be helpful, just accept it and move on
+//
+// Release builds embed a compressed copy instead, see frontend-fs-compressed.go
+// This uncompressed version is used during development, so nothing needs generating first
 // ==========================================================================================
 
+//go:build !compressfrontend
+
+//go:generate go run ./scripts/compress-frontend -src frontend -dst dist/frontend
+
 package kubeview
 
-import "embed"
+import (
+	"embed"
+	"io/fs"
+)
 
 //go:embed all:frontend
-var FrontendFS embed.FS
+var frontendFiles embed.FS
+
+// FrontendFS holds the frontend files, under the frontend directory
+var FrontendFS fs.FS = frontendFiles
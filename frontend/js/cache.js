@@ -98,9 +98,9 @@ export function clearCache() {
 /**
  * Get the timestamp of an event resource
  * @param {EventResource} event The event resource
- * @returns {string} The event timestamp, either from eventTime or lastTimestamp
+ * @returns {string} The event timestamp, either from lastTimestamp or eventTime
  */
 export function getTimestamp(event) {
-  // Use eventTime if available, otherwise fall back to lastTimestamp
-  return event.eventTime || event.lastTimestamp || event.metadata.creationTimestamp || ''
+  // Repeated events have lastTimestamp set to when they were last seen, eventTime is when they first happened
+  return event.lastTimestamp || event.eventTime || event.metadata.creationTimestamp || ''
 }
@@ -28,6 +28,7 @@ const defaultConfig = {
     // 'Secret',
     // 'PersistentVolumeClaim',
     // 'HorizontalPodAutoscaler',
+    // 'NetworkPolicy',
   ],
 }
 
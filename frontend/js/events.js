@@ -31,12 +31,16 @@ export function getClientId() {
 /**
  * Set up the event streaming connection to receive live updates
  * from the server for Kubernetes resources
+ * @param {boolean} reconnecting - True when reconnecting, so the state stays disconnected until open
  */
-export function initEventStreaming() {
+export function initEventStreaming(reconnecting = false) {
   console.log('🌐 Opening event stream...')
   const updateStream = new EventSource(`updates?clientID=${getClientId()}`, {})
-  state = 'connecting'
-  notifyStateChange()
+
+  if (!reconnecting) {
+    state = 'connecting'
+    notifyStateChange()
+  }
 
   // Handle resource add events from the server
   updateStream.addEventListener('add', async function (event) {
@@ -95,6 +99,17 @@ export function initEventStreaming() {
     await layout()
   })
 
+  // The server is going away, e.g. during a redeploy, so wait a random time before reconnecting
+  // This spreads out the reconnects from all clients, rather than them all arriving at once
+  updateStream.addEventListener('shutdown', function () {
+    console.warn('🛑 Server is shutting down, will reconnect shortly')
+    updateStream.close()
+    state = 'disconnected'
+    notifyStateChange()
+
+    setTimeout(() => initEventStreaming(true), 5000 + Math.random() * 10000)
+  })
+
   // Notify when the stream is connected
   updateStream.onopen = function () {
     console.log('✅ Event stream ready:', updateStream.readyState === 1)
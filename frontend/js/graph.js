@@ -313,6 +313,15 @@ export function processLinks(res) {
     }
   }
 
+  // Link a NetworkPolicy to the pods it applies to, an empty podSelector selects all pods
+  if (res.kind === 'NetworkPolicy' && res.spec?.podSelector) {
+    const pods = queryRes((r) => r.kind === 'Pod' && selectorMatches(res.spec.podSelector, r.metadata.labels || {}))
+    for (const pod of pods) {
+      if (getConfig().debug) console.log(`🔗 Linking NetworkPolicy ${res.metadata.name} to Pod ${pod.metadata.name}`)
+      addEdge(res.metadata.uid, pod.metadata.uid)
+    }
+  }
+
   // Try to link a HPA to the target resource
   if (res.kind === 'HorizontalPodAutoscaler' && res.spec?.scaleTargetRef) {
     const targetKind = res.spec.scaleTargetRef.kind
@@ -328,6 +337,30 @@ export function processLinks(res) {
   }
 }
 
+/**
+ * Check if a label selector matches a set of labels, supports matchLabels & matchExpressions
+ * @param {{matchLabels?: Record<string, string>, matchExpressions?: {key: string, operator: string, values?: string[]}[]}} selector
+ * @param {Record<string, string>} labels
+ * @returns {boolean}
+ */
+function selectorMatches(selector, labels) {
+  for (const [key, value] of Object.entries(selector.matchLabels || {})) {
+    if (labels[key] !== value) return false
+  }
+
+  for (const expr of selector.matchExpressions || []) {
+    const has = expr.key in labels
+    const inValues = has && (expr.values || []).includes(labels[expr.key])
+
+    if (expr.operator === 'In' && !inValues) return false
+    if (expr.operator === 'NotIn' && inValues) return false
+    if (expr.operator === 'Exists' && !has) return false
+    if (expr.operator === 'DoesNotExist' && has) return false
+  }
+
+  return true
+}
+
 /**
  * Create a node object for G6 from the k8s resource
  * @param {Resource} res The k8s resource to create a node for
@@ -366,6 +399,11 @@ function makeNode(res) {
  * @param {Resource} res The k8s resource to calculate the status colour for
  */
 function statusColour(res) {
+  // The server works out health for the kinds it has rules for, so every client agrees
+  if (res.health && res.health !== 'unknown') {
+    return { healthy: 'green', warning: 'grey', error: 'red' }[res.health] || ''
+  }
+
   try {
     if (res.kind === 'Deployment') {
       if (res.status == {} || !res.status.conditions) return 'grey'
@@ -100,6 +100,8 @@ Alpine.data('mainApp', () => ({
   errorMessage: '',
   /** @type {string[] | null} */
   namespaces: null,
+  /** @type {Record<string, {finalizers?: string[], blockers?: string[]}>} */
+  terminating: {},
   namespace: '',
   showWelcome: true,
   isLoading: false,
@@ -208,6 +210,28 @@ Alpine.data('mainApp', () => ({
     })
   },
 
+  /**
+   * Label for a namespace in the picker, marking those being deleted
+   * @param {string} ns
+   * @returns {string}
+   */
+  namespaceLabel(ns) {
+    return this.terminating[ns] ? `${ns} (terminating)` : ns
+  },
+
+  /**
+   * Explain what is blocking deletion of a terminating namespace, shown as a tooltip
+   * @param {string} ns
+   * @returns {string}
+   */
+  namespaceBlockers(ns) {
+    const status = this.terminating[ns]
+    if (!status) return ''
+
+    const finalizers = status.finalizers?.length ? `Finalizers: ${status.finalizers.join(', ')}` : ''
+    return [finalizers, ...(status.blockers || [])].filter(Boolean).join('\n')
+  },
+
   /**
    * Fetch the list of namespaces from the server
    */
@@ -219,6 +243,7 @@ Alpine.data('mainApp', () => ({
 
       const data = await res.json()
       this.namespaces = data.namespaces || []
+      this.terminating = Object.fromEntries((data.terminating || []).map((ns) => [ns.name, ns]))
       this.serviceMetadata.clusterHost = data.clusterHost || ''
       this.serviceMetadata.version = data.version || ''
       this.serviceMetadata.buildInfo = data.buildInfo || ''
@@ -228,6 +253,12 @@ Alpine.data('mainApp', () => ({
       if (this.namespaces && this.namespaces.length === 1) {
         this.namespace = this.namespaces[0]
       }
+
+      // Open the server's default namespace, unless one has already been picked or given in the URL
+      if (!this.namespace && data.defaultNamespace && this.namespaces.includes(data.defaultNamespace)) {
+        this.showWelcome = false
+        this.namespace = data.defaultNamespace
+      }
     } catch (err) {
       this.showError(`Failed to fetch namespaces: ${err.message}`, res)
       return
@@ -287,50 +318,63 @@ Alpine.data('mainApp', () => ({
 
     window.dispatchEvent(new CustomEvent('closePanel'))
 
-    let data
-    let res
-    try {
-      res = await fetch(`api/fetch/${this.namespace}?clientID=${getClientId()}`)
-      if (!res.ok) throw new Error(`HTTP error ${res.status}: ${res.statusText}`)
-      data = await res.json()
+    // Important: Clear the cache before adding new resources
+    clearCache()
+
+    // Resources arrive one type at a time, pods first, so the graph is drawn as they come in
+    /** @type {Resource[]} */
+    const fetched = []
+    const stream = new EventSource(`api/fetch/${this.namespace}/stream?clientID=${getClientId()}`)
+
+    stream.addEventListener('chunk', async (event) => {
+      let chunk
+      try {
+        chunk = JSON.parse(event.data)
+      } catch (err) {
+        console.error('💥 Error parsing chunk data:', err)
+        return
+      }
+
+      if (this.cfg.debug) console.log(`📦 Fetched ${chunk.kind}:`, chunk.items)
+      if (this.cfg.debug && chunk.hidden) console.log(`🙈 Hidden ${chunk.hidden} completed ${chunk.kind}`)
+      if (chunk.truncated) console.warn(`✂️ Only the newest ${chunk.kind} were fetched, ${chunk.truncated} more not shown`)
+
+      // Pass 1 - Add the resources to the graph as each type arrives
+      for (const res of chunk.items || []) {
+        addResource(res)
+        fetched.push(res)
+      }
 
       this.isLoading = false
       this.showWelcome = false
-    } catch (err) {
-      this.showError(`Failed to fetch namespace data: ${err.message}`, res)
-      return
-    }
 
-    if (this.cfg.debug) {
-      console.log('📦 Fetched data:', data)
-    }
+      if (chunk.items?.length) await layout()
+    })
 
-    // Important: Clear the cache before adding new resources
-    clearCache()
+    stream.addEventListener('done', async () => {
+      stream.close()
 
-    // Pass 1 - Add ALL the resources to the graph
-    for (const kindKey in data) {
-      const resources = data[kindKey]
-      for (const res of resources || []) {
-        addResource(res)
+      // Pass 2 - Add links between using metadata.ownerReferences, once everything is here
+      for (const res of fetched) {
+        processLinks(res)
       }
-    }
 
-    // Pass 2 - Add links between using metadata.ownerReferences
-    for (const kindKey in data) {
-      const resources = data[kindKey]
-      for (const res of resources || []) {
-        processLinks(res)
+      this.isLoading = false
+      this.showWelcome = false
+
+      try {
+        await graph.render()
+        await fitToVisible(graph, true)
+      } catch (e) {
+        console.error('💥 Error rendering graph:', e)
       }
-    }
+    })
 
-    try {
-      await graph.render()
-      await fitToVisible(graph, true)
-    } catch (e) {
-      console.error('💥 Error rendering graph:', e)
-      return
-    }
+    // EventSource doesn't expose the HTTP status, so any failure is reported the same way
+    stream.addEventListener('error', () => {
+      stream.close()
+      this.showError('Failed to fetch namespace data: the stream was closed by the server')
+    })
   },
 
   /**
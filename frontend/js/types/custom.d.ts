@@ -40,6 +40,14 @@ declare type Resource = {
   subsets?: any // Only on Endpoints
   endpoints?: any // Only on EndpointSlices
   data?: any // For Secret and ConfigMap
+  ageSeconds?: number | null // Added by the server when fetched, not on live updates
+  health?: 'healthy' | 'warning' | 'error' | 'unknown' // Added by the server, see services/health.go
+  endpointStatus?: {
+    ready: number
+    notReady: number
+    readyAddresses: string[]
+    notReadyAddresses: string[]
+  } // Only on Services, added by the server when fetched, see services/endpoints.go
 }
 
 declare type EventResource = {
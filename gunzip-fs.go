@@ -0,0 +1,165 @@
+// ==========================================================================================
+// A read only filesystem over gzipped files, decompressing them as they are opened
+// Each file "name" is stored as "name.gz", files stored without the suffix are read as they are
+// ==========================================================================================
+
+package kubeview
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+const gzipSuffix = ".gz"
+
+// gunzipFS wraps a filesystem of gzipped files, see GunzipFS
+type gunzipFS struct {
+	fsys fs.FS
+}
+
+// gunzipFile is an opened file, held decompressed in memory
+type gunzipFile struct {
+	*bytes.Reader
+	info fs.FileInfo
+}
+
+// gunzipDir is an opened directory, listing the files by their decompressed names
+type gunzipDir struct {
+	fs.ReadDirFile
+	fsys gunzipFS
+	path string
+}
+
+// gunzipEntry is a directory entry for a gzipped file
+type gunzipEntry struct {
+	fs.DirEntry
+	fsys gunzipFS
+	path string
+}
+
+// gunzipInfo describes a gzipped file by its decompressed name & size
+type gunzipInfo struct {
+	fs.FileInfo
+	name string
+	size int64
+}
+
+// GunzipFS returns a filesystem which serves the files in fsys as if they had never been compressed
+// Files are decompressed in full when opened, so it is only suited to small files such as the frontend
+func GunzipFS(fsys fs.FS) fs.FS {
+	return gunzipFS{fsys: fsys}
+}
+
+// Open opens the named file, falling back to the file as stored when there is no gzipped copy
+func (g gunzipFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	f, err := g.fsys.Open(name + gzipSuffix)
+	if errors.Is(err, fs.ErrNotExist) {
+		return g.openStored(name)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := gunzip(f)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	return &gunzipFile{
+		Reader: bytes.NewReader(content),
+		info:   gunzipInfo{FileInfo: info, name: path.Base(name), size: int64(len(content))},
+	}, nil
+}
+
+// ReadDir lists a directory, with the suffix removed from the names of gzipped files
+func (g gunzipFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(g.fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.renameEntries(name, entries), nil
+}
+
+// openStored opens a file or directory which isn't gzipped
+func (g gunzipFS) openStored(name string) (fs.File, error) {
+	f, err := g.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if dir, ok := f.(fs.ReadDirFile); ok {
+		return &gunzipDir{ReadDirFile: dir, fsys: g, path: name}, nil
+	}
+
+	return f, nil
+}
+
+// renameEntries wraps the entries for gzipped files in a directory, so they have their decompressed names
+func (g gunzipFS) renameEntries(dir string, entries []fs.DirEntry) []fs.DirEntry {
+	for i, entry := range entries {
+		name, gzipped := strings.CutSuffix(entry.Name(), gzipSuffix)
+		if gzipped && !entry.IsDir() {
+			entries[i] = gunzipEntry{DirEntry: entry, fsys: g, path: path.Join(dir, name)}
+		}
+	}
+
+	return entries
+}
+
+// Stat returns the decompressed file's info
+func (f *gunzipFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+// Close does nothing, the file is already fully read
+func (f *gunzipFile) Close() error {
+	return nil
+}
+
+// ReadDir lists the directory, with the suffix removed from the names of gzipped files
+func (d *gunzipDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	entries, err := d.ReadDirFile.ReadDir(n)
+
+	return d.fsys.renameEntries(d.path, entries), err
+}
+
+// Name is the decompressed file name
+func (e gunzipEntry) Name() string {
+	return path.Base(e.path)
+}
+
+// Info decompresses the file to find its size, so it is best avoided for large files
+func (e gunzipEntry) Info() (fs.FileInfo, error) {
+	return fs.Stat(e.fsys, e.path)
+}
+
+func (i gunzipInfo) Name() string { return i.name }
+func (i gunzipInfo) Size() int64  { return i.size }
+
+// gunzip reads & decompresses all of r
+func gunzip(r io.Reader) ([]byte, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	return io.ReadAll(gz)
+}
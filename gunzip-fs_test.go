@@ -0,0 +1,102 @@
+// ==========================================================================================
+// Unit tests for the gunzip filesystem
+// ==========================================================================================
+
+package kubeview
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"os"
+	"testing"
+	"testing/fstest"
+)
+
+// gzipMapFS gzips every file in a filesystem, as the compress-frontend build helper does
+func gzipMapFS(t *testing.T, fsys fs.FS) fstest.MapFS {
+	t.Helper()
+
+	compressed := fstest.MapFS{}
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		var buf bytes.Buffer
+
+		gz := gzip.NewWriter(&buf)
+		_, _ = gz.Write(content)
+		_ = gz.Close()
+
+		compressed[name+".gz"] = &fstest.MapFile{Data: buf.Bytes()}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to compress test files: %v", err)
+	}
+
+	return compressed
+}
+
+func TestGunzipFS_MatchesOriginal(t *testing.T) {
+	original := os.DirFS(".")
+
+	frontend, err := fs.Sub(original, "frontend")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gunzipped := GunzipFS(gzipMapFS(t, frontend))
+
+	count := 0
+
+	err = fs.WalkDir(gunzipped, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		count++
+
+		got, err := fs.ReadFile(gunzipped, name)
+		if err != nil {
+			return err
+		}
+
+		expected, err := fs.ReadFile(original, "frontend/"+name)
+		if err != nil {
+			t.Errorf("Unexpected file %s, not in the original frontend", name)
+			return nil
+		}
+
+		if !bytes.Equal(got, expected) {
+			t.Errorf("Expected %s to match the original content", name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to walk the gunzip filesystem: %v", err)
+	}
+
+	if count == 0 {
+		t.Fatal("Expected the frontend files to be listed")
+	}
+}
+
+func TestGunzipFS_Stored(t *testing.T) {
+	fsys := gzipMapFS(t, fstest.MapFS{"css/main.css": {Data: []byte("body { margin: 0; }")}})
+	fsys["img/logo.png"] = &fstest.MapFile{Data: []byte("not compressed")}
+
+	// TestFS checks the names, sizes & contents line up however the files are read
+	if err := fstest.TestFS(GunzipFS(fsys), "css/main.css", "img/logo.png"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,76 @@
+// ==========================================================================================
+// Build helper which gzips the frontend, so a smaller copy can be embedded in release builds
+// Run via `go generate` from the root of the repo, see frontend-fs.go & gunzip-fs.go
+// ==========================================================================================
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"flag"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+func main() {
+	src := flag.String("src", "frontend", "Directory holding the files to compress")
+	dst := flag.String("dst", "dist/frontend", "Directory to write the compressed files to, replacing its contents")
+
+	flag.Parse()
+
+	if err := os.RemoveAll(*dst); err != nil {
+		log.Fatalf("💥 Failed to clear %s: %v", *dst, err)
+	}
+
+	var before, after int
+
+	err := filepath.WalkDir(*src, func(srcPath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		content, err := os.ReadFile(srcPath)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(*src, srcPath)
+		if err != nil {
+			return err
+		}
+
+		// Files gzip can't shrink, such as images, are copied as they are
+		outPath, out := filepath.Join(*dst, rel), content
+		if gzipped := compress(content); len(gzipped) < len(content) {
+			outPath, out = outPath+".gz", gzipped
+		}
+
+		before += len(content)
+		after += len(out)
+
+		if err := os.MkdirAll(filepath.Dir(outPath), 0o755); err != nil {
+			return err
+		}
+
+		return os.WriteFile(outPath, out, 0o644)
+	})
+	if err != nil {
+		log.Fatalf("💥 Failed to compress %s: %v", *src, err)
+	}
+
+	log.Printf("📦 Compressed %s into %s, %d bytes down to %d", *src, *dst, before, after)
+}
+
+// compress gzips some content at the best compression, as it is only done once per build
+func compress(content []byte) []byte {
+	var buf bytes.Buffer
+
+	gz, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	_, _ = gz.Write(content)
+	_ = gz.Close()
+
+	return buf.Bytes()
+}
@@ -5,6 +5,9 @@
 package main
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"log"
 
 	"github.com/benc-uk/go-rest-api/pkg/api"
@@ -17,6 +20,8 @@ type KubeviewAPI struct {
 	kubeService *services.Kubernetes
 	eventBroker KubeEventBroker
 	config      Config
+	// Pinned namespaces for each user, only kept in memory for now
+	pins PinStore
 }
 
 type NamespaceListResult struct {
@@ -28,22 +33,70 @@ type NamespaceListResult struct {
 	BuildInfo      string `json:"buildInfo"`
 	Mode           string `json:"mode"`
 	PodLogsEnabled bool   `json:"podLogsEnabled"`
+	// Namespace for the frontend to open with when none has been picked, empty leaves it to the user
+	DefaultNamespace string `json:"defaultNamespace,omitempty"`
+	// Namespaces stuck or in the process of being deleted, with what is blocking them
+	Terminating []services.NamespaceStatus `json:"terminating,omitempty"`
+	// Only included when requested with counts=true, as it costs several API calls per namespace
+	Summaries []services.NamespaceSummary `json:"summaries,omitempty"`
 }
 
 func NewKubeviewAPI(conf Config) *KubeviewAPI {
 	broker := newKubeEventBroker(conf)
 
 	// Create a new Kubernetes service instance, which will connect to the cluster
-	kubeSvc, err := services.NewKubernetes(broker.Broker, conf.SingleNamespace)
+	kubeSvc, err := services.NewKubernetesWithOptions(broker, services.Options{
+		SingleNamespace: conf.SingleNamespace,
+		KubeContext:     conf.KubeContext,
+		ReadOnly:        conf.ReadOnly,
+		RedactSecrets:   conf.RedactSecrets,
+		RedactKeys:      conf.RedactKeys,
+
+		WatchedResources:   conf.WatchedResources,
+		WatchKindDenylist:  conf.WatchKindDenylist,
+		CoalesceWindow:     conf.CoalesceWindow,
+		PollInterval:       conf.PollInterval,
+		ResyncPeriod:       conf.ResyncPeriod,
+		NamespaceAllowlist: conf.NamespaceAllowlist,
+		NamespaceDenylist:  conf.NamespaceDenylist,
+		SecretTypeDenylist: conf.SecretTypeDenylist,
+
+		CAFile:                conf.KubeCAFile,
+		InsecureSkipTLSVerify: conf.KubeInsecureSkipTLS,
+		UserAgent:             cmp.Or(conf.KubeUserAgent, services.UserAgent(version)),
+		EnablePortForward:     conf.EnablePortForward,
+	})
 	if err != nil {
 		log.Fatalf("💥 Error connecting to Kubernetes, system will exit")
 	}
 
+	kubeSvc.RequestTimeout = conf.RequestTimeout
+	kubeSvc.RateLimitQPS = conf.APIRateLimit
+	kubeSvc.RateLimitBurst = conf.APIRateBurst
+	kubeSvc.MaxObjectBytes = conf.MaxObjectBytes
+	kubeSvc.MaxObjects = conf.MaxObjects
+	kubeSvc.MaxLogLines = conf.MaxLogLines
+
+	// Resolved once at startup, so a missing namespace is reported in the logs rather than breaking the frontend
+	// In single namespace mode there is nothing else it could be
+	if conf.SingleNamespace != "" {
+		conf.DefaultNamespace = conf.SingleNamespace
+	} else if conf.DefaultNamespace != "" {
+		conf.DefaultNamespace = kubeSvc.DefaultNamespace(conf.DefaultNamespace)
+		log.Println("🏠 Default namespace is:", cmp.Or(conf.DefaultNamespace, "none"))
+	}
+
 	// Our API struct is a wrapper around the base API functionality
 	return &KubeviewAPI{
 		api.NewBase("kubeview", version, buildInfo, true),
 		kubeSvc,
 		broker,
 		conf,
+		newMemoryPinStore(),
 	}
 }
+
+// Shutdown ends all the event streams, telling clients the server is going away, then stops watching the cluster
+func (s *KubeviewAPI) Shutdown(ctx context.Context) error {
+	return errors.Join(s.eventBroker.Shutdown(ctx), s.kubeService.Shutdown(ctx))
+}
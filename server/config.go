@@ -7,6 +7,8 @@ package main
 import (
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds the configuration for the system
@@ -16,6 +18,54 @@ type Config struct {
 	SingleNamespace string
 	Debug           bool
 	EnablePodLogs   bool
+	RequestTimeout  time.Duration
+	KubeContext     string
+	ReadOnly        bool
+	BasePath        string
+	RedactSecrets   bool
+	// Glob patterns for keys whose values are redacted anywhere in returned objects, e.g. *token*
+	RedactKeys []string
+	// Resource types watched for live updates by plural name, empty means all of them
+	WatchedResources []string
+	// Kinds never watched for live updates e.g. Event, they are still fetched when a namespace is loaded
+	WatchKindDenylist []string
+	// Only these namespaces can be seen when set, the denylist always wins over the allowlist
+	NamespaceAllowlist []string
+	NamespaceDenylist  []string
+	// Types of Secret which are never returned, e.g. helm.sh/release.v1
+	SecretTypeDenylist []string
+	// Limit on calls to the Kubernetes API per second, zero means no limit
+	APIRateLimit float64
+	APIRateBurst int
+	// Updates to the same object within this window are sent as a single event, zero sends every update
+	CoalesceWindow time.Duration
+	// Resources which can be listed but not watched are polled this often, zero turns polling off
+	PollInterval time.Duration
+	// Informers send every object again this often so the UI recovers from missed watch events, zero turns it off
+	ResyncPeriod time.Duration
+	// ConfigMaps & Secrets bigger than this have their data truncated, zero means no limit
+	MaxObjectBytes int
+	// Most resources of each type returned when fetching a namespace, the newest are kept, zero means no limit
+	MaxObjects int
+	// Requests for more lines of pod logs than this are clamped to it
+	MaxLogLines int
+	// CA bundle to verify the API server with, or skip verification entirely
+	KubeCAFile          string
+	KubeInsecureSkipTLS bool
+	// Sent to the API server with every request, defaults to kubeview/version
+	KubeUserAgent string
+	// Allows forwarding to pod ports, which also needs read-only mode to be off
+	EnablePortForward bool
+	// Most SSE & gRPC streams open at once, further clients are refused until one closes, zero means no limit
+	MaxSubscribers int
+	// Port for the gRPC event streaming API, zero turns it off
+	GRPCPort int
+	// Adds a Server-Timing header to namespace fetches, for debugging slow loads in the browser
+	ServerTiming bool
+	// Namespace the frontend opens with, checked at startup & falling back when it doesn't exist
+	DefaultNamespace string
+	// Header with the identity of the user, set by an authenticating proxy in front of KubeView
+	UserHeader string
 }
 
 // Parse the environment variables and return a Config struct
@@ -27,6 +77,23 @@ func getConfig() Config {
 	singleNamespace := ""
 	debug := false
 	enablePodLogs := true
+	requestTimeout := 10 * time.Second
+	coalesceWindow := 250 * time.Millisecond
+	pollInterval := 30 * time.Second
+	resyncPeriod := 10 * time.Minute
+	readOnly := true
+	enablePortForward := false
+	serverTiming := false
+	redactSecrets := true
+	apiRateLimit := 0.0
+	apiRateBurst := 10
+	maxObjectBytes := 512 * 1024
+	maxObjects := 5000
+	maxLogLines := 5000
+	maxSubscribers := 500
+	grpcPort := 0
+	basePath := ""
+	watchedResources := []string{}
 
 	if portEnv := os.Getenv("PORT"); portEnv != "" {
 		if p, err := strconv.Atoi(portEnv); err == nil {
@@ -42,12 +109,118 @@ func getConfig() Config {
 		nameSpaceFilter = s
 	}
 
+	kubeContext := os.Getenv("KUBE_CONTEXT")
+	kubeInsecureSkipTLS := false
+
+	if s := os.Getenv("KUBE_INSECURE_SKIP_TLS_VERIFY"); s != "" {
+		kubeInsecureSkipTLS, _ = strconv.ParseBool(s)
+	}
+
 	if s := os.Getenv("DISABLE_POD_LOGS"); s != "" {
 		if enable, err := strconv.ParseBool(s); err == nil {
 			enablePodLogs = !enable
 		}
 	}
 
+	if s := os.Getenv("REQUEST_TIMEOUT"); s != "" {
+		if timeout, err := time.ParseDuration(s); err == nil && timeout > 0 {
+			requestTimeout = timeout
+		}
+	}
+
+	if s := os.Getenv("UPDATE_COALESCE_WINDOW"); s != "" {
+		if window, err := time.ParseDuration(s); err == nil && window >= 0 {
+			coalesceWindow = window
+		}
+	}
+
+	if s := os.Getenv("POLL_INTERVAL"); s != "" {
+		if interval, err := time.ParseDuration(s); err == nil && interval >= 0 {
+			pollInterval = interval
+		}
+	}
+
+	if s := os.Getenv("RESYNC_PERIOD"); s != "" {
+		if period, err := time.ParseDuration(s); err == nil && period >= 0 {
+			resyncPeriod = period
+		}
+	}
+
+	if s := os.Getenv("READ_ONLY"); s != "" {
+		if ro, err := strconv.ParseBool(s); err == nil {
+			readOnly = ro
+		}
+	}
+
+	if s := os.Getenv("ENABLE_PORT_FORWARD"); s != "" {
+		if enable, err := strconv.ParseBool(s); err == nil {
+			enablePortForward = enable
+		}
+	}
+
+	if s := os.Getenv("REDACT_SECRETS"); s != "" {
+		if redact, err := strconv.ParseBool(s); err == nil {
+			redactSecrets = redact
+		}
+	}
+
+	// Normalise so the base path always starts with a slash and never ends with one, root is empty
+	if s := os.Getenv("BASE_PATH"); strings.Trim(s, "/") != "" {
+		basePath = "/" + strings.Trim(s, "/")
+	}
+
+	if s := os.Getenv("API_RATE_LIMIT"); s != "" {
+		if qps, err := strconv.ParseFloat(s, 64); err == nil && qps >= 0 {
+			apiRateLimit = qps
+		}
+	}
+
+	if s := os.Getenv("API_RATE_BURST"); s != "" {
+		if burst, err := strconv.Atoi(s); err == nil && burst > 0 {
+			apiRateBurst = burst
+		}
+	}
+
+	if s := os.Getenv("MAX_OBJECT_BYTES"); s != "" {
+		if size, err := strconv.Atoi(s); err == nil && size >= 0 {
+			maxObjectBytes = size
+		}
+	}
+
+	if s := os.Getenv("MAX_OBJECTS"); s != "" {
+		if limit, err := strconv.Atoi(s); err == nil && limit >= 0 {
+			maxObjects = limit
+		}
+	}
+
+	if s := os.Getenv("MAX_LOG_LINES"); s != "" {
+		if lines, err := strconv.Atoi(s); err == nil && lines > 0 {
+			maxLogLines = lines
+		}
+	}
+
+	if s := os.Getenv("MAX_SUBSCRIBERS"); s != "" {
+		if limit, err := strconv.Atoi(s); err == nil && limit >= 0 {
+			maxSubscribers = limit
+		}
+	}
+
+	if s := os.Getenv("GRPC_PORT"); s != "" {
+		if p, err := strconv.Atoi(s); err == nil && p >= 0 {
+			grpcPort = p
+		}
+	}
+
+	if s := os.Getenv("WATCHED_RESOURCES"); s != "" {
+		watchedResources = splitList(strings.ToLower(s))
+	}
+
+	if s := os.Getenv("SERVER_TIMING"); s != "" {
+		if enable, err := strconv.ParseBool(s); err == nil {
+			serverTiming = enable
+		}
+	}
+
 	if debugEnv := os.Getenv("DEBUG"); debugEnv != "" {
 		debug, _ = strconv.ParseBool(debugEnv)
 	}
@@ -58,5 +231,48 @@ func getConfig() Config {
 		SingleNamespace: singleNamespace,
 		Debug:           debug,
 		EnablePodLogs:   enablePodLogs,
+		RequestTimeout:  requestTimeout,
+		KubeContext:     kubeContext,
+		ReadOnly:        readOnly,
+		BasePath:        basePath,
+		RedactSecrets:   redactSecrets,
+
+		RedactKeys:         splitList(os.Getenv("REDACT_KEYS")),
+		WatchedResources:   watchedResources,
+		WatchKindDenylist:  splitList(os.Getenv("WATCH_KIND_DENYLIST")),
+		NamespaceAllowlist: splitList(os.Getenv("NAMESPACE_ALLOWLIST")),
+		NamespaceDenylist:  splitList(os.Getenv("NAMESPACE_DENYLIST")),
+		SecretTypeDenylist: splitList(os.Getenv("SECRET_TYPE_DENYLIST")),
+		APIRateLimit:       apiRateLimit,
+		APIRateBurst:       apiRateBurst,
+		MaxObjectBytes:     maxObjectBytes,
+		MaxObjects:         maxObjects,
+		MaxLogLines:        maxLogLines,
+		MaxSubscribers:     maxSubscribers,
+		GRPCPort:           grpcPort,
+		CoalesceWindow:     coalesceWindow,
+		PollInterval:       pollInterval,
+		ResyncPeriod:       resyncPeriod,
+
+		KubeCAFile:          os.Getenv("KUBE_CA_FILE"),
+		KubeInsecureSkipTLS: kubeInsecureSkipTLS,
+		KubeUserAgent:       os.Getenv("KUBE_USER_AGENT"),
+		EnablePortForward:   enablePortForward,
+		ServerTiming:        serverTiming,
+		DefaultNamespace:    strings.TrimSpace(os.Getenv("DEFAULT_NAMESPACE")),
+		UserHeader:          strings.TrimSpace(os.Getenv("USER_HEADER")),
 	}
 }
+
+// Split a comma separated list, trimming spaces and dropping empty entries
+func splitList(s string) []string {
+	list := []string{}
+
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,281 @@
+// ==========================================================================================
+// gRPC API streaming KubeEvents, an alternative to the SSE stream for programmatic clients
+// Regenerate the Go code from the repo root with:
+//   protoc --go_out=. --go_opt=paths=source_relative \
+//     --go-grpc_out=. --go-grpc_opt=paths=source_relative server/eventspb/events.proto
+// ==========================================================================================
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.31.1
+// source: server/eventspb/events.proto
+
+package eventspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// EventType is what happened to the object, matching the SSE event names
+type EventType int32
+
+const (
+	EventType_EVENT_TYPE_UNSPECIFIED EventType = 0
+	EventType_EVENT_TYPE_ADD         EventType = 1
+	EventType_EVENT_TYPE_UPDATE      EventType = 2
+	EventType_EVENT_TYPE_DELETE      EventType = 3
+	// The server is going away, the stream ends after this so back off before subscribing again
+	EventType_EVENT_TYPE_SHUTDOWN EventType = 4
+)
+
+// Enum value maps for EventType.
+var (
+	EventType_name = map[int32]string{
+		0: "EVENT_TYPE_UNSPECIFIED",
+		1: "EVENT_TYPE_ADD",
+		2: "EVENT_TYPE_UPDATE",
+		3: "EVENT_TYPE_DELETE",
+		4: "EVENT_TYPE_SHUTDOWN",
+	}
+	EventType_value = map[string]int32{
+		"EVENT_TYPE_UNSPECIFIED": 0,
+		"EVENT_TYPE_ADD":         1,
+		"EVENT_TYPE_UPDATE":      2,
+		"EVENT_TYPE_DELETE":      3,
+		"EVENT_TYPE_SHUTDOWN":    4,
+	}
+)
+
+func (x EventType) Enum() *EventType {
+	p := new(EventType)
+	*p = x
+	return p
+}
+
+func (x EventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_server_eventspb_events_proto_enumTypes[0].Descriptor()
+}
+
+func (EventType) Type() protoreflect.EnumType {
+	return &file_server_eventspb_events_proto_enumTypes[0]
+}
+
+func (x EventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventType.Descriptor instead.
+func (EventType) EnumDescriptor() ([]byte, []int) {
+	return file_server_eventspb_events_proto_rawDescGZIP(), []int{0}
+}
+
+type SubscribeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Namespace to receive events for
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// Only send events for objects of these kinds e.g. "Pod", ignoring case, empty means every kind
+	Kinds         []string `protobuf:"bytes,2,rep,name=kinds,proto3" json:"kinds,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_server_eventspb_events_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_server_eventspb_events_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_server_eventspb_events_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SubscribeRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *SubscribeRequest) GetKinds() []string {
+	if x != nil {
+		return x.Kinds
+	}
+	return nil
+}
+
+type KubeEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  EventType              `protobuf:"varint,1,opt,name=type,proto3,enum=kubeview.events.v1.EventType" json:"type,omitempty"`
+	// The Kubernetes resource, as it would be returned by the REST API
+	Object *structpb.Struct `protobuf:"bytes,2,opt,name=object,proto3" json:"object,omitempty"`
+	// Name of the cluster the event came from, only set when events from several clusters are merged
+	Cluster       string `protobuf:"bytes,3,opt,name=cluster,proto3" json:"cluster,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KubeEvent) Reset() {
+	*x = KubeEvent{}
+	mi := &file_server_eventspb_events_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KubeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KubeEvent) ProtoMessage() {}
+
+func (x *KubeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_server_eventspb_events_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KubeEvent.ProtoReflect.Descriptor instead.
+func (*KubeEvent) Descriptor() ([]byte, []int) {
+	return file_server_eventspb_events_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *KubeEvent) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *KubeEvent) GetObject() *structpb.Struct {
+	if x != nil {
+		return x.Object
+	}
+	return nil
+}
+
+func (x *KubeEvent) GetCluster() string {
+	if x != nil {
+		return x.Cluster
+	}
+	return ""
+}
+
+var File_server_eventspb_events_proto protoreflect.FileDescriptor
+
+const file_server_eventspb_events_proto_rawDesc = "" +
+	"\n" +
+	"\x1cserver/eventspb/events.proto\x12\x12kubeview.events.v1\x1a\x1cgoogle/protobuf/struct.proto\"F\n" +
+	"\x10SubscribeRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x14\n" +
+	"\x05kinds\x18\x02 \x03(\tR\x05kinds\"\x89\x01\n" +
+	"\tKubeEvent\x121\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x1d.kubeview.events.v1.EventTypeR\x04type\x12/\n" +
+	"\x06object\x18\x02 \x01(\v2\x17.google.protobuf.StructR\x06object\x12\x18\n" +
+	"\acluster\x18\x03 \x01(\tR\acluster*\x82\x01\n" +
+	"\tEventType\x12\x1a\n" +
+	"\x16EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eEVENT_TYPE_ADD\x10\x01\x12\x15\n" +
+	"\x11EVENT_TYPE_UPDATE\x10\x02\x12\x15\n" +
+	"\x11EVENT_TYPE_DELETE\x10\x03\x12\x17\n" +
+	"\x13EVENT_TYPE_SHUTDOWN\x10\x042b\n" +
+	"\fEventService\x12R\n" +
+	"\tSubscribe\x12$.kubeview.events.v1.SubscribeRequest\x1a\x1d.kubeview.events.v1.KubeEvent0\x01B-Z+github.com/benc-uk/kubeview/server/eventspbb\x06proto3"
+
+var (
+	file_server_eventspb_events_proto_rawDescOnce sync.Once
+	file_server_eventspb_events_proto_rawDescData []byte
+)
+
+func file_server_eventspb_events_proto_rawDescGZIP() []byte {
+	file_server_eventspb_events_proto_rawDescOnce.Do(func() {
+		file_server_eventspb_events_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_server_eventspb_events_proto_rawDesc), len(file_server_eventspb_events_proto_rawDesc)))
+	})
+	return file_server_eventspb_events_proto_rawDescData
+}
+
+var file_server_eventspb_events_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_server_eventspb_events_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_server_eventspb_events_proto_goTypes = []any{
+	(EventType)(0),           // 0: kubeview.events.v1.EventType
+	(*SubscribeRequest)(nil), // 1: kubeview.events.v1.SubscribeRequest
+	(*KubeEvent)(nil),        // 2: kubeview.events.v1.KubeEvent
+	(*structpb.Struct)(nil),  // 3: google.protobuf.Struct
+}
+var file_server_eventspb_events_proto_depIdxs = []int32{
+	0, // 0: kubeview.events.v1.KubeEvent.type:type_name -> kubeview.events.v1.EventType
+	3, // 1: kubeview.events.v1.KubeEvent.object:type_name -> google.protobuf.Struct
+	1, // 2: kubeview.events.v1.EventService.Subscribe:input_type -> kubeview.events.v1.SubscribeRequest
+	2, // 3: kubeview.events.v1.EventService.Subscribe:output_type -> kubeview.events.v1.KubeEvent
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_server_eventspb_events_proto_init() }
+func file_server_eventspb_events_proto_init() {
+	if File_server_eventspb_events_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_server_eventspb_events_proto_rawDesc), len(file_server_eventspb_events_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_server_eventspb_events_proto_goTypes,
+		DependencyIndexes: file_server_eventspb_events_proto_depIdxs,
+		EnumInfos:         file_server_eventspb_events_proto_enumTypes,
+		MessageInfos:      file_server_eventspb_events_proto_msgTypes,
+	}.Build()
+	File_server_eventspb_events_proto = out.File
+	file_server_eventspb_events_proto_goTypes = nil
+	file_server_eventspb_events_proto_depIdxs = nil
+}
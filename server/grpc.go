@@ -0,0 +1,211 @@
+// ==========================================================================================
+// gRPC API streaming KubeEvents, an alternative to SSE for programmatic clients
+//   See eventspb/events.proto for the service definition
+// - Each stream is subscribed to a namespace on the broker, getting the same events as SSE clients
+// - Subscriptions go through the broker's Authorize hook, the call's metadata is given as request headers
+// - Streams are ended when the broker shuts down, with a final shutdown event
+// ==========================================================================================
+
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/benc-uk/kubeview/server/eventspb"
+	"github.com/benc-uk/kubeview/server/services"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Events held for each gRPC stream while it is sending, more than this and events are dropped
+const grpcStreamBuffer = 100
+
+// grpcStreams holds the open gRPC streams by namespace, the broker sends them events alongside SSE clients
+type grpcStreams struct {
+	mu      sync.RWMutex
+	streams map[string]map[*grpcStream]bool
+}
+
+// grpcStream is one subscriber, with its kind filter and the events waiting to be sent
+type grpcStream struct {
+	kinds  []string
+	events chan services.KubeEvent
+}
+
+// eventServer implements the gRPC EventService, backed by the SSE broker
+type eventServer struct {
+	eventspb.UnimplementedEventServiceServer
+	broker KubeEventBroker
+	// Checks a namespace may be watched, when nil every namespace is allowed
+	permitted func(ns string) bool
+}
+
+// Maps the broker's event types to the gRPC enum, other types are never sent to namespace groups
+var grpcEventTypes = map[services.EventTypeEnum]eventspb.EventType{
+	services.AddEvent:      eventspb.EventType_EVENT_TYPE_ADD,
+	services.UpdateEvent:   eventspb.EventType_EVENT_TYPE_UPDATE,
+	services.DeleteEvent:   eventspb.EventType_EVENT_TYPE_DELETE,
+	services.ShutdownEvent: eventspb.EventType_EVENT_TYPE_SHUTDOWN,
+}
+
+// newGRPCServer creates a gRPC server with the EventService registered
+func newGRPCServer(broker KubeEventBroker, permitted func(ns string) bool) *grpc.Server {
+	server := grpc.NewServer()
+	eventspb.RegisterEventServiceServer(server, &eventServer{broker: broker, permitted: permitted})
+
+	return server
+}
+
+// Subscribe streams the events for a namespace until the client goes away or the broker is shut down
+func (s *eventServer) Subscribe(req *eventspb.SubscribeRequest,
+	stream grpc.ServerStreamingServer[eventspb.KubeEvent],
+) error {
+	ns := req.GetNamespace()
+	if ns == "" {
+		return status.Error(codes.InvalidArgument, "namespace is required")
+	}
+
+	if s.permitted != nil && !s.permitted(ns) {
+		return status.Error(codes.PermissionDenied, "namespace is not permitted: "+ns)
+	}
+
+	if s.broker.Authorize != nil && !s.broker.Authorize(authRequest(stream.Context()), ns) {
+		log.Printf("⛔ gRPC client denied subscription to namespace %s", ns)
+		return status.Error(codes.PermissionDenied, "subscription to namespace denied: "+ns)
+	}
+
+	if err := s.broker.streams.open(); err != nil {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+
+	defer s.broker.streams.done()
+
+	sub := s.broker.grpc.add(ns, req.GetKinds())
+	defer s.broker.grpc.remove(ns, sub)
+
+	log.Printf("⚡ gRPC client subscribed to namespace %s", ns)
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			log.Printf("🔌 gRPC client unsubscribed from namespace %s", ns)
+			return nil
+		case <-s.broker.streams.shutdown:
+			return stream.Send(&eventspb.KubeEvent{Type: eventspb.EventType_EVENT_TYPE_SHUTDOWN})
+		case event := <-sub.events:
+			msg, err := grpcEvent(event)
+			if err != nil {
+				log.Printf("💥 Error converting object for gRPC: %v", err)
+				continue
+			}
+
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// authRequest presents a gRPC call as an HTTP request, so the same Authorize hook works for SSE & gRPC
+// Metadata becomes the headers, e.g. authorization, and the peer's address becomes RemoteAddr
+func authRequest(ctx context.Context) *http.Request {
+	r, _ := http.NewRequestWithContext(ctx, http.MethodPost, eventspb.EventService_Subscribe_FullMethodName, nil)
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, values := range md {
+		// Pseudo headers aren't real headers, but the authority is the host the client asked for
+		if strings.HasPrefix(key, ":") {
+			if key == ":authority" && len(values) > 0 {
+				r.Host = values[0]
+			}
+
+			continue
+		}
+
+		for _, value := range values {
+			r.Header.Add(key, value)
+		}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		r.RemoteAddr = p.Addr.String()
+	}
+
+	return r
+}
+
+// grpcEvent converts a KubeEvent into its protobuf message
+func grpcEvent(event services.KubeEvent) (*eventspb.KubeEvent, error) {
+	msg := &eventspb.KubeEvent{Type: grpcEventTypes[event.EventType], Cluster: event.Cluster}
+
+	if event.Object != nil {
+		obj, err := structpb.NewStruct(event.Object.Object)
+		if err != nil {
+			return nil, err
+		}
+
+		msg.Object = obj
+	}
+
+	return msg, nil
+}
+
+// add registers a stream for a namespace, kinds are matched ignoring case with empty meaning every kind
+func (g *grpcStreams) add(ns string, kinds []string) *grpcStream {
+	sub := &grpcStream{events: make(chan services.KubeEvent, grpcStreamBuffer)}
+	for _, kind := range kinds {
+		sub.kinds = append(sub.kinds, strings.ToLower(kind))
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.streams[ns] == nil {
+		g.streams[ns] = map[*grpcStream]bool{}
+	}
+
+	g.streams[ns][sub] = true
+
+	return sub
+}
+
+// remove unregisters a stream once it has ended
+func (g *grpcStreams) remove(ns string, sub *grpcStream) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.streams[ns], sub)
+
+	if len(g.streams[ns]) == 0 {
+		delete(g.streams, ns)
+	}
+}
+
+// send queues an event for the streams subscribed to a namespace, without waiting on slow clients
+// Events are dropped for a stream whose buffer is full, rather than holding up the informers
+func (g *grpcStreams) send(ns string, message services.KubeEvent) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	for sub := range g.streams[ns] {
+		if message.Object != nil && len(sub.kinds) > 0 &&
+			!slices.Contains(sub.kinds, strings.ToLower(message.Object.GetKind())) {
+			continue
+		}
+
+		select {
+		case sub.events <- message:
+		default:
+			log.Printf("⚠️ gRPC stream for namespace %s is too slow, dropped a %s event", ns, message.EventType)
+		}
+	}
+}
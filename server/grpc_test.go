@@ -0,0 +1,154 @@
+// ==========================================================================================
+// Unit tests for the gRPC event streaming API
+// ==========================================================================================
+
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/benc-uk/kubeview/server/eventspb"
+	"github.com/benc-uk/kubeview/server/services"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// grpcSubscribed waits until there is a gRPC stream subscribed to a namespace
+func grpcSubscribed(broker KubeEventBroker, ns string) bool {
+	deadline := time.Now().Add(2 * time.Second)
+
+	for time.Now().Before(deadline) {
+		broker.grpc.mu.RLock()
+		subscribed := len(broker.grpc.streams[ns]) > 0
+		broker.grpc.mu.RUnlock()
+
+		if subscribed {
+			return true
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return false
+}
+
+func TestEventServer_Subscribe(t *testing.T) {
+	broker := newKubeEventBroker(Config{})
+	lis := bufconn.Listen(1024 * 1024)
+
+	server := newGRPCServer(broker, func(ns string) bool { return ns != "kube-system" })
+	go func() { _ = server.Serve(lis) }()
+
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := eventspb.NewEventServiceClient(conn)
+
+	stream, err := client.Subscribe(ctx, &eventspb.SubscribeRequest{Namespace: "default"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !grpcSubscribed(broker, "default") {
+		t.Fatal("Expected the stream to be subscribed to default")
+	}
+
+	pod := &unstructured.Unstructured{}
+	pod.SetKind("Pod")
+	pod.SetName("web-pod")
+	pod.SetNamespace("default")
+
+	broker.SendToGroup("other", services.KubeEvent{EventType: services.DeleteEvent, Object: pod})
+	broker.SendToGroup("default", services.KubeEvent{EventType: services.AddEvent, Object: pod})
+
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Expected an event, got %v", err)
+	}
+
+	if event.GetType() != eventspb.EventType_EVENT_TYPE_ADD {
+		t.Errorf("Expected an add event, got %v", event.GetType())
+	}
+
+	metadata := event.GetObject().GetFields()["metadata"].GetStructValue()
+	if name := metadata.GetFields()["name"].GetStringValue(); name != "web-pod" {
+		t.Errorf("Expected the object to be web-pod, got %q", name)
+	}
+
+	// Namespaces which aren't permitted are refused once the stream starts
+	denied, _ := client.Subscribe(ctx, &eventspb.SubscribeRequest{Namespace: "kube-system"})
+	if _, err := denied.Recv(); err == nil {
+		t.Error("Expected subscribing to kube-system to be denied")
+	}
+
+	// Shutting down ends the stream with a shutdown event
+	go func() { _ = broker.Shutdown(ctx) }()
+
+	event, err = stream.Recv()
+	if err != nil || event.GetType() != eventspb.EventType_EVENT_TYPE_SHUTDOWN {
+		t.Errorf("Expected a shutdown event, got %v %v", event, err)
+	}
+}
+
+func TestEventServer_Subscribe_Authorize(t *testing.T) {
+	broker := newKubeEventBroker(Config{})
+	lis := bufconn.Listen(1024 * 1024)
+
+	// Only clients sending the right token may subscribe, the same hook SSE subscriptions use
+	broker.Authorize = func(r *http.Request, _ string) bool {
+		return r.Header.Get("Authorization") == "Bearer letmein"
+	}
+
+	server := newGRPCServer(broker, nil)
+	go func() { _ = server.Serve(lis) }()
+
+	defer server.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := eventspb.NewEventServiceClient(conn)
+
+	denied, _ := client.Subscribe(ctx, &eventspb.SubscribeRequest{Namespace: "default"})
+	if _, err := denied.Recv(); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected a client without the token to be denied, got %v", err)
+	}
+
+	authCtx := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer letmein")
+	if _, err := client.Subscribe(authCtx, &eventspb.SubscribeRequest{Namespace: "default"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !grpcSubscribed(broker, "default") {
+		t.Error("Expected a client with the token to be subscribed")
+	}
+}
@@ -6,23 +6,38 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"google.golang.org/grpc"
 )
 
 // Version and build info are set at build time using -ldflags
 var version = "0.0.0"
 var buildInfo = "No build info available"
 
+// How long to wait for event streams & watchers to stop when shutting down, before forcing connections closed
+const shutdownTimeout = 10 * time.Second
+
 func main() {
 	config := getConfig()
 
 	log.Printf("🚀 KubeView %s starting on port %d...\n", version, config.Port)
 	log.Printf("🔧 Configuration %+v", config)
 
+	if config.BasePath != "" {
+		log.Printf("🪧 Serving under base path %s/", config.BasePath)
+	}
+
 	r := chi.NewRouter()
 
 	// This configures the core server, handling pretty much everything
@@ -37,12 +52,61 @@ func main() {
 	//nolint:gosec
 	httpServer := &http.Server{
 		Addr:    ":" + strconv.Itoa(config.Port),
-		Handler: r,
+		Handler: withBasePath(r, config.BasePath),
 		// Do NOT set timeouts it messes with the SSE connection
 		// Also why we don't use api.StartServer
 	}
 
-	if err := httpServer.ListenAndServe(); err != nil {
-		log.Fatalf("💥 Server failed to start: %v", err)
+	go func() {
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("💥 Server failed to start: %v", err)
+		}
+	}()
+
+	// The gRPC event streaming API is optional, and served on its own port
+	var grpcServer *grpc.Server
+
+	if config.GRPCPort > 0 {
+		lis, err := net.Listen("tcp", ":"+strconv.Itoa(config.GRPCPort))
+		if err != nil {
+			log.Fatalf("💥 gRPC server failed to listen: %v", err)
+		}
+
+		grpcServer = newGRPCServer(api.eventBroker, api.kubeService.NamespacePermitted)
+
+		log.Printf("📡 gRPC event streaming on port %d", config.GRPCPort)
+
+		go func() {
+			if err := grpcServer.Serve(lis); err != nil {
+				log.Fatalf("💥 gRPC server failed: %v", err)
+			}
+		}()
+	}
+
+	// Wait for a signal to stop, e.g. when the pod is being replaced during a redeploy
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	<-ctx.Done()
+	stop()
+
+	log.Println("🛑 Shutting down, ending event streams...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Clients are sent a shutdown event first, so they back off rather than all reconnecting at once
+	if err := api.Shutdown(shutdownCtx); err != nil {
+		log.Printf("💥 Error during shutdown: %v", err)
 	}
+
+	// Streams have been ended by the broker, so this only waits for them to finish sending
+	if grpcServer != nil {
+		grpcServer.GracefulStop()
+	}
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("💥 Server did not stop cleanly, closing connections: %v", err)
+		_ = httpServer.Close()
+	}
+
+	log.Println("👋 Server stopped")
 }
@@ -0,0 +1,136 @@
+// ==========================================================================================
+// Pinned namespaces, kept for each user so the ones they use most are easy to get back to
+// Users are identified by a header set by an authenticating proxy, see USER_HEADER
+// ==========================================================================================
+
+package main
+
+import (
+	"cmp"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/benc-uk/go-rest-api/pkg/problem"
+	"github.com/go-chi/chi/v5"
+)
+
+// Header holding the user's identity when USER_HEADER isn't set, as sent by oauth2-proxy & most others
+const defaultUserHeader = "X-Forwarded-User"
+
+// Without an identity header every request is treated as this one user, fine when only one person uses KubeView
+const anonymousUser = "anonymous"
+
+// Most namespaces one user can pin, so the store can't be grown without limit
+const maxPins = 50
+
+var errTooManyPins = errors.New("too many pinned namespaces")
+
+// PinStore keeps the namespaces each user has pinned, in the order they were pinned
+// Implementations must be safe to call from many requests at once
+type PinStore interface {
+	// Add pins a namespace, pinning one already pinned does nothing
+	Add(user, ns string) error
+	List(user string) ([]string, error)
+	// Remove unpins a namespace, unpinning one which isn't pinned does nothing
+	Remove(user, ns string) error
+}
+
+// memoryPinStore is a PinStore which is lost when the server restarts
+type memoryPinStore struct {
+	mu   sync.Mutex
+	pins map[string][]string
+}
+
+func newMemoryPinStore() *memoryPinStore {
+	return &memoryPinStore{pins: map[string][]string{}}
+}
+
+func (m *memoryPinStore) Add(user, ns string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if slices.Contains(m.pins[user], ns) {
+		return nil
+	}
+
+	if len(m.pins[user]) >= maxPins {
+		return errTooManyPins
+	}
+
+	m.pins[user] = append(m.pins[user], ns)
+
+	return nil
+}
+
+func (m *memoryPinStore) List(user string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]string{}, m.pins[user]...), nil
+}
+
+func (m *memoryPinStore) Remove(user, ns string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.pins[user] = slices.DeleteFunc(m.pins[user], func(pinned string) bool { return pinned == ns })
+	if len(m.pins[user]) == 0 {
+		delete(m.pins, user)
+	}
+
+	return nil
+}
+
+// userIdentity is who made the request, from the configured identity header
+func (s *KubeviewAPI) userIdentity(r *http.Request) string {
+	user := strings.TrimSpace(r.Header.Get(cmp.Or(s.config.UserHeader, defaultUserHeader)))
+	return cmp.Or(user, anonymousUser)
+}
+
+// List the namespaces pinned by the user making the request
+func (s *KubeviewAPI) handlePinList(w http.ResponseWriter, r *http.Request) {
+	pins, err := s.pins.List(s.userIdentity(r))
+	if err != nil {
+		problem.Wrap(500, r.RequestURI, "pinned namespaces", err).Send(w)
+		return
+	}
+
+	s.ReturnJSON(w, pins)
+}
+
+// Pin a namespace for the user making the request, returning all their pins
+func (s *KubeviewAPI) handlePinAdd(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	user := s.userIdentity(r)
+
+	if err := s.pins.Add(user, ns); err != nil {
+		if errors.Is(err, errTooManyPins) {
+			problem.Wrap(400, r.RequestURI, "pin namespace", err).Send(w)
+			return
+		}
+
+		problem.Wrap(500, r.RequestURI, "pin namespace", err).Send(w)
+
+		return
+	}
+
+	s.handlePinList(w, r)
+}
+
+// Unpin a namespace for the user making the request, returning their remaining pins
+func (s *KubeviewAPI) handlePinRemove(w http.ResponseWriter, r *http.Request) {
+	if err := s.pins.Remove(s.userIdentity(r), chi.URLParam(r, "namespace")); err != nil {
+		problem.Wrap(500, r.RequestURI, "unpin namespace", err).Send(w)
+		return
+	}
+
+	s.handlePinList(w, r)
+}
@@ -0,0 +1,95 @@
+// ==========================================================================================
+// Unit tests for pinned namespaces
+// ==========================================================================================
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestMemoryPinStore(t *testing.T) {
+	store := newMemoryPinStore()
+
+	for _, ns := range []string{"team-a", "default", "team-a"} {
+		if err := store.Add("alex", ns); err != nil {
+			t.Fatalf("Expected no error pinning %s, got %v", ns, err)
+		}
+	}
+
+	_ = store.Add("sam", "monitoring")
+
+	// Pinning twice does nothing, and pins are kept in the order they were added
+	if pins, _ := store.List("alex"); !slices.Equal(pins, []string{"team-a", "default"}) {
+		t.Errorf("Expected team-a & default, got %v", pins)
+	}
+
+	if pins, _ := store.List("sam"); !slices.Equal(pins, []string{"monitoring"}) {
+		t.Errorf("Expected each user to have their own pins, got %v", pins)
+	}
+
+	_ = store.Remove("alex", "team-a")
+	_ = store.Remove("alex", "never-pinned")
+
+	if pins, _ := store.List("alex"); !slices.Equal(pins, []string{"default"}) {
+		t.Errorf("Expected only default after unpinning team-a, got %v", pins)
+	}
+
+	_ = store.Remove("alex", "default")
+
+	if pins, err := store.List("alex"); err != nil || pins == nil || len(pins) != 0 {
+		t.Errorf("Expected an empty list with no pins, got %v %v", pins, err)
+	}
+
+	for i := range maxPins {
+		_ = store.Add("busy", fmt.Sprintf("ns-%d", i))
+	}
+
+	if err := store.Add("busy", "one-more"); !errors.Is(err, errTooManyPins) {
+		t.Errorf("Expected errTooManyPins, got %v", err)
+	}
+}
+
+func TestPins_Routes(t *testing.T) {
+	h := newTestServer(Config{UserHeader: "X-Auth-Request-User"})
+
+	send := func(method, path, user string) []string {
+		req := httptest.NewRequest(method, path, nil)
+		if user != "" {
+			req.Header.Set("X-Auth-Request-User", user)
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s %s: expected status 200, got %d", method, path, rec.Code)
+		}
+
+		pins := []string{}
+		_ = json.Unmarshal(rec.Body.Bytes(), &pins)
+
+		return pins
+	}
+
+	send(http.MethodPut, "/api/pins/team-a", "alex")
+
+	if pins := send(http.MethodPut, "/api/pins/default", "alex"); !slices.Equal(pins, []string{"team-a", "default"}) {
+		t.Errorf("Expected alex's pins to be returned, got %v", pins)
+	}
+
+	// Without the header the request is anonymous, so sees none of alex's pins
+	if pins := send(http.MethodGet, "/api/pins", ""); len(pins) != 0 {
+		t.Errorf("Expected no pins for an anonymous user, got %v", pins)
+	}
+
+	if pins := send(http.MethodDelete, "/api/pins/team-a", "alex"); !slices.Equal(pins, []string{"default"}) {
+		t.Errorf("Expected default to be left pinned, got %v", pins)
+	}
+}
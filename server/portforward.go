@@ -0,0 +1,54 @@
+// ==========================================================================================
+// Port forwarding, bridging a websocket from the browser or a local proxy to a pod port
+// - Each websocket is one TCP connection to the pod, data is sent as binary messages
+// - Needs ENABLE_PORT_FORWARD set and read-only mode off
+// ==========================================================================================
+
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/benc-uk/kubeview/server/services"
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/net/websocket"
+)
+
+// Forward a websocket connection to a port on a pod, the port is a number or a named container port
+func (s *KubeviewAPI) handlePortForward(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+	podName := chi.URLParam(r, "podname")
+	port := chi.URLParam(r, "port")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	// Checked before upgrading, so the caller gets a proper error response
+	if s.kubeService.ReadOnly {
+		sendOperationError(w, r, "port forward", services.ErrReadOnly)
+		return
+	}
+
+	if !s.kubeService.PortForwardEnabled {
+		sendOperationError(w, r, "port forward", services.ErrPortForwardDisabled)
+		return
+	}
+
+	server := websocket.Server{
+		Handshake: checkSameOrigin,
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			ws.PayloadType = websocket.BinaryFrame
+
+			err := s.kubeService.PortForward(ws.Request().Context(), ns, podName, port, ws)
+			if err != nil {
+				log.Printf("💥 Port forward to pod %s port %s in namespace %s failed: %v", podName, port, ns, err)
+			}
+		},
+	}
+
+	server.ServeHTTP(w, r)
+}
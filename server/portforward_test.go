@@ -0,0 +1,42 @@
+// ==========================================================================================
+// Unit tests for port forwarding
+// ==========================================================================================
+
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/benc-uk/go-rest-api/pkg/api"
+	"github.com/benc-uk/kubeview/server/services"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestPortForward_Disabled(t *testing.T) {
+	tests := []struct {
+		name string
+		kube *services.Kubernetes
+	}{
+		{"disabled by default", &services.Kubernetes{}},
+		{"read-only", &services.Kubernetes{ReadOnly: true, PortForwardEnabled: true}},
+	}
+
+	for _, test := range tests {
+		s := &KubeviewAPI{
+			Base:        api.NewBase("kubeview", "test", "test", true),
+			kubeService: test.kube,
+			eventBroker: newKubeEventBroker(Config{}),
+		}
+
+		r := chi.NewRouter()
+		s.AddRoutes(r)
+
+		res := doRequest(t, r, "/api/portforward/default/web/8080", nil)
+		_ = res.Body.Close()
+
+		if res.StatusCode != http.StatusForbidden {
+			t.Errorf("%s: expected status 403, got %d", test.name, res.StatusCode)
+		}
+	}
+}
@@ -5,18 +5,32 @@
 package main
 
 import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"io/fs"
 	"log"
 	"net/http"
 	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/benc-uk/go-rest-api/pkg/problem"
+	"github.com/benc-uk/go-rest-api/pkg/sse"
 	kubeview "github.com/benc-uk/kubeview"
+	"github.com/benc-uk/kubeview/server/services"
 	"github.com/go-chi/chi/v5"
 )
 
+// Largest manifest accepted by /api/apply, far more than any hand written manifest
+const maxManifestSize = 2 << 20
+
 // All application routes are defined here
 func (s *KubeviewAPI) AddRoutes(r *chi.Mux) {
 	// Create a sub-filesystem rooted at the "frontend" directory within the embedded FS
@@ -25,31 +39,103 @@ func (s *KubeviewAPI) AddRoutes(r *chi.Mux) {
 		log.Fatalf("💥 Failed to create sub-filesystem for embedded frontend dir: %v", err)
 	}
 
-	// Serve the index.html file from the embedded frontend folder
-	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		index, err := fs.ReadFile(frontendFS, "index.html")
-		if err != nil {
-			http.Error(w, "index.html not found", http.StatusNotFound)
-			return
-		}
+	// All the embedded files are loaded into memory up front, hashed for ETags and gzipped
+	files, err := loadStaticFiles(frontendFS)
+	if err != nil {
+		log.Fatalf("💥 Failed to load embedded frontend dir: %v", err)
+	}
+
+	// Set the base href in index.html to our base path
+	// This lets relative links to assets & the API work when we're served behind a proxy under a prefix
+	index, ok := files["index.html"]
+	if !ok {
+		log.Fatalf("💥 Failed to read index.html from embedded frontend dir")
+	}
+
+	files["index.html"] = newStaticFile("index.html", bytes.Replace(index.content,
+		[]byte(`<base href="/" />`), []byte(`<base href="`+s.config.BasePath+`/" />`), 1))
 
-		w.Header().Set("Content-Type", "text/html; charset=utf-8")
-		_, _ = w.Write(index)
+	// Serve the index.html file
+	r.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		files.serveFile(w, r, "index.html")
 	})
 
 	// Serve the embedded frontend folder, which contains static files, JS, CSS, images, etc.
 	// This is how the frontend is served, it's just static files embedded in the binary
-	r.HandleFunc("/public/*", func(w http.ResponseWriter, r *http.Request) {
-		http.StripPrefix("/public/", http.FileServer(http.FS(frontendFS))).ServeHTTP(w, r)
-	})
+	r.Handle("/public/*", http.StripPrefix("/public/", files))
 
 	// Special route for SSE streaming events to connected clients
 	r.HandleFunc("/updates", s.handleSSE)
 
 	// REST API routes
 	r.Get("/api/namespaces", s.handleNamespaceList)
+	r.Get("/api/resourcetypes", s.handleAPIResources)
+	r.Get("/api/serverinfo", s.handleServerInfo)
+	r.Get("/api/pins", s.handlePinList)
+	r.Put("/api/pins/{namespace}", s.handlePinAdd)
+	r.Delete("/api/pins/{namespace}", s.handlePinRemove)
 	r.Get("/api/fetch/{namespace}", s.handleFetchData)
+	r.Get("/api/fetch/{namespace}/stream", s.handleFetchStream)
 	r.Get("/api/logs/{namespace}/{podname}", s.handlePodLogs)
+	r.Get("/api/env/{namespace}/{podname}", s.handlePodEnv)
+	r.Get("/api/pullsecrets/{namespace}/{podname}", s.handlePodPullSecrets)
+	r.Get("/api/containers/{namespace}/{podname}", s.handlePodContainers)
+	r.Get("/api/exec/{namespace}/{podname}", s.handleExec)
+	r.Get("/api/portforward/{namespace}/{podname}/{port}", s.handlePortForward)
+	r.Get("/api/resources/{namespace}", s.handlePodResources)
+	r.Get("/api/podstatus/{namespace}", s.handlePodStatuses)
+	r.Get("/api/owners/{namespace}/{kind}/{name}", s.handleOwnerChain)
+	r.Get("/api/object/{namespace}/{kind}/{name}", s.handleObjectDetail)
+	r.Get("/api/describe/{namespace}/{kind}/{name}", s.handleDescribe)
+	r.Get("/api/watch/{namespace}/{kind}/{name}", s.handleWatchObject)
+	r.Get("/api/hpas/{namespace}", s.handleHPAStatuses)
+	r.Get("/api/hpas/{namespace}/{name}/timeline", s.handleScalingTimeline)
+	r.Get("/api/images/{namespace}", s.handleImageReport)
+	r.Get("/api/usedby/{namespace}", s.handleConfigUsage)
+	r.Get("/api/orphans/{namespace}", s.handleOrphans)
+	r.Get("/api/quotas/{namespace}", s.handleQuotaReport)
+	r.Get("/api/daemonsets/{namespace}", s.handleDaemonSetCoverage)
+	r.Get("/api/leases/{namespace}", s.handleLeaseStatuses)
+	r.Get("/api/scheduling/{namespace}", s.handleSchedulingSummaries)
+	r.Get("/api/rollout/{namespace}/{name}", s.handleRolloutStatus)
+	r.Get("/api/rollout/{namespace}/{name}/history", s.handleRolloutHistory)
+	r.Post("/api/rollout/{namespace}/{name}/rollback", s.handleRollback)
+	r.Get("/api/serviceaccounts/{namespace}", s.handleServiceAccountRoles)
+	r.Get("/api/networkpolicies/{namespace}", s.handleNetworkPolicies)
+	r.Get("/api/search/{namespace}", s.handleSearch)
+	r.Get("/api/select/{resource}", s.handleSelect)
+	r.Get("/api/table/{namespace}/{resource}", s.handleResourceTable)
+	r.Get("/api/export/{namespace}", s.handleExport)
+	r.Get("/api/export", s.handleStreamExport)
+	r.Get("/api/batch/{namespace}", s.handleBatchStatus)
+	r.Get("/api/cronjobs/{namespace}/{name}/runs", s.handleCronJobRuns)
+	r.Post("/api/cronjobs/{namespace}/{name}/trigger", s.handleCronJobTrigger)
+	r.Post("/api/annotate/{namespace}/{resource}/{name}", s.handleAddAnnotation)
+	r.Post("/api/scale/{namespace}/{resource}/{name}", s.handleScale)
+	r.Post("/api/apply", s.handleApplyManifest)
+	r.Post("/api/diff", s.handleServerDiff)
+	r.Post("/api/debug/{namespace}/{podname}", s.handleAddDebugContainer)
+	r.Post("/api/evict/{namespace}/{podname}", s.handleEvictPod)
+	r.Post("/api/drain/{node}", s.handleDrainNode)
+}
+
+// Serve everything under the base path when one is set, requests outside of it get a 404
+// Requests for the base path without a trailing slash are redirected, so relative links resolve
+func withBasePath(h http.Handler, basePath string) http.Handler {
+	if basePath == "" {
+		return h
+	}
+
+	stripped := http.StripPrefix(basePath, h)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == basePath {
+			http.Redirect(w, r, basePath+"/", http.StatusMovedPermanently)
+			return
+		}
+
+		stripped.ServeHTTP(w, r)
+	})
 }
 
 // Establish the SSE connection for streaming updates each client
@@ -60,11 +146,10 @@ func (s *KubeviewAPI) handleSSE(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := s.eventBroker.Stream(clientID, w, *r)
-	if err != nil {
-		log.Fatalln("💥 Error in SSE broker stream:", err)
-		return
-	}
+	// Optional comma separated kinds to receive events for, e.g. kinds=Pod,Service
+	s.eventBroker.SetKinds(clientID, splitList(r.URL.Query().Get("kinds")))
+
+	s.eventBroker.Stream(clientID, w, r)
 }
 
 // Get the list of namespaces from the Kubernetes cluster
@@ -75,18 +160,26 @@ func (s *KubeviewAPI) handleNamespaceList(w http.ResponseWriter, r *http.Request
 
 	var namespaces []string
 
-	var err error
+	terminating := []services.NamespaceStatus{}
 
 	if s.config.SingleNamespace != "" {
 		// If SingleNamespace is set, we only return that namespace
 		namespaces = []string{s.config.SingleNamespace}
 	} else {
-		namespaces, err = s.kubeService.GetNamespaces()
+		statuses, err := s.kubeService.GetNamespaceStatuses()
 		if err != nil {
 			problem.Wrap(500, r.RequestURI, "namespaces", err).Send(w)
 			return
 		}
 
+		for _, ns := range statuses {
+			namespaces = append(namespaces, ns.Name)
+
+			if ns.Phase == "Terminating" {
+				terminating = append(terminating, ns)
+			}
+		}
+
 		// Remove namespaces that are in the filter, filter is a regex
 		if s.config.NameSpaceFilter != "" {
 			filteredNamespaces := make([]string, 0, len(namespaces))
@@ -107,12 +200,24 @@ func (s *KubeviewAPI) handleNamespaceList(w http.ResponseWriter, r *http.Request
 		}
 	}
 
+	// Only report terminating namespaces which are still in the list after filtering
+	terminating = slices.DeleteFunc(terminating, func(ns services.NamespaceStatus) bool {
+		return !slices.Contains(namespaces, ns.Name)
+	})
+
 	res := NamespaceListResult{
 		ClusterHost: s.kubeService.ClusterHost,
 		Namespaces:  namespaces,
 		Version:     s.Version,
 		BuildInfo:   s.BuildInfo,
 		Mode:        s.kubeService.Mode,
+		Terminating: terminating,
+
+		DefaultNamespace: s.config.DefaultNamespace,
+	}
+
+	if r.URL.Query().Get("counts") == "true" {
+		res.Summaries = s.kubeService.GetNamespaceSummaries(namespaces)
 	}
 
 	s.ReturnJSON(w, res)
@@ -120,43 +225,141 @@ func (s *KubeviewAPI) handleNamespaceList(w http.ResponseWriter, r *http.Request
 
 // Return the resources for a specific namespace
 func (s *KubeviewAPI) handleFetchData(w http.ResponseWriter, r *http.Request) {
+	ns, opts, ok := s.startFetch(w, r)
+	if !ok {
+		return
+	}
+
+	timing := s.newServerTiming(&opts)
+
+	// Typed clients can ask for the NamespaceView, otherwise the original map form is returned
+	if r.URL.Query().Get("format") == "view" {
+		view, err := s.kubeService.FetchNamespaceView(ns, opts)
+		if err != nil {
+			sendOperationError(w, r, "fetch data", err)
+			return
+		}
+
+		timing.setHeader(w)
+		s.ReturnJSON(w, view)
+
+		return
+	}
+
+	// The map form has nowhere to say which types were cut down to MAX_OBJECTS, so it's sent as a header
+	truncated := []string{}
+	opts.Truncated = func(kind string, more int) {
+		truncated = append(truncated, kind+"="+strconv.Itoa(more))
+	}
+
+	data, err := s.kubeService.FetchNamespaceWithOptions(ns, opts)
+	if err != nil {
+		sendOperationError(w, r, "fetch data", err)
+		return
+	}
+
+	if len(truncated) > 0 {
+		w.Header().Set("X-Truncated-Resources", strings.Join(truncated, ", "))
+	}
+
+	timing.setHeader(w)
+	s.ReturnJSON(w, data)
+}
+
+// startFetch does the checks common to fetching a namespace, and subscribes the client to its events
+// Returns false when the request has failed, in which case the error has already been sent
+func (s *KubeviewAPI) startFetch(w http.ResponseWriter, r *http.Request) (string, services.FetchOptions, bool) {
 	ns := chi.URLParam(r, "namespace")
 
 	clientID := r.URL.Query().Get("clientID")
 
 	if clientID == "" {
 		http.Error(w, "clientID is required", http.StatusBadRequest)
-		return
+		return "", services.FetchOptions{}, false
 	}
 
 	log.Println("🍵 Fetching resources in", ns)
 
-	// Check single namespace mode
-	if s.config.SingleNamespace != "" && ns != s.config.SingleNamespace {
-		problem.Wrap(403, r.RequestURI, "single namespace mode",
-			errors.New("only namespace permitted is:"+s.config.SingleNamespace)).Send(w)
-
-		return
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return "", services.FetchOptions{}, false
 	}
 
 	// Critical: Puts the client in the correct SSE group for this namespace
 	// Events are sent to this group, so the client will receive updates ONLY for this namespace
-	s.eventBroker.RemoveFromAllGroups(clientID)
-	s.eventBroker.AddToGroup(clientID, ns)
+	if !s.eventBroker.Subscribe(r, clientID, ns) {
+		problem.Wrap(403, r.RequestURI, "subscription denied",
+			errors.New("not authorised to view namespace: "+ns)).Send(w)
+
+		return "", services.FetchOptions{}, false
+	}
 
 	exists := s.kubeService.CheckNamespaceExists(ns)
 	if !exists {
+		// Don't leave the client subscribed to a namespace it can't see, e.g. one on the denylist
+		s.eventBroker.RemoveFromAllGroups(clientID)
 		problem.Wrap(404, r.RequestURI, "namespace not found", errors.New("namespace does not exist")).Send(w)
+
+		return "", services.FetchOptions{}, false
+	}
+
+	// Optional filtering by age, e.g. maxAge=15m to see only recently created resources
+	minAge, err := durationParam(r, "minAge")
+	if err != nil {
+		problem.Wrap(400, r.RequestURI, "invalid minAge", err).Send(w)
+		return "", services.FetchOptions{}, false
+	}
+
+	maxAge, err := durationParam(r, "maxAge")
+	if err != nil {
+		problem.Wrap(400, r.RequestURI, "invalid maxAge", err).Send(w)
+		return "", services.FetchOptions{}, false
+	}
+
+	// Pods of completed Jobs are hidden unless asked for
+	includeCompleted := r.URL.Query().Get("includeCompleted") == "true"
+
+	return ns, services.FetchOptions{MinAge: minAge, MaxAge: maxAge, IncludeCompletedJobPods: includeCompleted}, true
+}
+
+// Stream the resources in a namespace as SSE, one chunk event per resource type then a done event
+// Lets the frontend start drawing the graph before slow types have been fetched
+func (s *KubeviewAPI) handleFetchStream(w http.ResponseWriter, r *http.Request) {
+	ns, opts, ok := s.startFetch(w, r)
+	if !ok {
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
 		return
 	}
 
-	data, err := s.kubeService.FetchNamespace(ns)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+
+	send := func(event services.EventTypeEnum, data any) {
+		json, err := json.Marshal(data)
+		if err != nil {
+			log.Printf("💥 Error marshalling chunk: %v", err)
+			return
+		}
+
+		msg := sse.SSE{Event: string(event), Data: string(json)}
+		msg.Write(w)
+		flusher.Flush()
+	}
+
+	err := s.kubeService.StreamNamespace(ns, opts, func(chunk services.ResourceChunk) {
+		send(services.ChunkEvent, chunk)
+	})
 	if err != nil {
-		problem.Wrap(500, r.RequestURI, "fetch data", err).Send(w)
+		sendOperationError(w, r, "fetch data", err)
 		return
 	}
 
-	s.ReturnJSON(w, data)
+	send(services.DoneEvent, nil)
 }
 
 // Pull logs for a specific pod in a namespace
@@ -169,18 +372,43 @@ func (s *KubeviewAPI) handlePodLogs(w http.ResponseWriter, r *http.Request) {
 	ns := chi.URLParam(r, "namespace")
 	podName := chi.URLParam(r, "podname")
 
-	count := r.URL.Query().Get("max")
-	if count == "" {
-		count = "100" // Default to 100 lines if not specified
+	// With no count the last 100 lines are returned, or every line in the window when one is given
+	logCount := 0
+
+	if count := r.URL.Query().Get("max"); count != "" {
+		var err error
+		if logCount, err = strconv.Atoi(count); err != nil {
+			problem.Wrap(400, r.RequestURI, "invalid log count", err).Send(w)
+			return
+		}
+	}
+
+	// Asking for too many lines is clamped rather than refused, the headers say when this has happened
+	logCount, clamped := s.kubeService.LogLineLimit(logCount)
+	if clamped {
+		w.Header().Set("X-Log-Lines-Clamped", "true")
+		w.Header().Set("X-Log-Max-Lines", strconv.Itoa(logCount))
 	}
 
-	logCount, err := strconv.Atoi(count)
+	// Optional window of time, e.g. sinceSeconds=300 for the last five minutes
+	opts, err := logOptions(r)
 	if err != nil {
-		problem.Wrap(400, r.RequestURI, "invalid log count", err).Send(w)
+		problem.Wrap(400, r.RequestURI, "invalid log window", err).Send(w)
+		return
+	}
+
+	// Logs from every container can be merged into one, with each line prefixed by the container name
+	getLogs := s.kubeService.GetPodLogsWithOptions
+	if r.URL.Query().Get("allContainers") == "true" {
+		getLogs = s.kubeService.GetPodLogsAllContainersWithOptions
+	}
+
+	logs, err := getLogs(ns, podName, logCount, opts)
+	if errors.Is(err, services.ErrInvalidLogOptions) {
+		problem.Wrap(400, r.RequestURI, "invalid log window", err).Send(w)
 		return
 	}
 
-	logs, err := s.kubeService.GetPodLogs(ns, podName, logCount)
 	if err != nil {
 		// Note: We don't send a problem response here, as we want to return something even if there's an error
 		// This is more graceful as the pod might not be in a state to fetch logs
@@ -189,3 +417,916 @@ func (s *KubeviewAPI) handlePodLogs(w http.ResponseWriter, r *http.Request) {
 
 	s.ReturnText(w, logs)
 }
+
+// Read the optional sinceSeconds, sinceTime & timestamps parameters for pod logs, times are in RFC 3339 format
+// Setting both is rejected by the service, as the API only allows one of them
+func logOptions(r *http.Request) (services.LogOptions, error) {
+	opts := services.LogOptions{}
+
+	if s := r.URL.Query().Get("sinceSeconds"); s != "" {
+		seconds, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return opts, fmt.Errorf("invalid sinceSeconds: %w", err)
+		}
+
+		opts.SinceSeconds = seconds
+	}
+
+	if s := r.URL.Query().Get("sinceTime"); s != "" {
+		since, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return opts, fmt.Errorf("invalid sinceTime: %w", err)
+		}
+
+		opts.SinceTime = since
+	}
+
+	opts.Timestamps = r.URL.Query().Get("timestamps") == "true"
+
+	return opts, nil
+}
+
+// Return the name & type of every container in a pod, for choosing one to view logs of or exec into
+func (s *KubeviewAPI) handlePodContainers(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	containers, err := s.kubeService.GetPodContainers(ns, chi.URLParam(r, "podname"))
+	if err != nil {
+		sendOperationError(w, r, "pod containers", err)
+		return
+	}
+
+	s.ReturnJSON(w, containers)
+}
+
+// Return the effective environment of each container in a pod, with references resolved
+func (s *KubeviewAPI) handlePodEnv(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+	podName := chi.URLParam(r, "podname")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	env, err := s.kubeService.GetPodEnv(ns, podName)
+	if err != nil {
+		sendOperationError(w, r, "pod env", err)
+		return
+	}
+
+	s.ReturnJSON(w, env)
+}
+
+// Return the image pull secrets a pod uses, from its spec & its ServiceAccount, flagging any which are missing
+func (s *KubeviewAPI) handlePodPullSecrets(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	secrets, err := s.kubeService.GetPodPullSecrets(ns, chi.URLParam(r, "podname"))
+	if err != nil {
+		sendOperationError(w, r, "pod pull secrets", err)
+		return
+	}
+
+	s.ReturnJSON(w, secrets)
+}
+
+// Return the requests, limits & current usage of every pod in a namespace
+func (s *KubeviewAPI) handlePodResources(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	resources, err := s.kubeService.GetPodResources(ns)
+	if err != nil {
+		sendOperationError(w, r, "pod resources", err)
+		return
+	}
+
+	s.ReturnJSON(w, resources)
+}
+
+// Return the namespaced resource types served by the cluster, including any from CRDs
+func (s *KubeviewAPI) handleAPIResources(w http.ResponseWriter, r *http.Request) {
+	resources, err := s.kubeService.GetAPIResources()
+	if err != nil {
+		sendOperationError(w, r, "api discovery", err)
+		return
+	}
+
+	s.ReturnJSON(w, resources)
+}
+
+// Return the API server's version & build details, and the API versions it serves
+func (s *KubeviewAPI) handleServerInfo(w http.ResponseWriter, r *http.Request) {
+	info, err := s.kubeService.GetServerInfo()
+	if err != nil {
+		sendOperationError(w, r, "server info", err)
+		return
+	}
+
+	s.ReturnJSON(w, info)
+}
+
+// Return the phase, QoS class & priority of every pod in a namespace
+func (s *KubeviewAPI) handlePodStatuses(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	statuses, err := s.kubeService.GetPodStatuses(ns)
+	if err != nil {
+		sendOperationError(w, r, "pod status", err)
+		return
+	}
+
+	s.ReturnJSON(w, statuses)
+}
+
+// Return the chain of controlling owners of a resource, e.g. Pod → ReplicaSet → Deployment
+func (s *KubeviewAPI) handleOwnerChain(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	chain, err := s.kubeService.ResolveOwnerChain(ns, chi.URLParam(r, "kind"), chi.URLParam(r, "name"))
+	if err != nil {
+		sendOperationError(w, r, "owner chain", err)
+		return
+	}
+
+	s.ReturnJSON(w, chain)
+}
+
+// Return a single object with its last applied configuration, so the declared & live state can be compared
+func (s *KubeviewAPI) handleObjectDetail(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	detail, err := s.kubeService.GetObjectDetail(ns, r.URL.Query().Get("apiVersion"),
+		chi.URLParam(r, "kind"), chi.URLParam(r, "name"))
+	if err != nil {
+		sendOperationError(w, r, "object detail", err)
+		return
+	}
+
+	s.ReturnJSON(w, detail)
+}
+
+// Return a kubectl describe style summary of an object as plain text
+func (s *KubeviewAPI) handleDescribe(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	description, err := s.kubeService.DescribeResource(ns, chi.URLParam(r, "kind"), chi.URLParam(r, "name"))
+	if err != nil {
+		sendOperationError(w, r, "describe", err)
+		return
+	}
+
+	s.ReturnText(w, description)
+}
+
+// Subscribe a client to live updates for a single object, e.g. the one open in the detail pane
+// Events go to the client's existing SSE stream, in the object's group, replacing any object it was watching
+func (s *KubeviewAPI) handleWatchObject(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+	kind := chi.URLParam(r, "kind")
+	name := chi.URLParam(r, "name")
+
+	clientID := r.URL.Query().Get("clientID")
+	if clientID == "" {
+		http.Error(w, "clientID is required", http.StatusBadRequest)
+		return
+	}
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	if s.eventBroker.Authorize != nil && !s.eventBroker.Authorize(r, ns) {
+		problem.Wrap(403, r.RequestURI, "subscription denied",
+			errors.New("not authorised to view namespace: "+ns)).Send(w)
+
+		return
+	}
+
+	group := services.ObjectGroup(ns, kind, name)
+	apiVersion := r.URL.Query().Get("apiVersion")
+
+	err := s.eventBroker.SubscribeObject(clientID, group, func(ctx context.Context) error {
+		return s.kubeService.WatchObject(ctx, ns, apiVersion, kind, name, s.eventBroker)
+	})
+	if err != nil {
+		sendOperationError(w, r, "watch object", err)
+		return
+	}
+
+	s.ReturnJSON(w, map[string]string{"group": group})
+}
+
+// Return the status of every HorizontalPodAutoscaler in a namespace
+func (s *KubeviewAPI) handleHPAStatuses(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	statuses, err := s.kubeService.GetHPAStatuses(ns)
+	if err != nil {
+		sendOperationError(w, r, "hpa status", err)
+		return
+	}
+
+	s.ReturnJSON(w, statuses)
+}
+
+// Return the rescales of an HPA joined with the replica changes of the workload it scales
+func (s *KubeviewAPI) handleScalingTimeline(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+	name := chi.URLParam(r, "name")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	timeline, err := s.kubeService.GetScalingTimeline(ns, name)
+	if err != nil {
+		sendOperationError(w, r, "scaling timeline", err)
+		return
+	}
+
+	s.ReturnJSON(w, timeline)
+}
+
+// Return the holder & expiry of every Lease in a namespace, for checking on leader election
+func (s *KubeviewAPI) handleLeaseStatuses(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	statuses, err := s.kubeService.GetLeaseStatuses(ns)
+	if err != nil {
+		sendOperationError(w, r, "lease status", err)
+		return
+	}
+
+	s.ReturnJSON(w, statuses)
+}
+
+// Return the node selector, affinity & topology spread of every Deployment & StatefulSet in a namespace
+func (s *KubeviewAPI) handleSchedulingSummaries(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	summaries, err := s.kubeService.GetSchedulingSummaries(ns)
+	if err != nil {
+		sendOperationError(w, r, "scheduling summary", err)
+		return
+	}
+
+	s.ReturnJSON(w, summaries)
+}
+
+// Report every container image in a namespace, flagging those using mutable tags like :latest
+func (s *KubeviewAPI) handleImageReport(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	report, err := s.kubeService.GetImageReport(ns)
+	if err != nil {
+		sendOperationError(w, r, "image report", err)
+		return
+	}
+
+	s.ReturnJSON(w, report)
+}
+
+// Return the pods using each ConfigMap & Secret in a namespace
+func (s *KubeviewAPI) handleConfigUsage(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	usage, err := s.kubeService.GetConfigUsage(ns)
+	if err != nil {
+		sendOperationError(w, r, "config usage", err)
+		return
+	}
+
+	s.ReturnJSON(w, usage)
+}
+
+// Return the resources in a namespace which appear to be orphaned, by type
+func (s *KubeviewAPI) handleOrphans(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	report, err := s.kubeService.GetOrphans(ns)
+	if err != nil {
+		sendOperationError(w, r, "orphans", err)
+		return
+	}
+
+	s.ReturnJSON(w, report)
+}
+
+// Return the ResourceQuotas & LimitRanges in a namespace, with quota usage against the hard limits
+func (s *KubeviewAPI) handleQuotaReport(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	report, err := s.kubeService.GetQuotaReport(ns)
+	if err != nil {
+		sendOperationError(w, r, "quotas", err)
+		return
+	}
+
+	s.ReturnJSON(w, report)
+}
+
+// Return the rollout progress of a Deployment, with a state of in-progress, complete, failed or paused
+func (s *KubeviewAPI) handleRolloutStatus(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	status, err := s.kubeService.GetRolloutStatus(ns, chi.URLParam(r, "name"))
+	if err != nil {
+		sendOperationError(w, r, "rollout status", err)
+		return
+	}
+
+	s.ReturnJSON(w, status)
+}
+
+// Return the revisions a Deployment has rolled out, oldest first, from the ReplicaSets it owns
+func (s *KubeviewAPI) handleRolloutHistory(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	history, err := s.kubeService.GetRolloutHistory(ns, chi.URLParam(r, "name"))
+	if err != nil {
+		sendOperationError(w, r, "rollout history", err)
+		return
+	}
+
+	s.ReturnJSON(w, history)
+}
+
+// Roll a Deployment back to an earlier revision, or the previous one when no revision is given
+func (s *KubeviewAPI) handleRollback(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	revision := 0
+
+	if rev := r.URL.Query().Get("revision"); rev != "" {
+		var err error
+		if revision, err = strconv.Atoi(rev); err != nil {
+			problem.Wrap(400, r.RequestURI, "invalid revision", err).Send(w)
+			return
+		}
+	}
+
+	if err := s.kubeService.RollbackDeployment(ns, chi.URLParam(r, "name"), revision, dryRun(r)); err != nil {
+		sendOperationError(w, r, "rollback deployment", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Compare the nodes each DaemonSet should run on with where its pods are, listing missing & unready nodes
+func (s *KubeviewAPI) handleDaemonSetCoverage(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	coverage, err := s.kubeService.GetDaemonSetCoverage(ns)
+	if err != nil {
+		sendOperationError(w, r, "daemonset coverage", err)
+		return
+	}
+
+	s.ReturnJSON(w, coverage)
+}
+
+// List the ServiceAccounts in a namespace, with the Roles & ClusterRoles bound to each of them
+func (s *KubeviewAPI) handleServiceAccountRoles(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	accounts, err := s.kubeService.GetServiceAccountRoles(ns)
+	if err != nil {
+		sendOperationError(w, r, "serviceaccount roles", err)
+		return
+	}
+
+	s.ReturnJSON(w, accounts)
+}
+
+// Summarise the NetworkPolicies in a namespace, with the pods they select and their rules
+func (s *KubeviewAPI) handleNetworkPolicies(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	policies, err := s.kubeService.GetNetworkPolicies(ns)
+	if err != nil {
+		sendOperationError(w, r, "network policies", err)
+		return
+	}
+
+	s.ReturnJSON(w, policies)
+}
+
+// Search the resources in a namespace by name or label value, the query is passed as q
+func (s *KubeviewAPI) handleSearch(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	query := r.URL.Query().Get("q")
+	if strings.TrimSpace(query) == "" {
+		problem.Wrap(400, r.RequestURI, "search", errors.New("query parameter q is required")).Send(w)
+		return
+	}
+
+	results, err := s.kubeService.SearchResources(ns, query)
+	if err != nil {
+		sendOperationError(w, r, "search", err)
+		return
+	}
+
+	s.ReturnJSON(w, results)
+}
+
+// List a resource type in every namespace matching a label selector, grouped by namespace
+func (s *KubeviewAPI) handleSelect(w http.ResponseWriter, r *http.Request) {
+	if s.config.SingleNamespace != "" {
+		problem.Wrap(403, r.RequestURI, "single namespace mode",
+			errors.New("namespaces can't be queried together in single namespace mode")).Send(w)
+
+		return
+	}
+
+	result, err := s.kubeService.SelectAcrossNamespaces(r.URL.Query().Get("group"),
+		cmp.Or(r.URL.Query().Get("version"), "v1"), chi.URLParam(r, "resource"), r.URL.Query().Get("selector"))
+	if err != nil {
+		sendOperationError(w, r, "select", err)
+		return
+	}
+
+	s.ReturnJSON(w, result)
+}
+
+// Export every resource in a namespace as a multi-document YAML file, for download
+func (s *KubeviewAPI) handleExport(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	bundle, err := s.kubeService.ExportNamespace(ns)
+	if err != nil {
+		sendOperationError(w, r, "export namespace", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", ns+".yaml"))
+	_, _ = w.Write(bundle)
+}
+
+// Stream every resource in a comma separated list of namespaces as JSON Lines
+// Errors once streaming has started can only be logged, the response is cut short
+func (s *KubeviewAPI) handleStreamExport(w http.ResponseWriter, r *http.Request) {
+	namespaces := splitList(r.URL.Query().Get("namespaces"))
+	if len(namespaces) == 0 {
+		problem.Wrap(400, r.RequestURI, "export", errors.New("namespaces is required")).Send(w)
+		return
+	}
+
+	for _, ns := range namespaces {
+		if !s.checkNamespacePermitted(w, r, ns) {
+			return
+		}
+	}
+
+	for _, ns := range namespaces {
+		if !s.kubeService.CheckNamespaceExists(ns) {
+			problem.Wrap(404, r.RequestURI, "namespace not found", errors.New("namespace does not exist: "+ns)).Send(w)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="export.jsonl"`)
+
+	if err := s.kubeService.StreamExport(namespaces, w); err != nil {
+		log.Printf("💥 Export of %v failed: %v", namespaces, err)
+	}
+}
+
+// Return the status of the Jobs & CronJobs in a namespace, with pod counts and schedules
+func (s *KubeviewAPI) handleBatchStatus(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	status, err := s.kubeService.GetBatchStatus(ns)
+	if err != nil {
+		sendOperationError(w, r, "batch status", err)
+		return
+	}
+
+	s.ReturnJSON(w, status)
+}
+
+// List the Jobs which have been run by a CronJob
+func (s *KubeviewAPI) handleCronJobRuns(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+	name := chi.URLParam(r, "name")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	runs, err := s.kubeService.ListCronJobRuns(ns, name)
+	if err != nil {
+		sendOperationError(w, r, "cronjob runs", err)
+		return
+	}
+
+	s.ReturnJSON(w, runs)
+}
+
+// Manually trigger a CronJob, creating a new Job from its template
+func (s *KubeviewAPI) handleCronJobTrigger(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+	name := chi.URLParam(r, "name")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	log.Printf("⏰ Triggering cronjob %s in %s", name, ns)
+
+	job, err := s.kubeService.TriggerCronJob(ns, name, dryRun(r))
+	if err != nil {
+		sendOperationError(w, r, "trigger cronjob", err)
+		return
+	}
+
+	s.ReturnJSON(w, job)
+}
+
+// List resources as a table with the same columns kubectl shows, the group & version are query parameters
+func (s *KubeviewAPI) handleResourceTable(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	table, err := s.kubeService.GetResourceTable(ns, r.URL.Query().Get("group"),
+		cmp.Or(r.URL.Query().Get("version"), "v1"), chi.URLParam(r, "resource"))
+	if err != nil {
+		sendOperationError(w, r, "table", err)
+		return
+	}
+
+	s.ReturnJSON(w, table)
+}
+
+// Set an annotation on a resource, the group & version are query parameters with the core v1 API as default
+// The body is JSON with the annotation key & value e.g. {"key": "example.com/note", "value": "investigating"}
+func (s *KubeviewAPI) handleAddAnnotation(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	body := struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}{}
+
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		problem.Wrap(400, r.RequestURI, "invalid annotation", err).Send(w)
+		return
+	}
+
+	obj, err := s.kubeService.AddAnnotation(ns, r.URL.Query().Get("group"),
+		cmp.Or(r.URL.Query().Get("version"), "v1"), chi.URLParam(r, "resource"), chi.URLParam(r, "name"),
+		body.Key, body.Value, dryRun(r))
+	if err != nil {
+		sendOperationError(w, r, "annotate", err)
+		return
+	}
+
+	s.ReturnJSON(w, obj)
+}
+
+// Set the replica count of a workload through its scale subresource, the group defaults to apps
+func (s *KubeviewAPI) handleScale(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	replicas, err := strconv.ParseInt(r.URL.Query().Get("replicas"), 10, 32)
+	if err != nil {
+		problem.Wrap(400, r.RequestURI, "invalid replicas", err).Send(w)
+		return
+	}
+
+	scale, err := s.kubeService.ScaleWorkload(ns, cmp.Or(r.URL.Query().Get("group"), "apps"),
+		cmp.Or(r.URL.Query().Get("version"), "v1"), chi.URLParam(r, "resource"), chi.URLParam(r, "name"),
+		replicas, dryRun(r))
+	if err != nil {
+		sendOperationError(w, r, "scale", err)
+		return
+	}
+
+	s.ReturnJSON(w, scale)
+}
+
+// Server-side apply a YAML or JSON manifest in the request body, returning the outcome of each document
+// Namespaces are checked per document by the service, as a manifest can span many
+func (s *KubeviewAPI) handleApplyManifest(w http.ResponseWriter, r *http.Request) {
+	manifest, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxManifestSize))
+	if err != nil {
+		problem.Wrap(400, r.RequestURI, "invalid manifest", err).Send(w)
+		return
+	}
+
+	results, err := s.kubeService.ApplyManifest(manifest, dryRun(r))
+	if err != nil {
+		sendOperationError(w, r, "apply manifest", err)
+		return
+	}
+
+	s.ReturnJSON(w, results)
+}
+
+// Show what applying the manifest in the request body would change, like kubectl diff
+func (s *KubeviewAPI) handleServerDiff(w http.ResponseWriter, r *http.Request) {
+	manifest, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxManifestSize))
+	if err != nil {
+		problem.Wrap(400, r.RequestURI, "invalid manifest", err).Send(w)
+		return
+	}
+
+	diffs, err := s.kubeService.ServerDiff(manifest)
+	if err != nil {
+		sendOperationError(w, r, "diff manifest", err)
+		return
+	}
+
+	s.ReturnJSON(w, diffs)
+}
+
+// Add an ephemeral debug container to a pod, which can then be exec'd into with /api/exec
+func (s *KubeviewAPI) handleAddDebugContainer(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	debug := services.DebugContainer{}
+
+	if err := json.NewDecoder(r.Body).Decode(&debug); err != nil {
+		problem.Wrap(400, r.RequestURI, "invalid debug container", err).Send(w)
+		return
+	}
+
+	container, err := s.kubeService.AddEphemeralContainer(ns, chi.URLParam(r, "podname"), debug, dryRun(r))
+	if err != nil {
+		sendOperationError(w, r, "debug container", err)
+		return
+	}
+
+	s.ReturnJSON(w, container)
+}
+
+// Evict a pod, which fails with a 429 while a PodDisruptionBudget is blocking it
+func (s *KubeviewAPI) handleEvictPod(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	if err := s.kubeService.EvictPod(ns, chi.URLParam(r, "podname"), dryRun(r)); err != nil {
+		sendOperationError(w, r, "evict pod", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Drain a node, streaming each step as SSE then a done event, or an error event if any pod wasn't evicted
+// Errors before the drain starts, such as a missing confirmation, are sent as a problem response instead
+func (s *KubeviewAPI) handleDrainNode(w http.ResponseWriter, r *http.Request) {
+	if s.config.SingleNamespace != "" {
+		problem.Wrap(403, r.RequestURI, "single namespace mode",
+			errors.New("nodes can't be drained in single namespace mode")).Send(w)
+
+		return
+	}
+
+	timeout, err := durationParam(r, "timeout")
+	if err != nil {
+		problem.Wrap(400, r.RequestURI, "invalid timeout", err).Send(w)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	started := false
+
+	send := func(event services.EventTypeEnum, data any) {
+		json, err := json.Marshal(data)
+		if err != nil {
+			log.Printf("💥 Error marshalling drain progress: %v", err)
+			return
+		}
+
+		if !started {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+
+			started = true
+		}
+
+		msg := sse.SSE{Event: string(event), Data: string(json)}
+		msg.Write(w)
+		flusher.Flush()
+	}
+
+	opts := services.DrainOptions{
+		Confirm: r.URL.Query().Get("confirm") == "true", Timeout: timeout, DryRun: dryRun(r),
+	}
+
+	err = s.kubeService.DrainNode(r.Context(), chi.URLParam(r, "node"), opts, func(p services.DrainProgress) {
+		send(services.DrainEvent, p)
+	})
+
+	switch {
+	case err != nil && !started:
+		sendOperationError(w, r, "drain node", err)
+	case err != nil:
+		send("error", map[string]string{"error": err.Error()})
+	default:
+		send(services.DoneEvent, nil)
+	}
+}
+
+// Check single namespace mode, sending a 403 problem and returning false when the namespace is not permitted
+func (s *KubeviewAPI) checkNamespacePermitted(w http.ResponseWriter, r *http.Request, ns string) bool {
+	if s.config.SingleNamespace != "" && ns != s.config.SingleNamespace {
+		problem.Wrap(403, r.RequestURI, "single namespace mode",
+			errors.New("only namespace permitted is:"+s.config.SingleNamespace)).Send(w)
+
+		return false
+	}
+
+	return true
+}
+
+// Operations which modify the cluster can be previewed with dryRun=true, the API server validates the change
+// and returns the result, without persisting anything
+func dryRun(r *http.Request) bool {
+	return r.URL.Query().Get("dryRun") == "true"
+}
+
+// Send the problem response for a failed operation
+// Read-only mode & forbidden namespaces are reported as a 403 rather than a 500
+func sendOperationError(w http.ResponseWriter, r *http.Request, title string, err error) {
+	if errors.Is(err, services.ErrReadOnly) {
+		problem.Wrap(403, r.RequestURI, "read-only mode", err).Send(w)
+		return
+	}
+
+	if errors.Is(err, services.ErrPortForwardDisabled) {
+		problem.Wrap(403, r.RequestURI, "port forwarding disabled", err).Send(w)
+		return
+	}
+
+	if errors.Is(err, services.ErrNamespaceForbidden) {
+		problem.Wrap(403, r.RequestURI, "namespace not permitted", err).Send(w)
+		return
+	}
+
+	if errors.Is(err, services.ErrNamespaceNotFound) {
+		problem.Wrap(404, r.RequestURI, "namespace not found", err).Send(w)
+		return
+	}
+
+	if errors.Is(err, services.ErrResourceTypeNotFound) {
+		problem.Wrap(404, r.RequestURI, "resource type not found", err).Send(w)
+		return
+	}
+
+	if errors.Is(err, services.ErrDrainNotConfirmed) {
+		problem.Wrap(400, r.RequestURI, "confirmation required", err).Send(w)
+		return
+	}
+
+	if errors.Is(err, services.ErrInvalidSelector) {
+		problem.Wrap(400, r.RequestURI, "invalid selector", err).Send(w)
+		return
+	}
+
+	if errors.Is(err, services.ErrInvalidManifest) {
+		problem.Wrap(400, r.RequestURI, "invalid manifest", err).Send(w)
+		return
+	}
+
+	if errors.Is(err, services.ErrRevisionNotFound) {
+		problem.Wrap(404, r.RequestURI, "revision not found", err).Send(w)
+		return
+	}
+
+	if errors.Is(err, services.ErrEvictionBlocked) {
+		problem.Wrap(429, r.RequestURI, "eviction blocked", err).Send(w)
+		return
+	}
+
+	problem.Wrap(500, r.RequestURI, title, err).Send(w)
+}
+
+// Parse an optional duration query parameter, returning zero when it is not set
+func durationParam(r *http.Request, name string) (time.Duration, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(v)
+}
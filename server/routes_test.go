@@ -0,0 +1,121 @@
+// ==========================================================================================
+// Unit tests for the HTTP routes serving the frontend
+// ==========================================================================================
+
+package main
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/benc-uk/go-rest-api/pkg/api"
+	kubeview "github.com/benc-uk/kubeview"
+	"github.com/go-chi/chi/v5"
+)
+
+// newTestServer creates the full HTTP handler, with no Kubernetes service behind it
+func newTestServer(conf Config) http.Handler {
+	return newTestServerWithBroker(conf, newKubeEventBroker(conf))
+}
+
+// newTestServerWithBroker creates the full HTTP handler using the given SSE broker
+func newTestServerWithBroker(conf Config, broker KubeEventBroker) http.Handler {
+	s := &KubeviewAPI{
+		Base:        api.NewBase("kubeview", "test", "test", true),
+		config:      conf,
+		eventBroker: broker,
+		pins:        newMemoryPinStore(),
+	}
+
+	r := chi.NewRouter()
+	s.AddRoutes(r)
+
+	return withBasePath(r, conf.BasePath)
+}
+
+// doRequest sends a GET request to the handler, returning the response
+func doRequest(t *testing.T, h http.Handler, path string, headers map[string]string) *http.Response {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec.Result()
+}
+
+func TestBasePath_Assets(t *testing.T) {
+	h := newTestServer(Config{BasePath: "/kubeview"})
+
+	expected, err := fs.ReadFile(kubeview.FrontendFS, "frontend/css/main.css")
+	if err != nil {
+		t.Fatalf("Failed to read embedded asset: %v", err)
+	}
+
+	res := doRequest(t, h, "/kubeview/public/css/main.css", nil)
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200 for asset under base path, got %d", res.StatusCode)
+	}
+
+	body, _ := io.ReadAll(res.Body)
+	if string(body) != string(expected) {
+		t.Error("Expected asset served under base path to match the embedded file")
+	}
+
+	// Outside the base path there is nothing to serve
+	res = doRequest(t, h, "/public/css/main.css", nil)
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404 for asset outside base path, got %d", res.StatusCode)
+	}
+}
+
+func TestBasePath_Index(t *testing.T) {
+	h := newTestServer(Config{BasePath: "/kubeview"})
+
+	res := doRequest(t, h, "/kubeview/", nil)
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(res.Body)
+	if !strings.Contains(string(body), `<base href="/kubeview/" />`) {
+		t.Error("Expected index base href to be rewritten to the base path")
+	}
+
+	// Missing trailing slash is redirected, otherwise relative links would break
+	res = doRequest(t, h, "/kubeview", nil)
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusMovedPermanently || res.Header.Get("Location") != "/kubeview/" {
+		t.Errorf("Expected redirect to /kubeview/, got %d %s", res.StatusCode, res.Header.Get("Location"))
+	}
+}
+
+func TestBasePath_Root(t *testing.T) {
+	h := newTestServer(Config{})
+
+	res := doRequest(t, h, "/", nil)
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(res.Body)
+	if !strings.Contains(string(body), `<base href="/" />`) {
+		t.Error("Expected index base href to be the root by default")
+	}
+
+	res = doRequest(t, h, "/public/css/main.css", nil)
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 for asset at root, got %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,59 @@
+// ==========================================================================================
+// Server-Timing header, showing how long each part of a request took in the browser dev tools
+// Only sent when SERVER_TIMING is enabled, as it reveals details of the cluster & server
+// ==========================================================================================
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benc-uk/kubeview/server/services"
+)
+
+// serverTiming collects named durations for a request, a nil *serverTiming does nothing
+type serverTiming struct {
+	mu      sync.Mutex
+	start   time.Time
+	metrics []string
+}
+
+// newServerTiming starts timing a namespace fetch, with the time for each resource type recorded
+// Returns nil when server timing isn't enabled
+func (s *KubeviewAPI) newServerTiming(opts *services.FetchOptions) *serverTiming {
+	if !s.config.ServerTiming {
+		return nil
+	}
+
+	st := &serverTiming{start: time.Now()}
+	opts.Timing = st.add
+
+	return st
+}
+
+// add records a duration, the name must be a valid HTTP token such as a resource type
+func (st *serverTiming) add(name string, elapsed time.Duration) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	ms := strconv.FormatFloat(float64(elapsed.Microseconds())/1000, 'f', 3, 64)
+	st.metrics = append(st.metrics, name+";dur="+ms)
+}
+
+// setHeader sets the Server-Timing header, ending with the total time since timing started
+func (st *serverTiming) setHeader(w http.ResponseWriter) {
+	if st == nil {
+		return
+	}
+
+	st.add("total", time.Since(st.start))
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	w.Header().Set("Server-Timing", strings.Join(st.metrics, ", "))
+}
@@ -0,0 +1,68 @@
+// ==========================================================================================
+// Unit tests for the Server-Timing header
+// ==========================================================================================
+
+package main
+
+import (
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/benc-uk/kubeview/server/services"
+)
+
+// A metric name followed by a duration in milliseconds, as defined by the Server-Timing spec
+var serverTimingMetric = regexp.MustCompile(`^[a-z]+;dur=\d+\.\d{3}$`)
+
+func TestServerTiming_Header(t *testing.T) {
+	s := &KubeviewAPI{config: Config{ServerTiming: true}}
+	opts := services.FetchOptions{}
+
+	timing := s.newServerTiming(&opts)
+	if opts.Timing == nil {
+		t.Fatal("Expected the fetch options to record timings when enabled")
+	}
+
+	opts.Timing("pods", 12*time.Millisecond+345*time.Microsecond)
+	opts.Timing("services", 2*time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	timing.setHeader(rec)
+
+	header := rec.Header().Get("Server-Timing")
+	metrics := strings.Split(header, ", ")
+
+	if len(metrics) != 3 {
+		t.Fatalf("Expected metrics for pods, services & the total, got %q", header)
+	}
+
+	for _, metric := range metrics {
+		if !serverTimingMetric.MatchString(metric) {
+			t.Errorf("Expected a well formed metric, got %q", metric)
+		}
+	}
+
+	if metrics[0] != "pods;dur=12.345" || !strings.HasPrefix(metrics[2], "total;") {
+		t.Errorf("Expected pods first and the total last, got %q", header)
+	}
+}
+
+func TestServerTiming_Disabled(t *testing.T) {
+	s := &KubeviewAPI{config: Config{}}
+	opts := services.FetchOptions{}
+
+	timing := s.newServerTiming(&opts)
+	if opts.Timing != nil {
+		t.Error("Expected no timings to be recorded when disabled")
+	}
+
+	rec := httptest.NewRecorder()
+	timing.setHeader(rec)
+
+	if header := rec.Header().Get("Server-Timing"); header != "" {
+		t.Errorf("Expected no Server-Timing header when disabled, got %q", header)
+	}
+}
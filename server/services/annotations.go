@@ -0,0 +1,71 @@
+// ==========================================================================================
+// Annotating resources, so notes can be left on a resource while triaging without editing YAML
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// AddAnnotation sets an annotation on a resource, replacing any existing value for the key
+// The key must be a valid annotation key, an optional DNS subdomain prefix and a name e.g. example.com/note
+// It's sent as a JSON merge patch rather than a strategic merge patch, as custom resources don't support the
+// latter, and both merge an annotation the same way. The annotated resource is returned trimmed & redacted
+// like any other, with dryRun it's the resource as it would be annotated, without changing it
+func (k *Kubernetes) AddAnnotation(ns, grp, ver, res, name, key, value string,
+	dryRun bool,
+) (*unstructured.Unstructured, error) {
+	if k.ReadOnly {
+		return nil, ErrReadOnly
+	}
+
+	if ns == "" || ver == "" || res == "" || name == "" {
+		return nil, errors.New("namespace, version, resource or name is empty")
+	}
+
+	if errs := validation.IsQualifiedName(strings.ToLower(key)); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid annotation key %q: %s", key, strings.Join(errs, ", "))
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	// Maps merge the same way in a strategic merge patch, but a JSON merge patch also works for custom resources
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{"annotations": map[string]string{key: value}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	gvr := schema.GroupVersionResource{Group: grp, Version: ver, Resource: res}
+
+	var patched *unstructured.Unstructured
+
+	err = k.callAPI(func(ctx context.Context) (err error) {
+		patched, err = k.dynamicClient.Resource(gvr).Namespace(ns).
+			Patch(ctx, name, types.MergePatchType, patch, metaV1.PatchOptions{DryRun: dryRunOption(dryRun)})
+		return err
+	})
+	if err != nil {
+		log.Printf("💥 Failed to annotate %s %s in namespace %s: %v", res, name, ns, err)
+		return nil, err
+	}
+
+	log.Printf("📝 Annotated %s %s in namespace %s with %s%s", res, name, ns, key, dryRunNote(dryRun))
+
+	return k.cleanObject(patched), nil
+}
@@ -0,0 +1,81 @@
+// ==========================================================================================
+// Unit tests for annotating resources
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestKubernetes_AddAnnotation(t *testing.T) {
+	k := mockKubernetes()
+
+	pod := createTestPod("test-pod", "default")
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+
+	patched, err := k.AddAnnotation("default", "", "v1", "pods", "test-pod", "example.com/note", "looking into it",
+		false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if note := patched.GetAnnotations()["example.com/note"]; note != "looking into it" {
+		t.Errorf("Expected annotation to be set, got %q", note)
+	}
+
+	// Setting the same key again replaces the value
+	patched, err = k.AddAnnotation("default", "", "v1", "pods", "test-pod", "example.com/note", "fixed", false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if note := patched.GetAnnotations()["example.com/note"]; note != "fixed" {
+		t.Errorf("Expected annotation to be replaced, got %q", note)
+	}
+
+	for _, key := range []string{"", "not a key", "-note", "example.com/", "a/b/c"} {
+		if _, err := k.AddAnnotation("default", "", "v1", "pods", "test-pod", key, "x", false); err == nil {
+			t.Errorf("Expected error for invalid key %q", key)
+		}
+	}
+
+	if _, err := k.AddAnnotation("default", "", "v1", "pods", "missing", "note", "x", false); err == nil {
+		t.Error("Expected error for missing pod")
+	}
+
+	k.ReadOnly = true
+	_, err = k.AddAnnotation("default", "", "v1", "pods", "test-pod", "note", "x", false)
+	if !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+}
+
+func TestKubernetes_AddAnnotation_Secret(t *testing.T) {
+	k := mockKubernetes()
+	k.RedactSecrets = true
+
+	_, _ = k.dynamicClient.Resource(secretGVR).Namespace("default").
+		Create(context.TODO(), createTestSecret("db-creds", "default"), metaV1.CreateOptions{})
+
+	patched, err := k.AddAnnotation("default", "", "v1", "secrets", "db-creds", "example.com/note", "rotating",
+		false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The annotated Secret goes back to the browser, so its values are hidden like any other
+	data, _, _ := unstructured.NestedStringMap(patched.Object, "data")
+	if data["password"] != redactedValue || data["username"] != redactedValue {
+		t.Errorf("Expected the secret data to be redacted, got %v", data)
+	}
+
+	if note := patched.GetAnnotations()["example.com/note"]; note != "rotating" {
+		t.Errorf("Expected annotation to be set, got %q", note)
+	}
+}
@@ -0,0 +1,206 @@
+// ==========================================================================================
+// Applying YAML manifests to the cluster with server-side apply, like kubectl apply --server-side
+// ==========================================================================================
+
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// ErrInvalidManifest is returned when a manifest can't be parsed, or has no documents
+var ErrInvalidManifest = errors.New("invalid manifest")
+
+// Field manager for server-side apply, so fields set through KubeView can be told apart from other tools
+const applyFieldManager = "kubeview"
+
+// Size of the buffer used to find the start of each document, it's only a hint to the decoder
+const applyDecodeBuffer = 4096
+
+// ApplyResult is the outcome of applying one document from a manifest
+type ApplyResult struct {
+	// Document is the position of the document in the manifest starting at 0, empty documents aren't counted
+	Document   int    `json:"document"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	Namespace  string `json:"namespace"`
+	// Error is why the document wasn't applied, empty when it was
+	Error string `json:"error,omitempty"`
+}
+
+// ApplyManifest server-side applies every document in a YAML or JSON manifest, returning a result for each
+// Documents are applied in order, one failing doesn't stop the rest. Each is resolved to a resource type
+// using discovery, only namespaced types can be applied, and a missing namespace defaults to "default".
+// Conflicts with fields owned by other managers are reported, not forced. An error is only returned when
+// nothing could be applied, e.g. in read-only mode, or when the manifest can't be parsed.
+// With dryRun every document is validated by the API server, and the results are the same, but nothing changes
+func (k *Kubernetes) ApplyManifest(manifest []byte, dryRun bool) ([]ApplyResult, error) {
+	if k.ReadOnly {
+		return nil, ErrReadOnly
+	}
+
+	objs, err := manifestObjects(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ApplyResult, 0, len(objs))
+	resources := map[string]*metaV1.APIResourceList{}
+
+	for i, obj := range objs {
+		result := newApplyResult(i, obj)
+
+		if _, err := k.applyObject(obj, resources, dryRun); err != nil {
+			result.Error = err.Error()
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func newApplyResult(document int, obj *unstructured.Unstructured) ApplyResult {
+	return ApplyResult{
+		Document:   document,
+		APIVersion: obj.GetAPIVersion(),
+		Kind:       obj.GetKind(),
+		Name:       obj.GetName(),
+		Namespace:  obj.GetNamespace(),
+	}
+}
+
+// applyObject server-side applies a single object, returning the object as stored, or as it would be with dryRun
+// The discovered resource lists are cached in resources
+func (k *Kubernetes) applyObject(obj *unstructured.Unstructured, resources map[string]*metaV1.APIResourceList,
+	dryRun bool,
+) (*unstructured.Unstructured, error) {
+	gvr, err := k.resourceForObject(obj, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied *unstructured.Unstructured
+
+	err = k.callNamespaceAPI(obj.GetNamespace(), func(ctx context.Context) (err error) {
+		applied, err = k.dynamicClient.Resource(gvr).Namespace(obj.GetNamespace()).Apply(ctx, obj.GetName(), obj,
+			metaV1.ApplyOptions{FieldManager: applyFieldManager, DryRun: dryRunOption(dryRun)})
+
+		return err
+	})
+
+	return applied, err
+}
+
+// resourceForObject checks an object from a manifest can be applied, returning its resource type
+func (k *Kubernetes) resourceForObject(obj *unstructured.Unstructured,
+	resources map[string]*metaV1.APIResourceList,
+) (schema.GroupVersionResource, error) {
+	if obj.GetAPIVersion() == "" || obj.GetKind() == "" {
+		return schema.GroupVersionResource{}, errors.New("apiVersion and kind are required")
+	}
+
+	if obj.GetName() == "" {
+		return schema.GroupVersionResource{}, errors.New("metadata.name is required")
+	}
+
+	if err := k.checkNamespace(obj.GetNamespace()); err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+
+	return k.resourceForApply(obj.GetAPIVersion(), obj.GetKind(), resources)
+}
+
+// resourceForApply finds the resource type serving a kind with discovery, it must be namespaced
+func (k *Kubernetes) resourceForApply(apiVersion, kind string,
+	resources map[string]*metaV1.APIResourceList,
+) (schema.GroupVersionResource, error) {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return schema.GroupVersionResource{}, err
+	}
+
+	list, ok := resources[apiVersion]
+	if !ok {
+		err = k.callAPI(func(context.Context) (err error) {
+			list, err = k.clientSet.Discovery().ServerResourcesForGroupVersion(apiVersion)
+			return err
+		})
+		if err != nil {
+			return schema.GroupVersionResource{}, fmt.Errorf("%w: %s, %v", ErrResourceTypeNotFound, apiVersion, err)
+		}
+
+		resources[apiVersion] = list
+	}
+
+	for _, res := range list.APIResources {
+		// Subresources like pods/status share the kind of their parent
+		if res.Kind != kind || strings.Contains(res.Name, "/") {
+			continue
+		}
+
+		if !res.Namespaced {
+			return schema.GroupVersionResource{}, fmt.Errorf("%s is cluster scoped, only namespaced resources can be applied",
+				kind)
+		}
+
+		return gv.WithResource(res.Name), nil
+	}
+
+	return schema.GroupVersionResource{}, fmt.Errorf("%w: %s in %s", ErrResourceTypeNotFound, kind, apiVersion)
+}
+
+// manifestObjects decodes the objects in a manifest, which must have at least one, defaulting their namespace
+func manifestObjects(manifest []byte) ([]*unstructured.Unstructured, error) {
+	objs, err := decodeManifest(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objs) == 0 {
+		return nil, fmt.Errorf("%w: no documents found", ErrInvalidManifest)
+	}
+
+	for _, obj := range objs {
+		if obj.GetNamespace() == "" {
+			obj.SetNamespace("default")
+		}
+	}
+
+	return objs, nil
+}
+
+// decodeManifest splits a manifest into its documents, either YAML separated by --- or concatenated JSON
+// Empty documents, e.g. from a leading --- or with only comments, are dropped
+func decodeManifest(manifest []byte) ([]*unstructured.Unstructured, error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(manifest), applyDecodeBuffer)
+	objs := []*unstructured.Unstructured{}
+
+	for {
+		doc := map[string]interface{}{}
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				return objs, nil
+			}
+
+			return nil, fmt.Errorf("%w, document %d: %v", ErrInvalidManifest, len(objs), err)
+		}
+
+		if len(doc) == 0 {
+			continue
+		}
+
+		objs = append(objs, &unstructured.Unstructured{Object: doc})
+	}
+}
@@ -0,0 +1,329 @@
+// ==========================================================================================
+// Unit tests for applying manifests
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+	"sigs.k8s.io/yaml"
+)
+
+const testManifest = `
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: default
+data:
+  colour: blue
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: other
+---
+apiVersion: example.com/v1
+kind: Gadget
+metadata:
+  name: unknown
+`
+
+// fakeApply makes the fake client handle apply as the API server does, it only updates objects which exist
+// Objects which don't exist are created, and with dry run the result is returned without being stored
+func fakeApply(k *Kubernetes) {
+	client, _ := k.dynamicClient.(*fake.FakeDynamicClient)
+
+	client.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch, _ := action.(k8stesting.PatchActionImpl)
+		if patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+
+		obj := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal(patch.GetPatch(), &obj.Object); err != nil {
+			return true, nil, err
+		}
+
+		stored, err := client.Tracker().Get(action.GetResource(), action.GetNamespace(), obj.GetName())
+
+		if slices.Contains(patch.PatchOptions.DryRun, metaV1.DryRunAll) {
+			if err != nil {
+				return true, obj, nil
+			}
+
+			merged, _ := stored.DeepCopyObject().(*unstructured.Unstructured)
+			mergeMaps(merged.Object, obj.Object)
+
+			return true, merged, nil
+		}
+
+		// Once it exists the default reactor updates it
+		if err == nil {
+			return false, nil, nil
+		}
+
+		return true, obj, client.Tracker().Create(action.GetResource(), obj, action.GetNamespace())
+	})
+}
+
+func setApplyResources(k *Kubernetes) {
+	disc, _ := k.clientSet.Discovery().(*fakediscovery.FakeDiscovery)
+	disc.Resources = []*metaV1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metaV1.APIResource{
+			{Name: "configmaps", Kind: "ConfigMap", Namespaced: true},
+			{Name: "namespaces", Kind: "Namespace", Namespaced: false},
+		}},
+	}
+}
+
+func TestKubernetes_ApplyManifest(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+	fakeApply(k)
+
+	setApplyResources(k)
+
+	results, err := k.ApplyManifest([]byte(testManifest), false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %+v", results)
+	}
+
+	if results[0].Error != "" || results[0].Kind != "ConfigMap" || results[0].Name != "settings" {
+		t.Errorf("Expected the ConfigMap to be applied, got %+v", results[0])
+	}
+
+	// Cluster scoped & unknown kinds fail, without stopping the other documents
+	if results[1].Error == "" || results[2].Error == "" || results[2].Document != 2 {
+		t.Errorf("Expected the Namespace & unknown kind to fail, got %+v", results[1:])
+	}
+
+	cm, err := k.dynamicClient.Resource(configMapGVR).Namespace("default").
+		Get(context.TODO(), "settings", metaV1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected the ConfigMap to be created, got %v", err)
+	}
+
+	if colour, _, _ := unstructured.NestedString(cm.Object, "data", "colour"); colour != "blue" {
+		t.Errorf("Expected colour to be blue, got %q", colour)
+	}
+
+	k.ReadOnly = true
+	if _, err := k.ApplyManifest([]byte(testManifest), false); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+
+	k.ReadOnly = false
+	if _, err := k.ApplyManifest([]byte("---\n# nothing here\n"), false); !errors.Is(err, ErrInvalidManifest) {
+		t.Errorf("Expected ErrInvalidManifest for an empty manifest, got %v", err)
+	}
+}
+
+func TestKubernetes_ApplyManifest_DiscoveryTimeout(t *testing.T) {
+	k := mockKubernetes()
+	k.RequestTimeout = 50 * time.Millisecond
+	addTestNamespace(k, "default")
+	setApplyResources(k)
+
+	// Discovery hangs for longer than the timeout, it must not hold up the apply
+	disc, _ := k.clientSet.Discovery().(*fakediscovery.FakeDiscovery)
+	disc.PrependReactor("get", "resource", func(k8stesting.Action) (bool, runtime.Object, error) {
+		time.Sleep(500 * time.Millisecond)
+		return false, nil, nil
+	})
+
+	start := time.Now()
+
+	results, err := k.ApplyManifest([]byte(testManifest), false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !strings.Contains(results[0].Error, "timed out") {
+		t.Errorf("Expected discovery to time out, got %+v", results[0])
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected each document to give up soon after the timeout, took %s", elapsed)
+	}
+}
+
+func TestKubernetes_ApplyManifest_SingleNamespace(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+	addTestNamespace(k, "apps")
+	fakeApply(k)
+	setApplyResources(k)
+
+	k.SingleNamespace = "apps"
+
+	results, err := k.ApplyManifest([]byte(testManifest), false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The manifest names its own namespace, which mustn't get around single namespace mode
+	if !strings.Contains(results[0].Error, ErrNamespaceForbidden.Error()) {
+		t.Errorf("Expected a document outside the single namespace to be forbidden, got %+v", results[0])
+	}
+
+	if _, err := k.dynamicClient.Resource(configMapGVR).Namespace("default").
+		Get(context.TODO(), "settings", metaV1.GetOptions{}); err == nil {
+		t.Error("Expected the ConfigMap not to be created outside the single namespace")
+	}
+
+	results, _ = k.ApplyManifest([]byte(strings.ReplaceAll(testManifest, "namespace: default", "namespace: apps")), false)
+	if results[0].Error != "" {
+		t.Errorf("Expected a document in the single namespace to be applied, got %+v", results[0])
+	}
+}
+
+func TestKubernetes_ServerDiff(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+	fakeApply(k)
+	setApplyResources(k)
+
+	cm := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "settings", "namespace": "default"},
+		"data":       map[string]interface{}{"colour": "blue", "size": "large"},
+	}}
+	_, _ = k.dynamicClient.Resource(configMapGVR).Namespace("default").Create(context.TODO(), cm, metaV1.CreateOptions{})
+
+	manifest := []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  colour: green
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: brand-new
+data:
+  colour: red
+`)
+
+	k.RedactSecrets = false
+
+	diffs, err := k.ServerDiff(manifest)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(diffs) != 2 {
+		t.Fatalf("Expected 2 diffs, got %+v", diffs)
+	}
+
+	changed := diffs[0]
+	if changed.New || len(changed.Changes) != 1 {
+		t.Fatalf("Expected one change to the existing ConfigMap, got %+v", changed)
+	}
+
+	if c := changed.Changes[0]; c.Path != "data.colour" || c.Before != "blue" || c.After != "green" {
+		t.Errorf("Expected data.colour to change from blue to green, got %+v", c)
+	}
+
+	// Nothing is created or changed by diffing
+	live, _ := k.dynamicClient.Resource(configMapGVR).Namespace("default").
+		Get(context.TODO(), "settings", metaV1.GetOptions{})
+	if colour, _, _ := unstructured.NestedString(live.Object, "data", "colour"); colour != "blue" {
+		t.Errorf("Expected the live ConfigMap to be unchanged, got %q", colour)
+	}
+
+	if _, err := k.dynamicClient.Resource(configMapGVR).Namespace("default").
+		Get(context.TODO(), "brand-new", metaV1.GetOptions{}); err == nil {
+		t.Error("Expected the new ConfigMap not to be created")
+	}
+
+	added := diffs[1]
+	if !added.New || !slices.ContainsFunc(added.Changes, func(c FieldChange) bool {
+		return c.Path == "data" && c.Before == nil && c.After != nil
+	}) {
+		t.Errorf("Expected a new ConfigMap to be all added, got %+v", added)
+	}
+
+	// Changed values are hidden when redacting, but not which fields changed
+	k.RedactSecrets = true
+
+	diffs, _ = k.ServerDiff(manifest)
+	if c := diffs[0].Changes[0]; c.Path != "data.colour" || c.Before != redactedValue || c.After != redactedValue {
+		t.Errorf("Expected the change to be redacted, got %+v", c)
+	}
+}
+
+func TestKubernetes_ServerDiff_Redaction(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+	addTestNamespace(k, "apps")
+	fakeApply(k)
+	setApplyResources(k)
+
+	cm := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "settings", "namespace": "default"},
+		"data":       map[string]interface{}{"colour": "blue", "apiToken": "old-secret"},
+	}}
+	_, _ = k.dynamicClient.Resource(configMapGVR).Namespace("default").Create(context.TODO(), cm, metaV1.CreateOptions{})
+
+	manifest := []byte(`
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+  namespace: default
+data:
+  colour: green
+  apiToken: new-secret
+`)
+
+	k.RedactSecrets = false
+	k.RedactKeys = []string{"*token*"}
+
+	diffs, err := k.ServerDiff(manifest)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Both values are redacted, so the token doesn't show as changed at all
+	for _, c := range diffs[0].Changes {
+		if c.Before == "old-secret" || c.After == "new-secret" {
+			t.Errorf("Expected the token to be redacted, got %+v", c)
+		}
+	}
+
+	if len(diffs[0].Changes) != 1 || diffs[0].Changes[0].Path != "data.colour" {
+		t.Errorf("Expected only data.colour to change, got %+v", diffs[0].Changes)
+	}
+
+	// The manifest's own namespace can't get around single namespace mode
+	k.SingleNamespace = "apps"
+
+	diffs, _ = k.ServerDiff(manifest)
+	if !strings.Contains(diffs[0].Error, ErrNamespaceForbidden.Error()) || len(diffs[0].Changes) != 0 {
+		t.Errorf("Expected a document outside the single namespace to be forbidden, got %+v", diffs[0])
+	}
+}
@@ -0,0 +1,318 @@
+// ==========================================================================================
+// Status of batch workloads, Jobs with their pod counts & CronJobs with their schedule
+// Worked out from resources already fetched, so no extra calls to the API server are made
+// ==========================================================================================
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Pods created by a Job carry its name in one of these labels, the second is the older form
+var jobNameLabels = []string{"batch.kubernetes.io/job-name", "job-name"}
+
+// JobStatus is the progress of a Job, times are RFC3339 and empty when not reached yet
+type JobStatus struct {
+	Name      string `json:"name"`
+	Active    int64  `json:"active"`
+	Succeeded int64  `json:"succeeded"`
+	Failed    int64  `json:"failed"`
+	// Completions is nil when the Job has no fixed number of completions
+	Completions    *int64 `json:"completions"`
+	StartTime      string `json:"startTime,omitempty"`
+	CompletionTime string `json:"completionTime,omitempty"`
+	// CronJob is the name of the CronJob which created this Job, if any
+	CronJob string   `json:"cronJob,omitempty"`
+	Pods    []string `json:"pods"`
+}
+
+// CronJobStatus is the schedule of a CronJob, times are RFC3339 and empty when unknown
+type CronJobStatus struct {
+	Name               string `json:"name"`
+	Schedule           string `json:"schedule"`
+	Suspended          bool   `json:"suspended"`
+	LastScheduleTime   string `json:"lastScheduleTime,omitempty"`
+	LastSuccessfulTime string `json:"lastSuccessfulTime,omitempty"`
+	// NextScheduleTime is empty when suspended or the schedule can't be parsed
+	NextScheduleTime string `json:"nextScheduleTime,omitempty"`
+	// Jobs are the names of the Jobs this CronJob has created
+	Jobs []string `json:"jobs"`
+}
+
+// BatchStatus holds the status of all the Jobs & CronJobs in a namespace
+type BatchStatus struct {
+	Jobs     []JobStatus     `json:"jobs"`
+	CronJobs []CronJobStatus `json:"cronJobs"`
+}
+
+// GetBatchStatus fetches a namespace and returns the status of its Jobs & CronJobs
+func (k *Kubernetes) GetBatchStatus(ns string) (*BatchStatus, error) {
+	// The pods of completed Jobs are needed to report on those Jobs
+	data, err := k.FetchNamespaceWithOptions(ns, FetchOptions{IncludeCompletedJobPods: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBatchStatus(data, time.Now()), nil
+}
+
+// NewBatchStatus builds the batch status from the map of resources returned by FetchNamespace
+// The next schedule time of CronJobs is the first one after now
+func NewBatchStatus(data map[string][]unstructured.Unstructured, now time.Time) *BatchStatus {
+	status := &BatchStatus{Jobs: []JobStatus{}, CronJobs: []CronJobStatus{}}
+	cronJobJobs := map[string][]string{}
+
+	for i := range data["jobs"] {
+		job := &data["jobs"][i]
+		js := jobStatus(job, data["pods"])
+
+		if js.CronJob != "" {
+			cronJobJobs[js.CronJob] = append(cronJobJobs[js.CronJob], js.Name)
+		}
+
+		status.Jobs = append(status.Jobs, js)
+	}
+
+	for i := range data["cronjobs"] {
+		cs := cronJobStatus(&data["cronjobs"][i], now)
+
+		if jobs := cronJobJobs[cs.Name]; jobs != nil {
+			cs.Jobs = jobs
+		}
+
+		status.CronJobs = append(status.CronJobs, cs)
+	}
+
+	return status
+}
+
+// jobStatus returns the status of a Job, linked to its owning CronJob and its pods
+func jobStatus(job *unstructured.Unstructured, pods []unstructured.Unstructured) JobStatus {
+	js := JobStatus{Name: job.GetName(), Pods: []string{}}
+
+	js.Active, _, _ = unstructured.NestedInt64(job.Object, "status", "active")
+	js.Succeeded, _, _ = unstructured.NestedInt64(job.Object, "status", "succeeded")
+	js.Failed, _, _ = unstructured.NestedInt64(job.Object, "status", "failed")
+	js.StartTime, _, _ = unstructured.NestedString(job.Object, "status", "startTime")
+	js.CompletionTime, _, _ = unstructured.NestedString(job.Object, "status", "completionTime")
+
+	if completions, found, _ := unstructured.NestedInt64(job.Object, "spec", "completions"); found {
+		js.Completions = &completions
+	}
+
+	for _, owner := range job.GetOwnerReferences() {
+		if owner.Kind == "CronJob" {
+			js.CronJob = owner.Name
+		}
+	}
+
+	for i := range pods {
+		if ownedByJob(&pods[i], job) {
+			js.Pods = append(js.Pods, pods[i].GetName())
+		}
+	}
+
+	return js
+}
+
+// ownedByJob checks if a pod belongs to a Job, by owner reference or by the job name label
+func ownedByJob(pod, job *unstructured.Unstructured) bool {
+	for _, owner := range pod.GetOwnerReferences() {
+		if owner.Kind == "Job" && owner.UID == job.GetUID() {
+			return true
+		}
+	}
+
+	podLabels := pod.GetLabels()
+
+	return slices.ContainsFunc(jobNameLabels, func(label string) bool {
+		return podLabels[label] == job.GetName()
+	})
+}
+
+// cronJobStatus returns the status of a CronJob, including when it will next run
+func cronJobStatus(cronJob *unstructured.Unstructured, now time.Time) CronJobStatus {
+	cs := CronJobStatus{Name: cronJob.GetName(), Jobs: []string{}}
+
+	cs.Schedule, _, _ = unstructured.NestedString(cronJob.Object, "spec", "schedule")
+	cs.Suspended, _, _ = unstructured.NestedBool(cronJob.Object, "spec", "suspend")
+	cs.LastScheduleTime, _, _ = unstructured.NestedString(cronJob.Object, "status", "lastScheduleTime")
+	cs.LastSuccessfulTime, _, _ = unstructured.NestedString(cronJob.Object, "status", "lastSuccessfulTime")
+
+	if cs.Suspended {
+		return cs
+	}
+
+	loc := time.UTC
+
+	if tz, _, _ := unstructured.NestedString(cronJob.Object, "spec", "timeZone"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return cs
+		}
+	}
+
+	if next, err := nextSchedule(cs.Schedule, now.In(loc)); err == nil {
+		cs.NextScheduleTime = next.UTC().Format(time.RFC3339)
+	}
+
+	return cs
+}
+
+// The shorthand schedules supported by CronJobs
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// The minimum & maximum of each cron field, in order minute, hour, day of month, month, day of week
+var cronBounds = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+
+// Names allowed in the month & day of week fields
+var cronNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// nextSchedule returns the first time after the given time matching a standard five field cron schedule
+// Like cron, when both day of month & day of week are restricted a day matching either is used
+func nextSchedule(schedule string, after time.Time) (time.Time, error) {
+	if macro, ok := cronMacros[strings.ToLower(schedule)]; ok {
+		schedule = macro
+	}
+
+	fields := strings.Fields(schedule)
+	if len(fields) != len(cronBounds) {
+		return time.Time{}, fmt.Errorf("schedule %q must have %d fields", schedule, len(cronBounds))
+	}
+
+	sets := [5]map[int]bool{}
+
+	for i, field := range fields {
+		set, err := parseCronField(field, cronBounds[i][0], cronBounds[i][1])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("schedule %q: %w", schedule, err)
+		}
+
+		sets[i] = set
+	}
+
+	// Sunday can be written as 7 as well as 0
+	if sets[4][7] {
+		sets[4][0] = true
+	}
+
+	// A field starting with * is unrestricted, even with a step, which matches how cron itself behaves
+	domAny, dowAny := strings.HasPrefix(fields[2], "*"), strings.HasPrefix(fields[4], "*")
+	dayMatches := func(t time.Time) bool {
+		dom, dow := sets[2][t.Day()], sets[4][int(t.Weekday())]
+		if domAny || dowAny {
+			return dom && dow
+		}
+
+		return dom || dow
+	}
+
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case !sets[3][int(t.Month())]:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !sets[1][t.Hour()]:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !sets[0][t.Minute()]:
+			t = t.Add(time.Minute)
+		default:
+			return t, nil
+		}
+	}
+
+	return time.Time{}, errors.New("schedule never runs")
+}
+
+// parseCronField parses a comma separated list of values, ranges & steps e.g. "*/15", "1-5" or "mon,wed"
+func parseCronField(field string, minVal, maxVal int) (map[int]bool, error) {
+	set := map[int]bool{}
+
+	for part := range strings.SplitSeq(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepPart); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+		}
+
+		start, end := minVal, maxVal
+
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+
+			var err error
+			if start, err = cronValue(from); err != nil {
+				return nil, err
+			}
+
+			end = start
+
+			if isRange {
+				if end, err = cronValue(to); err != nil {
+					return nil, err
+				}
+			} else if hasStep {
+				end = maxVal
+			}
+		}
+
+		// Day of week allows 7 for Sunday, so the upper bound is stretched by one
+		upper := maxVal
+		if maxVal == 6 {
+			upper = 7
+		}
+
+		if start < minVal || end > upper || start > end {
+			return nil, fmt.Errorf("value out of range in %q", part)
+		}
+
+		for v := start; v <= end; v += step {
+			set[v] = true
+		}
+	}
+
+	return set, nil
+}
+
+// cronValue parses a single number or name in a cron field
+func cronValue(s string) (int, error) {
+	if v, ok := cronNames[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+
+	return v, nil
+}
@@ -0,0 +1,107 @@
+// ==========================================================================================
+// Unit tests for Job & CronJob status
+// ==========================================================================================
+
+package services
+
+import (
+	"testing"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNewBatchStatus(t *testing.T) {
+	cronJob := createTestCronJob("backup", "default")
+	cronJob.SetUID("backup-uid")
+	_ = unstructured.SetNestedField(cronJob.Object, "0 2 * * *", "spec", "schedule")
+	_ = unstructured.SetNestedField(cronJob.Object, "2025-06-01T02:00:00Z", "status", "lastScheduleTime")
+
+	job := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "batch/v1",
+		"kind":       "Job",
+		"metadata":   map[string]interface{}{"name": "backup-29150520", "namespace": "default", "uid": "job-uid"},
+		"spec":       map[string]interface{}{"completions": int64(1)},
+		"status": map[string]interface{}{
+			"succeeded":      int64(1),
+			"startTime":      "2025-06-01T02:00:01Z",
+			"completionTime": "2025-06-01T02:00:30Z",
+		},
+	}}
+	job.SetOwnerReferences([]metaV1.OwnerReference{{Kind: "CronJob", Name: "backup", UID: "backup-uid"}})
+
+	byOwner := createTestPod("backup-29150520-abcde", "default")
+	byOwner.SetOwnerReferences([]metaV1.OwnerReference{{Kind: "Job", Name: "backup-29150520", UID: "job-uid"}})
+
+	byLabel := createTestPod("backup-29150520-fghij", "default")
+	byLabel.SetLabels(map[string]string{"job-name": "backup-29150520"})
+
+	data := map[string][]unstructured.Unstructured{
+		"cronjobs": {*cronJob},
+		"jobs":     {*job},
+		"pods":     {*byOwner, *byLabel, *createTestPod("web", "default")},
+	}
+
+	status := NewBatchStatus(data, time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC))
+
+	if len(status.Jobs) != 1 || len(status.CronJobs) != 1 {
+		t.Fatalf("Expected 1 job & 1 cronjob, got %+v", status)
+	}
+
+	js := status.Jobs[0]
+	if js.Succeeded != 1 || js.Active != 0 || js.Failed != 0 || js.Completions == nil || *js.Completions != 1 {
+		t.Errorf("Expected 1 of 1 succeeded, got %+v", js)
+	}
+
+	if js.CompletionTime != "2025-06-01T02:00:30Z" || js.CronJob != "backup" {
+		t.Errorf("Expected completion time & owning cronjob, got %+v", js)
+	}
+
+	if len(js.Pods) != 2 {
+		t.Errorf("Expected 2 pods linked to the job, got %v", js.Pods)
+	}
+
+	cs := status.CronJobs[0]
+	if cs.LastScheduleTime != "2025-06-01T02:00:00Z" || cs.NextScheduleTime != "2025-06-02T02:00:00Z" {
+		t.Errorf("Expected last & next schedule, got %+v", cs)
+	}
+
+	if len(cs.Jobs) != 1 || cs.Jobs[0] != "backup-29150520" {
+		t.Errorf("Expected cronjob linked to its job, got %v", cs.Jobs)
+	}
+}
+
+func TestNextSchedule(t *testing.T) {
+	// A Sunday
+	after := time.Date(2025, 6, 1, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		schedule string
+		expected string
+	}{
+		{"*/15 * * * *", "2025-06-01T10:15:00Z"},
+		{"@hourly", "2025-06-01T11:00:00Z"},
+		{"30 9 * * mon-fri", "2025-06-02T09:30:00Z"},
+		{"0 0 1 jan *", "2026-01-01T00:00:00Z"},
+		{"0 12 15 * 7", "2025-06-01T12:00:00Z"},
+	}
+
+	for _, test := range tests {
+		next, err := nextSchedule(test.schedule, after)
+		if err != nil {
+			t.Errorf("Expected no error for %q, got %v", test.schedule, err)
+			continue
+		}
+
+		if got := next.Format(time.RFC3339); got != test.expected {
+			t.Errorf("Expected %q to next run at %s, got %s", test.schedule, test.expected, got)
+		}
+	}
+
+	for _, bad := range []string{"* * *", "61 * * * *", "*/0 * * * *", "0 0 30 2 *"} {
+		if _, err := nextSchedule(bad, after); err == nil {
+			t.Errorf("Expected error for schedule %q", bad)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// ==========================================================================================
+// Tagging events with the cluster they came from, so events from several clusters can share
+// one broker & SSE stream, each cluster's Kubernetes service sending through its own sender
+// Only the tagging is here, the server itself still connects to one cluster, nothing in api.go
+// creates a service per cluster or routes API calls by cluster. Programs embedding the services
+// package can create several services on one broker with NewClusterSender
+// ==========================================================================================
+
+package services
+
+// clusterSender sets the cluster on every event before passing it on
+type clusterSender struct {
+	cluster string
+	next    EventSender
+}
+
+// NewClusterSender wraps the sender events from a cluster are sent to, tagging each with the cluster name
+// Give one to each cluster's Kubernetes service, all wrapping the same broker, to merge their events into one
+// stream. Each cluster has its own informers, so one which can't be reached only stops its own events, the
+// informers keep retrying in the background while the other clusters carry on
+func NewClusterSender(cluster string, next EventSender) EventSender {
+	return &clusterSender{cluster: cluster, next: next}
+}
+
+func (c *clusterSender) SendToGroup(group string, message KubeEvent) {
+	message.Cluster = c.cluster
+	c.next.SendToGroup(group, message)
+}
@@ -0,0 +1,80 @@
+// ==========================================================================================
+// Unit tests for merging events from several clusters
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestClusterSender_MergesClusters(t *testing.T) {
+	east, west, down := mockKubernetes(), mockKubernetes(), mockKubernetes()
+
+	_, _ = east.dynamicClient.Resource(podGVR).Namespace("default").
+		Create(context.TODO(), createTestPod("east-web", "default"), metaV1.CreateOptions{})
+	_, _ = west.dynamicClient.Resource(podGVR).Namespace("default").
+		Create(context.TODO(), createTestPod("west-web", "default"), metaV1.CreateOptions{})
+
+	// A cluster which can't be reached fails every list & watch
+	client, _ := down.dynamicClient.(*fake.FakeDynamicClient)
+	client.PrependReactor("*", "*", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+	client.PrependWatchReactor("*", func(k8stesting.Action) (bool, watch.Interface, error) {
+		return true, nil, errors.New("connection refused")
+	})
+
+	merged := &recordingSender{}
+	stop := make(chan struct{})
+	resources := resourcesToWatch([]string{"pods"}, true, false)
+
+	for name, k := range map[string]*Kubernetes{"east": east, "west": west} {
+		factory := startInformers(stop, k.dynamicClient, "", NewClusterSender(name, merged), resources, 0, 0)
+		defer factory.Shutdown()
+	}
+
+	// Its informers never sync, they keep retrying until stopped, then are shut down the same as the others
+	downDone := make(chan struct{})
+
+	go func() {
+		defer close(downDone)
+		startInformers(stop, down.dynamicClient, "", NewClusterSender("down", merged), resources, 0, 0).Shutdown()
+	}()
+
+	defer func() {
+		close(stop)
+		<-downDone
+	}()
+
+	clusters := func() map[string]string {
+		merged.mu.Lock()
+		defer merged.mu.Unlock()
+
+		found := map[string]string{}
+		for _, e := range merged.events {
+			found[e.Object.GetName()] = e.Cluster
+		}
+
+		return found
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(clusters()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	found := clusters()
+	if found["east-web"] != "east" || found["west-web"] != "west" {
+		t.Errorf("Expected a pod from each reachable cluster tagged with its name, got %v", found)
+	}
+}
@@ -0,0 +1,115 @@
+// ==========================================================================================
+// Coalescing of update events, so a burst of updates to one object (e.g. during a rollout)
+// is sent to clients as a single event holding the latest state
+// ==========================================================================================
+
+package services
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// coalescingSender holds back update events for a short window, only the latest update to each object
+// in the window is sent. Add & delete events are always sent straight away, a delete discards any held update
+type coalescingSender struct {
+	next   EventSender
+	window time.Duration
+
+	mu      sync.Mutex
+	pending map[types.UID]*pendingUpdate
+	stopped bool
+}
+
+// pendingUpdate is the latest update to an object, waiting for the window to end
+type pendingUpdate struct {
+	group string
+	event KubeEvent
+	timer *time.Timer
+}
+
+// newCoalescingSender wraps an EventSender, coalescing updates until stop is closed
+func newCoalescingSender(next EventSender, window time.Duration, stop <-chan struct{}) *coalescingSender {
+	c := &coalescingSender{
+		next:    next,
+		window:  window,
+		pending: map[types.UID]*pendingUpdate{},
+	}
+
+	go func() {
+		<-stop
+		c.stop()
+	}()
+
+	return c
+}
+
+// SendToGroup sends or holds back an event, see coalescingSender
+// Events are sent with the lock held, so a held update can never be sent after a later delete
+func (c *coalescingSender) SendToGroup(group string, message KubeEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopped {
+		return
+	}
+
+	if message.Object == nil || message.Object.GetUID() == "" {
+		c.next.SendToGroup(group, message)
+		return
+	}
+
+	uid := message.Object.GetUID()
+
+	if message.EventType != UpdateEvent {
+		// The held update is out of date once the object is deleted, sending it later would bring it back
+		if p, ok := c.pending[uid]; ok {
+			p.timer.Stop()
+			delete(c.pending, uid)
+		}
+
+		c.next.SendToGroup(group, message)
+
+		return
+	}
+
+	// Already waiting to send an update for this object, so just swap in the latest
+	if p, ok := c.pending[uid]; ok {
+		p.group = group
+		p.event = message
+
+		return
+	}
+
+	c.pending[uid] = &pendingUpdate{
+		group: group,
+		event: message,
+		timer: time.AfterFunc(c.window, func() { c.flush(uid) }),
+	}
+}
+
+// flush sends the held update for an object when its window ends
+func (c *coalescingSender) flush(uid types.UID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if p, ok := c.pending[uid]; ok {
+		delete(c.pending, uid)
+		c.next.SendToGroup(p.group, p.event)
+	}
+}
+
+// stop discards all held updates, nothing more is sent once the informers are stopping
+func (c *coalescingSender) stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stopped = true
+
+	for uid, p := range c.pending {
+		p.timer.Stop()
+		delete(c.pending, uid)
+	}
+}
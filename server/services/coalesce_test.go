@@ -0,0 +1,89 @@
+// ==========================================================================================
+// Unit tests for coalescing update events
+// ==========================================================================================
+
+package services
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// sent returns a copy of the events sent so far
+func (r *recordingSender) sent() []KubeEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]KubeEvent{}, r.events...)
+}
+
+func TestCoalescingSender_Updates(t *testing.T) {
+	next := &recordingSender{}
+	stop := make(chan struct{})
+	defer close(stop)
+
+	c := newCoalescingSender(next, 50*time.Millisecond, stop)
+
+	pod := createTestPod("web", "default")
+	pod.SetUID(types.UID("web-uid"))
+	c.SendToGroup("default", KubeEvent{EventType: AddEvent, Object: pod.DeepCopy()})
+
+	// A rollout style burst of updates, each with a new resource version
+	for i := range 50 {
+		update := pod.DeepCopy()
+		update.SetResourceVersion(strconv.Itoa(i))
+		c.SendToGroup("default", KubeEvent{EventType: UpdateEvent, Object: update})
+	}
+
+	if events := next.sent(); len(events) != 1 || events[0].EventType != AddEvent {
+		t.Fatalf("Expected only the add to be sent straight away, got %d events", len(events))
+	}
+
+	time.Sleep(150 * time.Millisecond)
+
+	events := next.sent()
+	if len(events) != 2 {
+		t.Fatalf("Expected 50 updates to be coalesced into 1, got %d events", len(events)-1)
+	}
+
+	if version := events[1].Object.GetResourceVersion(); version != "49" {
+		t.Errorf("Expected the latest update to be sent, got version %s", version)
+	}
+}
+
+func TestCoalescingSender_Delete(t *testing.T) {
+	next := &recordingSender{}
+	stop := make(chan struct{})
+	defer close(stop)
+
+	c := newCoalescingSender(next, 50*time.Millisecond, stop)
+
+	pod := createTestPod("web", "default")
+	pod.SetUID(types.UID("web-uid"))
+
+	other := createTestPod("db", "default")
+	other.SetUID(types.UID("db-uid"))
+
+	c.SendToGroup("default", KubeEvent{EventType: UpdateEvent, Object: pod.DeepCopy()})
+	c.SendToGroup("default", KubeEvent{EventType: UpdateEvent, Object: other.DeepCopy()})
+	c.SendToGroup("default", KubeEvent{EventType: DeleteEvent, Object: pod.DeepCopy()})
+
+	time.Sleep(150 * time.Millisecond)
+
+	// The delete is sent at once and the held update to the deleted pod is dropped, other objects are unaffected
+	events := next.sent()
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d", len(events))
+	}
+
+	if events[0].EventType != DeleteEvent || events[0].Object.GetName() != "web" {
+		t.Errorf("Expected the delete of web first, got %s of %s", events[0].EventType, events[0].Object.GetName())
+	}
+
+	if events[1].EventType != UpdateEvent || events[1].Object.GetName() != "db" {
+		t.Errorf("Expected the update of db, got %s of %s", events[1].EventType, events[1].Object.GetName())
+	}
+}
@@ -0,0 +1,91 @@
+// ==========================================================================================
+// The containers in a pod, so the UI can offer a choice before fetching logs or opening exec
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ContainerType is the kind of container within a pod
+type ContainerType string
+
+const (
+	InitContainer      ContainerType = "init"
+	RegularContainer   ContainerType = "regular"
+	EphemeralContainer ContainerType = "ephemeral"
+)
+
+// PodContainer is the name & type of one container in a pod
+type PodContainer struct {
+	Name string        `json:"name"`
+	Type ContainerType `json:"type"`
+	// State is waiting, running or terminated, empty when the container has no status e.g. the pod isn't scheduled
+	State string `json:"state"`
+}
+
+// GetPodContainers returns every container in a pod, init containers first, then regular & ephemeral ones
+func (k *Kubernetes) GetPodContainers(ns, podName string) ([]PodContainer, error) {
+	if ns == "" || podName == "" {
+		return nil, errors.New("namespace or pod name is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	var pod *coreV1.Pod
+
+	err := k.callAPI(func(ctx context.Context) (err error) {
+		pod, err = k.clientSet.CoreV1().Pods(ns).Get(ctx, podName, metaV1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return podContainers(pod), nil
+}
+
+// podContainers lists the containers in a pod spec, with the state of each from the pod's status
+func podContainers(pod *coreV1.Pod) []PodContainer {
+	statuses := slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses,
+		pod.Status.EphemeralContainerStatuses)
+
+	state := func(name string) string {
+		i := slices.IndexFunc(statuses, func(s coreV1.ContainerStatus) bool { return s.Name == name })
+
+		switch {
+		case i < 0:
+			return ""
+		case statuses[i].State.Running != nil:
+			return "running"
+		case statuses[i].State.Terminated != nil:
+			return "terminated"
+		default:
+			return "waiting"
+		}
+	}
+
+	containers := []PodContainer{}
+
+	for _, c := range pod.Spec.InitContainers {
+		containers = append(containers, PodContainer{Name: c.Name, Type: InitContainer, State: state(c.Name)})
+	}
+
+	for _, c := range pod.Spec.Containers {
+		containers = append(containers, PodContainer{Name: c.Name, Type: RegularContainer, State: state(c.Name)})
+	}
+
+	for _, c := range pod.Spec.EphemeralContainers {
+		containers = append(containers, PodContainer{Name: c.Name, Type: EphemeralContainer, State: state(c.Name)})
+	}
+
+	return containers
+}
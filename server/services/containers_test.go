@@ -0,0 +1,80 @@
+// ==========================================================================================
+// Unit tests for listing the containers in a pod
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestKubernetes_GetPodContainers(t *testing.T) {
+	k := mockKubernetes()
+
+	if _, err := k.GetPodContainers("default", ""); err == nil {
+		t.Error("Expected error for empty pod name, got nil")
+	}
+
+	if _, err := k.GetPodContainers("default", "missing"); err == nil {
+		t.Error("Expected error for missing pod, got nil")
+	}
+
+	pod := &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: coreV1.PodSpec{
+			InitContainers: []coreV1.Container{{Name: "setup"}},
+			Containers:     []coreV1.Container{{Name: "app"}, {Name: "sidecar"}},
+			EphemeralContainers: []coreV1.EphemeralContainer{
+				{EphemeralContainerCommon: coreV1.EphemeralContainerCommon{Name: "debugger"}},
+			},
+		},
+		Status: coreV1.PodStatus{
+			InitContainerStatuses: []coreV1.ContainerStatus{
+				{Name: "setup", State: coreV1.ContainerState{Terminated: &coreV1.ContainerStateTerminated{}}},
+			},
+			ContainerStatuses: []coreV1.ContainerStatus{
+				{Name: "app", State: coreV1.ContainerState{Running: &coreV1.ContainerStateRunning{}}},
+				{Name: "sidecar", State: coreV1.ContainerState{Waiting: &coreV1.ContainerStateWaiting{}}},
+			},
+		},
+	}
+	_, _ = k.clientSet.CoreV1().Pods("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+
+	containers, err := k.GetPodContainers("default", "web")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []PodContainer{
+		{Name: "setup", Type: InitContainer, State: "terminated"},
+		{Name: "app", Type: RegularContainer, State: "running"},
+		{Name: "sidecar", Type: RegularContainer, State: "waiting"},
+		{Name: "debugger", Type: EphemeralContainer, State: ""},
+	}
+
+	if fmt.Sprint(containers) != fmt.Sprint(expected) {
+		t.Errorf("Expected containers %v, got %v", expected, containers)
+	}
+
+	// A pod which isn't scheduled yet has no statuses, but its containers are still listed
+	pending := &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{Name: "pending", Namespace: "default"},
+		Spec:       coreV1.PodSpec{Containers: []coreV1.Container{{Name: "app"}}},
+		Status:     coreV1.PodStatus{Phase: coreV1.PodPending},
+	}
+	_, _ = k.clientSet.CoreV1().Pods("default").Create(context.TODO(), pending, metaV1.CreateOptions{})
+
+	containers, err = k.GetPodContainers("default", "pending")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(containers) != 1 || containers[0] != (PodContainer{Name: "app", Type: RegularContainer}) {
+		t.Errorf("Expected the unscheduled pod's container with no state, got %v", containers)
+	}
+}
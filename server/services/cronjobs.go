@@ -0,0 +1,169 @@
+// ==========================================================================================
+// CronJob operations, listing the Jobs a CronJob has run & triggering new runs manually
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/rand"
+)
+
+var (
+	cronJobGVR = schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}
+	jobGVR     = schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}
+)
+
+// Job names are used as label values on their pods, so must fit in 63 characters
+const maxJobNameLength = 63
+
+// ListCronJobRuns returns the Jobs owned by a CronJob, newest first
+func (k *Kubernetes) ListCronJobRuns(ns, name string) ([]unstructured.Unstructured, error) {
+	if ns == "" || name == "" {
+		return nil, errors.New("namespace or cronjob name is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	cronJob, err := k.getCronJob(ns, name)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs, err := k.GetResourcesSorted(ns, jobGVR.Group, jobGVR.Version, jobGVR.Resource, SortByCreatedDesc)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]unstructured.Unstructured, 0, len(jobs))
+
+	for _, job := range jobs {
+		for _, owner := range job.GetOwnerReferences() {
+			if owner.Kind == "CronJob" && owner.UID == cronJob.GetUID() {
+				runs = append(runs, job)
+				break
+			}
+		}
+	}
+
+	return runs, nil
+}
+
+// TriggerCronJob runs a CronJob now, by creating a Job from its job template
+// This mimics `kubectl create job --from=cronjob/name` and returns the created Job
+// With dryRun the Job which would be created is returned, but not created
+func (k *Kubernetes) TriggerCronJob(ns, name string, dryRun bool) (*unstructured.Unstructured, error) {
+	if k.ReadOnly {
+		return nil, ErrReadOnly
+	}
+
+	if ns == "" || name == "" {
+		return nil, errors.New("namespace or cronjob name is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	cronJob, err := k.getCronJob(ns, name)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := jobFromCronJob(cronJob)
+	if err != nil {
+		return nil, err
+	}
+
+	var created *unstructured.Unstructured
+
+	err = k.callAPI(func(ctx context.Context) (err error) {
+		created, err = k.dynamicClient.Resource(jobGVR).Namespace(ns).
+			Create(ctx, job, metaV1.CreateOptions{DryRun: dryRunOption(dryRun)})
+		return err
+	})
+	if err != nil {
+		log.Printf("💥 Failed to create job from cronjob %s in namespace %s: %v", name, ns, err)
+		return nil, err
+	}
+
+	log.Printf("⏰ Triggered cronjob %s in namespace %s, created job %s%s", name, ns, created.GetName(),
+		dryRunNote(dryRun))
+
+	return k.cleanObject(created), nil
+}
+
+// getCronJob fetches a single CronJob by name
+func (k *Kubernetes) getCronJob(ns, name string) (*unstructured.Unstructured, error) {
+	var cronJob *unstructured.Unstructured
+
+	err := k.callAPI(func(ctx context.Context) (err error) {
+		cronJob, err = k.dynamicClient.Resource(cronJobGVR).Namespace(ns).Get(ctx, name, metaV1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		log.Printf("💥 Failed to get cronjob %s in namespace %s: %v", name, ns, err)
+		return nil, err
+	}
+
+	return cronJob, nil
+}
+
+// jobFromCronJob builds a new Job from the CronJob's job template, owned by the CronJob
+func jobFromCronJob(cronJob *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	spec, found, err := unstructured.NestedMap(cronJob.Object, "spec", "jobTemplate", "spec")
+	if err != nil || !found {
+		return nil, errors.New("cronjob has no job template spec")
+	}
+
+	labels, _, _ := unstructured.NestedStringMap(cronJob.Object, "spec", "jobTemplate", "metadata", "labels")
+	annotations, _, _ := unstructured.NestedStringMap(cronJob.Object, "spec", "jobTemplate", "metadata", "annotations")
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	// Same annotation kubectl sets, marks the job as manually created
+	annotations["cronjob.kubernetes.io/instantiate"] = "manual"
+
+	// Generate a unique name, trimming the CronJob name so the result is never too long
+	suffix := "-manual-" + rand.String(5)
+
+	baseName := cronJob.GetName()
+	if len(baseName)+len(suffix) > maxJobNameLength {
+		baseName = baseName[:maxJobNameLength-len(suffix)]
+	}
+
+	job := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "batch/v1",
+			"kind":       "Job",
+			"spec":       spec,
+		},
+	}
+
+	job.SetName(baseName + suffix)
+	job.SetNamespace(cronJob.GetNamespace())
+	job.SetLabels(labels)
+	job.SetAnnotations(annotations)
+
+	controller := true
+
+	job.SetOwnerReferences([]metaV1.OwnerReference{{
+		APIVersion: "batch/v1",
+		Kind:       "CronJob",
+		Name:       cronJob.GetName(),
+		UID:        cronJob.GetUID(),
+		Controller: &controller,
+	}})
+
+	return job, nil
+}
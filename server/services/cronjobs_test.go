@@ -0,0 +1,124 @@
+// ==========================================================================================
+// Unit tests for CronJob operations
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// createTestCronJob creates a test cronjob object with a simple job template
+func createTestCronJob(name, namespace string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "batch/v1",
+			"kind":       "CronJob",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+				"uid":       "cronjob-uid-1234",
+			},
+			"spec": map[string]interface{}{
+				"schedule": "*/5 * * * *",
+				"jobTemplate": map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"labels": map[string]interface{}{
+							"app": name,
+						},
+					},
+					"spec": map[string]interface{}{
+						"template": map[string]interface{}{
+							"spec": map[string]interface{}{
+								"restartPolicy": "OnFailure",
+								"containers": []interface{}{
+									map[string]interface{}{
+										"name":  "task",
+										"image": "busybox:1.36",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestKubernetes_TriggerCronJob(t *testing.T) {
+	k := mockKubernetes()
+
+	_, _ = k.dynamicClient.Resource(cronJobGVR).Namespace("default").
+		Create(context.TODO(), createTestCronJob("nightly", "default"), metaV1.CreateOptions{})
+
+	job, err := k.TriggerCronJob("default", "nightly", false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !strings.HasPrefix(job.GetName(), "nightly-manual-") {
+		t.Errorf("Expected generated job name, got %s", job.GetName())
+	}
+
+	owners := job.GetOwnerReferences()
+	if len(owners) != 1 || owners[0].Kind != "CronJob" || owners[0].UID != "cronjob-uid-1234" {
+		t.Errorf("Expected job to be owned by the cronjob, got %v", owners)
+	}
+
+	if job.GetLabels()["app"] != "nightly" {
+		t.Error("Expected job to carry the labels from the job template")
+	}
+
+	containers, _, _ := unstructured.NestedSlice(job.Object, "spec", "template", "spec", "containers")
+	if len(containers) != 1 {
+		t.Error("Expected job spec to be copied from the job template")
+	}
+
+	// The job should now be stored, and listed as a run of the cronjob
+	runs, err := k.ListCronJobRuns("default", "nightly")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(runs) != 1 || runs[0].GetName() != job.GetName() {
+		t.Errorf("Expected the triggered job to be listed as a run, got %d runs", len(runs))
+	}
+}
+
+func TestKubernetes_TriggerCronJob_Errors(t *testing.T) {
+	k := mockKubernetes()
+
+	if _, err := k.TriggerCronJob("", "nightly", false); err == nil {
+		t.Error("Expected error for empty namespace, got nil")
+	}
+
+	if _, err := k.TriggerCronJob("default", "missing", false); err == nil {
+		t.Error("Expected error for missing cronjob, got nil")
+	}
+
+	k.ReadOnly = true
+
+	if _, err := k.TriggerCronJob("default", "nightly", false); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected read-only error, got %v", err)
+	}
+}
+
+func TestJobFromCronJob_LongName(t *testing.T) {
+	cronJob := createTestCronJob(strings.Repeat("x", 60), "default")
+
+	job, err := jobFromCronJob(cronJob)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(job.GetName()) > maxJobNameLength {
+		t.Errorf("Expected job name to be at most %d characters, got %d", maxJobNameLength, len(job.GetName()))
+	}
+}
@@ -0,0 +1,248 @@
+// ==========================================================================================
+// DaemonSet coverage, which nodes a DaemonSet should be running on and where its pods are
+// missing or not ready, to spot scheduling gaps such as an untolerated taint
+// ==========================================================================================
+
+package services
+
+import (
+	"errors"
+	"log"
+	"slices"
+
+	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+var nodeGVR = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "nodes"}
+
+// Taints the DaemonSet controller tolerates on every DaemonSet pod, so they never stop one being scheduled
+var daemonSetTolerations = []coreV1.Toleration{
+	{Key: "node.kubernetes.io/not-ready", Operator: coreV1.TolerationOpExists},
+	{Key: "node.kubernetes.io/unreachable", Operator: coreV1.TolerationOpExists},
+	{Key: "node.kubernetes.io/disk-pressure", Operator: coreV1.TolerationOpExists},
+	{Key: "node.kubernetes.io/memory-pressure", Operator: coreV1.TolerationOpExists},
+	{Key: "node.kubernetes.io/pid-pressure", Operator: coreV1.TolerationOpExists},
+	{Key: "node.kubernetes.io/unschedulable", Operator: coreV1.TolerationOpExists},
+	{Key: "node.kubernetes.io/network-unavailable", Operator: coreV1.TolerationOpExists},
+}
+
+// Operators used in node affinity, mapped to the label selector equivalent
+var nodeSelectorOperators = map[coreV1.NodeSelectorOperator]selection.Operator{
+	coreV1.NodeSelectorOpIn:           selection.In,
+	coreV1.NodeSelectorOpNotIn:        selection.NotIn,
+	coreV1.NodeSelectorOpExists:       selection.Exists,
+	coreV1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	coreV1.NodeSelectorOpGt:           selection.GreaterThan,
+	coreV1.NodeSelectorOpLt:           selection.LessThan,
+}
+
+// DaemonSetCoverage compares where a DaemonSet is running with where it should be
+type DaemonSetCoverage struct {
+	Name string `json:"name"`
+	// Counts reported by the DaemonSet controller
+	DesiredNumberScheduled int64 `json:"desiredNumberScheduled"`
+	NumberReady            int64 `json:"numberReady"`
+	// EligibleNodes are the nodes matching the node selector, affinity & tolerations of the pod template
+	EligibleNodes []string `json:"eligibleNodes"`
+	// MissingNodes are eligible nodes with no pod from this DaemonSet
+	MissingNodes []string `json:"missingNodes"`
+	// UnreadyNodes are nodes where the pod exists but isn't ready
+	UnreadyNodes []string `json:"unreadyNodes"`
+}
+
+// GetDaemonSetCoverage returns the coverage of every DaemonSet in a namespace
+// Listing nodes needs cluster wide permission, which a namespace scoped install won't have
+func (k *Kubernetes) GetDaemonSetCoverage(ns string) ([]DaemonSetCoverage, error) {
+	if ns == "" {
+		return nil, errors.New("namespace is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	dsList, err := k.GetResources(ns, "apps", "v1", "daemonsets")
+	if err != nil {
+		return nil, err
+	}
+
+	podList, err := k.GetResources(ns, podGVR.Group, podGVR.Version, podGVR.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeList, err := k.GetResources("", nodeGVR.Group, nodeGVR.Version, nodeGVR.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := make([]coreV1.Node, 0, len(nodeList))
+
+	for _, obj := range nodeList {
+		node := coreV1.Node{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &node); err != nil {
+			log.Printf("💥 Failed to decode node %s: %v", obj.GetName(), err)
+			continue
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	pods := make([]coreV1.Pod, 0, len(podList))
+
+	for _, obj := range podList {
+		pod := coreV1.Pod{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &pod); err != nil {
+			log.Printf("💥 Failed to decode pod %s: %v", obj.GetName(), err)
+			continue
+		}
+
+		pods = append(pods, pod)
+	}
+
+	coverage := make([]DaemonSetCoverage, 0, len(dsList))
+
+	for _, obj := range dsList {
+		ds := appsV1.DaemonSet{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &ds); err != nil {
+			log.Printf("💥 Failed to decode daemonset %s: %v", obj.GetName(), err)
+			continue
+		}
+
+		coverage = append(coverage, daemonSetCoverage(&ds, nodes, pods))
+	}
+
+	return coverage, nil
+}
+
+// daemonSetCoverage works out the coverage of one DaemonSet, from the nodes and the pods in its namespace
+func daemonSetCoverage(ds *appsV1.DaemonSet, nodes []coreV1.Node, pods []coreV1.Pod) DaemonSetCoverage {
+	coverage := DaemonSetCoverage{
+		Name:                   ds.Name,
+		DesiredNumberScheduled: int64(ds.Status.DesiredNumberScheduled),
+		NumberReady:            int64(ds.Status.NumberReady),
+		EligibleNodes:          []string{},
+		MissingNodes:           []string{},
+		UnreadyNodes:           []string{},
+	}
+
+	// Pods owned by this DaemonSet, keyed by the node they are on
+	ready := map[string]bool{}
+	for _, pod := range pods {
+		if pod.Spec.NodeName == "" || !ownedBy(&pod, ds) {
+			continue
+		}
+
+		ready[pod.Spec.NodeName] = ready[pod.Spec.NodeName] || podReady(&pod)
+	}
+
+	for i := range nodes {
+		node := &nodes[i]
+		if !nodeEligible(&ds.Spec.Template.Spec, node) {
+			continue
+		}
+
+		coverage.EligibleNodes = append(coverage.EligibleNodes, node.Name)
+
+		isReady, hasPod := ready[node.Name]
+
+		switch {
+		case !hasPod:
+			coverage.MissingNodes = append(coverage.MissingNodes, node.Name)
+		case !isReady:
+			coverage.UnreadyNodes = append(coverage.UnreadyNodes, node.Name)
+		}
+	}
+
+	return coverage
+}
+
+// ownedBy checks if a pod is controlled by a DaemonSet
+func ownedBy(pod *coreV1.Pod, ds *appsV1.DaemonSet) bool {
+	return slices.ContainsFunc(pod.OwnerReferences, func(owner metaV1.OwnerReference) bool {
+		return owner.Kind == "DaemonSet" && owner.UID == ds.UID
+	})
+}
+
+// podReady checks the Ready condition of a pod
+func podReady(pod *coreV1.Pod) bool {
+	return slices.ContainsFunc(pod.Status.Conditions, func(c coreV1.PodCondition) bool {
+		return c.Type == coreV1.PodReady && c.Status == coreV1.ConditionTrue
+	})
+}
+
+// nodeEligible checks if a pod could run on a node, by node selector, required node affinity & taints
+// Resources & ports aren't checked, so a node which is full still counts as eligible
+func nodeEligible(spec *coreV1.PodSpec, node *coreV1.Node) bool {
+	nodeLabels := labels.Set(node.Labels)
+
+	if !labels.SelectorFromSet(spec.NodeSelector).Matches(nodeLabels) {
+		return false
+	}
+
+	if spec.Affinity != nil && spec.Affinity.NodeAffinity != nil {
+		required := spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+		if required != nil && !nodeSelectorMatches(required, nodeLabels) {
+			return false
+		}
+	}
+
+	tolerations := append(slices.Clone(spec.Tolerations), daemonSetTolerations...)
+
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == coreV1.TaintEffectPreferNoSchedule {
+			continue
+		}
+
+		if !slices.ContainsFunc(tolerations, func(t coreV1.Toleration) bool { return tolerates(&t, taint) }) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// tolerates checks if a toleration matches a taint, an empty key with Exists tolerates everything
+func tolerates(t *coreV1.Toleration, taint *coreV1.Taint) bool {
+	if t.Effect != "" && t.Effect != taint.Effect {
+		return false
+	}
+
+	if t.Key != taint.Key && (t.Key != "" || t.Operator != coreV1.TolerationOpExists) {
+		return false
+	}
+
+	return t.Operator == coreV1.TolerationOpExists || t.Value == taint.Value
+}
+
+// nodeSelectorMatches checks node labels against a node selector, where any one of the terms must match
+// Terms using matchFields (e.g. metadata.name) are treated as matching, as only labels are checked
+func nodeSelectorMatches(ns *coreV1.NodeSelector, nodeLabels labels.Set) bool {
+	for _, term := range ns.NodeSelectorTerms {
+		selector := labels.NewSelector()
+		valid := true
+
+		for _, expr := range term.MatchExpressions {
+			req, err := labels.NewRequirement(expr.Key, nodeSelectorOperators[expr.Operator], expr.Values)
+			if err != nil {
+				valid = false
+				break
+			}
+
+			selector = selector.Add(*req)
+		}
+
+		if valid && selector.Matches(nodeLabels) {
+			return true
+		}
+	}
+
+	return false
+}
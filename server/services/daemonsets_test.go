@@ -0,0 +1,114 @@
+// ==========================================================================================
+// Unit tests for DaemonSet coverage
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func createTestNode(k *Kubernetes, name string, taints []interface{}) {
+	node := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Node",
+		"metadata":   map[string]interface{}{"name": name, "labels": map[string]interface{}{"role": "worker"}},
+		"spec":       map[string]interface{}{"taints": taints},
+	}}
+
+	_, _ = k.dynamicClient.Resource(nodeGVR).Create(context.TODO(), node, metaV1.CreateOptions{})
+}
+
+func createTestDaemonSetPod(k *Kubernetes, name, node string, ready bool) {
+	status := "False"
+	if ready {
+		status = "True"
+	}
+
+	pod := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "default",
+			"ownerReferences": []interface{}{
+				map[string]interface{}{"apiVersion": "apps/v1", "kind": "DaemonSet", "name": "agent", "uid": "ds-uid"},
+			},
+		},
+		"spec": map[string]interface{}{"nodeName": node},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": status}},
+		},
+	}}
+
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+}
+
+func TestKubernetes_GetDaemonSetCoverage(t *testing.T) {
+	k := mockKubernetes()
+
+	createTestNode(k, "node-a", nil)
+	createTestNode(k, "node-b", nil)
+	createTestNode(k, "node-c", nil)
+	createTestNode(k, "node-gpu", []interface{}{
+		map[string]interface{}{"key": "gpu", "value": "true", "effect": "NoSchedule"},
+	})
+	createTestNode(k, "node-cordoned", []interface{}{
+		map[string]interface{}{"key": "node.kubernetes.io/unschedulable", "effect": "NoSchedule"},
+	})
+
+	ds := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "DaemonSet",
+		"metadata":   map[string]interface{}{"name": "agent", "namespace": "default", "uid": "ds-uid"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{"nodeSelector": map[string]interface{}{"role": "worker"}},
+			},
+		},
+		"status": map[string]interface{}{"desiredNumberScheduled": int64(4), "numberReady": int64(1)},
+	}}
+
+	dsGvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "daemonsets"}
+	_, _ = k.dynamicClient.Resource(dsGvr).Namespace("default").Create(context.TODO(), ds, metaV1.CreateOptions{})
+
+	createTestDaemonSetPod(k, "agent-a", "node-a", true)
+	createTestDaemonSetPod(k, "agent-b", "node-b", false)
+
+	coverage, err := k.GetDaemonSetCoverage("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(coverage) != 1 {
+		t.Fatalf("Expected 1 DaemonSet, got %d", len(coverage))
+	}
+
+	c := coverage[0]
+	if c.DesiredNumberScheduled != 4 || c.NumberReady != 1 {
+		t.Errorf("Expected 4 desired & 1 ready, got %d & %d", c.DesiredNumberScheduled, c.NumberReady)
+	}
+
+	// The GPU node's taint isn't tolerated, the cordoned node's is tolerated by every DaemonSet
+	if !slices.Equal(c.EligibleNodes, []string{"node-a", "node-b", "node-c", "node-cordoned"}) {
+		t.Errorf("Unexpected eligible nodes %v", c.EligibleNodes)
+	}
+
+	if !slices.Equal(c.MissingNodes, []string{"node-c", "node-cordoned"}) {
+		t.Errorf("Unexpected missing nodes %v", c.MissingNodes)
+	}
+
+	if !slices.Equal(c.UnreadyNodes, []string{"node-b"}) {
+		t.Errorf("Unexpected unready nodes %v", c.UnreadyNodes)
+	}
+
+	if _, err := k.GetDaemonSetCoverage(""); err == nil {
+		t.Error("Expected error for empty namespace")
+	}
+}
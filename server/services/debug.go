@@ -0,0 +1,119 @@
+// ==========================================================================================
+// Ephemeral debug containers, the same as `kubectl debug`, for pods with no shell of their own
+// such as distroless images. Once added, a debug container can't be removed from the pod
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// An image reference: an optional registry & path, an optional tag then an optional digest, e.g.
+// busybox, busybox:1.36, registry.example.com:5000/tools/netshoot@sha256:...
+var imageReference = regexp.MustCompile(`^[a-zA-Z0-9]+([._-][a-zA-Z0-9]+)*(:[0-9]+)?` +
+	`(/[a-z0-9]+([._-]+[a-z0-9]+)*)*(:[A-Za-z0-9_][A-Za-z0-9_.-]{0,127})?(@[a-z0-9]+:[a-f0-9]{32,})?$`)
+
+// DebugContainer is an ephemeral container to add to a pod
+type DebugContainer struct {
+	// Name defaults to "debugger-" and a random suffix, as kubectl does
+	Name  string `json:"name"`
+	Image string `json:"image"`
+	// TargetContainer shares its process namespace with the debug container, so its processes can be seen
+	TargetContainer string   `json:"targetContainer"`
+	Command         []string `json:"command"`
+}
+
+// AddEphemeralContainer adds a debug container to a running pod, with stdin & a TTY so it can be exec'd into
+// With dryRun the container is validated by the API server, but not added
+func (k *Kubernetes) AddEphemeralContainer(ns, podName string, debug DebugContainer,
+	dryRun bool,
+) (*PodContainer, error) {
+	if k.ReadOnly {
+		return nil, ErrReadOnly
+	}
+
+	if ns == "" || podName == "" {
+		return nil, errors.New("namespace or pod name is empty")
+	}
+
+	if debug.Image == "" || !imageReference.MatchString(debug.Image) {
+		return nil, fmt.Errorf("invalid image %q", debug.Image)
+	}
+
+	if debug.Name == "" {
+		debug.Name = "debugger-" + utilrand.String(5)
+	}
+
+	if errs := validation.IsDNS1123Label(debug.Name); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid container name %q: %s", debug.Name, strings.Join(errs, ", "))
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	var pod *coreV1.Pod
+
+	err := k.callAPI(func(ctx context.Context) (err error) {
+		pod, err = k.clientSet.CoreV1().Pods(ns).Get(ctx, podName, metaV1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, c := range podContainers(pod) {
+		names = append(names, c.Name)
+	}
+
+	if slices.Contains(names, debug.Name) {
+		return nil, fmt.Errorf("pod %s already has a container named %s", podName, debug.Name)
+	}
+
+	if debug.TargetContainer != "" && !slices.ContainsFunc(pod.Spec.Containers, func(c coreV1.Container) bool {
+		return c.Name == debug.TargetContainer
+	}) {
+		return nil, fmt.Errorf("target container %s not found in pod %s", debug.TargetContainer, podName)
+	}
+
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, coreV1.EphemeralContainer{
+		EphemeralContainerCommon: coreV1.EphemeralContainerCommon{
+			Name:                     debug.Name,
+			Image:                    debug.Image,
+			Command:                  debug.Command,
+			ImagePullPolicy:          coreV1.PullIfNotPresent,
+			Stdin:                    true,
+			TTY:                      true,
+			TerminationMessagePolicy: coreV1.TerminationMessageReadFile,
+		},
+		TargetContainerName: debug.TargetContainer,
+	})
+
+	log.Printf("🐞 Adding debug container %s with image %s to pod %s in namespace %s%s",
+		debug.Name, debug.Image, podName, ns, dryRunNote(dryRun))
+
+	err = k.callAPI(func(ctx context.Context) (err error) {
+		_, err = k.clientSet.CoreV1().Pods(ns).UpdateEphemeralContainers(ctx, podName, pod,
+			metaV1.UpdateOptions{DryRun: dryRunOption(dryRun)})
+		return err
+	})
+	if err != nil {
+		log.Printf("💥 Failed to add debug container to pod %s in namespace %s: %v", podName, ns, err)
+		return nil, err
+	}
+
+	return &PodContainer{Name: debug.Name, Type: EphemeralContainer}, nil
+}
@@ -0,0 +1,102 @@
+// ==========================================================================================
+// Unit tests for ephemeral debug containers
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestKubernetes_AddEphemeralContainer(t *testing.T) {
+	k := mockKubernetes()
+
+	pod := &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       coreV1.PodSpec{Containers: []coreV1.Container{{Name: "app"}}},
+	}
+	_, _ = k.clientSet.CoreV1().Pods("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+
+	// Read-only mode is checked before anything else
+	k.ReadOnly = true
+
+	_, err := k.AddEphemeralContainer("default", "web", DebugContainer{Image: "busybox"}, false)
+	if !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+
+	k.ReadOnly = false
+
+	invalid := []struct {
+		name  string
+		pod   string
+		debug DebugContainer
+	}{
+		{"empty pod name", "", DebugContainer{Image: "busybox"}},
+		{"empty image", "web", DebugContainer{}},
+		{"image with spaces", "web", DebugContainer{Image: "busybox; rm -rf"}},
+		{"image with upper case path", "web", DebugContainer{Image: "example.com/Tools/debug"}},
+		{"invalid name", "web", DebugContainer{Name: "Debug_Me", Image: "busybox"}},
+		{"name already used", "web", DebugContainer{Name: "app", Image: "busybox"}},
+		{"missing target", "web", DebugContainer{Image: "busybox", TargetContainer: "missing"}},
+	}
+
+	for _, tc := range invalid {
+		if _, err := k.AddEphemeralContainer("default", tc.pod, tc.debug, false); err == nil {
+			t.Errorf("%s: expected an error, got nil", tc.name)
+		}
+	}
+
+	added, err := k.AddEphemeralContainer("default", "web", DebugContainer{
+		Image: "registry.example.com:5000/tools/netshoot:v0.13", TargetContainer: "app",
+	}, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if added.Type != EphemeralContainer || !strings.HasPrefix(added.Name, "debugger-") {
+		t.Errorf("Expected an ephemeral container with a generated name, got %+v", added)
+	}
+
+	updated, _ := k.clientSet.CoreV1().Pods("default").Get(context.TODO(), "web", metaV1.GetOptions{})
+	if len(updated.Spec.EphemeralContainers) != 1 || updated.Spec.EphemeralContainers[0].TargetContainerName != "app" {
+		t.Errorf("Expected the debug container to be added to the pod, got %+v", updated.Spec.EphemeralContainers)
+	}
+}
+
+func TestKubernetes_AddEphemeralContainer_DryRun(t *testing.T) {
+	k := mockKubernetes()
+
+	pod := &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec:       coreV1.PodSpec{Containers: []coreV1.Container{{Name: "app"}}},
+	}
+	_, _ = k.clientSet.CoreV1().Pods("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+
+	if _, err := k.AddEphemeralContainer("default", "web", DebugContainer{Image: "busybox"}, true); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	fake, _ := k.clientSet.(*k8sfake.Clientset)
+	idx := slices.IndexFunc(fake.Actions(), func(action k8stesting.Action) bool {
+		return action.GetSubresource() == "ephemeralcontainers"
+	})
+
+	if idx < 0 {
+		t.Fatal("Expected the ephemeral containers to be updated")
+	}
+
+	update, _ := fake.Actions()[idx].(k8stesting.UpdateActionImpl)
+	if !slices.Equal(update.UpdateOptions.DryRun, []string{metaV1.DryRunAll}) {
+		t.Errorf("Expected the debug container to be added as a dry run, got %v", update.UpdateOptions.DryRun)
+	}
+}
@@ -0,0 +1,455 @@
+// ==========================================================================================
+// Plain text description of a resource, like kubectl describe, with its spec, status & events
+// Pods, Deployments & Services are summarised field by field, other kinds which can be found
+// have their spec & status given as YAML
+// ==========================================================================================
+
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+var serviceGVR = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "services"}
+
+// Names & short names kubectl accepts for the kinds with their own description, e.g. "po" or "svc"
+var describeAliases = map[string]string{
+	"pod": "Pod", "pods": "Pod", "po": "Pod",
+	"deployment": "Deployment", "deployments": "Deployment", "deploy": "Deployment",
+	"service": "Service", "services": "Service", "svc": "Service",
+}
+
+// describer writes the lines of a description, with the values after a tab lined up in columns
+type describer struct {
+	tw *tabwriter.Writer
+}
+
+// line writes a line indented by the given level, tabs separate the columns
+func (d *describer) line(level int, format string, args ...any) {
+	fmt.Fprintf(d.tw, strings.Repeat("  ", level)+format+"\n", args...)
+}
+
+// DescribeResource returns a kubectl describe style summary of a resource & the events about it
+// The kind is matched ignoring case, and can be plural or a short name such as "svc"
+func (k *Kubernetes) DescribeResource(ns, kind, name string) (string, error) {
+	if ns == "" || kind == "" || name == "" {
+		return "", errors.New("namespace, kind or name is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return "", err
+	}
+
+	kind, gvr, err := describeKind(kind)
+	if err != nil {
+		return "", err
+	}
+
+	var obj *unstructured.Unstructured
+
+	err = k.callNamespaceAPI(ns, func(ctx context.Context) (err error) {
+		obj, err = k.dynamicClient.Resource(gvr).Namespace(ns).Get(ctx, name, metaV1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	obj = k.cleanObject(obj)
+
+	out := &strings.Builder{}
+	d := &describer{tw: tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)}
+
+	describeMetadata(d, obj, kind)
+
+	switch kind {
+	case "Pod":
+		err = describePod(d, obj)
+	case "Deployment":
+		err = describeDeployment(d, obj)
+	case "Service":
+		err = describeService(d, obj, k.serviceEndpoints(ns, name))
+	default:
+		describeFields(d, obj)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	// Events are a help, so failing to list them is noted in the description rather than being an error
+	events, err := k.getEvents(ns)
+	if err != nil {
+		d.line(0, "Events:\t<unable to list: %v>", err)
+	} else {
+		describeEvents(d, objectEvents(events, obj, kind), time.Now())
+	}
+
+	if err := d.tw.Flush(); err != nil {
+		return "", err
+	}
+
+	return out.String(), nil
+}
+
+// describeKind resolves a kind as kubectl would accept it, falling back to the kinds found in owner chains
+func describeKind(kind string) (string, schema.GroupVersionResource, error) {
+	lower := strings.ToLower(kind)
+
+	if canonical, ok := describeAliases[lower]; ok {
+		if canonical == "Service" {
+			return canonical, serviceGVR, nil
+		}
+
+		return canonical, kindResources[canonical], nil
+	}
+
+	for canonical, gvr := range kindResources {
+		if strings.ToLower(canonical) == lower || gvr.Resource == lower {
+			return canonical, gvr, nil
+		}
+	}
+
+	return "", schema.GroupVersionResource{}, fmt.Errorf("unknown kind %s", kind)
+}
+
+// serviceEndpoints gets the endpoints of a Service, nil when it has none or they can't be listed
+func (k *Kubernetes) serviceEndpoints(ns, name string) *ServiceEndpoints {
+	byService, err := k.GetServiceEndpoints(ns)
+	if err != nil {
+		return nil
+	}
+
+	return byService[name]
+}
+
+func describeMetadata(d *describer, obj *unstructured.Unstructured, kind string) {
+	annotations := obj.GetAnnotations()
+	delete(annotations, lastAppliedAnnotation)
+
+	d.line(0, "Name:\t%s", obj.GetName())
+	d.line(0, "Namespace:\t%s", obj.GetNamespace())
+	d.line(0, "Kind:\t%s", kind)
+	d.line(0, "Labels:\t%s", orNone(labels.FormatLabels(obj.GetLabels())))
+	d.line(0, "Annotations:\t%s", orNone(labels.FormatLabels(annotations)))
+	d.line(0, "Created:\t%s", orNone(formatTime(obj.GetCreationTimestamp().Time)))
+
+	if deleted := obj.GetDeletionTimestamp(); deleted != nil {
+		d.line(0, "Deleting Since:\t%s", formatTime(deleted.Time))
+	}
+}
+
+func describePod(d *describer, obj *unstructured.Unstructured) error {
+	pod := coreV1.Pod{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &pod); err != nil {
+		return err
+	}
+
+	startTime := ""
+	if pod.Status.StartTime != nil {
+		startTime = formatTime(pod.Status.StartTime.Time)
+	}
+
+	d.line(0, "Node:\t%s", orNone(pod.Spec.NodeName))
+	d.line(0, "Start Time:\t%s", orNone(startTime))
+	d.line(0, "Status:\t%s", orNone(string(pod.Status.Phase)))
+	d.line(0, "IP:\t%s", orNone(pod.Status.PodIP))
+	d.line(0, "Service Account:\t%s", orNone(pod.Spec.ServiceAccountName))
+	d.line(0, "QoS Class:\t%s", podQOSClass(&pod))
+
+	if pod.Spec.PriorityClassName != "" {
+		d.line(0, "Priority Class Name:\t%s", pod.Spec.PriorityClassName)
+	}
+
+	if len(pod.Spec.InitContainers) > 0 {
+		d.line(0, "Init Containers:")
+		describeContainers(d, pod.Spec.InitContainers, pod.Status.InitContainerStatuses)
+	}
+
+	d.line(0, "Containers:")
+	describeContainers(d, pod.Spec.Containers, pod.Status.ContainerStatuses)
+
+	if len(pod.Status.Conditions) > 0 {
+		d.line(0, "Conditions:")
+		d.line(1, "Type\tStatus")
+
+		for _, cond := range pod.Status.Conditions {
+			d.line(1, "%s\t%s", cond.Type, cond.Status)
+		}
+	}
+
+	return nil
+}
+
+// describeContainers writes the image, ports, resources & current state of each container
+func describeContainers(d *describer, containers []coreV1.Container, statuses []coreV1.ContainerStatus) {
+	for _, c := range containers {
+		d.line(1, "%s:", c.Name)
+		d.line(2, "Image:\t%s", c.Image)
+
+		ports := []string{}
+		for _, p := range c.Ports {
+			ports = append(ports, fmt.Sprintf("%d/%s", p.ContainerPort, cmp.Or(string(p.Protocol), "TCP")))
+		}
+
+		if len(ports) > 0 {
+			d.line(2, "Ports:\t%s", strings.Join(ports, ", "))
+		}
+
+		if len(c.Resources.Requests) > 0 {
+			d.line(2, "Requests:\t%s", formatResourceList(c.Resources.Requests))
+		}
+
+		if len(c.Resources.Limits) > 0 {
+			d.line(2, "Limits:\t%s", formatResourceList(c.Resources.Limits))
+		}
+
+		i := slices.IndexFunc(statuses, func(cs coreV1.ContainerStatus) bool { return cs.Name == c.Name })
+		if i < 0 {
+			d.line(2, "State:\tWaiting")
+			continue
+		}
+
+		cs := statuses[i]
+		d.line(2, "State:\t%s", containerStateSummary(cs.State))
+
+		if last := cs.LastTerminationState; last.Terminated != nil {
+			d.line(2, "Last State:\t%s", containerStateSummary(last))
+		}
+
+		d.line(2, "Ready:\t%t", cs.Ready)
+		d.line(2, "Restart Count:\t%d", cs.RestartCount)
+	}
+}
+
+// containerStateSummary gives a container state on one line, e.g. "Waiting (CrashLoopBackOff)"
+func containerStateSummary(state coreV1.ContainerState) string {
+	switch {
+	case state.Running != nil:
+		return "Running since " + orNone(formatTime(state.Running.StartedAt.Time))
+	case state.Terminated != nil:
+		return fmt.Sprintf("Terminated (%s), exit code %d",
+			cmp.Or(state.Terminated.Reason, "Unknown"), state.Terminated.ExitCode)
+	case state.Waiting != nil && state.Waiting.Reason != "":
+		return fmt.Sprintf("Waiting (%s)", state.Waiting.Reason)
+	default:
+		return "Waiting"
+	}
+}
+
+func describeDeployment(d *describer, obj *unstructured.Unstructured) error {
+	deploy := appsV1.Deployment{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &deploy); err != nil {
+		return err
+	}
+
+	desired := int32(1)
+	if deploy.Spec.Replicas != nil {
+		desired = *deploy.Spec.Replicas
+	}
+
+	status := deploy.Status
+
+	d.line(0, "Selector:\t%s", orNone(formatSelector(deploy.Spec.Selector)))
+	d.line(0, "Replicas:\t%d desired | %d updated | %d total | %d available | %d unavailable",
+		desired, status.UpdatedReplicas, status.Replicas, status.AvailableReplicas, status.UnavailableReplicas)
+	d.line(0, "Strategy:\t%s", orNone(string(deploy.Spec.Strategy.Type)))
+
+	if ru := deploy.Spec.Strategy.RollingUpdate; ru != nil && ru.MaxUnavailable != nil && ru.MaxSurge != nil {
+		d.line(0, "Rolling Update:\t%s max unavailable, %s max surge", ru.MaxUnavailable, ru.MaxSurge)
+	}
+
+	d.line(0, "Pod Template:")
+	d.line(1, "Labels:\t%s", orNone(labels.FormatLabels(deploy.Spec.Template.Labels)))
+	d.line(1, "Containers:")
+
+	for _, c := range deploy.Spec.Template.Spec.Containers {
+		d.line(2, "%s:\t%s", c.Name, c.Image)
+	}
+
+	if len(status.Conditions) > 0 {
+		d.line(0, "Conditions:")
+		d.line(1, "Type\tStatus\tReason")
+
+		for _, cond := range status.Conditions {
+			d.line(1, "%s\t%s\t%s", cond.Type, cond.Status, cond.Reason)
+		}
+	}
+
+	return nil
+}
+
+func describeService(d *describer, obj *unstructured.Unstructured, endpoints *ServiceEndpoints) error {
+	svc := coreV1.Service{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &svc); err != nil {
+		return err
+	}
+
+	d.line(0, "Type:\t%s", cmp.Or(string(svc.Spec.Type), string(coreV1.ServiceTypeClusterIP)))
+	d.line(0, "Selector:\t%s", orNone(labels.FormatLabels(svc.Spec.Selector)))
+	d.line(0, "IP:\t%s", orNone(svc.Spec.ClusterIP))
+
+	if len(svc.Spec.ExternalIPs) > 0 {
+		d.line(0, "External IPs:\t%s", strings.Join(svc.Spec.ExternalIPs, ","))
+	}
+
+	ingress := []string{}
+	for _, lb := range svc.Status.LoadBalancer.Ingress {
+		ingress = append(ingress, cmp.Or(lb.IP, lb.Hostname))
+	}
+
+	if len(ingress) > 0 {
+		d.line(0, "LoadBalancer Ingress:\t%s", strings.Join(ingress, ", "))
+	}
+
+	for _, p := range svc.Spec.Ports {
+		d.line(0, "Port:\t%s %d/%s", orNone(p.Name), p.Port, cmp.Or(string(p.Protocol), "TCP"))
+
+		if p.TargetPort.String() != "0" {
+			d.line(0, "TargetPort:\t%s/%s", p.TargetPort.String(), cmp.Or(string(p.Protocol), "TCP"))
+		}
+
+		if p.NodePort != 0 {
+			d.line(0, "NodePort:\t%d/%s", p.NodePort, cmp.Or(string(p.Protocol), "TCP"))
+		}
+	}
+
+	ready := ""
+	if endpoints != nil {
+		ready = strings.Join(endpoints.ReadyAddresses, ",")
+	}
+
+	d.line(0, "Endpoints:\t%s", orNone(ready))
+
+	if endpoints != nil && endpoints.NotReady > 0 {
+		d.line(0, "Not Ready Endpoints:\t%s", strings.Join(endpoints.NotReadyAddresses, ","))
+	}
+
+	d.line(0, "Session Affinity:\t%s", cmp.Or(string(svc.Spec.SessionAffinity), "None"))
+
+	return nil
+}
+
+// describeFields gives the spec & status of other kinds as YAML, the best that can be done without knowing them
+func describeFields(d *describer, obj *unstructured.Unstructured) {
+	for _, field := range []string{"spec", "status"} {
+		value, found := obj.Object[field]
+		if !found {
+			continue
+		}
+
+		out, err := yaml.Marshal(value)
+		if err != nil {
+			continue
+		}
+
+		d.line(0, "%s:", strings.ToUpper(field[:1])+field[1:])
+
+		for _, l := range strings.Split(strings.TrimRight(string(out), "\n"), "\n") {
+			d.line(1, "%s", l)
+		}
+	}
+}
+
+// objectEvents picks out the events about an object, oldest first as kubectl gives them
+// Events are matched by UID when they have one, so those about an earlier object with the same name are left out
+func objectEvents(events []unstructured.Unstructured, obj *unstructured.Unstructured,
+	kind string,
+) []unstructured.Unstructured {
+	matched := []unstructured.Unstructured{}
+
+	for _, event := range events {
+		involved, _, _ := unstructured.NestedStringMap(event.Object, "involvedObject")
+		if involved["kind"] != kind || involved["name"] != obj.GetName() {
+			continue
+		}
+
+		if uid := involved["uid"]; uid != "" && obj.GetUID() != "" && uid != string(obj.GetUID()) {
+			continue
+		}
+
+		matched = append(matched, event)
+	}
+
+	slices.Reverse(matched)
+
+	return matched
+}
+
+func describeEvents(d *describer, events []unstructured.Unstructured, now time.Time) {
+	if len(events) == 0 {
+		d.line(0, "Events:\t<none>")
+		return
+	}
+
+	d.line(0, "Events:")
+	d.line(1, "Type\tReason\tAge\tFrom\tMessage")
+	d.line(1, "----\t------\t---\t----\t-------")
+
+	for i := range events {
+		event := events[i].Object
+		eventType, _, _ := unstructured.NestedString(event, "type")
+		reason, _, _ := unstructured.NestedString(event, "reason")
+		from, _, _ := unstructured.NestedString(event, "source", "component")
+		message, _, _ := unstructured.NestedString(event, "message")
+		count, _, _ := unstructured.NestedInt64(event, "count")
+
+		age := "<unknown>"
+		if _, seen := eventLastSeen(&events[i]); !seen.IsZero() {
+			age = shortDuration(now.Sub(seen))
+		}
+
+		if count > 1 {
+			age = fmt.Sprintf("%s (x%d)", age, count)
+		}
+
+		d.line(1, "%s\t%s\t%s\t%s\t%s", eventType, reason, age, from, strings.TrimSpace(message))
+	}
+}
+
+// shortDuration formats a duration in its largest whole unit, like kubectl's ages e.g. "45s", "12m" or "3d"
+func shortDuration(age time.Duration) string {
+	switch {
+	case age < time.Minute:
+		return fmt.Sprintf("%ds", max(int(age.Seconds()), 0))
+	case age < time.Hour:
+		return fmt.Sprintf("%dm", int(age.Minutes()))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(age.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(age.Hours()/24))
+	}
+}
+
+// formatResourceList formats requests or limits in name order, e.g. "cpu=100m, memory=128Mi"
+func formatResourceList(list coreV1.ResourceList) string {
+	parts := []string{}
+	for name, quantity := range list {
+		parts = append(parts, fmt.Sprintf("%s=%s", name, quantity.String()))
+	}
+
+	slices.Sort(parts)
+
+	return strings.Join(parts, ", ")
+}
+
+// orNone shows empty values as kubectl does
+func orNone(value string) string {
+	return cmp.Or(value, "<none>")
+}
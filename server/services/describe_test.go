@@ -0,0 +1,83 @@
+// ==========================================================================================
+// Unit tests for kubectl describe style output
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestKubernetes_DescribeResource_Pod(t *testing.T) {
+	k := mockKubernetes()
+
+	pod := createTestPod("web", "default")
+	pod.SetLabels(map[string]string{"app": "web"})
+	_ = unstructured.SetNestedField(pod.Object, "Running", "status", "phase")
+	_ = unstructured.SetNestedField(pod.Object, "node-1", "spec", "nodeName")
+	_ = unstructured.SetNestedSlice(pod.Object, []interface{}{
+		map[string]interface{}{
+			"name":         "test-container",
+			"ready":        true,
+			"restartCount": int64(2),
+			"state":        map[string]interface{}{"running": map[string]interface{}{"startedAt": "2024-01-01T10:00:00Z"}},
+		},
+	}, "status", "containerStatuses")
+
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+
+	// Before there are any events the section is still there, marked none
+	out, err := k.DescribeResource("default", "po", "web")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !strings.Contains(out, "\nEvents:") || !strings.HasSuffix(strings.TrimSpace(out), "<none>") {
+		t.Errorf("Expected an empty events section, got:\n%s", out)
+	}
+
+	pulled := createTestCoreEvent("web.pulled", "default")
+	pulled.Object["reason"] = "Pulled"
+	pulled.Object["type"] = "Normal"
+	pulled.Object["message"] = `Successfully pulled image "nginx:latest"`
+	pulled.Object["source"] = map[string]interface{}{"component": "kubelet"}
+	pulled.Object["lastTimestamp"] = time.Now().Add(-5 * time.Minute).UTC().Format(time.RFC3339)
+
+	other := createTestCoreEvent("api.pulled", "default")
+	other.Object["involvedObject"] = map[string]interface{}{"kind": "Pod", "name": "api"}
+
+	for _, event := range []*unstructured.Unstructured{pulled, other} {
+		_, _ = k.dynamicClient.Resource(coreEventGVR).Namespace("default").
+			Create(context.TODO(), event, metaV1.CreateOptions{})
+	}
+
+	out, err = k.DescribeResource("default", "Pod", "web")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, expected := range []string{
+		"Name:", "web", "Labels:", "app=web", "Node:", "node-1", "Status:", "Running",
+		"test-container:", "Image:", "nginx:latest", "Restart Count:", "2",
+		"Events:", "Type", "Reason", "Normal", "Pulled", "5m", "kubelet", `Successfully pulled image "nginx:latest"`,
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("Expected the description to include %q, got:\n%s", expected, out)
+		}
+	}
+
+	events := out[strings.Index(out, "Events:"):]
+	if strings.Contains(events, "Scheduled") {
+		t.Errorf("Expected only the events about web, got:\n%s", events)
+	}
+
+	if _, err := k.DescribeResource("default", "Widget", "web"); err == nil {
+		t.Error("Expected an error for an unknown kind")
+	}
+}
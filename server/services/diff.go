@@ -0,0 +1,125 @@
+// ==========================================================================================
+// Structured diff between two versions of a resource, used to show what changed on update
+// ==========================================================================================
+
+package services
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// FieldChange is a single changed field between two versions of a resource
+// Before is nil when the field was added, and After is nil when it was removed
+type FieldChange struct {
+	Path   string      `json:"path"`
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Fields which change constantly and say nothing useful about what really changed
+var noisyDiffPaths = map[string]bool{
+	"metadata.resourceVersion": true,
+	"metadata.managedFields":   true,
+}
+
+// ComputeDiff compares two versions of a resource and returns the changed fields, sorted by path
+// Paths are in dotted form with list indexes, e.g. spec.containers[0].image
+func ComputeDiff(oldObj, newObj *unstructured.Unstructured) []FieldChange {
+	var oldMap, newMap map[string]interface{}
+
+	if oldObj != nil {
+		oldMap = oldObj.Object
+	}
+
+	if newObj != nil {
+		newMap = newObj.Object
+	}
+
+	changes := []FieldChange{}
+	diffValues("", oldMap, newMap, &changes)
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+
+	return changes
+}
+
+// diffValues recursively compares two values, appending any differences found
+func diffValues(path string, before, after interface{}, changes *[]FieldChange) {
+	if noisyDiffPaths[path] {
+		return
+	}
+
+	beforeMap, beforeIsMap := before.(map[string]interface{})
+	afterMap, afterIsMap := after.(map[string]interface{})
+
+	if beforeIsMap && afterIsMap {
+		diffMaps(path, beforeMap, afterMap, changes)
+		return
+	}
+
+	beforeList, beforeIsList := before.([]interface{})
+	afterList, afterIsList := after.([]interface{})
+
+	if beforeIsList && afterIsList {
+		diffLists(path, beforeList, afterList, changes)
+		return
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		*changes = append(*changes, FieldChange{Path: path, Before: before, After: after})
+	}
+}
+
+// diffMaps compares every key found in either map
+func diffMaps(path string, before, after map[string]interface{}, changes *[]FieldChange) {
+	keys := map[string]bool{}
+
+	for key := range before {
+		keys[key] = true
+	}
+
+	for key := range after {
+		keys[key] = true
+	}
+
+	for key := range keys {
+		diffValues(joinDiffPath(path, key), before[key], after[key], changes)
+	}
+}
+
+// diffLists compares lists by index, extra items on either side show as added or removed
+func diffLists(path string, before, after []interface{}, changes *[]FieldChange) {
+	for i := 0; i < max(len(before), len(after)); i++ {
+		var b, a interface{}
+
+		if i < len(before) {
+			b = before[i]
+		}
+
+		if i < len(after) {
+			a = after[i]
+		}
+
+		diffValues(path+"["+strconv.Itoa(i)+"]", b, a, changes)
+	}
+}
+
+// joinDiffPath appends a key to a path, keys containing dots (e.g. annotations) are quoted in brackets
+func joinDiffPath(path, key string) string {
+	if strings.Contains(key, ".") {
+		return path + `["` + key + `"]`
+	}
+
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
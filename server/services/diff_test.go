@@ -0,0 +1,72 @@
+// ==========================================================================================
+// Unit tests for resource diffs
+// ==========================================================================================
+
+package services
+
+import (
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestComputeDiff_ChangedImage(t *testing.T) {
+	oldPod := createTestPod("test-pod", "default")
+	oldPod.SetResourceVersion("100")
+	oldPod.SetManagedFields([]metaV1.ManagedFieldsEntry{{Manager: "kubectl"}})
+	oldPod.SetLabels(map[string]string{"tier": "frontend"})
+
+	newPod := oldPod.DeepCopy()
+	newPod.SetResourceVersion("101")
+	newPod.SetManagedFields([]metaV1.ManagedFieldsEntry{{Manager: "kubectl"}, {Manager: "kubelet"}})
+	newPod.SetLabels(map[string]string{"tier": "frontend", "app.kubernetes.io/name": "web"})
+
+	containers, _, _ := unstructured.NestedSlice(newPod.Object, "spec", "containers")
+	containers[0].(map[string]interface{})["image"] = "nginx:1.27"
+	_ = unstructured.SetNestedSlice(newPod.Object, containers, "spec", "containers")
+
+	changes := ComputeDiff(oldPod, newPod)
+
+	if len(changes) != 2 {
+		t.Fatalf("Expected 2 changes, got %d: %+v", len(changes), changes)
+	}
+
+	// Changes are sorted by path, so the label comes first
+	label := changes[0]
+	if label.Path != `metadata.labels["app.kubernetes.io/name"]` || label.Before != nil || label.After != "web" {
+		t.Errorf("Expected added label change, got %+v", label)
+	}
+
+	image := changes[1]
+	if image.Path != "spec.containers[0].image" {
+		t.Errorf("Expected image path spec.containers[0].image, got %s", image.Path)
+	}
+
+	if image.Before != "nginx:latest" || image.After != "nginx:1.27" {
+		t.Errorf("Expected image to change from nginx:latest to nginx:1.27, got %v to %v", image.Before, image.After)
+	}
+}
+
+func TestComputeDiff_NoChanges(t *testing.T) {
+	pod := createTestPod("test-pod", "default")
+
+	changes := ComputeDiff(pod, pod.DeepCopy())
+	if len(changes) != 0 {
+		t.Errorf("Expected no changes, got %+v", changes)
+	}
+}
+
+func TestComputeDiff_RemovedListItem(t *testing.T) {
+	oldPod := createTestPod("test-pod", "default")
+	containers, _, _ := unstructured.NestedSlice(oldPod.Object, "spec", "containers")
+	containers = append(containers, map[string]interface{}{"name": "sidecar", "image": "envoy:v1"})
+	_ = unstructured.SetNestedSlice(oldPod.Object, containers, "spec", "containers")
+
+	newPod := createTestPod("test-pod", "default")
+
+	changes := ComputeDiff(oldPod, newPod)
+	if len(changes) != 1 || changes[0].Path != "spec.containers[1]" || changes[0].After != nil {
+		t.Errorf("Expected removed container change, got %+v", changes)
+	}
+}
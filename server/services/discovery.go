@@ -0,0 +1,245 @@
+// ==========================================================================================
+// Discovery of the resource types served by the cluster, so the frontend can adapt to
+// what's actually installed, including CRDs, rather than relying on a hardcoded list
+// ==========================================================================================
+
+package services
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ErrResourceTypeNotFound is returned when a resource type isn't served by the cluster in the requested group
+var ErrResourceTypeNotFound = errors.New("resource type not found")
+
+// How long discovery results are kept, when CRDs are watched changes to them clear the cache straight away
+const discoveryCacheTTL = 5 * time.Minute
+
+// How often discovery is refreshed instead, when not permitted to watch CRDs
+const crdRefreshInterval = time.Minute
+
+// APIResource is a namespaced resource type served by the cluster, at the version the server prefers
+type APIResource struct {
+	Group   string `json:"group"`
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+	// Plural is the resource name used in API paths, e.g. "deployments"
+	Plural string `json:"plural"`
+}
+
+// GetAPIResources returns the namespaced resource types which can be listed, sorted by group then kind
+// Results are cached, when an aggregated API is unavailable the rest are returned but not cached
+func (k *Kubernetes) GetAPIResources() ([]APIResource, error) {
+	k.discoveryMu.Lock()
+	defer k.discoveryMu.Unlock()
+
+	if k.apiResources != nil && time.Since(k.apiResourcesTime) < discoveryCacheTTL {
+		return k.apiResources, nil
+	}
+
+	groups, lists, err := k.clientSet.Discovery().ServerGroupsAndResources()
+
+	partial := false
+
+	if err != nil {
+		// Aggregated APIs such as metrics.k8s.io can be down, without the rest of the cluster being affected
+		var groupErr *discovery.ErrGroupDiscoveryFailed
+		if !errors.As(err, &groupErr) {
+			return nil, err
+		}
+
+		for gv, gvErr := range groupErr.Groups {
+			log.Printf("⚠️ API group %s is unavailable, skipping it: %v", gv, gvErr)
+		}
+
+		partial = true
+	}
+
+	resources := namespacedResources(groups, lists)
+
+	if !partial {
+		k.apiResources = resources
+		k.apiResourcesTime = time.Now()
+	}
+
+	return resources, nil
+}
+
+// InvalidateAPIResources clears the cached discovery, so the next GetAPIResources asks the cluster again
+func (k *Kubernetes) InvalidateAPIResources() {
+	k.discoveryMu.Lock()
+	defer k.discoveryMu.Unlock()
+
+	k.apiResources = nil
+	k.serverInfo = nil
+}
+
+// ServerInfo is the build of the API server, and the API versions it serves
+type ServerInfo struct {
+	Version *version.Info `json:"version"`
+	// APIVersions are group/versions e.g. "apps/v1", left out when discovery of the groups isn't permitted
+	APIVersions []string `json:"apiVersions,omitempty"`
+}
+
+// GetServerInfo returns the API server's version & build details, with the API versions it serves when readable
+// Results are cached the same as GetAPIResources
+func (k *Kubernetes) GetServerInfo() (*ServerInfo, error) {
+	k.discoveryMu.Lock()
+	defer k.discoveryMu.Unlock()
+
+	if k.serverInfo != nil && time.Since(k.serverInfoTime) < discoveryCacheTTL {
+		return k.serverInfo, nil
+	}
+
+	serverVersion, err := k.clientSet.Discovery().ServerVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ServerInfo{Version: serverVersion}
+
+	// The version is all that's needed for diagnostics, so failing to list groups isn't an error
+	groups, err := k.clientSet.Discovery().ServerGroups()
+	if err != nil {
+		log.Printf("⚠️ Unable to list API groups: %v", err)
+	} else {
+		info.APIVersions = metaV1.ExtractGroupVersions(groups)
+		slices.Sort(info.APIVersions)
+	}
+
+	k.serverInfo = info
+	k.serverInfoTime = time.Now()
+
+	return info, nil
+}
+
+// watchCRDs clears the cached discovery whenever a CRD is installed, changed or removed, until stop is closed
+// Without permission to watch CRDs the cache is cleared every crdRefreshInterval instead
+func (k *Kubernetes) watchCRDs(stop <-chan struct{}) {
+	if !canWatch(k.dynamicClient, "", crdGVR) {
+		log.Printf("🔁 Not permitted to watch CRDs, refreshing discovery every %s instead", crdRefreshInterval)
+
+		go func() {
+			ticker := time.NewTicker(crdRefreshInterval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+					k.InvalidateAPIResources()
+				}
+			}
+		}()
+
+		return
+	}
+
+	changed := func(action string, obj interface{}) {
+		if crd, ok := obj.(*unstructured.Unstructured); ok {
+			log.Printf("🧩 CRD %s %s, refreshing discovery", crd.GetName(), action)
+		}
+
+		k.InvalidateAPIResources()
+	}
+
+	informer := dynamicinformer.NewFilteredDynamicInformer(k.dynamicClient, crdGVR, "", 0, cache.Indexers{}, nil)
+
+	_, _ = informer.Informer().AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			// The CRDs already installed at startup are in the first discovery anyway
+			if !isInInitialList {
+				changed("installed", obj)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// Status updates don't change the served types, only the spec does, e.g. a new version
+			oldCRD, okOld := oldObj.(*unstructured.Unstructured)
+			newCRD, okNew := newObj.(*unstructured.Unstructured)
+
+			if okOld && okNew && oldCRD.GetGeneration() != newCRD.GetGeneration() {
+				changed("changed", newObj)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			changed("removed", obj)
+		},
+	})
+
+	go informer.Informer().Run(stop)
+
+	cache.WaitForCacheSync(stop, informer.Informer().HasSynced)
+}
+
+// GetResourcesForGroup lists resources like GetResources, but the version is the group's preferred version
+// This is for CRDs & other types where the version isn't known, the version is found from the cached discovery
+func (k *Kubernetes) GetResourcesForGroup(ns, grp, res string) ([]unstructured.Unstructured, error) {
+	ver, err := k.PreferredVersion(grp, res)
+	if err != nil {
+		return nil, err
+	}
+
+	return k.GetResources(ns, grp, ver, res)
+}
+
+// PreferredVersion finds the version the cluster prefers for a namespaced resource type in a group
+func (k *Kubernetes) PreferredVersion(grp, res string) (string, error) {
+	resources, err := k.GetAPIResources()
+	if err != nil {
+		return "", err
+	}
+
+	idx := slices.IndexFunc(resources, func(r APIResource) bool { return r.Group == grp && r.Plural == res })
+	if idx < 0 {
+		return "", fmt.Errorf("%w: %s in API group %q", ErrResourceTypeNotFound, res, grp)
+	}
+
+	return resources[idx].Version, nil
+}
+
+// namespacedResources picks the namespaced, listable resources at each group's preferred version
+func namespacedResources(groups []*metaV1.APIGroup, lists []*metaV1.APIResourceList) []APIResource {
+	preferred := map[string]string{}
+	for _, group := range groups {
+		preferred[group.Name] = group.PreferredVersion.Version
+	}
+
+	resources := []APIResource{}
+
+	for _, list := range lists {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil || preferred[gv.Group] != gv.Version {
+			continue
+		}
+
+		for _, res := range list.APIResources {
+			// Subresources like pods/log have a slash in their name
+			if !res.Namespaced || strings.Contains(res.Name, "/") || !slices.Contains(res.Verbs, "list") {
+				continue
+			}
+
+			resources = append(resources, APIResource{Group: gv.Group, Version: gv.Version, Kind: res.Kind, Plural: res.Name})
+		}
+	}
+
+	slices.SortFunc(resources, func(a, b APIResource) int {
+		return cmp.Or(cmp.Compare(a.Group, b.Group), cmp.Compare(a.Kind, b.Kind))
+	})
+
+	return resources
+}
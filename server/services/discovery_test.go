@@ -0,0 +1,210 @@
+// ==========================================================================================
+// Unit tests for API resource discovery & server info
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var testAPIResources = []*metaV1.APIResourceList{
+	{GroupVersion: "v1", APIResources: []metaV1.APIResource{
+		{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: []string{"get", "list", "watch"}},
+		{Name: "pods/log", Kind: "Pod", Namespaced: true, Verbs: []string{"get"}},
+		{Name: "namespaces", Kind: "Namespace", Namespaced: false, Verbs: []string{"get", "list"}},
+		{Name: "bindings", Kind: "Binding", Namespaced: true, Verbs: []string{"create"}},
+	}},
+	{GroupVersion: "apps/v1", APIResources: []metaV1.APIResource{
+		{Name: "deployments", Kind: "Deployment", Namespaced: true, Verbs: []string{"get", "list"}},
+	}},
+	{GroupVersion: "example.com/v1", APIResources: []metaV1.APIResource{
+		{Name: "widgets", Kind: "Widget", Namespaced: true, Verbs: []string{"get", "list"}},
+	}},
+	// Not the preferred version, the first version of each group is preferred by the fake client
+	{GroupVersion: "example.com/v1beta1", APIResources: []metaV1.APIResource{
+		{Name: "widgets", Kind: "Widget", Namespaced: true, Verbs: []string{"get", "list"}},
+	}},
+}
+
+func TestKubernetes_GetAPIResources(t *testing.T) {
+	k := mockKubernetes()
+	disc, _ := k.clientSet.Discovery().(*fakediscovery.FakeDiscovery)
+	disc.Resources = testAPIResources
+
+	resources, err := k.GetAPIResources()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []APIResource{
+		{Group: "", Version: "v1", Kind: "Pod", Plural: "pods"},
+		{Group: "apps", Version: "v1", Kind: "Deployment", Plural: "deployments"},
+		{Group: "example.com", Version: "v1", Kind: "Widget", Plural: "widgets"},
+	}
+
+	if len(resources) != len(expected) {
+		t.Fatalf("Expected %d resources, got %+v", len(expected), resources)
+	}
+
+	for i := range expected {
+		if resources[i] != expected[i] {
+			t.Errorf("Expected resource %d to be %+v, got %+v", i, expected[i], resources[i])
+		}
+	}
+
+	// The cached result is returned, even though the cluster has changed
+	disc.Resources = testAPIResources[:1]
+
+	if cached, _ := k.GetAPIResources(); len(cached) != len(expected) {
+		t.Errorf("Expected cached result with %d resources, got %d", len(expected), len(cached))
+	}
+}
+
+func TestKubernetes_GetAPIResources_GroupUnavailable(t *testing.T) {
+	k := mockKubernetes()
+	disc, _ := k.clientSet.Discovery().(*fakediscovery.FakeDiscovery)
+	disc.Resources = testAPIResources[:2]
+
+	// Discovery of an aggregated API fails, the other groups are still returned
+	failed := &discovery.ErrGroupDiscoveryFailed{Groups: map[schema.GroupVersion]error{
+		{Group: "metrics.k8s.io", Version: "v1beta1"}: errors.New("the server is currently unable to handle the request"),
+	}}
+	disc.PrependReactor("get", "resource", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, failed
+	})
+
+	resources, err := k.GetAPIResources()
+	if err != nil {
+		t.Fatalf("Expected no error for a partial failure, got %v", err)
+	}
+
+	if len(resources) != 2 {
+		t.Errorf("Expected 2 resources, got %+v", resources)
+	}
+
+	if k.apiResources != nil {
+		t.Error("Expected a partial result not to be cached")
+	}
+}
+
+func TestKubernetes_GetResourcesForGroup(t *testing.T) {
+	k := mockKubernetes()
+	disc, _ := k.clientSet.Discovery().(*fakediscovery.FakeDiscovery)
+	disc.Resources = testAPIResources
+
+	ver, err := k.PreferredVersion("example.com", "widgets")
+	if err != nil || ver != "v1" {
+		t.Errorf("Expected widgets at the preferred version v1, got %q %v", ver, err)
+	}
+
+	deploy := createTestDeployment("web", "default", 1)
+	_, _ = k.dynamicClient.Resource(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}).
+		Namespace("default").Create(context.TODO(), deploy, metaV1.CreateOptions{})
+
+	items, err := k.GetResourcesForGroup("default", "apps", "deployments")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(items) != 1 || items[0].GetName() != "web" {
+		t.Errorf("Expected the web deployment, got %d items", len(items))
+	}
+
+	if _, err := k.GetResourcesForGroup("default", "example.com", "gadgets"); !errors.Is(err, ErrResourceTypeNotFound) {
+		t.Errorf("Expected ErrResourceTypeNotFound, got %v", err)
+	}
+
+	// Namespaces aren't namespaced, so can't be listed in a namespace
+	if _, err := k.PreferredVersion("", "namespaces"); !errors.Is(err, ErrResourceTypeNotFound) {
+		t.Errorf("Expected ErrResourceTypeNotFound for a cluster scoped type, got %v", err)
+	}
+}
+
+func TestKubernetes_WatchCRDs(t *testing.T) {
+	k := mockKubernetes()
+	disc, _ := k.clientSet.Discovery().(*fakediscovery.FakeDiscovery)
+	disc.Resources = testAPIResources[:2]
+
+	stop := make(chan struct{})
+	defer close(stop)
+
+	k.watchCRDs(stop)
+
+	if resources, _ := k.GetAPIResources(); len(resources) != 2 {
+		t.Fatalf("Expected 2 resources before the CRD is installed, got %+v", resources)
+	}
+
+	// Installing the CRD serves the new type, which should be found without waiting for the cache to expire
+	disc.Resources = testAPIResources
+
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": "widgets.example.com"},
+	}}
+	_, _ = k.dynamicClient.Resource(crdGVR).Create(context.TODO(), crd, metaV1.CreateOptions{})
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		if _, err := k.PreferredVersion("example.com", "widgets"); err == nil {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("Expected discovery to be refreshed after the CRD was installed")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestKubernetes_GetServerInfo(t *testing.T) {
+	k := mockKubernetes()
+	disc, _ := k.clientSet.Discovery().(*fakediscovery.FakeDiscovery)
+	disc.Resources = testAPIResources
+	disc.FakedServerVersion = &version.Info{
+		Major: "1", Minor: "33", GitVersion: "v1.33.2", BuildDate: "2025-06-17T18:30:00Z", Platform: "linux/amd64",
+	}
+
+	info, err := k.GetServerInfo()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if info.Version.GitVersion != "v1.33.2" || info.Version.Platform != "linux/amd64" || info.Version.BuildDate == "" {
+		t.Errorf("Unexpected version %+v", info.Version)
+	}
+
+	expected := []string{"apps/v1", "example.com/v1", "example.com/v1beta1", "v1"}
+	if !slices.Equal(info.APIVersions, expected) {
+		t.Errorf("Expected API versions %v, got %v", expected, info.APIVersions)
+	}
+
+	// The cached result is returned until discovery is invalidated
+	disc.FakedServerVersion = &version.Info{GitVersion: "v1.34.0"}
+
+	if cached, _ := k.GetServerInfo(); cached.Version.GitVersion != "v1.33.2" {
+		t.Errorf("Expected the cached version, got %s", cached.Version.GitVersion)
+	}
+
+	k.InvalidateAPIResources()
+
+	if info, _ := k.GetServerInfo(); info.Version.GitVersion != "v1.34.0" {
+		t.Errorf("Expected the new version after invalidating, got %s", info.Version.GitVersion)
+	}
+}
@@ -0,0 +1,271 @@
+// ==========================================================================================
+// Draining a node, the same as `kubectl drain`, cordoning it then evicting its pods
+// DaemonSet & mirror pods are left alone, as they can't be rescheduled anywhere else
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	coreV1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ErrDrainNotConfirmed is returned when a drain is requested without DrainOptions.Confirm
+var ErrDrainNotConfirmed = errors.New("draining a node must be confirmed")
+
+// Defaults for DrainOptions, matching the timeout most people give kubectl drain
+const (
+	defaultDrainTimeout       = 2 * time.Minute
+	defaultDrainRetryInterval = 5 * time.Second
+)
+
+// Pods created from a static manifest on the node have this annotation, the API server only holds a mirror
+const mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+// DrainOptions control how a node is drained
+type DrainOptions struct {
+	// Confirm must be set, as draining disrupts every workload on the node
+	Confirm bool
+	// Timeout is how long to keep retrying evictions blocked by a PodDisruptionBudget, defaults to 2 minutes
+	Timeout time.Duration
+	// RetryInterval is how long to wait between retries of blocked evictions, defaults to 5 seconds
+	RetryInterval time.Duration
+	// DryRun previews the drain, the cordon & evictions are validated by the API server but nothing changes.
+	// Blocked evictions aren't retried, as nothing changes between retries, they're reported as failed
+	DryRun bool
+}
+
+// DrainStep is a stage of draining a node, reported as progress
+type DrainStep string
+
+const (
+	DrainCordoned DrainStep = "cordoned"
+	DrainSkipped  DrainStep = "skipped"
+	DrainEvicted  DrainStep = "evicted"
+	DrainRetrying DrainStep = "retrying"
+	DrainFailed   DrainStep = "failed"
+)
+
+// DrainProgress reports each step of a drain, the pod is empty when cordoning
+type DrainProgress struct {
+	Node      string    `json:"node"`
+	Step      DrainStep `json:"step"`
+	Namespace string    `json:"namespace,omitempty"`
+	Pod       string    `json:"pod,omitempty"`
+	// Message explains why a pod was skipped or its eviction failed
+	Message string `json:"message,omitempty"`
+}
+
+// DrainNode cordons a node then evicts all of its pods apart from DaemonSet & mirror pods, passing each step
+// to progress. Evictions blocked by a PodDisruptionBudget are retried until the timeout, or ctx is cancelled.
+// It doesn't wait for evicted pods to terminate, an error is returned if any pod couldn't be evicted.
+// A node running pods in namespaces which aren't permitted isn't drained at all, as they couldn't be evicted,
+// and a drain is refused rather than leaving the node cordoned with pods on it that can't even be named
+func (k *Kubernetes) DrainNode(ctx context.Context, name string, opts DrainOptions,
+	progress func(DrainProgress),
+) error {
+	if k.ReadOnly {
+		return ErrReadOnly
+	}
+
+	if name == "" {
+		return errors.New("node name is empty")
+	}
+
+	if !opts.Confirm {
+		return ErrDrainNotConfirmed
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultDrainTimeout
+	}
+
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = defaultDrainRetryInterval
+	}
+
+	pods, err := k.nodePods(name)
+	if err != nil {
+		return err
+	}
+
+	if forbidden := k.forbiddenPods(pods); forbidden > 0 {
+		return fmt.Errorf("%w: node %s runs %d pods in namespaces which aren't permitted", ErrNamespaceForbidden,
+			name, forbidden)
+	}
+
+	if err := k.CordonNode(name, opts.DryRun); err != nil {
+		return err
+	}
+
+	progress(DrainProgress{Node: name, Step: DrainCordoned})
+
+	// Listed again, as pods could have been scheduled before the node was cordoned
+	if pods, err = k.nodePods(name); err != nil {
+		return err
+	}
+
+	pending := []coreV1.Pod{}
+	failed := 0
+
+	for _, pod := range pods {
+		// Only pods which have just arrived can be here, they're counted but not named
+		if !k.NamespacePermitted(pod.Namespace) {
+			progress(DrainProgress{Node: name, Step: DrainFailed, Message: "pod in a namespace which isn't permitted"})
+
+			failed++
+
+			continue
+		}
+
+		if reason := drainSkipReason(pod); reason != "" {
+			progress(DrainProgress{Node: name, Step: DrainSkipped, Namespace: pod.Namespace, Pod: pod.Name,
+				Message: reason})
+
+			continue
+		}
+
+		pending = append(pending, pod)
+	}
+
+	log.Printf("🚧 Draining node %s, evicting %d pods%s", name, len(pending), dryRunNote(opts.DryRun))
+
+	deadline := time.Now().Add(opts.Timeout)
+
+	// Each round tries every pod still pending, so one blocked pod doesn't hold up the rest
+	for len(pending) > 0 {
+		blocked := []coreV1.Pod{}
+
+		for _, pod := range pending {
+			if ctx.Err() != nil {
+				return fmt.Errorf("drain of node %s stopped: %w", name, ctx.Err())
+			}
+
+			step := DrainProgress{Node: name, Step: DrainEvicted, Namespace: pod.Namespace, Pod: pod.Name}
+
+			err := k.EvictPod(pod.Namespace, pod.Name, opts.DryRun)
+
+			switch {
+			case err == nil || apiErrors.IsNotFound(err):
+			case errors.Is(err, ErrEvictionBlocked) && !opts.DryRun &&
+				time.Now().Add(opts.RetryInterval).Before(deadline):
+				step.Step, step.Message = DrainRetrying, err.Error()
+
+				blocked = append(blocked, pod)
+			default:
+				step.Step, step.Message = DrainFailed, err.Error()
+				failed++
+			}
+
+			progress(step)
+		}
+
+		if len(blocked) > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("drain of node %s stopped: %w", name, ctx.Err())
+			case <-time.After(opts.RetryInterval):
+			}
+		}
+
+		pending = blocked
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to evict %d pods from node %s", failed, name)
+	}
+
+	log.Printf("🚧 Drained node %s%s", name, dryRunNote(opts.DryRun))
+
+	return nil
+}
+
+// forbiddenPods counts the pods in namespaces which aren't permitted
+func (k *Kubernetes) forbiddenPods(pods []coreV1.Pod) int {
+	forbidden := 0
+
+	for _, pod := range pods {
+		if !k.NamespacePermitted(pod.Namespace) {
+			forbidden++
+		}
+	}
+
+	return forbidden
+}
+
+// CordonNode marks a node as unschedulable, so no new pods are placed on it
+// With dryRun the change is validated, but the node isn't changed
+func (k *Kubernetes) CordonNode(name string, dryRun bool) error {
+	if k.ReadOnly {
+		return ErrReadOnly
+	}
+
+	if name == "" {
+		return errors.New("node name is empty")
+	}
+
+	patch := []byte(`{"spec":{"unschedulable":true}}`)
+
+	err := k.callAPI(func(ctx context.Context) error {
+		_, err := k.clientSet.CoreV1().Nodes().Patch(ctx, name, types.MergePatchType, patch,
+			metaV1.PatchOptions{DryRun: dryRunOption(dryRun)})
+		return err
+	})
+	if err != nil {
+		log.Printf("💥 Failed to cordon node %s: %v", name, err)
+		return err
+	}
+
+	log.Printf("🚧 Cordoned node %s%s", name, dryRunNote(dryRun))
+
+	return nil
+}
+
+// nodePods lists the pods scheduled to a node, across all namespaces
+func (k *Kubernetes) nodePods(name string) ([]coreV1.Pod, error) {
+	var list *coreV1.PodList
+
+	selector := fields.OneTermEqualSelector("spec.nodeName", name).String()
+
+	err := k.callAPI(func(ctx context.Context) (err error) {
+		list, err = k.clientSet.CoreV1().Pods("").List(ctx, metaV1.ListOptions{FieldSelector: selector})
+		return err
+	})
+	if err != nil {
+		log.Printf("💥 Failed to list pods on node %s: %v", name, err)
+		return nil, err
+	}
+
+	// The field selector is applied by the API server, this keeps the result right if it wasn't
+	pods := make([]coreV1.Pod, 0, len(list.Items))
+
+	for _, pod := range list.Items {
+		if pod.Spec.NodeName == name {
+			pods = append(pods, pod)
+		}
+	}
+
+	return pods, nil
+}
+
+// drainSkipReason explains why a pod is left on a node being drained, empty when it should be evicted
+func drainSkipReason(pod coreV1.Pod) string {
+	if _, found := pod.Annotations[mirrorPodAnnotation]; found {
+		return "mirror pod, managed by the node"
+	}
+
+	if owner := metaV1.GetControllerOf(&pod); owner != nil && owner.Kind == "DaemonSet" {
+		return "managed by DaemonSet " + owner.Name
+	}
+
+	return ""
+}
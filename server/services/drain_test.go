@@ -0,0 +1,244 @@
+// ==========================================================================================
+// Unit tests for draining nodes
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	coreV1 "k8s.io/api/core/v1"
+	policyV1 "k8s.io/api/policy/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// createDrainTestPod creates a pod on a node, optionally controlled by an owner of the given kind
+func createDrainTestPod(k *Kubernetes, name, node, ownerKind string, annotations map[string]string) {
+	pod := &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations},
+		Spec:       coreV1.PodSpec{NodeName: node},
+	}
+
+	if ownerKind != "" {
+		controller := true
+		pod.OwnerReferences = []metaV1.OwnerReference{{Kind: ownerKind, Name: name + "-owner", Controller: &controller}}
+	}
+
+	_, _ = k.clientSet.CoreV1().Pods("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+}
+
+func TestKubernetes_DrainNode(t *testing.T) {
+	k := mockKubernetes()
+	noProgress := func(DrainProgress) {}
+	ctx := context.Background()
+
+	k.ReadOnly = true
+
+	if err := k.DrainNode(ctx, "node-1", DrainOptions{Confirm: true}, noProgress); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+
+	k.ReadOnly = false
+
+	if err := k.DrainNode(ctx, "node-1", DrainOptions{}, noProgress); !errors.Is(err, ErrDrainNotConfirmed) {
+		t.Errorf("Expected ErrDrainNotConfirmed without confirmation, got %v", err)
+	}
+
+	node := &coreV1.Node{ObjectMeta: metaV1.ObjectMeta{Name: "node-1"}}
+	_, _ = k.clientSet.CoreV1().Nodes().Create(context.TODO(), node, metaV1.CreateOptions{})
+
+	createDrainTestPod(k, "web", "node-1", "ReplicaSet", nil)
+	createDrainTestPod(k, "api", "node-1", "", nil)
+	createDrainTestPod(k, "agent", "node-1", "DaemonSet", nil)
+	createDrainTestPod(k, "static", "node-1", "", map[string]string{mirrorPodAnnotation: "abc"})
+	createDrainTestPod(k, "elsewhere", "node-2", "", nil)
+
+	// The api pod is blocked by its disruption budget the first time only
+	evicted := []string{}
+	blocked := false
+
+	fake, _ := k.clientSet.(*k8sfake.Clientset)
+	fake.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+
+		name := action.(k8stesting.CreateAction).GetObject().(metaV1.Object).GetName()
+		if name == "api" && !blocked {
+			blocked = true
+			return true, nil, apiErrors.NewTooManyRequests("would violate the pod's disruption budget", 1)
+		}
+
+		evicted = append(evicted, name)
+
+		return true, nil, nil
+	})
+
+	steps := map[string]DrainStep{}
+	opts := DrainOptions{Confirm: true, Timeout: time.Second, RetryInterval: 10 * time.Millisecond}
+
+	err := k.DrainNode(ctx, "node-1", opts, func(p DrainProgress) {
+		steps[p.Pod] = p.Step
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	cordoned, _ := k.clientSet.CoreV1().Nodes().Get(context.TODO(), "node-1", metaV1.GetOptions{})
+	if !cordoned.Spec.Unschedulable {
+		t.Error("Expected the node to be cordoned")
+	}
+
+	slices.Sort(evicted)
+
+	if !slices.Equal(evicted, []string{"api", "web"}) {
+		t.Errorf("Expected only the api & web pods to be evicted, got %v", evicted)
+	}
+
+	if steps[""] != DrainCordoned || steps["agent"] != DrainSkipped || steps["static"] != DrainSkipped {
+		t.Errorf("Expected cordon & skipped steps to be reported, got %v", steps)
+	}
+
+	if steps["api"] != DrainEvicted || steps["web"] != DrainEvicted {
+		t.Errorf("Expected the api pod to be evicted after retrying, got %v", steps)
+	}
+}
+
+func TestKubernetes_DrainNode_Timeout(t *testing.T) {
+	k := mockKubernetes()
+
+	node := &coreV1.Node{ObjectMeta: metaV1.ObjectMeta{Name: "node-1"}}
+	_, _ = k.clientSet.CoreV1().Nodes().Create(context.TODO(), node, metaV1.CreateOptions{})
+	createDrainTestPod(k, "api", "node-1", "", nil)
+
+	// Always blocked, so the drain gives up once the timeout is reached
+	fake, _ := k.clientSet.(*k8sfake.Clientset)
+	fake.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return action.GetSubresource() == "eviction", nil, apiErrors.NewTooManyRequests("disruption budget", 1)
+	})
+
+	retries := 0
+	opts := DrainOptions{Confirm: true, Timeout: 100 * time.Millisecond, RetryInterval: 10 * time.Millisecond}
+
+	err := k.DrainNode(context.Background(), "node-1", opts, func(p DrainProgress) {
+		if p.Step == DrainRetrying {
+			retries++
+		}
+	})
+	if err == nil {
+		t.Fatal("Expected an error when a pod can't be evicted")
+	}
+
+	if retries == 0 {
+		t.Error("Expected the blocked eviction to be retried before failing")
+	}
+}
+
+func TestKubernetes_DrainNode_ForbiddenNamespace(t *testing.T) {
+	k := mockKubernetes()
+	k.NamespaceDenylist = []string{"kube-system"}
+
+	node := &coreV1.Node{ObjectMeta: metaV1.ObjectMeta{Name: "node-1"}}
+	_, _ = k.clientSet.CoreV1().Nodes().Create(context.TODO(), node, metaV1.CreateOptions{})
+	createDrainTestPod(k, "web", "node-1", "", nil)
+
+	hidden := &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{Name: "coredns", Namespace: "kube-system"},
+		Spec:       coreV1.PodSpec{NodeName: "node-1"},
+	}
+	_, _ = k.clientSet.CoreV1().Pods("kube-system").Create(context.TODO(), hidden, metaV1.CreateOptions{})
+
+	steps := []DrainProgress{}
+	opts := DrainOptions{Confirm: true, Timeout: time.Second}
+
+	err := k.DrainNode(context.Background(), "node-1", opts, func(p DrainProgress) { steps = append(steps, p) })
+	if !errors.Is(err, ErrNamespaceForbidden) {
+		t.Errorf("Expected ErrNamespaceForbidden, got %v", err)
+	}
+
+	// Refused before anything happens, so the node isn't left cordoned & nothing is reported
+	cordoned, _ := k.clientSet.CoreV1().Nodes().Get(context.TODO(), "node-1", metaV1.GetOptions{})
+	if cordoned.Spec.Unschedulable || len(steps) != 0 {
+		t.Errorf("Expected the node to be left alone, got unschedulable %v & steps %v", cordoned.Spec.Unschedulable,
+			steps)
+	}
+}
+
+func TestKubernetes_DrainNode_Cancelled(t *testing.T) {
+	k := mockKubernetes()
+
+	node := &coreV1.Node{ObjectMeta: metaV1.ObjectMeta{Name: "node-1"}}
+	_, _ = k.clientSet.CoreV1().Nodes().Create(context.TODO(), node, metaV1.CreateOptions{})
+	createDrainTestPod(k, "api", "node-1", "", nil)
+
+	fake, _ := k.clientSet.(*k8sfake.Clientset)
+	fake.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return action.GetSubresource() == "eviction", nil, apiErrors.NewTooManyRequests("disruption budget", 1)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	opts := DrainOptions{Confirm: true, Timeout: time.Minute, RetryInterval: 10 * time.Millisecond}
+
+	// The client goes away after the first blocked eviction, which stops the retries long before the timeout
+	start := time.Now()
+
+	err := k.DrainNode(ctx, "node-1", opts, func(p DrainProgress) {
+		if p.Step == DrainRetrying {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the drain to stop when cancelled, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the drain to stop straight away, took %s", elapsed)
+	}
+}
+
+func TestKubernetes_DrainNode_DryRun(t *testing.T) {
+	k := mockKubernetes()
+
+	node := &coreV1.Node{ObjectMeta: metaV1.ObjectMeta{Name: "node-1"}}
+	_, _ = k.clientSet.CoreV1().Nodes().Create(context.TODO(), node, metaV1.CreateOptions{})
+	createDrainTestPod(k, "web", "node-1", "", nil)
+
+	// The fake client doesn't honour dry run itself, so the options sent are recorded instead
+	dryRuns := map[string][]string{}
+
+	fake, _ := k.clientSet.(*k8sfake.Clientset)
+	fake.PrependReactor("patch", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		dryRuns["cordon"] = action.(k8stesting.PatchActionImpl).PatchOptions.DryRun
+		return true, nil, nil
+	})
+	fake.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+
+		eviction, _ := action.(k8stesting.CreateAction).GetObject().(*policyV1.Eviction)
+		dryRuns["evict"] = eviction.DeleteOptions.DryRun
+
+		return true, nil, nil
+	})
+
+	opts := DrainOptions{Confirm: true, Timeout: time.Second, DryRun: true}
+
+	if err := k.DrainNode(context.Background(), "node-1", opts, func(DrainProgress) {}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, step := range []string{"cordon", "evict"} {
+		if !slices.Equal(dryRuns[step], []string{metaV1.DryRunAll}) {
+			t.Errorf("Expected the %s to be a dry run, got %v", step, dryRuns[step])
+		}
+	}
+}
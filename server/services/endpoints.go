@@ -0,0 +1,162 @@
+// ==========================================================================================
+// Endpoint addresses behind each Service, resolved from Endpoints or EndpointSlices depending
+// on the cluster, so a Service with nothing ready behind it can be spotted
+// ==========================================================================================
+
+package services
+
+import (
+	"slices"
+
+	coreV1 "k8s.io/api/core/v1"
+	discoveryV1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Computed field added to fetched Services, see setServiceEndpoints
+const endpointStatusField = "endpointStatus"
+
+// ServiceEndpoints are the addresses of the endpoints backing a Service, split by whether they are ready
+type ServiceEndpoints struct {
+	Ready             int      `json:"ready"`
+	NotReady          int      `json:"notReady"`
+	ReadyAddresses    []string `json:"readyAddresses"`
+	NotReadyAddresses []string `json:"notReadyAddresses"`
+}
+
+// GetServiceEndpoints resolves the endpoint addresses of every Service in a namespace, keyed by Service name
+// EndpointSlices are used when UseEndpointSlices is set, otherwise Endpoints. Services with none are left out
+func (k *Kubernetes) GetServiceEndpoints(ns string) (map[string]*ServiceEndpoints, error) {
+	if k.UseEndpointSlices {
+		endpointSlices, err := k.GetResources(ns, "discovery.k8s.io", "v1", "endpointslices")
+		if err != nil {
+			return nil, err
+		}
+
+		return endpointsFromSlices(endpointSlices), nil
+	}
+
+	endpoints, err := k.GetResources(ns, "", "v1", "endpoints")
+	if err != nil {
+		return nil, err
+	}
+
+	return endpointsFromEndpoints(endpoints), nil
+}
+
+// endpointsFromEndpoints reads addresses from Endpoints, which are named the same as their Service
+func endpointsFromEndpoints(items []unstructured.Unstructured) map[string]*ServiceEndpoints {
+	result := map[string]*ServiceEndpoints{}
+
+	for i := range items {
+		ep := coreV1.Endpoints{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(items[i].Object, &ep); err != nil {
+			continue
+		}
+
+		se := serviceEndpoints(result, ep.Name)
+
+		// Each subset is a set of addresses with the same ports, the same address can be in more than one
+		for _, subset := range ep.Subsets {
+			for _, addr := range subset.Addresses {
+				se.add(addr.IP, true)
+			}
+
+			for _, addr := range subset.NotReadyAddresses {
+				se.add(addr.IP, false)
+			}
+		}
+	}
+
+	return result
+}
+
+// endpointsFromSlices reads addresses from EndpointSlices, a Service can have many slices linked by a label
+func endpointsFromSlices(items []unstructured.Unstructured) map[string]*ServiceEndpoints {
+	result := map[string]*ServiceEndpoints{}
+
+	for i := range items {
+		slice := discoveryV1.EndpointSlice{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(items[i].Object, &slice); err != nil {
+			continue
+		}
+
+		name := slice.Labels[discoveryV1.LabelServiceName]
+		if name == "" {
+			continue
+		}
+
+		se := serviceEndpoints(result, name)
+
+		for _, ep := range slice.Endpoints {
+			// An unknown ready condition should be treated as ready, as the API docs say
+			ready := ep.Conditions.Ready == nil || *ep.Conditions.Ready
+
+			for _, addr := range ep.Addresses {
+				se.add(addr, ready)
+			}
+		}
+	}
+
+	return result
+}
+
+// serviceEndpoints gets the entry for a Service, adding it when missing
+func serviceEndpoints(result map[string]*ServiceEndpoints, name string) *ServiceEndpoints {
+	if result[name] == nil {
+		result[name] = newServiceEndpoints()
+	}
+
+	return result[name]
+}
+
+func newServiceEndpoints() *ServiceEndpoints {
+	return &ServiceEndpoints{ReadyAddresses: []string{}, NotReadyAddresses: []string{}}
+}
+
+// add records an address once, an address both ready & not ready (e.g. on different ports) counts as not ready
+func (se *ServiceEndpoints) add(addr string, ready bool) {
+	if slices.Contains(se.NotReadyAddresses, addr) {
+		return
+	}
+
+	if ready {
+		if !slices.Contains(se.ReadyAddresses, addr) {
+			se.ReadyAddresses = append(se.ReadyAddresses, addr)
+		}
+	} else {
+		se.ReadyAddresses = slices.DeleteFunc(se.ReadyAddresses, func(a string) bool { return a == addr })
+		se.NotReadyAddresses = append(se.NotReadyAddresses, addr)
+	}
+
+	se.Ready = len(se.ReadyAddresses)
+	se.NotReady = len(se.NotReadyAddresses)
+}
+
+// setServiceEndpoints adds an endpointStatus field to each Service, with zero ready when it has no endpoints
+// It's built from plain maps & slices, as unstructured objects can only hold JSON types
+func setServiceEndpoints(services []unstructured.Unstructured, byService map[string]*ServiceEndpoints) {
+	for i := range services {
+		se := byService[services[i].GetName()]
+		if se == nil {
+			se = newServiceEndpoints()
+		}
+
+		services[i].Object[endpointStatusField] = map[string]interface{}{
+			"ready":             int64(se.Ready),
+			"notReady":          int64(se.NotReady),
+			"readyAddresses":    stringsToInterfaces(se.ReadyAddresses),
+			"notReadyAddresses": stringsToInterfaces(se.NotReadyAddresses),
+		}
+	}
+}
+
+func stringsToInterfaces(values []string) []interface{} {
+	out := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		out = append(out, v)
+	}
+
+	return out
+}
@@ -0,0 +1,165 @@
+// ==========================================================================================
+// Unit tests for Service endpoint addresses
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	endpointsGVR     = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "endpoints"}
+	endpointSliceGVR = schema.GroupVersionResource{Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"}
+)
+
+// createTestServices creates the web & empty services, only web ever has endpoints
+func createTestServices(k *Kubernetes) {
+	for _, name := range []string{"web", "empty"} {
+		svc := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Service",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		}}
+
+		_, _ = k.dynamicClient.Resource(serviceGVR).Namespace("default").Create(context.TODO(), svc, metaV1.CreateOptions{})
+	}
+}
+
+// createTestEndpointSlice creates a slice for the web service, with each address ready or not
+func createTestEndpointSlice(k *Kubernetes, name string, addresses map[string]interface{}) {
+	endpoints := []interface{}{}
+
+	for addr, ready := range addresses {
+		ep := map[string]interface{}{"addresses": []interface{}{addr}, "conditions": map[string]interface{}{}}
+		if ready != nil {
+			ep["conditions"] = map[string]interface{}{"ready": ready}
+		}
+
+		endpoints = append(endpoints, ep)
+	}
+
+	slice := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "discovery.k8s.io/v1",
+		"kind":       "EndpointSlice",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "default",
+			"labels":    map[string]interface{}{"kubernetes.io/service-name": "web"},
+		},
+		"addressType": "IPv4",
+		"endpoints":   endpoints,
+	}}
+
+	_, _ = k.dynamicClient.Resource(endpointSliceGVR).Namespace("default").
+		Create(context.TODO(), slice, metaV1.CreateOptions{})
+}
+
+// fetchedEndpointStatus fetches the namespace, returning the endpointStatus of each service
+func fetchedEndpointStatus(t *testing.T, k *Kubernetes) map[string]map[string]interface{} {
+	t.Helper()
+
+	data, err := k.FetchNamespace("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	status := map[string]map[string]interface{}{}
+
+	for _, svc := range data["services"] {
+		s, _, _ := unstructured.NestedMap(svc.Object, endpointStatusField)
+		status[svc.GetName()] = s
+	}
+
+	return status
+}
+
+func endpointAddresses(status map[string]interface{}, field string) []string {
+	found, _, _ := unstructured.NestedStringSlice(status, field)
+	slices.Sort(found)
+
+	return found
+}
+
+func TestKubernetes_ServiceEndpoints_Endpoints(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+	createTestServices(k)
+
+	// The same address on two ports is only counted once
+	endpoints := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Endpoints",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		"subsets": []interface{}{
+			map[string]interface{}{
+				"addresses":         []interface{}{map[string]interface{}{"ip": "10.0.0.1"}},
+				"notReadyAddresses": []interface{}{map[string]interface{}{"ip": "10.0.0.3"}},
+				"ports":             []interface{}{map[string]interface{}{"port": int64(80)}},
+			},
+			map[string]interface{}{
+				"addresses": []interface{}{
+					map[string]interface{}{"ip": "10.0.0.1"}, map[string]interface{}{"ip": "10.0.0.2"},
+				},
+				"ports": []interface{}{map[string]interface{}{"port": int64(443)}},
+			},
+		},
+	}}
+	_, _ = k.dynamicClient.Resource(endpointsGVR).Namespace("default").
+		Create(context.TODO(), endpoints, metaV1.CreateOptions{})
+
+	status := fetchedEndpointStatus(t, k)
+
+	web := status["web"]
+	if web["ready"] != int64(2) || web["notReady"] != int64(1) {
+		t.Errorf("Expected 2 ready & 1 not ready, got %v", web)
+	}
+
+	if ready := endpointAddresses(web, "readyAddresses"); !slices.Equal(ready, []string{"10.0.0.1", "10.0.0.2"}) {
+		t.Errorf("Unexpected ready addresses %v", ready)
+	}
+
+	if notReady := endpointAddresses(web, "notReadyAddresses"); !slices.Equal(notReady, []string{"10.0.0.3"}) {
+		t.Errorf("Unexpected not ready addresses %v", notReady)
+	}
+
+	if empty := status["empty"]; empty == nil || empty["ready"] != int64(0) || empty["notReady"] != int64(0) {
+		t.Errorf("Expected a service with no endpoints to have 0 ready, got %v", empty)
+	}
+}
+
+func TestKubernetes_ServiceEndpoints_EndpointSlices(t *testing.T) {
+	k := mockKubernetes()
+	k.UseEndpointSlices = true
+	addTestNamespace(k, "default")
+	createTestServices(k)
+
+	// A service can be split over many slices, a missing ready condition counts as ready
+	createTestEndpointSlice(k, "web-abc", map[string]interface{}{"10.0.0.1": true, "10.0.0.2": nil})
+	createTestEndpointSlice(k, "web-def", map[string]interface{}{"10.0.0.3": false})
+
+	status := fetchedEndpointStatus(t, k)
+
+	web := status["web"]
+	if web["ready"] != int64(2) || web["notReady"] != int64(1) {
+		t.Errorf("Expected 2 ready & 1 not ready, got %v", web)
+	}
+
+	if ready := endpointAddresses(web, "readyAddresses"); !slices.Equal(ready, []string{"10.0.0.1", "10.0.0.2"}) {
+		t.Errorf("Unexpected ready addresses %v", ready)
+	}
+
+	if notReady := endpointAddresses(web, "notReadyAddresses"); !slices.Equal(notReady, []string{"10.0.0.3"}) {
+		t.Errorf("Unexpected not ready addresses %v", notReady)
+	}
+
+	if empty := status["empty"]; empty == nil || empty["ready"] != int64(0) {
+		t.Errorf("Expected a service with no endpoint slices to have 0 ready, got %v", empty)
+	}
+}
@@ -0,0 +1,320 @@
+// ==========================================================================================
+// Container environment variables, with references to ConfigMaps & Secrets resolved
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	coreV1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	podGVR       = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
+	configMapGVR = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+	secretGVR    = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}
+)
+
+// EnvVar is a single environment variable with its value resolved
+type EnvVar struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	// Source describes where the value came from e.g. "secret:db-creds/password", empty for literal values
+	Source string `json:"source,omitempty"`
+	// Redacted is true when the value came from a Secret, or its name matches RedactKeys, and has been hidden
+	Redacted bool `json:"redacted,omitempty"`
+}
+
+// ContainerEnv is the effective environment of a single container in a pod
+type ContainerEnv struct {
+	Container string   `json:"container"`
+	Init      bool     `json:"init,omitempty"`
+	Env       []EnvVar `json:"env"`
+	// Warnings about references which could not be resolved, e.g. a missing ConfigMap
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// envResolver resolves env references for one pod, fetching each ConfigMap & Secret only once
+type envResolver struct {
+	k     *Kubernetes
+	ns    string
+	pod   *unstructured.Unstructured
+	cache map[string]map[string]string
+	// Hides the values of variables whose names match RedactKeys, wherever the value came from
+	redactor *KeyRedactor
+}
+
+// GetPodEnv returns the effective environment of every container in a pod
+// Values from configMapKeyRef, secretKeyRef, envFrom & simple fieldRefs are resolved
+// Secret sourced values are redacted when RedactSecrets is set, and any value whose name matches RedactKeys
+func (k *Kubernetes) GetPodEnv(ns, podName string) ([]ContainerEnv, error) {
+	if ns == "" || podName == "" {
+		return nil, errors.New("namespace or pod name is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	var podObj *unstructured.Unstructured
+
+	err := k.callAPI(func(ctx context.Context) (err error) {
+		podObj, err = k.dynamicClient.Resource(podGVR).Namespace(ns).Get(ctx, podName, metaV1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		log.Printf("💥 Failed to get pod %s in namespace %s: %v", podName, ns, err)
+		return nil, err
+	}
+
+	pod := coreV1.Pod{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(podObj.Object, &pod); err != nil {
+		return nil, err
+	}
+
+	r := &envResolver{
+		k: k, ns: ns, pod: podObj, cache: map[string]map[string]string{}, redactor: NewKeyRedactor(k.RedactKeys),
+	}
+	result := make([]ContainerEnv, 0, len(pod.Spec.InitContainers)+len(pod.Spec.Containers))
+
+	for _, c := range pod.Spec.InitContainers {
+		env := r.resolveContainer(c)
+		env.Init = true
+		result = append(result, env)
+	}
+
+	for _, c := range pod.Spec.Containers {
+		result = append(result, r.resolveContainer(c))
+	}
+
+	return result, nil
+}
+
+// resolveContainer works out the environment of a container, envFrom is applied first then env
+// Later variables replace earlier ones with the same name, the same as the kubelet does
+func (r *envResolver) resolveContainer(c coreV1.Container) ContainerEnv {
+	result := ContainerEnv{Container: c.Name, Env: []EnvVar{}}
+	seen := map[string]int{}
+
+	add := func(v EnvVar) {
+		if v.Value != "" && r.redactor.matches(v.Name) {
+			v.Value = redactedValue
+			v.Redacted = true
+		}
+
+		if i, ok := seen[v.Name]; ok {
+			result.Env[i] = v
+			return
+		}
+
+		seen[v.Name] = len(result.Env)
+		result.Env = append(result.Env, v)
+	}
+
+	for _, from := range c.EnvFrom {
+		vars, err := r.resolveEnvFrom(from)
+		if err != nil {
+			result.Warnings = append(result.Warnings, err.Error())
+		}
+
+		for _, v := range vars {
+			add(v)
+		}
+	}
+
+	for _, e := range c.Env {
+		v, err := r.resolveEnvVar(e)
+		if err != nil {
+			result.Warnings = append(result.Warnings, err.Error())
+		}
+
+		add(v)
+	}
+
+	return result
+}
+
+// resolveEnvFrom expands all the keys of a referenced ConfigMap or Secret into variables
+func (r *envResolver) resolveEnvFrom(from coreV1.EnvFromSource) ([]EnvVar, error) {
+	var (
+		gvr      schema.GroupVersionResource
+		name     string
+		optional *bool
+	)
+
+	switch {
+	case from.ConfigMapRef != nil:
+		gvr, name, optional = configMapGVR, from.ConfigMapRef.Name, from.ConfigMapRef.Optional
+	case from.SecretRef != nil:
+		gvr, name, optional = secretGVR, from.SecretRef.Name, from.SecretRef.Optional
+	default:
+		return nil, nil
+	}
+
+	data, err := r.lookup(gvr, name)
+	if err != nil {
+		return nil, missingRefError(err, optional)
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	vars := make([]EnvVar, 0, len(keys))
+	for _, key := range keys {
+		vars = append(vars, r.refValue(from.Prefix+key, gvr, name, key, data[key]))
+	}
+
+	return vars, nil
+}
+
+// resolveEnvVar resolves a single env entry, either a literal value or a reference
+func (r *envResolver) resolveEnvVar(e coreV1.EnvVar) (EnvVar, error) {
+	if e.ValueFrom == nil {
+		return EnvVar{Name: e.Name, Value: e.Value}, nil
+	}
+
+	var (
+		gvr      schema.GroupVersionResource
+		sel      coreV1.LocalObjectReference
+		key      string
+		optional *bool
+	)
+
+	switch {
+	case e.ValueFrom.ConfigMapKeyRef != nil:
+		ref := e.ValueFrom.ConfigMapKeyRef
+		gvr, sel, key, optional = configMapGVR, ref.LocalObjectReference, ref.Key, ref.Optional
+	case e.ValueFrom.SecretKeyRef != nil:
+		ref := e.ValueFrom.SecretKeyRef
+		gvr, sel, key, optional = secretGVR, ref.LocalObjectReference, ref.Key, ref.Optional
+	case e.ValueFrom.FieldRef != nil:
+		path := e.ValueFrom.FieldRef.FieldPath
+		value, _ := podFieldValue(r.pod, path)
+
+		return EnvVar{Name: e.Name, Value: value, Source: "field:" + path}, nil
+	default:
+		// Resource field refs depend on the node, so we can't know the value
+		return EnvVar{Name: e.Name, Source: "resource"}, nil
+	}
+
+	unresolved := EnvVar{Name: e.Name, Source: refSource(gvr, sel.Name, key)}
+
+	data, err := r.lookup(gvr, sel.Name)
+	if err != nil {
+		return unresolved, missingRefError(err, optional)
+	}
+
+	value, ok := data[key]
+	if !ok {
+		if optional != nil && *optional {
+			return unresolved, nil
+		}
+
+		return unresolved, fmt.Errorf("key %s not found in %s %s", key, gvr.Resource, sel.Name)
+	}
+
+	return r.refValue(e.Name, gvr, sel.Name, key, value), nil
+}
+
+// refValue builds a variable from a ConfigMap or Secret value, redacting secrets when required
+func (r *envResolver) refValue(name string, gvr schema.GroupVersionResource, objName, key, value string) EnvVar {
+	v := EnvVar{Name: name, Value: value, Source: refSource(gvr, objName, key)}
+
+	if gvr == secretGVR && r.k.RedactSecrets {
+		v.Value = redactedValue
+		v.Redacted = true
+	}
+
+	return v
+}
+
+// lookup fetches the data of a ConfigMap or Secret, with Secret values base64 decoded
+func (r *envResolver) lookup(gvr schema.GroupVersionResource, name string) (map[string]string, error) {
+	cacheKey := gvr.Resource + "/" + name
+	if data, ok := r.cache[cacheKey]; ok {
+		return data, nil
+	}
+
+	var obj *unstructured.Unstructured
+
+	err := r.k.callAPI(func(ctx context.Context) (err error) {
+		obj, err = r.k.dynamicClient.Resource(gvr).Namespace(r.ns).Get(ctx, name, metaV1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to get %s %s: %w", gvr.Resource, name, err)
+	}
+
+	data, _, _ := unstructured.NestedStringMap(obj.Object, "data")
+	if data == nil {
+		data = map[string]string{}
+	}
+
+	if gvr == secretGVR {
+		for key, encoded := range data {
+			if decoded, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+				data[key] = string(decoded)
+			}
+		}
+	}
+
+	r.cache[cacheKey] = data
+
+	return data, nil
+}
+
+// missingRefError returns nothing for optional references which don't exist, as the kubelet ignores them
+func missingRefError(err error, optional *bool) error {
+	if optional != nil && *optional && apiErrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// refSource describes a ConfigMap or Secret key reference e.g. "configmap:settings/mode"
+func refSource(gvr schema.GroupVersionResource, name, key string) string {
+	kind := "configmap"
+	if gvr == secretGVR {
+		kind = "secret"
+	}
+
+	return kind + ":" + name + "/" + key
+}
+
+// podFieldValue resolves the simple downward API field paths, e.g. metadata.name or status.podIP
+func podFieldValue(pod *unstructured.Unstructured, path string) (string, bool) {
+	fields := map[string][]string{
+		"metadata.name":           {"metadata", "name"},
+		"metadata.namespace":      {"metadata", "namespace"},
+		"metadata.uid":            {"metadata", "uid"},
+		"spec.nodeName":           {"spec", "nodeName"},
+		"spec.serviceAccountName": {"spec", "serviceAccountName"},
+		"status.hostIP":           {"status", "hostIP"},
+		"status.podIP":            {"status", "podIP"},
+	}
+
+	nested, ok := fields[path]
+	if !ok {
+		return "", false
+	}
+
+	value, found, _ := unstructured.NestedString(pod.Object, nested...)
+
+	return value, found
+}
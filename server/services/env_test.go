@@ -0,0 +1,171 @@
+// ==========================================================================================
+// Unit tests for resolving container environment variables
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// createTestEnvPod creates a pod with env vars referencing a ConfigMap & Secret
+func createTestEnvPod(name, namespace string) *unstructured.Unstructured {
+	pod := createTestPod(name, namespace)
+
+	container := map[string]interface{}{
+		"name":  "app",
+		"image": "nginx:latest",
+		"envFrom": []interface{}{
+			map[string]interface{}{"configMapRef": map[string]interface{}{"name": "settings"}, "prefix": "CFG_"},
+		},
+		"env": []interface{}{
+			map[string]interface{}{"name": "PLAIN", "value": "hello"},
+			map[string]interface{}{"name": "MODE", "valueFrom": map[string]interface{}{
+				"configMapKeyRef": map[string]interface{}{"name": "settings", "key": "mode"},
+			}},
+			map[string]interface{}{"name": "DB_PASSWORD", "valueFrom": map[string]interface{}{
+				"secretKeyRef": map[string]interface{}{"name": "db-creds", "key": "password"},
+			}},
+			map[string]interface{}{"name": "MISSING", "valueFrom": map[string]interface{}{
+				"configMapKeyRef": map[string]interface{}{"name": "not-there", "key": "x"},
+			}},
+			map[string]interface{}{"name": "POD_NAME", "valueFrom": map[string]interface{}{
+				"fieldRef": map[string]interface{}{"fieldPath": "metadata.name"},
+			}},
+		},
+	}
+
+	_ = unstructured.SetNestedSlice(pod.Object, []interface{}{container}, "spec", "containers")
+
+	return pod
+}
+
+func TestKubernetes_GetPodEnv(t *testing.T) {
+	k := mockKubernetes()
+
+	configMap := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "settings", "namespace": "default"},
+		"data":       map[string]interface{}{"mode": "debug"},
+	}}
+
+	_, _ = k.dynamicClient.Resource(configMapGVR).Namespace("default").
+		Create(context.TODO(), configMap, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(secretGVR).Namespace("default").
+		Create(context.TODO(), createTestSecret("db-creds", "default"), metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").
+		Create(context.TODO(), createTestEnvPod("web", "default"), metaV1.CreateOptions{})
+
+	containers, err := k.GetPodEnv("default", "web")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(containers) != 1 || containers[0].Container != "app" {
+		t.Fatalf("Expected env for the app container, got %v", containers)
+	}
+
+	env := map[string]EnvVar{}
+	for _, v := range containers[0].Env {
+		env[v.Name] = v
+	}
+
+	expected := map[string]string{
+		"CFG_mode":    "debug",
+		"PLAIN":       "hello",
+		"MODE":        "debug",
+		"DB_PASSWORD": redactedValue,
+		"MISSING":     "",
+		"POD_NAME":    "web",
+	}
+
+	for name, value := range expected {
+		if env[name].Value != value {
+			t.Errorf("Expected %s to be %q, got %q", name, value, env[name].Value)
+		}
+	}
+
+	if env["MODE"].Source != "configmap:settings/mode" {
+		t.Errorf("Expected MODE source to be the configmap key, got %s", env["MODE"].Source)
+	}
+
+	if !env["DB_PASSWORD"].Redacted {
+		t.Error("Expected secret sourced value to be marked as redacted")
+	}
+
+	// The missing ConfigMap should be reported, not fail the whole call
+	if len(containers[0].Warnings) != 1 {
+		t.Errorf("Expected a single warning for the missing configmap, got %v", containers[0].Warnings)
+	}
+
+	// With redaction off secret values are decoded
+	k.RedactSecrets = false
+
+	containers, _ = k.GetPodEnv("default", "web")
+	for _, v := range containers[0].Env {
+		if v.Name == "DB_PASSWORD" && v.Value != "secret" {
+			t.Errorf("Expected decoded secret value, got %q", v.Value)
+		}
+	}
+}
+
+func TestKubernetes_GetPodEnv_RedactKeys(t *testing.T) {
+	k := mockKubernetes()
+	k.RedactKeys = []string{"*token*"}
+
+	configMap := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "settings", "namespace": "default"},
+		"data":       map[string]interface{}{"mode": "debug", "TOKEN": "from-configmap"},
+	}}
+
+	pod := createTestEnvPod("web", "default")
+	podContainers, _, _ := unstructured.NestedSlice(pod.Object, "spec", "containers")
+	container, _ := podContainers[0].(map[string]interface{})
+	literals, _ := container["env"].([]interface{})
+	container["env"] = append(literals, map[string]interface{}{"name": "API_TOKEN", "value": "literal"})
+	_ = unstructured.SetNestedSlice(pod.Object, podContainers, "spec", "containers")
+
+	_, _ = k.dynamicClient.Resource(configMapGVR).Namespace("default").
+		Create(context.TODO(), configMap, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+
+	containers, err := k.GetPodEnv("default", "web")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	vars := map[string]EnvVar{}
+	for _, v := range containers[0].Env {
+		vars[v.Name] = v
+	}
+
+	// Literal & ConfigMap sourced values are redacted by name, the same as Secret sourced ones
+	for _, name := range []string{"API_TOKEN", "CFG_TOKEN"} {
+		if vars[name].Value != redactedValue || !vars[name].Redacted {
+			t.Errorf("Expected %s to be redacted, got %+v", name, vars[name])
+		}
+	}
+
+	if vars["PLAIN"].Value != "hello" || vars["PLAIN"].Redacted {
+		t.Errorf("Expected PLAIN not to be redacted, got %+v", vars["PLAIN"])
+	}
+}
+
+func TestKubernetes_GetPodEnv_NotFound(t *testing.T) {
+	k := mockKubernetes()
+
+	if _, err := k.GetPodEnv("default", "nope"); err == nil {
+		t.Error("Expected an error for a pod which doesn't exist")
+	}
+
+	if _, err := k.GetPodEnv("", "web"); err == nil {
+		t.Error("Expected an error for an empty namespace")
+	}
+}
@@ -0,0 +1,228 @@
+// ==========================================================================================
+// Support for both Event APIs, newer clusters use events.k8s.io/v1 while older use core/v1
+// Events from events.k8s.io/v1 are normalised into the core/v1 shape used by the frontend
+// ==========================================================================================
+
+package services
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+var (
+	coreEventGVR = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "events"}
+	eventsV1GVR  = schema.GroupVersionResource{Group: "events.k8s.io", Version: "v1", Resource: "events"}
+)
+
+// detectEventsV1 checks if the cluster serves events.k8s.io/v1
+// Clusters serving both APIs hold the same events in each, so only the newer API is used
+func detectEventsV1(disc discovery.DiscoveryInterface) bool {
+	resources, err := disc.ServerResourcesForGroupVersion(eventsV1GVR.GroupVersion().String())
+	if err != nil || resources == nil {
+		return false
+	}
+
+	for _, res := range resources.APIResources {
+		if res.Name == eventsV1GVR.Resource {
+			return true
+		}
+	}
+
+	return false
+}
+
+// eventsGVR returns the Event API in use
+func eventsGVR(useEventsV1 bool) schema.GroupVersionResource {
+	if useEventsV1 {
+		return eventsV1GVR
+	}
+
+	return coreEventGVR
+}
+
+// getEvents lists the events in a namespace from the Event API in use, always in the core/v1 shape
+func (k *Kubernetes) getEvents(ns string) ([]unstructured.Unstructured, error) {
+	gvr := eventsGVR(k.UseEventsV1)
+
+	events, err := k.GetResources(ns, gvr.Group, gvr.Version, gvr.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range events {
+		events[i] = *normaliseEvent(&events[i])
+	}
+
+	return mergeEvents(events), nil
+}
+
+// mergeEvents combines events repeated about the same object as separate objects, adding up their counts
+// Every event is given a count & lastTimestamp, then they're sorted with the most recently seen first
+func mergeEvents(events []unstructured.Unstructured) []unstructured.Unstructured {
+	merged := []unstructured.Unstructured{}
+	index := map[string]int{}
+
+	for _, event := range events {
+		count, _, _ := unstructured.NestedInt64(event.Object, "count")
+		event.Object["count"] = max(count, 1)
+
+		last, lastTime := eventLastSeen(&event)
+		if last != "" {
+			event.Object["lastTimestamp"] = last
+		}
+
+		key := eventKey(&event)
+
+		i, found := index[key]
+		if !found {
+			index[key] = len(merged)
+			merged = append(merged, event)
+
+			continue
+		}
+
+		// The most recent copy is kept, with the total count & the earliest first timestamp
+		total := merged[i].Object["count"].(int64) + event.Object["count"].(int64)
+		first := earliestTimestamp(merged[i].Object["firstTimestamp"], event.Object["firstTimestamp"])
+
+		if _, keptTime := eventLastSeen(&merged[i]); lastTime.After(keptTime) {
+			merged[i] = event
+		}
+
+		merged[i].Object["count"] = total
+
+		if first != nil {
+			merged[i].Object["firstTimestamp"] = first
+		}
+	}
+
+	slices.SortStableFunc(merged, func(a, b unstructured.Unstructured) int {
+		_, aTime := eventLastSeen(&a)
+		_, bTime := eventLastSeen(&b)
+
+		return cmp.Or(bTime.Compare(aTime), cmp.Compare(a.GetName(), b.GetName()))
+	})
+
+	return merged
+}
+
+// eventKey identifies repeats of the same event, which have the same object, reason, type, message & source
+func eventKey(event *unstructured.Unstructured) string {
+	parts := []string{}
+
+	for _, path := range [][]string{
+		{"involvedObject", "kind"}, {"involvedObject", "name"}, {"involvedObject", "uid"},
+		{"reason"}, {"type"}, {"message"}, {"source", "component"}, {"source", "host"},
+	} {
+		value, _, _ := unstructured.NestedString(event.Object, path...)
+		parts = append(parts, value)
+	}
+
+	return strings.Join(parts, "\x00")
+}
+
+// eventLastSeen is when an event last happened, from the last timestamp, event time or creation time
+// The timestamp is returned as given along with the parsed time, which is zero when there isn't one
+func eventLastSeen(event *unstructured.Unstructured) (string, time.Time) {
+	for _, field := range []string{"lastTimestamp", "eventTime"} {
+		if value, ok := event.Object[field].(string); ok && value != "" {
+			if parsed, err := time.Parse(time.RFC3339Nano, value); err == nil {
+				return value, parsed
+			}
+		}
+	}
+
+	created := event.GetCreationTimestamp()
+	if created.IsZero() {
+		return "", time.Time{}
+	}
+
+	return created.UTC().Format(time.RFC3339), created.Time
+}
+
+// earliestTimestamp picks the earlier of two timestamps, either of which can be missing
+func earliestTimestamp(a, b interface{}) interface{} {
+	aStr, _ := a.(string)
+	bStr, _ := b.(string)
+
+	aTime, aErr := time.Parse(time.RFC3339Nano, aStr)
+	bTime, bErr := time.Parse(time.RFC3339Nano, bStr)
+
+	switch {
+	case aErr != nil && bErr != nil:
+		return nil
+	case aErr != nil:
+		return bStr
+	case bErr != nil || !bTime.Before(aTime):
+		return aStr
+	default:
+		return bStr
+	}
+}
+
+// isEventsV1 checks if an object is an Event from the events.k8s.io/v1 API
+func isEventsV1(obj *unstructured.Unstructured) bool {
+	return obj.GetKind() == "Event" && obj.GetAPIVersion() == eventsV1GVR.GroupVersion().String()
+}
+
+// normaliseEvent converts an events.k8s.io/v1 Event into the core/v1 shape, other objects are returned as is
+// The renamed fields are mapped across, and series information is folded into the count & last timestamp
+func normaliseEvent(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	if !isEventsV1(obj) {
+		return obj
+	}
+
+	in := obj.Object
+	out := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Event",
+		"metadata":   in["metadata"],
+	}
+
+	// Fields with the same name in both APIs
+	for _, field := range []string{"reason", "type", "action", "related", "eventTime", "reportingInstance"} {
+		if v, ok := in[field]; ok {
+			out[field] = v
+		}
+	}
+
+	renamed := map[string]string{
+		"regarding":                "involvedObject",
+		"note":                     "message",
+		"deprecatedCount":          "count",
+		"deprecatedFirstTimestamp": "firstTimestamp",
+		"deprecatedLastTimestamp":  "lastTimestamp",
+		"deprecatedSource":         "source",
+		"reportingController":      "reportingComponent",
+	}
+
+	for from, to := range renamed {
+		if v, ok := in[from]; ok && v != nil {
+			out[to] = v
+		}
+	}
+
+	// Repeated events are reported as a series rather than with the deprecated count
+	if count, found, _ := unstructured.NestedInt64(in, "series", "count"); found {
+		out["count"] = count
+	}
+
+	if last, found, _ := unstructured.NestedString(in, "series", "lastObservedTime"); found {
+		out["lastTimestamp"] = last
+	}
+
+	if _, ok := out["source"]; !ok {
+		if controller, ok := in["reportingController"].(string); ok {
+			out["source"] = map[string]interface{}{"component": controller}
+		}
+	}
+
+	return &unstructured.Unstructured{Object: out}
+}
@@ -0,0 +1,215 @@
+// ==========================================================================================
+// Unit tests for the events.k8s.io/v1 & core/v1 Event APIs
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+)
+
+// createTestEventsV1 creates an events.k8s.io/v1 Event, repeated as a series
+func createTestEventsV1(name, namespace string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "events.k8s.io/v1",
+			"kind":       "Event",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"reason":              "BackOff",
+			"type":                "Warning",
+			"note":                "Back-off restarting failed container",
+			"reportingController": "kubelet",
+			"eventTime":           "2025-01-01T10:00:00.000000Z",
+			"regarding": map[string]interface{}{
+				"kind": "Pod",
+				"name": "web",
+			},
+			"series": map[string]interface{}{
+				"count":            int64(7),
+				"lastObservedTime": "2025-01-01T10:05:00.000000Z",
+			},
+		},
+	}
+}
+
+// createTestCoreEvent creates a core/v1 Event
+func createTestCoreEvent(name, namespace string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Event",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"reason":  "Scheduled",
+			"message": "Successfully assigned default/web to node-1",
+			"count":   int64(1),
+			"involvedObject": map[string]interface{}{
+				"kind": "Pod",
+				"name": "web",
+			},
+		},
+	}
+}
+
+// discoveryWithGroups returns a fake discovery client serving the given group versions & resources
+func discoveryWithGroups(groups map[string][]string) *fakediscovery.FakeDiscovery {
+	disc := k8sfake.NewClientset().Discovery().(*fakediscovery.FakeDiscovery)
+
+	for gv, names := range groups {
+		list := &metaV1.APIResourceList{GroupVersion: gv}
+		for _, name := range names {
+			list.APIResources = append(list.APIResources, metaV1.APIResource{Name: name})
+		}
+
+		disc.Resources = append(disc.Resources, list)
+	}
+
+	return disc
+}
+
+func TestDetectEventsV1(t *testing.T) {
+	testCases := []struct {
+		name     string
+		groups   map[string][]string
+		expected bool
+	}{
+		{"core only", map[string][]string{"v1": {"pods", "events"}}, false},
+		{"events v1 only", map[string][]string{"events.k8s.io/v1": {"events"}}, true},
+		{"both", map[string][]string{"v1": {"events"}, "events.k8s.io/v1": {"events"}}, true},
+	}
+
+	for _, tc := range testCases {
+		if got := detectEventsV1(discoveryWithGroups(tc.groups)); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestKubernetes_FetchNamespace_EventsV1(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+	k.UseEventsV1 = true
+
+	_, _ = k.dynamicClient.Resource(eventsV1GVR).Namespace("default").
+		Create(context.TODO(), createTestEventsV1("web.1", "default"), metaV1.CreateOptions{})
+
+	data, err := k.FetchNamespace("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(data["events"]) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(data["events"]))
+	}
+
+	event := data["events"][0]
+
+	if event.GetAPIVersion() != "v1" {
+		t.Errorf("Expected event to be normalised to v1, got %s", event.GetAPIVersion())
+	}
+
+	message, _, _ := unstructured.NestedString(event.Object, "message")
+	count, _, _ := unstructured.NestedInt64(event.Object, "count")
+	last, _, _ := unstructured.NestedString(event.Object, "lastTimestamp")
+	involved, _, _ := unstructured.NestedString(event.Object, "involvedObject", "name")
+	source, _, _ := unstructured.NestedString(event.Object, "source", "component")
+
+	if message != "Back-off restarting failed container" || involved != "web" || source != "kubelet" {
+		t.Errorf("Expected note, regarding & controller to be mapped, got %q %q %q", message, involved, source)
+	}
+
+	if count != 7 || last != "2025-01-01T10:05:00.000000Z" {
+		t.Errorf("Expected series to set count & last timestamp, got %d %s", count, last)
+	}
+}
+
+func TestKubernetes_FetchNamespace_CoreEvents(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	_, _ = k.dynamicClient.Resource(coreEventGVR).Namespace("default").
+		Create(context.TODO(), createTestCoreEvent("web.1", "default"), metaV1.CreateOptions{})
+
+	data, err := k.FetchNamespace("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(data["events"]) != 1 {
+		t.Fatalf("Expected 1 event, got %d", len(data["events"]))
+	}
+
+	if message, _, _ := unstructured.NestedString(data["events"][0].Object, "message"); message == "" {
+		t.Error("Expected core event to be returned unchanged")
+	}
+}
+
+func TestGetHandlerFuncs_NormalisesEvents(t *testing.T) {
+	sender := &recordingSender{}
+	handlers := getHandlerFuncs(sender)
+
+	handlers.AddFunc(createTestEventsV1("web.1", "default"))
+
+	if len(sender.events) != 1 || sender.events[0].Object.GetAPIVersion() != "v1" {
+		t.Errorf("Expected a normalised v1 event to be sent, got %v", sender.events)
+	}
+}
+
+func TestKubernetes_FetchNamespace_MergedEvents(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	event := func(name, reason string, count int64, first, last string) *unstructured.Unstructured {
+		e := createTestCoreEvent(name, "default")
+		e.Object["reason"], e.Object["message"], e.Object["count"] = reason, "0/3 nodes are available", count
+		e.Object["firstTimestamp"], e.Object["lastTimestamp"] = first, last
+
+		return e
+	}
+
+	// The same scheduling failure recorded twice, plus an older unrelated event
+	for _, e := range []*unstructured.Unstructured{
+		event("web.1", "FailedScheduling", 5, "2025-01-01T10:00:00Z", "2025-01-01T10:04:00Z"),
+		event("web.2", "FailedScheduling", 7, "2025-01-01T10:05:00Z", "2025-01-01T10:12:00Z"),
+		event("web.3", "Pulled", 0, "2025-01-01T10:08:00Z", "2025-01-01T10:08:00Z"),
+	} {
+		_, _ = k.dynamicClient.Resource(coreEventGVR).Namespace("default").
+			Create(context.TODO(), e, metaV1.CreateOptions{})
+	}
+
+	data, err := k.FetchNamespace("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	events := data["events"]
+	if len(events) != 2 {
+		t.Fatalf("Expected the repeated event to be merged into 2 events, got %d", len(events))
+	}
+
+	merged := events[0].Object
+	if merged["reason"] != "FailedScheduling" || merged["count"] != int64(12) {
+		t.Errorf("Expected FailedScheduling first with a count of 12, got %v x%v", merged["reason"], merged["count"])
+	}
+
+	if merged["firstTimestamp"] != "2025-01-01T10:00:00Z" || merged["lastTimestamp"] != "2025-01-01T10:12:00Z" {
+		t.Errorf("Expected the first & last seen times to span both, got %v to %v",
+			merged["firstTimestamp"], merged["lastTimestamp"])
+	}
+
+	// A missing count means the event happened once
+	if events[1].Object["reason"] != "Pulled" || events[1].Object["count"] != int64(1) {
+		t.Errorf("Expected Pulled with a count of 1, got %v x%v", events[1].Object["reason"], events[1].Object["count"])
+	}
+}
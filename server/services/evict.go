@@ -0,0 +1,68 @@
+// ==========================================================================================
+// Evicting pods through the Eviction API, the same as `kubectl drain` does for each pod
+// Unlike deleting, eviction respects PodDisruptionBudgets so it's safe for running workloads
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	policyV1 "k8s.io/api/policy/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ErrEvictionBlocked is returned when evicting a pod would violate a PodDisruptionBudget, it can be retried later
+var ErrEvictionBlocked = errors.New("eviction blocked by a pod disruption budget")
+
+// EvictPod evicts a pod, which is deleted gracefully unless a PodDisruptionBudget doesn't allow it
+// With dryRun the eviction is checked, including against PodDisruptionBudgets, but the pod is left running
+func (k *Kubernetes) EvictPod(ns, name string, dryRun bool) error {
+	if k.ReadOnly {
+		return ErrReadOnly
+	}
+
+	if ns == "" || name == "" {
+		return errors.New("namespace or pod name is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return err
+	}
+
+	eviction := &policyV1.Eviction{
+		ObjectMeta:    metaV1.ObjectMeta{Name: name, Namespace: ns},
+		DeleteOptions: &metaV1.DeleteOptions{DryRun: dryRunOption(dryRun)},
+	}
+
+	err := k.callAPI(func(ctx context.Context) error {
+		return k.clientSet.CoreV1().Pods(ns).EvictV1(ctx, eviction)
+	})
+	if err != nil {
+		log.Printf("💥 Failed to evict pod %s in namespace %s: %v", name, ns, err)
+		return evictionError(err)
+	}
+
+	log.Printf("🚪 Evicted pod %s in namespace %s%s", name, ns, dryRunNote(dryRun))
+
+	return nil
+}
+
+// evictionError wraps the API server's refusal to evict with ErrEvictionBlocked, keeping its explanation
+// Evictions blocked by a PodDisruptionBudget are rejected as 429 Too Many Requests
+func evictionError(err error) error {
+	if !apiErrors.IsTooManyRequests(err) {
+		return err
+	}
+
+	var status apiErrors.APIStatus
+	if errors.As(err, &status) && status.Status().Message != "" {
+		return fmt.Errorf("%w: %s", ErrEvictionBlocked, status.Status().Message)
+	}
+
+	return ErrEvictionBlocked
+}
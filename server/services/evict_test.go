@@ -0,0 +1,54 @@
+// ==========================================================================================
+// Unit tests for evicting pods
+// ==========================================================================================
+
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestKubernetes_EvictPod(t *testing.T) {
+	k := mockKubernetes()
+
+	k.ReadOnly = true
+
+	if err := k.EvictPod("default", "web", false); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+
+	k.ReadOnly = false
+
+	if err := k.EvictPod("default", "", false); err == nil {
+		t.Error("Expected error for empty pod name, got nil")
+	}
+
+	k.NamespaceDenylist = []string{"kube-system"}
+
+	if err := k.EvictPod("kube-system", "web", false); !errors.Is(err, ErrNamespaceForbidden) {
+		t.Errorf("Expected ErrNamespaceForbidden, got %v", err)
+	}
+
+	// The API server refuses with 429 when a disruption budget would be violated
+	message := "Cannot evict pod as it would violate the pod's disruption budget."
+	fake, _ := k.clientSet.(*k8sfake.Clientset)
+	fake.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+
+		return true, nil, apiErrors.NewTooManyRequests(message, 10)
+	})
+
+	err := k.EvictPod("default", "web", false)
+	if !errors.Is(err, ErrEvictionBlocked) || !strings.Contains(err.Error(), "disruption budget") {
+		t.Errorf("Expected ErrEvictionBlocked with the reason, got %v", err)
+	}
+}
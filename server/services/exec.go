@@ -0,0 +1,315 @@
+// ==========================================================================================
+// Exec into a container, streaming stdin, stdout & stderr for a web terminal
+// Speaks the Kubernetes channel.k8s.io websocket protocol to the API server directly
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/websocket"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// Version 4 of the protocol adds the resize channel and exit codes in the error channel
+// The same protocol is used for port forwarding, see portforward.go
+const channelProtocol = "v4.channel.k8s.io"
+
+// Channels multiplexed over the websocket, the first byte of every message
+const (
+	stdinChannel byte = iota
+	stdoutChannel
+	stderrChannel
+	errorChannel
+	resizeChannel
+)
+
+// The annotation kubectl uses to pick the container when none is given
+const defaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
+
+// ExecOptions is what to run in a pod, the container defaults to the pod's default container
+type ExecOptions struct {
+	Container string
+	Command   []string
+	TTY       bool
+}
+
+// TerminalSize is the size of the terminal in characters, sent when the browser window is resized
+type TerminalSize struct {
+	Width  uint16 `json:"width"`
+	Height uint16 `json:"height"`
+}
+
+// ExecStreams connects a command to the caller, Stdin & Resize may be nil
+type ExecStreams struct {
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	Resize <-chan TerminalSize
+}
+
+// ExecInPod runs a command in a container, streaming until it exits or the context is cancelled
+// A command exiting with a non-zero code is returned as an error
+func (k *Kubernetes) ExecInPod(ctx context.Context, ns, podName string, opts ExecOptions, streams ExecStreams) error {
+	if k.ReadOnly {
+		return ErrReadOnly
+	}
+
+	if ns == "" || podName == "" {
+		return errors.New("namespace or pod name is empty")
+	}
+
+	if len(opts.Command) == 0 {
+		return errors.New("command is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return err
+	}
+
+	var pod *coreV1.Pod
+
+	err := k.callAPI(func(ctx context.Context) (err error) {
+		pod, err = k.clientSet.CoreV1().Pods(ns).Get(ctx, podName, metaV1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	container, err := execContainer(pod, opts.Container)
+	if err != nil {
+		return err
+	}
+
+	if k.restConfig == nil {
+		return errors.New("exec is not available, no connection to the API server")
+	}
+
+	log.Printf("🐚 Exec into pod %s container %s in namespace %s: %s", podName, container, ns,
+		strings.Join(opts.Command, " "))
+
+	execURL := k.clientSet.CoreV1().RESTClient().Post().
+		Namespace(ns).Resource("pods").Name(podName).SubResource("exec").
+		VersionedParams(&coreV1.PodExecOptions{
+			Container: container,
+			Command:   opts.Command,
+			Stdin:     streams.Stdin != nil,
+			Stdout:    true,
+			Stderr:    !opts.TTY, // A TTY merges stderr into stdout
+			TTY:       opts.TTY,
+		}, scheme.ParameterCodec).URL()
+
+	return k.streamExec(ctx, execURL, streams)
+}
+
+// execContainer picks the container to exec into, checking it exists in the pod
+// With no name the default container annotation is used, then the first container
+func execContainer(pod *coreV1.Pod, name string) (string, error) {
+	if len(pod.Spec.Containers) == 0 {
+		return "", fmt.Errorf("pod %s has no containers", pod.Name)
+	}
+
+	if name == "" {
+		name = pod.Annotations[defaultContainerAnnotation]
+	}
+
+	if name == "" {
+		return pod.Spec.Containers[0].Name, nil
+	}
+
+	found := slices.ContainsFunc(pod.Spec.Containers, func(c coreV1.Container) bool { return c.Name == name })
+	if !found {
+		return "", fmt.Errorf("container %s not found in pod %s", name, pod.Name)
+	}
+
+	return name, nil
+}
+
+// streamExec connects to the exec URL and copies the streams until the command exits
+func (k *Kubernetes) streamExec(ctx context.Context, execURL *url.URL, streams ExecStreams) error {
+	ws, err := k.dialAPIWebsocket(execURL)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	// Closing the connection is the only way to stop a blocked read
+	stop := context.AfterFunc(ctx, func() { _ = ws.Close() })
+	defer stop()
+
+	if streams.Stdin != nil {
+		go copyToChannel(ws, stdinChannel, streams.Stdin)
+	}
+
+	if streams.Resize != nil {
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case size := <-streams.Resize:
+					data, _ := json.Marshal(size)
+					if websocket.Message.Send(ws, append([]byte{resizeChannel}, data...)) != nil {
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	for {
+		var msg []byte
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			// The API server closes the connection once the command has exited
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+
+			return err
+		}
+
+		if len(msg) == 0 {
+			continue
+		}
+
+		switch msg[0] {
+		case stdoutChannel:
+			_, err = streams.Stdout.Write(msg[1:])
+		case stderrChannel:
+			if streams.Stderr != nil {
+				_, err = streams.Stderr.Write(msg[1:])
+			}
+		case errorChannel:
+			return execStatusError(msg[1:])
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// copyToChannel sends everything read from r to a channel, until r or the connection is closed
+func copyToChannel(ws *websocket.Conn, channel byte, r io.Reader) {
+	buf := make([]byte, 32*1024)
+
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if websocket.Message.Send(ws, append([]byte{channel}, buf[:n]...)) != nil {
+				return
+			}
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// execStatusError turns the status sent on the error channel into an error, nil when the command succeeded
+func execStatusError(data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	status := metaV1.Status{}
+	if err := json.Unmarshal(data, &status); err != nil {
+		// Older servers send the error as plain text
+		return errors.New(string(data))
+	}
+
+	if status.Status == metaV1.StatusSuccess {
+		return nil
+	}
+
+	if status.Details != nil {
+		for _, cause := range status.Details.Causes {
+			if cause.Type == "ExitCode" {
+				return fmt.Errorf("command exited with code %s", cause.Message)
+			}
+		}
+	}
+
+	return errors.New(status.Message)
+}
+
+// dialAPIWebsocket opens a websocket to the API server, with the same TLS & auth as every other call
+func (k *Kubernetes) dialAPIWebsocket(target *url.URL) (*websocket.Conn, error) {
+	location := *target
+	location.Scheme = strings.Replace(location.Scheme, "http", "ws", 1)
+
+	origin := *target
+	origin.Path, origin.RawQuery = "", ""
+
+	config, err := websocket.NewConfig(location.String(), origin.String())
+	if err != nil {
+		return nil, err
+	}
+
+	config.Protocol = []string{channelProtocol}
+
+	if config.TlsConfig, err = rest.TLSConfigFor(k.restConfig); err != nil {
+		return nil, err
+	}
+
+	if config.Header, err = authHeaders(k.restConfig); err != nil {
+		return nil, err
+	}
+
+	return websocket.DialConfig(config)
+}
+
+// authHeaders returns the headers client-go would add to a request, e.g. the bearer token
+// This covers tokens from exec plugins & auth providers too, which are only available as transport wrappers
+func authHeaders(config *rest.Config) (http.Header, error) {
+	capture := &headerCapture{}
+
+	rt, err := rest.HTTPWrappersForConfig(config, capture)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, config.Host, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = resp.Body.Close()
+
+	return capture.header, nil
+}
+
+// headerCapture is a round tripper which records the request headers, without sending anything
+type headerCapture struct {
+	header http.Header
+}
+
+func (h *headerCapture) RoundTrip(req *http.Request) (*http.Response, error) {
+	h.header = req.Header.Clone()
+
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+}
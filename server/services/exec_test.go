@@ -0,0 +1,107 @@
+// ==========================================================================================
+// Unit tests for exec into a container
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func createTestExecPod(k *Kubernetes, annotations map[string]string) {
+	pod := &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{Name: "web", Namespace: "default", Annotations: annotations},
+		Spec: coreV1.PodSpec{Containers: []coreV1.Container{
+			{Name: "app", Image: "nginx"},
+			{Name: "sidecar", Image: "envoy"},
+		}},
+	}
+
+	_, _ = k.clientSet.CoreV1().Pods("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+}
+
+func TestKubernetes_ExecInPod_Validation(t *testing.T) {
+	k := mockKubernetes()
+	createTestExecPod(k, nil)
+
+	shell := ExecOptions{Command: []string{"/bin/sh"}}
+
+	k.ReadOnly = true
+	if err := k.ExecInPod(context.TODO(), "default", "web", shell, ExecStreams{}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly in read-only mode, got %v", err)
+	}
+
+	k.ReadOnly = false
+
+	tests := []struct {
+		name    string
+		ns, pod string
+		opts    ExecOptions
+		errText string
+	}{
+		{"empty namespace", "", "web", shell, "empty"},
+		{"empty pod", "default", "", shell, "empty"},
+		{"empty command", "default", "web", ExecOptions{}, "command is empty"},
+		{"missing pod", "default", "nope", shell, "not found"},
+		{"missing container", "default", "web", ExecOptions{Container: "db", Command: shell.Command}, "container db"},
+	}
+
+	for _, test := range tests {
+		err := k.ExecInPod(context.TODO(), test.ns, test.pod, test.opts, ExecStreams{})
+		if err == nil || !strings.Contains(err.Error(), test.errText) {
+			t.Errorf("%s: expected error containing %q, got %v", test.name, test.errText, err)
+		}
+	}
+
+	k.NamespaceDenylist = []string{"default"}
+	if err := k.ExecInPod(context.TODO(), "default", "web", shell, ExecStreams{}); !errors.Is(err, ErrNamespaceForbidden) {
+		t.Errorf("Expected ErrNamespaceForbidden, got %v", err)
+	}
+}
+
+func TestExecContainer(t *testing.T) {
+	pod := &coreV1.Pod{Spec: coreV1.PodSpec{Containers: []coreV1.Container{{Name: "app"}, {Name: "sidecar"}}}}
+
+	if name, _ := execContainer(pod, ""); name != "app" {
+		t.Errorf("Expected first container by default, got %s", name)
+	}
+
+	pod.Annotations = map[string]string{defaultContainerAnnotation: "sidecar"}
+	if name, _ := execContainer(pod, ""); name != "sidecar" {
+		t.Errorf("Expected default container from annotation, got %s", name)
+	}
+
+	if name, _ := execContainer(pod, "app"); name != "app" {
+		t.Errorf("Expected named container, got %s", name)
+	}
+}
+
+func TestExecStatusError(t *testing.T) {
+	if err := execStatusError([]byte(`{"status":"Success"}`)); err != nil {
+		t.Errorf("Expected no error for success, got %v", err)
+	}
+
+	failed := `{"status":"Failure","message":"command terminated with non-zero exit code",` +
+		`"details":{"causes":[{"reason":"ExitCode","message":"2"}]}}`
+	if err := execStatusError([]byte(failed)); err == nil || err.Error() != "command exited with code 2" {
+		t.Errorf("Expected exit code error, got %v", err)
+	}
+}
+
+func TestTerminalSize_JSON(t *testing.T) {
+	data, err := json.Marshal(TerminalSize{Width: 120, Height: 40})
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	if string(data) != `{"width":120,"height":40}` {
+		t.Errorf("Expected camelCase keys, got %s", data)
+	}
+}
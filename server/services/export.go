@@ -0,0 +1,142 @@
+// ==========================================================================================
+// Exporting all the resources in a namespace as a multi-document YAML bundle, or many
+// namespaces streamed as JSON Lines for offline analysis
+// ==========================================================================================
+
+package services
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// Metadata fields set by the API server, which would only get in the way when re-applying an export
+var serverMetadataFields = []string{
+	"uid", "resourceVersion", "generation", "creationTimestamp", "deletionTimestamp", "selfLink", "managedFields",
+}
+
+// ExportNamespace returns every resource in a namespace as YAML documents separated by "---"
+// Server populated fields & status are removed, and Secrets are redacted the same as when fetched
+func (k *Kubernetes) ExportNamespace(ns string) ([]byte, error) {
+	data, err := k.FetchNamespaceWithOptions(ns, FetchOptions{IncludeCompletedJobPods: true})
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the export in a stable order, following the order types are fetched in
+	order := map[string]int{}
+	for i, gvr := range k.namespaceResources() {
+		order[gvr.Resource] = i
+	}
+
+	type exportItem struct {
+		resType string
+		obj     *unstructured.Unstructured
+	}
+
+	items := []exportItem{}
+
+	for resType, list := range data {
+		// Events are a record of what happened, not part of the namespace's configuration
+		if resType == "events" {
+			continue
+		}
+
+		for i := range list {
+			items = append(items, exportItem{resType: resType, obj: list[i].DeepCopy()})
+		}
+	}
+
+	slices.SortFunc(items, func(a, b exportItem) int {
+		return cmp.Or(cmp.Compare(order[a.resType], order[b.resType]), cmp.Compare(a.obj.GetName(), b.obj.GetName()))
+	})
+
+	var out bytes.Buffer
+
+	for i, item := range items {
+		stripServerFields(item.obj)
+
+		doc, err := yaml.Marshal(item.obj.Object)
+		if err != nil {
+			return nil, fmt.Errorf("exporting %s %s: %w", item.obj.GetKind(), item.obj.GetName(), err)
+		}
+
+		if i > 0 {
+			out.WriteString("---\n")
+		}
+
+		out.Write(doc)
+	}
+
+	return out.Bytes(), nil
+}
+
+// StreamExport writes every resource in the namespaces to w as JSON Lines, one object per line
+// Resources are written as each type is fetched, rather than held in memory, and flushed after each type
+// when w can be flushed. Objects are complete, status included, with Secrets redacted the same as when fetched
+func (k *Kubernetes) StreamExport(namespaces []string, w io.Writer) error {
+	if len(namespaces) == 0 {
+		return errors.New("no namespaces to export")
+	}
+
+	// Checked up front, so nothing has been written when a namespace can't be exported
+	for _, ns := range namespaces {
+		if err := k.checkNamespace(ns); err != nil {
+			return err
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	flusher, _ := w.(interface{ Flush() })
+
+	for _, ns := range namespaces {
+		var writeErr error
+
+		err := k.StreamNamespace(ns, FetchOptions{IncludeCompletedJobPods: true}, func(chunk ResourceChunk) {
+			for i := range chunk.Items {
+				if writeErr != nil {
+					return
+				}
+
+				writeErr = enc.Encode(chunk.Items[i].Object)
+			}
+
+			if flusher != nil {
+				flusher.Flush()
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("exporting namespace %s: %w", ns, err)
+		}
+
+		// Most likely the client has gone away, so there is no point fetching any more
+		if writeErr != nil {
+			return writeErr
+		}
+	}
+
+	return nil
+}
+
+// stripServerFields removes the status and metadata the API server fills in
+func stripServerFields(obj *unstructured.Unstructured) {
+	delete(obj.Object, "status")
+
+	for _, field := range serverMetadataFields {
+		unstructured.RemoveNestedField(obj.Object, "metadata", field)
+	}
+
+	unstructured.RemoveNestedField(obj.Object, "metadata", "annotations", lastAppliedAnnotation)
+
+	if len(obj.GetAnnotations()) == 0 {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "annotations")
+	}
+}
@@ -0,0 +1,117 @@
+// ==========================================================================================
+// Unit tests for exporting a namespace as YAML, and streaming namespaces as JSON Lines
+// ==========================================================================================
+
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestKubernetes_ExportNamespace(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	pod := createTestPod("web", "default")
+	pod.Object["status"] = map[string]interface{}{"phase": "Running"}
+	pod.SetAnnotations(map[string]string{"kubectl.kubernetes.io/last-applied-configuration": "{}"})
+
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(kindResources["Deployment"]).Namespace("default").
+		Create(context.TODO(), createTestDeployment("web", "default", 2), metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(secretGVR).Namespace("default").
+		Create(context.TODO(), createTestSecret("creds", "default"), metaV1.CreateOptions{})
+
+	out, err := k.ExportNamespace("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	docs := strings.Split(string(out), "---\n")
+	if len(docs) != 3 {
+		t.Fatalf("Expected 3 documents, got %d:\n%s", len(docs), out)
+	}
+
+	kinds := []string{}
+
+	for _, doc := range docs {
+		obj := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			t.Fatalf("Expected document to parse, got %v:\n%s", err, doc)
+		}
+
+		kinds = append(kinds, obj["kind"].(string))
+
+		if _, ok := obj["status"]; ok {
+			t.Errorf("Expected status to be removed from %s", obj["kind"])
+		}
+
+		metadata := obj["metadata"].(map[string]interface{})
+		for _, field := range []string{"uid", "resourceVersion", "annotations"} {
+			if _, ok := metadata[field]; ok {
+				t.Errorf("Expected metadata.%s to be removed from %s", field, obj["kind"])
+			}
+		}
+
+		if obj["kind"] == "Secret" {
+			data := obj["data"].(map[string]interface{})
+			if data["username"] != redactedValue || data["password"] != redactedValue {
+				t.Errorf("Expected secret data to be redacted, got %v", data)
+			}
+		}
+	}
+
+	if strings.Join(kinds, ",") != "Pod,Deployment,Secret" {
+		t.Errorf("Expected documents in fetch order, got %v", kinds)
+	}
+}
+
+func TestKubernetes_StreamExport(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+	addTestNamespace(k, "team-a")
+
+	for _, ns := range []string{"default", "team-a"} {
+		_, _ = k.dynamicClient.Resource(podGVR).Namespace(ns).
+			Create(context.TODO(), createTestPod("web", ns), metaV1.CreateOptions{})
+		_, _ = k.dynamicClient.Resource(secretGVR).Namespace(ns).
+			Create(context.TODO(), createTestSecret("creds", ns), metaV1.CreateOptions{})
+	}
+
+	_, _ = k.dynamicClient.Resource(kindResources["Deployment"]).Namespace("team-a").
+		Create(context.TODO(), createTestDeployment("web", "team-a", 2), metaV1.CreateOptions{})
+
+	var out bytes.Buffer
+	if err := k.StreamExport([]string{"default", "team-a"}, &out); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("Expected 5 lines, one for each object, got %d:\n%s", len(lines), out.String())
+	}
+
+	for _, line := range lines {
+		obj := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			t.Fatalf("Expected each line to be valid JSON, got %v: %s", err, line)
+		}
+
+		if obj["kind"] == "Secret" {
+			if data := obj["data"].(map[string]interface{}); data["password"] != redactedValue {
+				t.Errorf("Expected secret values to be redacted, got %v", data)
+			}
+		}
+	}
+
+	if err := k.StreamExport(nil, &out); err == nil {
+		t.Error("Expected error with no namespaces")
+	}
+}
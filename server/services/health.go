@@ -0,0 +1,201 @@
+// ==========================================================================================
+// Health of each object, worked out by the server from rules for each kind so every client
+// colours objects the same way. Rules for more kinds can be registered, or the defaults replaced
+// ==========================================================================================
+
+package services
+
+import (
+	"sync"
+
+	appsV1 "k8s.io/api/apps/v1"
+	batchV1 "k8s.io/api/batch/v1"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Health is the normalised health of an object
+type Health string
+
+const (
+	HealthHealthy Health = "healthy"
+	// HealthWarning is for objects on their way to healthy, or partly working, e.g. a pending pod
+	HealthWarning Health = "warning"
+	HealthError   Health = "error"
+	// HealthUnknown is for kinds with no rule, or objects with no status yet
+	HealthUnknown Health = "unknown"
+)
+
+// Computed field added to fetched resources & live updates, see setHealth
+const healthField = "health"
+
+// HealthRule works out the health of an object of one kind
+type HealthRule func(obj *unstructured.Unstructured) Health
+
+var (
+	healthRules = map[string]HealthRule{
+		"Pod":                   typedHealthRule(podHealth),
+		"Deployment":            typedHealthRule(deploymentHealth),
+		"ReplicaSet":            typedHealthRule(replicaSetHealth),
+		"StatefulSet":           typedHealthRule(statefulSetHealth),
+		"DaemonSet":             typedHealthRule(daemonSetHealth),
+		"Job":                   typedHealthRule(jobHealth),
+		"PersistentVolumeClaim": typedHealthRule(pvcHealth),
+	}
+	healthRulesMu sync.RWMutex
+)
+
+// RegisterHealthRule sets the rule for a kind, replacing any existing rule, e.g. to add health for a CRD
+func RegisterHealthRule(kind string, rule HealthRule) {
+	healthRulesMu.Lock()
+	defer healthRulesMu.Unlock()
+
+	healthRules[kind] = rule
+}
+
+// ObjectHealth works out the health of an object, unknown when there is no rule for its kind
+func ObjectHealth(obj *unstructured.Unstructured) Health {
+	healthRulesMu.RLock()
+	rule, found := healthRules[obj.GetKind()]
+	healthRulesMu.RUnlock()
+
+	if !found {
+		return HealthUnknown
+	}
+
+	return rule(obj)
+}
+
+// setHealth adds a health field to each object
+func setHealth(items []unstructured.Unstructured) {
+	for i := range items {
+		items[i].Object[healthField] = string(ObjectHealth(&items[i]))
+	}
+}
+
+// typedHealthRule turns a rule for a typed object into a HealthRule, objects which can't be decoded are unknown
+func typedHealthRule[T any](rule func(obj *T) Health) HealthRule {
+	return func(u *unstructured.Unstructured) Health {
+		obj := new(T)
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, obj); err != nil {
+			return HealthUnknown
+		}
+
+		return rule(obj)
+	}
+}
+
+// A pod is healthy when ready or completed, and in error when failed or a container can't start
+func podHealth(pod *coreV1.Pod) Health {
+	if pod.DeletionTimestamp != nil {
+		return HealthWarning
+	}
+
+	if pod.Status.Phase == coreV1.PodFailed || len(containerProblems(pod)) > 0 {
+		return HealthError
+	}
+
+	if pod.Status.Phase == coreV1.PodSucceeded {
+		return HealthHealthy
+	}
+
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == coreV1.PodReady && cond.Status == coreV1.ConditionTrue {
+			return HealthHealthy
+		}
+	}
+
+	if pod.Status.Phase == "" {
+		return HealthUnknown
+	}
+
+	// Pending, or running but not ready yet
+	return HealthWarning
+}
+
+// A deployment is judged on its conditions, once available it's only a warning while pods are missing
+func deploymentHealth(deploy *appsV1.Deployment) Health {
+	if len(deploy.Status.Conditions) == 0 {
+		return HealthUnknown
+	}
+
+	available := false
+
+	for _, cond := range deploy.Status.Conditions {
+		switch {
+		case cond.Type == appsV1.DeploymentReplicaFailure && cond.Status == coreV1.ConditionTrue:
+			return HealthError
+		case cond.Type == appsV1.DeploymentProgressing && cond.Status == coreV1.ConditionFalse:
+			// The rollout has passed its progress deadline
+			return HealthError
+		case cond.Type == appsV1.DeploymentAvailable:
+			available = cond.Status == coreV1.ConditionTrue
+		}
+	}
+
+	if !available {
+		return HealthError
+	}
+
+	return replicasHealth(deploy.Status.Replicas, deploy.Status.ReadyReplicas)
+}
+
+func replicaSetHealth(rs *appsV1.ReplicaSet) Health {
+	return replicasHealth(rs.Status.Replicas, rs.Status.ReadyReplicas)
+}
+
+func statefulSetHealth(sts *appsV1.StatefulSet) Health {
+	return replicasHealth(sts.Status.Replicas, sts.Status.ReadyReplicas)
+}
+
+func daemonSetHealth(ds *appsV1.DaemonSet) Health {
+	return replicasHealth(ds.Status.DesiredNumberScheduled, ds.Status.NumberReady)
+}
+
+// replicasHealth is healthy when every replica is ready, scaled to zero included, and in error when none are
+func replicasHealth(replicas, ready int32) Health {
+	switch {
+	case ready >= replicas:
+		return HealthHealthy
+	case ready == 0:
+		return HealthError
+	default:
+		return HealthWarning
+	}
+}
+
+// A job is in error once it has failed for good, and a warning while its pods are failing & being retried
+func jobHealth(job *batchV1.Job) Health {
+	for _, cond := range job.Status.Conditions {
+		if cond.Status != coreV1.ConditionTrue {
+			continue
+		}
+
+		switch cond.Type {
+		case batchV1.JobComplete:
+			return HealthHealthy
+		case batchV1.JobFailed:
+			return HealthError
+		}
+	}
+
+	if job.Status.Failed > 0 {
+		return HealthWarning
+	}
+
+	return HealthHealthy
+}
+
+func pvcHealth(pvc *coreV1.PersistentVolumeClaim) Health {
+	switch pvc.Status.Phase {
+	case coreV1.ClaimBound:
+		return HealthHealthy
+	case coreV1.ClaimPending:
+		return HealthWarning
+	case coreV1.ClaimLost:
+		return HealthError
+	default:
+		return HealthUnknown
+	}
+}
@@ -0,0 +1,152 @@
+// ==========================================================================================
+// Unit tests for object health
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// healthTestObject creates an object of a kind with the given status
+func healthTestObject(kind string, status map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       kind,
+		"metadata":   map[string]interface{}{"name": "test", "namespace": "default"},
+		"status":     status,
+	}}
+}
+
+func TestObjectHealth_Pod(t *testing.T) {
+	ready := []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}}
+	crashing := []interface{}{map[string]interface{}{
+		"name": "app", "state": map[string]interface{}{"waiting": map[string]interface{}{"reason": "CrashLoopBackOff"}},
+	}}
+
+	testCases := []struct {
+		name     string
+		status   map[string]interface{}
+		expected Health
+	}{
+		{"ready", map[string]interface{}{"phase": "Running", "conditions": ready}, HealthHealthy},
+		{"not ready", map[string]interface{}{"phase": "Running"}, HealthWarning},
+		{"pending", map[string]interface{}{"phase": "Pending"}, HealthWarning},
+		{"completed", map[string]interface{}{"phase": "Succeeded"}, HealthHealthy},
+		{"failed", map[string]interface{}{"phase": "Failed"}, HealthError},
+		{"crash looping", map[string]interface{}{"phase": "Running", "containerStatuses": crashing}, HealthError},
+		{"no status", map[string]interface{}{}, HealthUnknown},
+	}
+
+	for _, tc := range testCases {
+		if health := ObjectHealth(healthTestObject("Pod", tc.status)); health != tc.expected {
+			t.Errorf("Pod %s: expected %s, got %s", tc.name, tc.expected, health)
+		}
+	}
+
+	// A terminating pod is on its way out, however healthy it was
+	pod := healthTestObject("Pod", map[string]interface{}{"phase": "Running", "conditions": ready})
+	now := metaV1.Now()
+	pod.SetDeletionTimestamp(&now)
+
+	if health := ObjectHealth(pod); health != HealthWarning {
+		t.Errorf("Expected a terminating pod to be a warning, got %s", health)
+	}
+}
+
+func TestObjectHealth_Deployment(t *testing.T) {
+	condition := func(condType, status string) map[string]interface{} {
+		return map[string]interface{}{"type": condType, "status": status}
+	}
+
+	testCases := []struct {
+		name     string
+		status   map[string]interface{}
+		expected Health
+	}{
+		{"available", map[string]interface{}{
+			"replicas": int64(3), "readyReplicas": int64(3),
+			"conditions": []interface{}{condition("Available", "True"), condition("Progressing", "True")},
+		}, HealthHealthy},
+		{"rolling out", map[string]interface{}{
+			"replicas": int64(3), "readyReplicas": int64(2),
+			"conditions": []interface{}{condition("Available", "True")},
+		}, HealthWarning},
+		{"unavailable", map[string]interface{}{
+			"replicas": int64(3), "conditions": []interface{}{condition("Available", "False")},
+		}, HealthError},
+		{"progress deadline exceeded", map[string]interface{}{
+			"replicas": int64(3), "readyReplicas": int64(3),
+			"conditions": []interface{}{condition("Available", "True"), condition("Progressing", "False")},
+		}, HealthError},
+		{"no conditions", map[string]interface{}{}, HealthUnknown},
+	}
+
+	for _, tc := range testCases {
+		deploy := healthTestObject("Deployment", tc.status)
+		deploy.SetAPIVersion("apps/v1")
+
+		if health := ObjectHealth(deploy); health != tc.expected {
+			t.Errorf("Deployment %s: expected %s, got %s", tc.name, tc.expected, health)
+		}
+	}
+}
+
+func TestObjectHealth_PersistentVolumeClaim(t *testing.T) {
+	for phase, expected := range map[string]Health{
+		"Bound": HealthHealthy, "Pending": HealthWarning, "Lost": HealthError, "": HealthUnknown,
+	} {
+		pvc := healthTestObject("PersistentVolumeClaim", map[string]interface{}{"phase": phase})
+
+		if health := ObjectHealth(pvc); health != expected {
+			t.Errorf("PVC %q: expected %s, got %s", phase, expected, health)
+		}
+	}
+}
+
+func TestRegisterHealthRule(t *testing.T) {
+	widget := healthTestObject("Widget", map[string]interface{}{"ok": false})
+
+	if health := ObjectHealth(widget); health != HealthUnknown {
+		t.Errorf("Expected unknown for a kind with no rule, got %s", health)
+	}
+
+	RegisterHealthRule("Widget", func(obj *unstructured.Unstructured) Health {
+		if ok, _, _ := unstructured.NestedBool(obj.Object, "status", "ok"); ok {
+			return HealthHealthy
+		}
+
+		return HealthError
+	})
+	defer func() {
+		healthRulesMu.Lock()
+		delete(healthRules, "Widget")
+		healthRulesMu.Unlock()
+	}()
+
+	if health := ObjectHealth(widget); health != HealthError {
+		t.Errorf("Expected the registered rule to be used, got %s", health)
+	}
+}
+
+func TestKubernetes_FetchNamespace_Health(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	pod := createTestPod("web", "default")
+	_ = unstructured.SetNestedField(pod.Object, "Failed", "status", "phase")
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+
+	data, err := k.FetchNamespace("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(data["pods"]) != 1 || data["pods"][0].Object["health"] != "error" {
+		t.Errorf("Expected the failed pod to have error health, got %v", data["pods"])
+	}
+}
@@ -0,0 +1,161 @@
+// ==========================================================================================
+// HorizontalPodAutoscaler status, with the current & target metric values pulled together
+// Handles the autoscaling/v2 shape, and the older v2beta1 fields still found on some objects
+// ==========================================================================================
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// HPATarget is the workload an autoscaler scales
+type HPATarget struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// UID of the target, used to link the HPA to it in the graph. Empty when the target doesn't exist
+	UID types.UID `json:"uid,omitempty"`
+}
+
+// HPAMetric is a single metric an autoscaler scales on, with its current & target values
+type HPAMetric struct {
+	// Type is the metric source e.g. "Resource", "Pods", "Object", "External" or "ContainerResource"
+	Type string `json:"type"`
+	// Name of the metric, e.g. "cpu" for resource metrics
+	Name    string `json:"name"`
+	Current string `json:"current"`
+	Target  string `json:"target"`
+}
+
+// HPAStatus is the state of an autoscaler
+type HPAStatus struct {
+	Name            string      `json:"name"`
+	Target          HPATarget   `json:"target"`
+	MinReplicas     int64       `json:"minReplicas"`
+	MaxReplicas     int64       `json:"maxReplicas"`
+	CurrentReplicas int64       `json:"currentReplicas"`
+	DesiredReplicas int64       `json:"desiredReplicas"`
+	Metrics         []HPAMetric `json:"metrics"`
+}
+
+// GetHPAStatuses returns the status of every HorizontalPodAutoscaler in a namespace
+func (k *Kubernetes) GetHPAStatuses(ns string) ([]HPAStatus, error) {
+	if ns == "" {
+		return nil, errors.New("namespace is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	hpas, err := k.GetResources(ns, "autoscaling", "v2", "horizontalpodautoscalers")
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]HPAStatus, 0, len(hpas))
+
+	for i := range hpas {
+		status := hpaStatus(&hpas[i])
+
+		// Link to the target, a missing target is left unlinked rather than being an error
+		if target, err := k.getByKind(ns, "", status.Target.Kind, status.Target.Name); err == nil {
+			status.Target.UID = target.GetUID()
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// hpaStatus pulls the replica counts & metrics out of an HPA
+func hpaStatus(hpa *unstructured.Unstructured) HPAStatus {
+	obj := hpa.Object
+
+	status := HPAStatus{Name: hpa.GetName(), Metrics: []HPAMetric{}}
+	status.Target.Kind, _, _ = unstructured.NestedString(obj, "spec", "scaleTargetRef", "kind")
+	status.Target.Name, _, _ = unstructured.NestedString(obj, "spec", "scaleTargetRef", "name")
+	status.MinReplicas, _, _ = unstructured.NestedInt64(obj, "spec", "minReplicas")
+	status.MaxReplicas, _, _ = unstructured.NestedInt64(obj, "spec", "maxReplicas")
+	status.CurrentReplicas, _, _ = unstructured.NestedInt64(obj, "status", "currentReplicas")
+	status.DesiredReplicas, _, _ = unstructured.NestedInt64(obj, "status", "desiredReplicas")
+
+	// Min replicas defaults to one when not set
+	if status.MinReplicas == 0 {
+		status.MinReplicas = 1
+	}
+
+	specMetrics, _, _ := unstructured.NestedSlice(obj, "spec", "metrics")
+	currentMetrics, _, _ := unstructured.NestedSlice(obj, "status", "currentMetrics")
+
+	// Current values are matched to the spec by type & name, as the lists aren't always in the same order
+	current := map[string]string{}
+
+	for _, m := range currentMetrics {
+		metricType, name, source := metricSource(m)
+		current[metricType+"/"+name] = metricValue(source, "current")
+	}
+
+	for _, m := range specMetrics {
+		metricType, name, source := metricSource(m)
+
+		status.Metrics = append(status.Metrics, HPAMetric{
+			Type:    metricType,
+			Name:    name,
+			Current: current[metricType+"/"+name],
+			Target:  metricValue(source, "target"),
+		})
+	}
+
+	return status
+}
+
+// metricSource returns the type, name & source block of a metric, e.g. the "resource" block for cpu
+func metricSource(m interface{}) (string, string, map[string]interface{}) {
+	metric, _ := m.(map[string]interface{})
+	metricType, _, _ := unstructured.NestedString(metric, "type")
+
+	// The source block is named after the type, with a lower case first letter e.g. "containerResource"
+	key := metricType
+	if key != "" {
+		key = strings.ToLower(key[:1]) + key[1:]
+	}
+
+	source, _, _ := unstructured.NestedMap(metric, key)
+
+	// v2 puts the metric name under "metric", resource metrics have it at the top of the source
+	name, found, _ := unstructured.NestedString(source, "name")
+	if !found {
+		name, _, _ = unstructured.NestedString(source, "metric", "name")
+	}
+
+	return metricType, name, source
+}
+
+// metricValue formats the current or target value of a metric source, for both v2 & v2beta1 shapes
+// In v2 values are under "current" or "target", v2beta1 used prefixed fields e.g. "targetAverageUtilization"
+func metricValue(source map[string]interface{}, which string) string {
+	if util, found, _ := unstructured.NestedInt64(source, which, "averageUtilization"); found {
+		return fmt.Sprintf("%d%%", util)
+	}
+
+	if util, found, _ := unstructured.NestedInt64(source, which+"AverageUtilization"); found {
+		return fmt.Sprintf("%d%%", util)
+	}
+
+	for _, field := range [][]string{
+		{which, "averageValue"}, {which, "value"}, {which + "AverageValue"}, {which + "Value"},
+	} {
+		if value, found, _ := unstructured.NestedFieldNoCopy(source, field...); found {
+			return fmt.Sprint(value)
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,112 @@
+// ==========================================================================================
+// Unit tests for HorizontalPodAutoscaler status
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// createTestHPA creates an autoscaling/v2 HPA scaling a deployment on CPU
+func createTestHPA(name, namespace, target string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "autoscaling/v2",
+			"kind":       "HorizontalPodAutoscaler",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"spec": map[string]interface{}{
+				"scaleTargetRef": map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": target},
+				"minReplicas":    int64(2),
+				"maxReplicas":    int64(10),
+				"metrics": []interface{}{
+					map[string]interface{}{
+						"type": "Resource",
+						"resource": map[string]interface{}{
+							"name":   "cpu",
+							"target": map[string]interface{}{"type": "Utilization", "averageUtilization": int64(70)},
+						},
+					},
+				},
+			},
+			"status": map[string]interface{}{
+				"currentReplicas": int64(3),
+				"desiredReplicas": int64(4),
+				"currentMetrics": []interface{}{
+					map[string]interface{}{
+						"type": "Resource",
+						"resource": map[string]interface{}{
+							"name":    "cpu",
+							"current": map[string]interface{}{"averageUtilization": int64(85), "averageValue": "170m"},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestKubernetes_GetHPAStatuses(t *testing.T) {
+	k := mockKubernetes()
+
+	hpaGvr := schema.GroupVersionResource{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}
+	deployGvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	_, _ = k.dynamicClient.Resource(deployGvr).Namespace("default").
+		Create(context.TODO(), createTestDeployment("web", "default", 3), metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(hpaGvr).Namespace("default").
+		Create(context.TODO(), createTestHPA("web", "default", "web"), metaV1.CreateOptions{})
+
+	statuses, err := k.GetHPAStatuses("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(statuses) != 1 {
+		t.Fatalf("Expected 1 HPA, got %d", len(statuses))
+	}
+
+	s := statuses[0]
+	if s.Target.Kind != "Deployment" || s.Target.Name != "web" || s.Target.UID != "web-uid" {
+		t.Errorf("Expected HPA to be linked to the web deployment, got %+v", s.Target)
+	}
+
+	if s.MinReplicas != 2 || s.MaxReplicas != 10 || s.CurrentReplicas != 3 || s.DesiredReplicas != 4 {
+		t.Errorf("Unexpected replica counts %+v", s)
+	}
+
+	if len(s.Metrics) != 1 || s.Metrics[0].Name != "cpu" || s.Metrics[0].Current != "85%" || s.Metrics[0].Target != "70%" {
+		t.Errorf("Expected cpu metric at 85%% of 70%%, got %+v", s.Metrics)
+	}
+}
+
+func TestHPAStatus_V2Beta1(t *testing.T) {
+	// Older objects put values in prefixed fields rather than current & target blocks
+	hpa := createTestHPA("web", "default", "web")
+	hpa.Object["spec"].(map[string]interface{})["metrics"] = []interface{}{
+		map[string]interface{}{
+			"type":     "Resource",
+			"resource": map[string]interface{}{"name": "memory", "targetAverageValue": "200Mi"},
+		},
+	}
+	hpa.Object["status"].(map[string]interface{})["currentMetrics"] = []interface{}{
+		map[string]interface{}{
+			"type":     "Resource",
+			"resource": map[string]interface{}{"name": "memory", "currentAverageValue": "150Mi"},
+		},
+	}
+
+	s := hpaStatus(hpa)
+
+	if len(s.Metrics) != 1 || s.Metrics[0].Current != "150Mi" || s.Metrics[0].Target != "200Mi" {
+		t.Errorf("Expected memory metric at 150Mi of 200Mi, got %+v", s.Metrics)
+	}
+}
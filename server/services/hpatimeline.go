@@ -0,0 +1,198 @@
+// ==========================================================================================
+// Timeline of an autoscaler's rescales joined with the replica changes of the workload it scales,
+// from the SuccessfulRescale events of the HPA & the ScalingReplicaSet events of a Deployment
+// Events expire after an hour by default, so the timeline only goes back as far as they do
+// ==========================================================================================
+
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"log"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var hpaGVR = schema.GroupVersionResource{
+	Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers",
+}
+
+// How long after a rescale the workload can change to the new size and still be linked to it
+const scalingLinkWindow = 2 * time.Minute
+
+var (
+	// e.g. "New size: 4; reason: cpu resource utilization (percentage of request) above target"
+	rescaleSizeRegex = regexp.MustCompile(`New size: (\d+)`)
+	// e.g. "Scaled up replica set web-5d4f8 from 2 to 4", older clusters leave out the "from 2"
+	replicaSetScaleRegex = regexp.MustCompile(`replica set (\S+) (?:from \d+ )?to (\d+)`)
+)
+
+// ScalingEntry is one change in a scaling timeline, either a rescale by the HPA or the workload scaling
+type ScalingEntry struct {
+	Time string `json:"time"`
+	// Source is "hpa" or "workload"
+	Source  string `json:"source"`
+	Reason  string `json:"reason"`
+	Message string `json:"message"`
+	// Replicas is the new size, left out when it can't be read from the message
+	Replicas *int64 `json:"replicas,omitempty"`
+	// ReplicaSet scaled by a Deployment, only on workload entries
+	ReplicaSet string `json:"replicaSet,omitempty"`
+	// Count is how many times the event happened, only the last time is given when more than one
+	Count int64 `json:"count"`
+	// Applied is when the workload was seen scaling to the new size, only on HPA entries
+	Applied string `json:"applied,omitempty"`
+	// Autoscaled is true when a workload change followed a rescale by the HPA, rather than being manual
+	Autoscaled bool `json:"autoscaled,omitempty"`
+
+	// When the event was last seen, for ordering the timeline
+	at time.Time
+}
+
+// ScalingTimeline is the scaling history of an HPA & its target, with the target's current state
+type ScalingTimeline struct {
+	HPA    string    `json:"hpa"`
+	Target HPATarget `json:"target"`
+	// Generation & status of the target, zero when it doesn't exist
+	Generation         int64 `json:"generation"`
+	ObservedGeneration int64 `json:"observedGeneration"`
+	Replicas           int64 `json:"replicas"`
+	ReadyReplicas      int64 `json:"readyReplicas"`
+	// Entries are oldest first, empty when there are no scaling events
+	Entries []ScalingEntry `json:"entries"`
+}
+
+// GetScalingTimeline joins the rescales of an HPA with the replica changes of the workload it scales
+func (k *Kubernetes) GetScalingTimeline(ns, name string) (*ScalingTimeline, error) {
+	if ns == "" || name == "" {
+		return nil, errors.New("namespace or hpa name is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	var hpa *unstructured.Unstructured
+
+	err := k.callNamespaceAPI(ns, func(ctx context.Context) (err error) {
+		hpa, err = k.dynamicClient.Resource(hpaGVR).Namespace(ns).Get(ctx, name, metaV1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		log.Printf("💥 Failed to get hpa %s in namespace %s: %v", name, ns, err)
+		return nil, err
+	}
+
+	status := hpaStatus(hpa)
+	timeline := &ScalingTimeline{HPA: name, Target: status.Target, Entries: []ScalingEntry{}}
+
+	// A missing target still has its events, so is left with zero values rather than being an error
+	if target, err := k.getByKind(ns, "", status.Target.Kind, status.Target.Name); err == nil {
+		timeline.Target.UID = target.GetUID()
+		timeline.Generation = target.GetGeneration()
+		timeline.ObservedGeneration, _, _ = unstructured.NestedInt64(target.Object, "status", "observedGeneration")
+		timeline.Replicas, _, _ = unstructured.NestedInt64(target.Object, "status", "replicas")
+		timeline.ReadyReplicas, _, _ = unstructured.NestedInt64(target.Object, "status", "readyReplicas")
+	}
+
+	events, err := k.getEvents(ns)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline.Entries = scalingEntries(events, name, status.Target)
+
+	return timeline, nil
+}
+
+// scalingEntries picks out the events of the HPA & its target, then links each rescale to the workload change
+func scalingEntries(events []unstructured.Unstructured, hpaName string, target HPATarget) []ScalingEntry {
+	entries := []ScalingEntry{}
+
+	for i := range events {
+		event := events[i].Object
+		kind, _, _ := unstructured.NestedString(event, "involvedObject", "kind")
+		name, _, _ := unstructured.NestedString(event, "involvedObject", "name")
+		reason, _, _ := unstructured.NestedString(event, "reason")
+		message, _, _ := unstructured.NestedString(event, "message")
+
+		entry := ScalingEntry{Reason: reason, Message: message}
+		entry.Count, _, _ = unstructured.NestedInt64(event, "count")
+
+		switch {
+		case kind == "HorizontalPodAutoscaler" && name == hpaName && reason == "SuccessfulRescale":
+			entry.Source = "hpa"
+			entry.Replicas = parseReplicas(rescaleSizeRegex, message, 1)
+		case kind == target.Kind && name == target.Name && reason == "ScalingReplicaSet":
+			entry.Source = "workload"
+			entry.Replicas = parseReplicas(replicaSetScaleRegex, message, 2)
+
+			if match := replicaSetScaleRegex.FindStringSubmatch(message); match != nil {
+				entry.ReplicaSet = match[1]
+			}
+		default:
+			continue
+		}
+
+		entry.Time, entry.at = eventLastSeen(&events[i])
+		entries = append(entries, entry)
+	}
+
+	// Rescales go before workload changes seen in the same second, as they cause them
+	slices.SortStableFunc(entries, func(a, b ScalingEntry) int {
+		return cmp.Or(a.at.Compare(b.at), cmp.Compare(a.Source, b.Source))
+	})
+
+	linkRescales(entries)
+
+	return entries
+}
+
+// linkRescales marks the first workload change to the new size after each rescale, within scalingLinkWindow
+// Entries must be oldest first, each workload change is linked to one rescale at most
+func linkRescales(entries []ScalingEntry) {
+	for i := range entries {
+		rescale := &entries[i]
+		if rescale.Source != "hpa" || rescale.Replicas == nil || rescale.at.IsZero() {
+			continue
+		}
+
+		for j := i + 1; j < len(entries); j++ {
+			if entries[j].at.Sub(rescale.at) > scalingLinkWindow {
+				break
+			}
+
+			change := &entries[j]
+			if change.Source == "workload" && !change.Autoscaled && change.Replicas != nil &&
+				*change.Replicas == *rescale.Replicas {
+				rescale.Applied = change.Time
+				change.Autoscaled = true
+
+				break
+			}
+		}
+	}
+}
+
+// parseReplicas reads a replica count from a group of a regex match, nil when the message doesn't match
+func parseReplicas(re *regexp.Regexp, message string, group int) *int64 {
+	match := re.FindStringSubmatch(message)
+	if match == nil {
+		return nil
+	}
+
+	replicas, err := strconv.ParseInt(match[group], 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	return &replicas
+}
@@ -0,0 +1,93 @@
+// ==========================================================================================
+// Unit tests for the scaling timeline of an HPA & its target
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// createScalingEvent creates a core event about an object, last seen at the given time
+func createScalingEvent(name, kind, object, reason, message string, seen time.Time) *unstructured.Unstructured {
+	event := createTestCoreEvent(name, "default")
+	event.Object["involvedObject"] = map[string]interface{}{"kind": kind, "name": object}
+	event.Object["reason"] = reason
+	event.Object["message"] = message
+	event.Object["lastTimestamp"] = seen.UTC().Format(time.RFC3339)
+
+	return event
+}
+
+func TestKubernetes_GetScalingTimeline(t *testing.T) {
+	k := mockKubernetes()
+	now := time.Now().Truncate(time.Second)
+
+	deploy := createTestDeployment("web", "default", 4)
+	deploy.SetGeneration(3)
+	_ = unstructured.SetNestedField(deploy.Object, int64(3), "status", "observedGeneration")
+	_ = unstructured.SetNestedField(deploy.Object, int64(4), "status", "replicas")
+
+	_, _ = k.dynamicClient.Resource(deploymentGVR).Namespace("default").
+		Create(context.TODO(), deploy, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(hpaGVR).Namespace("default").
+		Create(context.TODO(), createTestHPA("web", "default", "web"), metaV1.CreateOptions{})
+
+	// Before any events there's an empty timeline, as events expire
+	timeline, err := k.GetScalingTimeline("default", "web")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(timeline.Entries) != 0 || timeline.Target.UID != "web-uid" || timeline.ObservedGeneration != 3 {
+		t.Errorf("Expected an empty timeline for the web deployment, got %+v", timeline)
+	}
+
+	events := []*unstructured.Unstructured{
+		createScalingEvent("manual", "Deployment", "web", "ScalingReplicaSet",
+			"Scaled up replica set web-5d4f8 to 2", now.Add(-10*time.Minute)),
+		createScalingEvent("rescale", "HorizontalPodAutoscaler", "web", "SuccessfulRescale",
+			"New size: 4; reason: cpu resource utilization (percentage of request) above target", now.Add(-time.Minute)),
+		createScalingEvent("scaled", "Deployment", "web", "ScalingReplicaSet",
+			"Scaled up replica set web-5d4f8 from 2 to 4", now.Add(-time.Minute+2*time.Second)),
+		createScalingEvent("other", "Deployment", "api", "ScalingReplicaSet",
+			"Scaled up replica set api-7c9 to 4", now.Add(-time.Minute)),
+	}
+
+	for _, event := range events {
+		_, _ = k.dynamicClient.Resource(coreEventGVR).Namespace("default").
+			Create(context.TODO(), event, metaV1.CreateOptions{})
+	}
+
+	timeline, err = k.GetScalingTimeline("default", "web")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	entries := timeline.Entries
+	if len(entries) != 3 {
+		t.Fatalf("Expected 3 entries for the HPA & its target, got %+v", entries)
+	}
+
+	if entries[0].Source != "workload" || entries[0].Autoscaled || *entries[0].Replicas != 2 {
+		t.Errorf("Expected the manual scale first & not autoscaled, got %+v", entries[0])
+	}
+
+	rescale, scaled := entries[1], entries[2]
+	if rescale.Source != "hpa" || *rescale.Replicas != 4 || rescale.Applied != scaled.Time {
+		t.Errorf("Expected the rescale to 4 to be applied when the deployment scaled, got %+v", rescale)
+	}
+
+	if !scaled.Autoscaled || scaled.ReplicaSet != "web-5d4f8" || *scaled.Replicas != 4 {
+		t.Errorf("Expected the deployment scale to 4 to be autoscaled, got %+v", scaled)
+	}
+
+	if _, err := k.GetScalingTimeline("default", "missing"); err == nil {
+		t.Error("Expected an error for a missing HPA")
+	}
+}
@@ -0,0 +1,122 @@
+// ==========================================================================================
+// Container image report, listing every image in a namespace and flagging mutable tags
+// An image with no tag, or the :latest tag, can change underneath a running workload
+// ==========================================================================================
+
+package services
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ImageUser is a container which uses an image
+type ImageUser struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Container string `json:"container"`
+	Init      bool   `json:"init"`
+}
+
+// ImageUsage is where an image is used, and if it is referenced by a mutable tag
+type ImageUsage struct {
+	// Mutable is true when the image has no tag or the :latest tag, and isn't pinned by digest
+	Mutable bool        `json:"mutable"`
+	UsedBy  []ImageUser `json:"usedBy"`
+}
+
+// Workload types walked for images, with the path to the pod spec in each
+var imageSources = []struct {
+	resource string
+	group    string
+	kind     string
+	specPath []string
+}{
+	{resource: "pods", group: "", kind: "Pod", specPath: []string{"spec"}},
+	{resource: "deployments", group: "apps", kind: "Deployment", specPath: []string{"spec", "template", "spec"}},
+	{resource: "statefulsets", group: "apps", kind: "StatefulSet", specPath: []string{"spec", "template", "spec"}},
+	{resource: "daemonsets", group: "apps", kind: "DaemonSet", specPath: []string{"spec", "template", "spec"}},
+}
+
+// GetImageReport returns every container image used in a namespace, keyed by image
+// Pods, Deployments, StatefulSets & DaemonSets are all included, along with their init containers
+func (k *Kubernetes) GetImageReport(ns string) (map[string]ImageUsage, error) {
+	if ns == "" {
+		return nil, errors.New("namespace is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	report := map[string]ImageUsage{}
+
+	for _, source := range imageSources {
+		items, err := k.GetResources(ns, source.group, "v1", source.resource)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			for _, user := range imageUsers(item, source.kind, source.specPath) {
+				usage := report[user.image]
+				usage.Mutable = isMutableImage(user.image)
+				usage.UsedBy = append(usage.UsedBy, user.ImageUser)
+				report[user.image] = usage
+			}
+		}
+	}
+
+	return report, nil
+}
+
+type imageUser struct {
+	ImageUser
+	image string
+}
+
+// imageUsers lists the image of each container & init container in a pod spec
+func imageUsers(obj unstructured.Unstructured, kind string, specPath []string) []imageUser {
+	users := []imageUser{}
+
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _, _ := unstructured.NestedSlice(obj.Object, slices.Concat(specPath, []string{field})...)
+
+		for _, c := range containers {
+			container, _ := c.(map[string]interface{})
+			image, _, _ := unstructured.NestedString(container, "image")
+			name, _, _ := unstructured.NestedString(container, "name")
+
+			if image == "" {
+				continue
+			}
+
+			users = append(users, imageUser{
+				ImageUser: ImageUser{Kind: kind, Name: obj.GetName(), Container: name, Init: field == "initContainers"},
+				image:     image,
+			})
+		}
+	}
+
+	return users
+}
+
+// isMutableImage checks if an image reference has no tag or the :latest tag, digests are never mutable
+func isMutableImage(image string) bool {
+	if strings.Contains(image, "@") {
+		return false
+	}
+
+	// The tag follows the last colon after the final slash, earlier colons are registry ports
+	name := image[strings.LastIndex(image, "/")+1:]
+
+	colon := strings.LastIndex(name, ":")
+	if colon < 0 {
+		return true
+	}
+
+	return name[colon+1:] == "latest"
+}
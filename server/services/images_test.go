@@ -0,0 +1,76 @@
+// ==========================================================================================
+// Unit tests for the container image report
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestKubernetes_GetImageReport(t *testing.T) {
+	k := mockKubernetes()
+
+	pod := createTestPod("web", "default")
+	_ = unstructured.SetNestedSlice(pod.Object, []interface{}{
+		map[string]interface{}{"name": "app", "image": "nginx:latest"},
+		map[string]interface{}{"name": "sidecar", "image": "registry.local:5000/proxy"},
+	}, "spec", "containers")
+	_ = unstructured.SetNestedSlice(pod.Object, []interface{}{
+		map[string]interface{}{"name": "setup", "image": "busybox:1.36"},
+	}, "spec", "initContainers")
+
+	deploy := createTestDeployment("api", "default", 1)
+	_ = unstructured.SetNestedSlice(deploy.Object, []interface{}{
+		map[string]interface{}{"name": "api", "image": "nginx:latest"},
+		map[string]interface{}{"name": "pinned", "image": "redis@sha256:abc123"},
+	}, "spec", "template", "spec", "containers")
+
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(kindResources["Deployment"]).Namespace("default").
+		Create(context.TODO(), deploy, metaV1.CreateOptions{})
+
+	report, err := k.GetImageReport("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		image   string
+		mutable bool
+		users   int
+	}{
+		{"nginx:latest", true, 2},
+		{"registry.local:5000/proxy", true, 1},
+		{"busybox:1.36", false, 1},
+		{"redis@sha256:abc123", false, 1},
+	}
+
+	for _, tt := range tests {
+		usage, ok := report[tt.image]
+		if !ok {
+			t.Errorf("Expected %s in the report", tt.image)
+			continue
+		}
+
+		if usage.Mutable != tt.mutable {
+			t.Errorf("Expected %s mutable to be %v", tt.image, tt.mutable)
+		}
+
+		if len(usage.UsedBy) != tt.users {
+			t.Errorf("Expected %s to be used by %d containers, got %d", tt.image, tt.users, len(usage.UsedBy))
+		}
+	}
+
+	if busybox := report["busybox:1.36"].UsedBy[0]; !busybox.Init || busybox.Kind != "Pod" {
+		t.Errorf("Expected busybox to be used by an init container in the pod, got %+v", busybox)
+	}
+
+	if _, err := k.GetImageReport(""); err == nil {
+		t.Error("Expected error for empty namespace, got nil")
+	}
+}
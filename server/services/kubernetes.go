@@ -5,14 +5,22 @@
 package services
 
 import (
+	"cmp"
 	"context"
 	"errors"
+	"fmt"
 	"log"
+	"math"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
-	"github.com/benc-uk/go-rest-api/pkg/sse"
+	"golang.org/x/time/rate"
 	coreV1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -24,6 +32,10 @@ import (
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+
+	// Registers the auth provider plugins (oidc, plus stubs for the removed azure & gcp providers)
+	// Exec credential plugins, e.g. kubelogin, are handled natively by client-go from the kubeconfig
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
 )
 
 // Kubernetes is a service that connects to a Kubernetes cluster and provides access to its resources
@@ -34,14 +46,121 @@ type Kubernetes struct {
 	Mode              string // "in-cluster" or "out-of-cluster"
 	KubeVersion       string
 	UseEndpointSlices bool
+	// UseEventsV1 is set when the cluster serves events.k8s.io/v1, which is then used rather than core/v1
+	UseEventsV1 bool
+	// RequestTimeout is applied to each call made to the Kubernetes API, zero means use the default
+	RequestTimeout time.Duration
+	// ReadOnly blocks all operations which would modify resources in the cluster
+	ReadOnly bool
+	// PortForwardEnabled allows forwarding to pod ports, it's also blocked by ReadOnly
+	PortForwardEnabled bool
+	// RedactSecrets hides the values held in Secrets & ConfigMaps, and env vars sourced from Secrets
+	RedactSecrets bool
+	// SingleNamespace when set is the only namespace which can be seen, whatever the allowlist says
+	SingleNamespace string
+	// NamespaceAllowlist when not empty is the only namespaces which can be seen, see namespaces.go
+	NamespaceAllowlist []string
+	// NamespaceDenylist are namespaces which can never be seen, this wins over the allowlist
+	NamespaceDenylist []string
+	// SecretTypeDenylist are types of Secret left out when fetching, e.g. "helm.sh/release.v1"
+	SecretTypeDenylist []string
+	// RateLimitQPS limits the calls made to the Kubernetes API per second, zero means no limit
+	RateLimitQPS float64
+	// RateLimitBurst is how many calls can be made at once above the QPS, at least one is always allowed
+	RateLimitBurst int
+	// MaxObjectBytes truncates the data of ConfigMaps & Secrets bigger than this when fetched, zero means no limit
+	MaxObjectBytes int
+	// MaxObjects is the most resources of each type returned when fetching a namespace, the most recently
+	// created are kept. Zero means no limit
+	MaxObjects int
+	// MaxLogLines is the most lines of logs which can be asked for, larger counts are clamped, zero uses the default
+	MaxLogLines int
+	// Trimmer is applied to every object listed, when nil DefaultTrimmer is used, see trim.go
+	Trimmer Trimmer
+	// RedactKeys are glob patterns for keys whose values are redacted in every object returned, see KeyRedactor
+	RedactKeys []string
+
+	// Created on first use from the rate limit fields
+	limiter     *rate.Limiter
+	limiterOnce sync.Once
+	// Each namespace also has its own limiter, so one busy namespace can't queue ahead of all the others, at most
+	// maxNamespaceLimiters of them
+	nsLimiters   map[string]*rate.Limiter
+	nsLimitersMu sync.Mutex
+
+	// Used to open websockets to the API server, which client-go's clients don't cover, see exec.go
+	restConfig *rest.Config
+
+	// Cached result of GetAPIResources, see discovery.go
+	apiResources     []APIResource
+	apiResourcesTime time.Time
+	discoveryMu      sync.Mutex
+	// Cached result of GetServerInfo, also guarded by discoveryMu
+	serverInfo     *ServerInfo
+	serverInfoTime time.Time
+
+	// Informers run until stopInformers is closed, see Shutdown
+	informers     dynamicinformer.DynamicSharedInformerFactory
+	stopInformers chan struct{}
+	stopOnce      sync.Once
+}
+
+// ErrReadOnly is returned by any operation which would modify the cluster, when in read-only mode
+var ErrReadOnly = errors.New("operation not permitted, server is in read-only mode")
+
+// dryRunOption is the DryRun option for calls which modify the cluster, with dry run set the API server
+// validates & returns the result of the change without persisting it
+func dryRunOption(dryRun bool) []string {
+	if dryRun {
+		return []string{metaV1.DryRunAll}
+	}
+
+	return nil
+}
+
+// dryRunNote is added to log messages about changes made with dry run, so it's clear nothing changed
+func dryRunNote(dryRun bool) string {
+	if dryRun {
+		return " (dry run)"
+	}
+
+	return ""
 }
 
+// ErrNamespaceNotFound is returned when fetching a namespace which doesn't exist
+var ErrNamespaceNotFound = errors.New("namespace not found")
+
+// Replaces any sensitive value hidden by RedactSecrets
+const redactedValue = "*REDACTED*"
+
+// Computed field added to fetched resources, see setAgeSeconds
+const ageSecondsField = "ageSeconds"
+
+// Default timeout applied to each Kubernetes API call, when RequestTimeout is not set
+const defaultRequestTimeout = 10 * time.Second
+
+// Most namespaces with their own rate limiter, idle limiters are dropped to make room beyond this
+const maxNamespaceLimiters = 256
+
 // This is used by the SSE broker to send events to connected clients
 type KubeEvent struct {
 	// EventType is the type of event, e.g. "add", "update", "delete" or "ping"
 	EventType EventTypeEnum
 	// Object is the Kubernetes resource that triggered the event
 	Object *unstructured.Unstructured
+	// Cluster is the name of the cluster the event came from, only set when sent with a NewClusterSender
+	Cluster string
+}
+
+// ResourceChunk is every resource of one type in a namespace, e.g. all the pods
+type ResourceChunk struct {
+	// Kind is the plural resource name e.g. "pods", the same key used by FetchNamespace
+	Kind  string                      `json:"kind"`
+	Items []unstructured.Unstructured `json:"items"`
+	// Hidden is how many resources of this type were left out, see FetchOptions.IncludeCompletedJobPods
+	Hidden int `json:"hidden,omitempty"`
+	// Truncated is how many more resources of this type there are, left out as over Kubernetes.MaxObjects
+	Truncated int `json:"truncated,omitempty"`
 }
 
 // EventTypeEnum is an enum for the type of event
@@ -56,36 +175,98 @@ const (
 	DeleteEvent EventTypeEnum = "delete"
 	// PingEvent is a heartbeat event to keep the connection alive
 	PingEvent EventTypeEnum = "ping"
+	// ShutdownEvent tells clients the server is going away, so they should back off before reconnecting
+	ShutdownEvent EventTypeEnum = "shutdown"
+	// ChunkEvent carries a ResourceChunk when a namespace is streamed
+	ChunkEvent EventTypeEnum = "chunk"
+	// DoneEvent is sent when every chunk of a streamed namespace has been sent, or a drain has finished
+	DoneEvent EventTypeEnum = "done"
+	// DrainEvent carries a DrainProgress while a node is drained
+	DrainEvent EventTypeEnum = "drain"
 )
 
-// NewKubernetes creates a new Kubernetes service instance
-// - needs an SSE broker to send events to connected clients
-func NewKubernetes(sseBroker *sse.Broker[KubeEvent], singleNamespace string) (*Kubernetes, error) {
-	var kubeConfig *rest.Config
-
-	var err error
-
-	mode := "out-of-cluster" // Default to out-of-cluster mode
+// Options used when creating the Kubernetes service, all fields are optional
+type Options struct {
+	// SingleNamespace restricts the service to watching & accessing a single namespace
+	SingleNamespace string
+	// KubeconfigPath is an explicit kubeconfig file to load, rather than $KUBECONFIG or $HOME/.kube/config
+	KubeconfigPath string
+	// KubeContext is the name of the context to use from the kubeconfig, rather than the current context
+	KubeContext string
+	// CAFile is a CA bundle used to verify the API server, for clusters using a private CA
+	CAFile string
+	// InsecureSkipTLSVerify turns off verification of the API server certificate, never use in production
+	InsecureSkipTLSVerify bool
+	// UserAgent is sent with every request so KubeView shows up in audit logs, see UserAgent()
+	UserAgent string
+	// ReadOnly blocks all operations which would modify resources in the cluster
+	ReadOnly bool
+	// EnablePortForward allows forwarding to pod ports, it's off by default as it reaches inside the cluster
+	EnablePortForward bool
+	// RedactSecrets hides sensitive values, see Kubernetes.RedactSecrets
+	RedactSecrets bool
+	// NamespaceAllowlist & NamespaceDenylist restrict which namespaces can be seen
+	NamespaceAllowlist []string
+	NamespaceDenylist  []string
+	// SecretTypeDenylist are types of Secret which are never returned, see Kubernetes.SecretTypeDenylist
+	SecretTypeDenylist []string
+	// WatchedResources limits which resource types are watched for live updates, by plural name e.g. "pods"
+	// Resources not watched are still fetched on demand. When empty all watchable resources are watched
+	WatchedResources []string
+	// WatchKindDenylist are kinds never watched for live updates, e.g. "Event", even when in WatchedResources
+	// They are still fetched on demand
+	WatchKindDenylist []string
+	// CoalesceWindow is how long updates to an object are held back, only the latest is sent. Zero turns it off
+	CoalesceWindow time.Duration
+	// PollInterval is how often resources which can be listed but not watched are polled, zero turns it off
+	PollInterval time.Duration
+	// EventTrimmer is applied to the objects in add & update events before they're sent, when nil DefaultTrimmer
+	// is used. Objects in delete events are always cut down to their kind, name, namespace & UID
+	EventTrimmer Trimmer
+	// ResyncPeriod is how often informers send every object again as an update, so clients recover from any
+	// missed watch events. Zero turns it off
+	ResyncPeriod time.Duration
+	// RedactKeys are glob patterns for keys whose values are redacted, e.g. "*token*", see KeyRedactor
+	RedactKeys []string
+}
 
-	// In cluster connect using in-cluster "magic", else build config from .kube/config file
-	if inCluster() {
-		log.Println("⚓ Running in cluster, will try to use cluster config")
+// EventSender sends KubeEvents to groups of connected clients, this is normally the SSE broker
+type EventSender interface {
+	SendToGroup(group string, message KubeEvent)
+}
 
-		kubeConfig, err = rest.InClusterConfig()
-		mode = "in-cluster"
-	} else {
-		// Default location for kubeconfig file is $HOME/.kube/config
-		kubeconfigFile := filepath.Join(os.Getenv("HOME"), ".kube", "config")
+// All the resource types which can be watched for live updates
+// Endpoints, EndpointSlices & Events are left out, as which API is watched depends on the cluster
+var watchableResources = []schema.GroupVersionResource{
+	{Group: "", Version: "v1", Resource: "pods"},
+	{Group: "", Version: "v1", Resource: "services"},
+	{Group: "apps", Version: "v1", Resource: "deployments"},
+	{Group: "apps", Version: "v1", Resource: "replicasets"},
+	{Group: "apps", Version: "v1", Resource: "statefulsets"},
+	{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
+	{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"},
+	{Group: "batch", Version: "v1", Resource: "jobs"},
+	{Group: "batch", Version: "v1", Resource: "cronjobs"},
+	{Group: "", Version: "v1", Resource: "persistentvolumeclaims"},
+	{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"},
+	{Group: "", Version: "v1", Resource: "configmaps"},
+	{Group: "", Version: "v1", Resource: "secrets"},
+}
 
-		// If KUBECONFIG environment variable is set, use that instead
-		if os.Getenv("KUBECONFIG") != "" {
-			kubeconfigFile = os.Getenv("KUBECONFIG")
-		}
+// Leases are only watched when named in WatchedResources, leader election renews them every few seconds
+// which would flood the update stream if they were watched by default
+var leaseGVR = schema.GroupVersionResource{Group: "coordination.k8s.io", Version: "v1", Resource: "leases"}
 
-		log.Println("🏠 Running outside cluster, will use config file:", kubeconfigFile)
-		kubeConfig, err = clientcmd.BuildConfigFromFlags("", kubeconfigFile)
-	}
+// NewKubernetes creates a new Kubernetes service instance
+// - needs an SSE broker to send events to connected clients
+func NewKubernetes(sseBroker EventSender, singleNamespace string) (*Kubernetes, error) {
+	return NewKubernetesWithOptions(sseBroker, Options{SingleNamespace: singleNamespace, RedactSecrets: true})
+}
 
+// NewKubernetesWithOptions creates a new Kubernetes service instance, with extra connection options
+// - needs an SSE broker to send events to connected clients
+func NewKubernetesWithOptions(sseBroker EventSender, opts Options) (*Kubernetes, error) {
+	kubeConfig, mode, err := buildRestConfig(opts)
 	if err != nil {
 		return nil, err
 	}
@@ -118,6 +299,11 @@ func NewKubernetes(sseBroker *sse.Broker[KubeEvent], singleNamespace string) (*K
 		useEndpointSlices = true
 	}
 
+	useEventsV1 := detectEventsV1(discClient)
+	if useEventsV1 {
+		log.Println("📰 Using events.k8s.io/v1 API for events")
+	}
+
 	// Use the dynamic client to interact with the Kubernetes API
 	// This allows us to work with any resource type without needing to know the schema in advance
 	dynamicClient, err := dynamic.NewForConfig(kubeConfig)
@@ -133,224 +319,464 @@ func NewKubernetes(sseBroker *sse.Broker[KubeEvent], singleNamespace string) (*K
 	}
 
 	namespace := coreV1.NamespaceAll // Work in all namespaces
-	if singleNamespace != "" {
-		namespace = singleNamespace
+	if opts.SingleNamespace != "" {
+		namespace = opts.SingleNamespace
 		log.Println("🔑 Authorised for a single namespace:", namespace)
 	}
 
 	log.Println("👀 Setting up resource watchers...")
 
-	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(
-		dynamicClient, time.Minute, namespace, nil)
-
-	// Add listening event handlers for ALL resources we want to track
-	_, _ = factory.ForResource(schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}).
-		Informer().
-		AddEventHandler(getHandlerFuncs(sseBroker))
-
-	_, _ = factory.ForResource(schema.GroupVersionResource{Group: "", Version: "v1", Resource: "services"}).
-		Informer().
-		AddEventHandler(getHandlerFuncs(sseBroker))
-
-	_, _ = factory.ForResource(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}).
-		Informer().
-		AddEventHandler(getHandlerFuncs(sseBroker))
-
-	_, _ = factory.ForResource(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}).
-		Informer().
-		AddEventHandler(getHandlerFuncs(sseBroker))
+	resources := withoutDeniedKinds(resourcesToWatch(opts.WatchedResources, useEndpointSlices, useEventsV1),
+		opts.WatchKindDenylist)
+	stopInformers := make(chan struct{})
 
-	_, _ = factory.ForResource(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "statefulsets"}).
-		Informer().
-		AddEventHandler(getHandlerFuncs(sseBroker))
-
-	_, _ = factory.ForResource(schema.GroupVersionResource{Group: "networking.k8s.io",
-		Version: "v1", Resource: "ingresses"}).
-		Informer().
-		AddEventHandler(getHandlerFuncs(sseBroker))
-
-	_, _ = factory.ForResource(schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}).
-		Informer().
-		AddEventHandler(getHandlerFuncs(sseBroker))
-
-	_, _ = factory.ForResource(schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "cronjobs"}).
-		Informer().
-		AddEventHandler(getHandlerFuncs(sseBroker))
-
-	_, _ = factory.ForResource(schema.GroupVersionResource{Group: "",
-		Version: "v1", Resource: "persistentvolumeclaims"}).
-		Informer().
-		AddEventHandler(getHandlerFuncs(sseBroker))
-
-	_, _ = factory.ForResource(schema.GroupVersionResource{Group: "", Version: "v1", Resource: "events"}).
-		Informer().
-		AddEventHandler(getHandlerFuncs(sseBroker))
-
-	_, _ = factory.ForResource(schema.GroupVersionResource{Group: "autoscaling", Version: "v2",
-		Resource: "horizontalpodautoscalers"}).
-		Informer().
-		AddEventHandler(getHandlerFuncs(sseBroker))
-
-	if useEndpointSlices {
-		_, _ = factory.ForResource(schema.GroupVersionResource{Group: "discovery.k8s.io",
-			Version: "v1", Resource: "endpointslices"}).
-			Informer().
-			AddEventHandler(getHandlerFuncs(sseBroker))
-	} else {
-		_, _ = factory.ForResource(schema.GroupVersionResource{Group: "", Version: "v1", Resource: "endpoints"}).
-			Informer().
-			AddEventHandler(getHandlerFuncs(sseBroker))
+	// Bursts of updates to the same object are collapsed into one, rather than flooding clients
+	sender := sseBroker
+	if opts.CoalesceWindow > 0 {
+		sender = newCoalescingSender(sseBroker, opts.CoalesceWindow, stopInformers)
 	}
 
-	_, _ = factory.ForResource(schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}).
-		Informer().
-		AddEventHandler(getHandlerFuncs(sseBroker))
-
-	_, _ = factory.ForResource(schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}).
-		Informer().
-		AddEventHandler(getHandlerFuncs(sseBroker))
+	sender = newTrimmingSender(sender, withRedaction(opts.EventTrimmer, opts.RedactKeys))
 
-	factory.Start(context.Background().Done())
-	factory.WaitForCacheSync(context.Background().Done())
+	informers := startInformers(stopInformers, dynamicClient, namespace, sender, resources, opts.PollInterval,
+		opts.ResyncPeriod)
 
-	return &Kubernetes{
+	k := &Kubernetes{
 		dynamicClient:     dynamicClient,
 		clientSet:         clientSet, // Deprecated, use client instead
+		restConfig:        kubeConfig,
 		ClusterHost:       kubeConfig.Host,
 		Mode:              mode,
 		UseEndpointSlices: useEndpointSlices,
+		UseEventsV1:       useEventsV1,
 		KubeVersion:       serverVersion.String(),
-	}, nil
+		RequestTimeout:    defaultRequestTimeout,
+		ReadOnly:          opts.ReadOnly,
+		RedactSecrets:     opts.RedactSecrets,
+		RedactKeys:        opts.RedactKeys,
+		informers:         informers,
+		stopInformers:     stopInformers,
+
+		PortForwardEnabled: opts.EnablePortForward,
+
+		SingleNamespace:    opts.SingleNamespace,
+		NamespaceAllowlist: opts.NamespaceAllowlist,
+		NamespaceDenylist:  opts.NamespaceDenylist,
+		SecretTypeDenylist: opts.SecretTypeDenylist,
+	}
+
+	// New CRDs need discovery refreshing before they can be found, see GetAPIResources
+	k.watchCRDs(stopInformers)
+
+	return k, nil
 }
 
 // Get namespaces
 func (k *Kubernetes) GetNamespaces() ([]string, error) {
-	out := []string{}
-
-	// Use the dynamicClient to get the list of namespaces
-	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
-
-	l, err := k.dynamicClient.Resource(gvr).List(context.TODO(), metaV1.ListOptions{})
+	statuses, err := k.GetNamespaceStatuses()
 	if err != nil {
-		log.Println("💥 Failed to get namespaces:", err)
 		return nil, err
 	}
 
-	// Iterate over the namespaces and add them to the list
-	for _, ns := range l.Items {
-		out = append(out, ns.GetName())
+	out := make([]string, 0, len(statuses))
+	for _, ns := range statuses {
+		out = append(out, ns.Name)
 	}
 
 	return out, nil
 }
 
-// Validate if a namespace exists in the cluster
+// Validate if a namespace exists in the cluster, namespaces which aren't permitted never exist
 func (k *Kubernetes) CheckNamespaceExists(ns string) bool {
+	if !k.NamespacePermitted(ns) {
+		return false
+	}
+
 	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
 
 	// Try to get the namespace
-	_, err := k.dynamicClient.Resource(gvr).Get(context.TODO(), ns, metaV1.GetOptions{})
+	err := k.callAPI(func(ctx context.Context) error {
+		_, err := k.dynamicClient.Resource(gvr).Get(ctx, ns, metaV1.GetOptions{})
+		return err
+	})
 
 	return err == nil
 }
 
+// FetchOptions control which resources are returned when fetching a namespace
+// The zero value returns everything, except the pods of Jobs which have completed
+type FetchOptions struct {
+	// MinAge excludes resources created more recently than this, zero means no minimum
+	MinAge time.Duration
+	// MaxAge excludes resources created longer ago than this, zero means no maximum
+	MaxAge time.Duration
+	// Timing is called with how long each resource type took to fetch, when set
+	Timing func(kind string, elapsed time.Duration)
+	// IncludeCompletedJobPods returns pods which have succeeded & are owned by a Job, these are hidden by default
+	// as finished Jobs leave them behind, cluttering the namespace
+	IncludeCompletedJobPods bool
+	// Truncated is called for each resource type cut down to MaxObjects, with how many were left out, when set
+	Truncated func(kind string, more int)
+}
+
 // Retrieves all resources in a specific namespace and returns them in a big ol' map
 func (k *Kubernetes) FetchNamespace(ns string) (map[string][]unstructured.Unstructured, error) {
+	return k.FetchNamespaceWithOptions(ns, FetchOptions{})
+}
+
+// Retrieves all resources in a specific namespace, filtered by the given options
+func (k *Kubernetes) FetchNamespaceWithOptions(ns string,
+	opts FetchOptions) (map[string][]unstructured.Unstructured, error) {
+	data := make(map[string][]unstructured.Unstructured)
+
+	err := k.StreamNamespace(ns, opts, func(chunk ResourceChunk) {
+		data[chunk.Kind] = chunk.Items
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// StreamNamespace fetches the resources in a namespace one type at a time, passing each type to emit as
+// soon as it has been fetched. Pods come first & secrets last, so big namespaces can be shown progressively
+func (k *Kubernetes) StreamNamespace(ns string, opts FetchOptions, emit func(ResourceChunk)) error {
 	if ns == "" {
-		return nil, errors.New("namespace is empty")
+		return errors.New("namespace is empty")
 	}
 
-	data := make(map[string][]unstructured.Unstructured)
+	if err := k.checkNamespace(ns); err != nil {
+		return err
+	}
+
+	if !k.CheckNamespaceExists(ns) {
+		return fmt.Errorf("%w: %s", ErrNamespaceNotFound, ns)
+	}
+
+	now := time.Now()
 
-	podList, _ := k.GetResources(ns, "", "v1", "pods")
-	serviceList, _ := k.GetResources(ns, "", "v1", "services")
-	// endpointList, _ := k.getResources(ns, "", "v1", "endpoints")
-	deploymentList, _ := k.GetResources(ns, "apps", "v1", "deployments")
-	replicaSetList, _ := k.GetResources(ns, "apps", "v1", "replicasets")
-	statefulSetList, _ := k.GetResources(ns, "apps", "v1", "statefulsets")
-	daemonSetList, _ := k.GetResources(ns, "apps", "v1", "daemonsets")
-	jobList, _ := k.GetResources(ns, "batch", "v1", "jobs")
-	cronJobList, _ := k.GetResources(ns, "batch", "v1", "cronjobs")
-	ingressList, _ := k.GetResources(ns, "networking.k8s.io", "v1", "ingresses")
-	confMapList, _ := k.GetResources(ns, "", "v1", "configmaps")
-	secretList, _ := k.GetResources(ns, "", "v1", "secrets")
-	pvcList, _ := k.GetResources(ns, "", "v1", "persistentvolumeclaims")
-	eventList, _ := k.GetResources(ns, "", "v1", "events")
-	hpaList, _ := k.GetResources(ns, "autoscaling", "v2", "horizontalpodautoscalers")
-
-	data["pods"] = podList
-	data["services"] = serviceList
-	data["deployments"] = deploymentList
-	data["replicasets"] = replicaSetList
-	data["statefulsets"] = statefulSetList
-	data["daemonsets"] = daemonSetList
-	data["jobs"] = jobList
-	data["cronjobs"] = cronJobList
-	data["ingresses"] = ingressList
-	data["configmaps"] = confMapList
-	data["secrets"] = secretList
-	data["persistentvolumeclaims"] = pvcList
-	data["events"] = eventList
-	data["horizontalpodautoscalers"] = hpaList
+	for _, gvr := range k.namespaceResources() {
+		var items []unstructured.Unstructured
+
+		start := time.Now()
+
+		// Errors are ignored, a type which can't be fetched is simply empty
+		if gvr.Resource == "events" {
+			items, _ = k.getEvents(ns)
+		} else {
+			items, _ = k.GetResources(ns, gvr.Group, gvr.Version, gvr.Resource)
+		}
+
+		if gvr.Resource == "secrets" {
+			items = k.filterSecretTypes(items)
+		}
+
+		// Without access to endpoints the field is left off, rather than every service showing nothing ready
+		if gvr.Resource == "services" {
+			if byService, err := k.GetServiceEndpoints(ns); err == nil {
+				setServiceEndpoints(items, byService)
+			}
+		}
+
+		if opts.MinAge > 0 || opts.MaxAge > 0 {
+			items = filterByAge(items, opts.MinAge, opts.MaxAge, now)
+		}
+
+		hidden := 0
+		if gvr.Resource == "pods" && !opts.IncludeCompletedJobPods {
+			items, hidden = hideCompletedJobPods(items)
+		}
+
+		items, truncated := keepNewest(items, k.MaxObjects)
+		if truncated > 0 && opts.Truncated != nil {
+			opts.Truncated(gvr.Resource, truncated)
+		}
+
+		k.cleanResources(items)
+		setAgeSeconds(items, now)
+		setHealth(items)
+
+		if opts.Timing != nil {
+			opts.Timing(gvr.Resource, time.Since(start))
+		}
+
+		emit(ResourceChunk{Kind: gvr.Resource, Items: items, Hidden: hidden, Truncated: truncated})
+	}
+
+	return nil
+}
+
+// namespaceResources lists the resource types fetched for a namespace, in the order they are fetched
+func (k *Kubernetes) namespaceResources() []schema.GroupVersionResource {
+	resources := []schema.GroupVersionResource{
+		{Group: "", Version: "v1", Resource: "pods"},
+		{Group: "", Version: "v1", Resource: "services"},
+		{Group: "apps", Version: "v1", Resource: "deployments"},
+		{Group: "apps", Version: "v1", Resource: "replicasets"},
+		{Group: "apps", Version: "v1", Resource: "statefulsets"},
+		{Group: "apps", Version: "v1", Resource: "daemonsets"},
+		{Group: "batch", Version: "v1", Resource: "jobs"},
+		{Group: "batch", Version: "v1", Resource: "cronjobs"},
+		{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"},
+		{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"},
+		{Group: "", Version: "v1", Resource: "configmaps"},
+		{Group: "", Version: "v1", Resource: "persistentvolumeclaims"},
+		{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"},
+		{Group: "", Version: "v1", Resource: "resourcequotas"},
+		{Group: "", Version: "v1", Resource: "limitranges"},
+		{Group: "", Version: "v1", Resource: "serviceaccounts"},
+		leaseGVR,
+		eventsGVR(k.UseEventsV1),
+	}
 
 	// If we are using EndpointSlices, get those instead of Endpoints
 	if k.UseEndpointSlices {
-		endpointList, _ := k.GetResources(ns, "discovery.k8s.io", "v1", "endpointslices")
-		data["endpointslices"] = endpointList
+		resources = append(resources, schema.GroupVersionResource{
+			Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices",
+		})
 	} else {
-		endpointList, _ := k.GetResources(ns, "", "v1", "endpoints")
-		data["endpoints"] = endpointList
-	}
-
-	// Clean up the managed fields and redact sensitive data
-	for _, items := range data {
-		for i := range items {
-			// Managed fields are simply clutter
-			items[i].SetManagedFields(nil)
-
-			// Loop through the data field of Secrets & ConfigMaps and redact it
-			if items[i].GetKind() == "Secret" || items[i].GetKind() == "ConfigMap" {
-				if data, ok := items[i].Object["data"].(map[string]interface{}); ok {
-					for k := range data {
-						data[k] = "*REDACTED*"
-					}
-				}
+		resources = append(resources, schema.GroupVersionResource{Group: "", Version: "v1", Resource: "endpoints"})
+	}
+
+	return append(resources, schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"})
+}
+
+// cleanResources redacts sensitive data and truncates huge data, managed fields are removed by the Trimmer
+func (k *Kubernetes) cleanResources(items []unstructured.Unstructured) {
+	for i := range items {
+		if items[i].GetKind() != "Secret" && items[i].GetKind() != "ConfigMap" {
+			continue
+		}
+
+		// Loop through the data field of Secrets & ConfigMaps and redact it
+		if data, ok := items[i].Object["data"].(map[string]interface{}); ok && k.RedactSecrets {
+			for key := range data {
+				data[key] = redactedValue
 			}
 		}
+
+		// kubectl apply leaves a copy of the values in an annotation, which needs redacting too
+		if k.RedactSecrets {
+			redactLastApplied(&items[i])
+		}
+
+		if k.MaxObjectBytes > 0 {
+			truncateData(&items[i], k.MaxObjectBytes)
+		}
 	}
+}
 
-	return data, nil
+// cleanObject returns a copy of a single object ready to be returned, trimmed & cleaned like a listed one
+// Every object the API returns goes through this or GetResources, so RedactKeys & RedactSecrets always apply
+func (k *Kubernetes) cleanObject(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	items := []unstructured.Unstructured{*obj.DeepCopy()}
+
+	k.trimmer().Trim(&items[0])
+	k.cleanResources(items)
+
+	return &items[0]
 }
 
-// Generic function to list resources from a specific namespace
+// filterSecretTypes drops Secrets with a type in the denylist, they are noise such as Helm release data
+func (k *Kubernetes) filterSecretTypes(secrets []unstructured.Unstructured) []unstructured.Unstructured {
+	if len(k.SecretTypeDenylist) == 0 {
+		return secrets
+	}
+
+	return slices.DeleteFunc(secrets, func(secret unstructured.Unstructured) bool {
+		secretType, _, _ := unstructured.NestedString(secret.Object, "type")
+		return slices.Contains(k.SecretTypeDenylist, secretType)
+	})
+}
+
+// filterByAge keeps only resources with an age between minAge and maxAge, where zero means no limit
+// Resources without a creation timestamp can't be judged, so they are always kept
+func filterByAge(items []unstructured.Unstructured,
+	minAge, maxAge time.Duration, now time.Time) []unstructured.Unstructured {
+	filtered := make([]unstructured.Unstructured, 0, len(items))
+
+	for _, item := range items {
+		created := item.GetCreationTimestamp()
+		if created.IsZero() {
+			filtered = append(filtered, item)
+			continue
+		}
+
+		age := now.Sub(created.Time)
+		if (minAge > 0 && age < minAge) || (maxAge > 0 && age > maxAge) {
+			continue
+		}
+
+		filtered = append(filtered, item)
+	}
+
+	return filtered
+}
+
+// hideCompletedJobPods drops pods which have succeeded & are controlled by a Job, returning how many were dropped
+func hideCompletedJobPods(pods []unstructured.Unstructured) ([]unstructured.Unstructured, int) {
+	total := len(pods)
+
+	pods = slices.DeleteFunc(pods, func(pod unstructured.Unstructured) bool {
+		phase, _, _ := unstructured.NestedString(pod.Object, "status", "phase")
+		owner := metaV1.GetControllerOf(&pod)
+
+		return phase == string(coreV1.PodSucceeded) && owner != nil && owner.Kind == "Job"
+	})
+
+	return pods, total - len(pods)
+}
+
+// keepNewest cuts the resources down to the most recently created, keeping their order, zero means no limit
+// Returns how many were dropped. Resources with no creation timestamp count as the oldest
+func keepNewest(items []unstructured.Unstructured, limit int) ([]unstructured.Unstructured, int) {
+	if limit <= 0 || len(items) <= limit {
+		return items, 0
+	}
+
+	newest := make([]int, len(items))
+	for i := range newest {
+		newest[i] = i
+	}
+
+	slices.SortStableFunc(newest, func(a, b int) int {
+		return items[b].GetCreationTimestamp().Time.Compare(items[a].GetCreationTimestamp().Time)
+	})
+
+	keep := make(map[int]bool, limit)
+	for _, i := range newest[:limit] {
+		keep[i] = true
+	}
+
+	kept := make([]unstructured.Unstructured, 0, limit)
+
+	for i := range items {
+		if keep[i] {
+			kept = append(kept, items[i])
+		}
+	}
+
+	return kept, len(items) - limit
+}
+
+// setAgeSeconds adds an ageSeconds field to each resource, how long ago it was created by the server's clock
+// so ages are consistent however skewed the browser's clock is. Without a creation timestamp it's null
+func setAgeSeconds(items []unstructured.Unstructured, now time.Time) {
+	for i := range items {
+		created := items[i].GetCreationTimestamp()
+		if created.IsZero() {
+			items[i].Object[ageSecondsField] = nil
+			continue
+		}
+
+		items[i].Object[ageSecondsField] = int64(max(now.Sub(created.Time), 0).Seconds())
+	}
+}
+
+// SortOrder is the order in which listed resources are returned
+type SortOrder string
+
+const (
+	// SortByName sorts resources alphabetically by name, this is the default
+	SortByName SortOrder = "name"
+	// SortByCreated sorts resources by creation time, oldest first
+	SortByCreated SortOrder = "created"
+	// SortByCreatedDesc sorts resources by creation time, newest first
+	SortByCreatedDesc SortOrder = "created-desc"
+)
+
+// Generic function to list resources from a specific namespace, sorted by name
 func (k *Kubernetes) GetResources(ns string, grp string, ver string, res string) ([]unstructured.Unstructured, error) {
+	return k.GetResourcesSorted(ns, grp, ver, res, SortByName)
+}
+
+// List resources from a specific namespace, sorted in the given order
+// The API server returns objects in no guaranteed order, so sorting keeps the UI & tests stable
+func (k *Kubernetes) GetResourcesSorted(ns, grp, ver, res string,
+	order SortOrder) ([]unstructured.Unstructured, error) {
+	if order != SortByName && order != SortByCreated && order != SortByCreatedDesc {
+		return nil, errors.New("invalid sort order: " + string(order))
+	}
+
 	gvr := schema.GroupVersionResource{Group: grp, Version: ver, Resource: res}
 
-	l, err := k.dynamicClient.Resource(gvr).Namespace(ns).List(context.TODO(), metaV1.ListOptions{Limit: 1000})
+	var l *unstructured.UnstructuredList
+
+	err := k.callNamespaceAPI(ns, func(ctx context.Context) (err error) {
+		l, err = k.dynamicClient.Resource(gvr).Namespace(ns).List(ctx, metaV1.ListOptions{Limit: 1000})
+		return err
+	})
 	if err != nil {
 		log.Printf("💥 Failed to get %s %v", res, err)
 		return nil, err
 	}
 
-	return l.Items, nil
+	// Deep copy the items, so redaction & trimming never mutates an object shared with another consumer
+	items := make([]unstructured.Unstructured, len(l.Items))
+	trimmer := k.trimmer()
+
+	for i := range l.Items {
+		items[i] = *l.Items[i].DeepCopy()
+		trimmer.Trim(&items[i])
+	}
+
+	sortResources(items, order)
+
+	return items, nil
+}
+
+// sortResources sorts a slice of resources in place, ties are always broken by name
+func sortResources(items []unstructured.Unstructured, order SortOrder) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if order != SortByName {
+			ti := items[i].GetCreationTimestamp()
+			tj := items[j].GetCreationTimestamp()
+
+			if !ti.Equal(&tj) {
+				if order == SortByCreatedDesc {
+					return tj.Before(&ti)
+				}
+
+				return ti.Before(&tj)
+			}
+		}
+
+		return items[i].GetName() < items[j].GetName()
+	})
 }
 
 // Retrieves the logs of a specific pod in a given namespace
 func (k *Kubernetes) GetPodLogs(ns, podName string, lineCount int) (string, error) {
+	return k.GetPodLogsWithOptions(ns, podName, lineCount, LogOptions{})
+}
+
+// Retrieves the logs of a pod, limited to a window of time by the given options
+func (k *Kubernetes) GetPodLogsWithOptions(ns, podName string, lineCount int, opts LogOptions) (string, error) {
 	if ns == "" || podName == "" {
 		return "", errors.New("namespace or pod name is empty")
 	}
 
-	if lineCount <= 0 {
-		lineCount = 100 // Default to 100 lines if not specified
+	if err := k.checkNamespace(ns); err != nil {
+		return "", err
 	}
 
+	lineCount, _ = k.LogLineLimit(lineCount)
+
 	// Get the lines of logs from the pod
-	req := k.clientSet.CoreV1().Pods(ns).GetLogs(podName, &coreV1.PodLogOptions{
-		TailLines: &[]int64{int64(lineCount)}[0], // We pass in how many lines we want to get
-	})
+	logOpts, err := opts.podLogOptions(lineCount)
+	if err != nil {
+		return "", err
+	}
 
-	logs, err := req.DoRaw(context.TODO())
+	req := k.clientSet.CoreV1().Pods(ns).GetLogs(podName, logOpts)
+
+	var logs []byte
+
+	err = k.callAPI(func(ctx context.Context) (err error) {
+		logs, err = req.DoRaw(ctx)
+		return err
+	})
 	if err != nil {
 		log.Printf("💥 Failed to get logs for pod %s in namespace %s: %v", podName, ns, err)
 		return "", err
@@ -359,6 +785,328 @@ func (k *Kubernetes) GetPodLogs(ns, podName string, lineCount int) (string, erro
 	return string(logs), nil
 }
 
+// callAPI runs a single Kubernetes API call with the request timeout applied via the context
+// The call runs in a goroutine so we always return once the deadline passes, rather than hanging
+func (k *Kubernetes) callAPI(call func(ctx context.Context) error) error {
+	return k.callNamespaceAPI("", call)
+}
+
+// callNamespaceAPI is callAPI for a call about a namespace, which first waits its turn within that namespace
+// The shared limiter hands out tokens in the order calls arrive, so a namespace making many calls at once
+// would hold up every other namespace behind it. Waiting on the namespace's own limiter first spaces out
+// a busy namespace's calls, leaving room for calls from other namespaces in between
+func (k *Kubernetes) callNamespaceAPI(ns string, call func(ctx context.Context) error) error {
+	timeout := k.RequestTimeout
+	if timeout <= 0 {
+		timeout = defaultRequestTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Waiting for the rate limiter counts against the timeout, it fails fast when the wait would be too long
+	if limiter := k.rateLimiter(); limiter != nil {
+		if ns != "" {
+			if err := k.namespaceLimiter(ns).Wait(ctx); err != nil {
+				return fmt.Errorf("kubernetes API call rate limited in namespace %s: %w", ns, err)
+			}
+		}
+
+		if err := limiter.Wait(ctx); err != nil {
+			return fmt.Errorf("kubernetes API call rate limited: %w", err)
+		}
+	}
+
+	// Buffered so the goroutine can always complete, even after we've given up waiting
+	done := make(chan error, 1)
+
+	go func() {
+		done <- call(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("kubernetes API call timed out after %s: %w", timeout, ctx.Err())
+	}
+}
+
+// rateLimiter returns the token bucket limiting calls to the API, or nil when there is no limit
+func (k *Kubernetes) rateLimiter() *rate.Limiter {
+	k.limiterOnce.Do(func() {
+		if k.RateLimitQPS > 0 {
+			k.limiter = rate.NewLimiter(rate.Limit(k.RateLimitQPS), max(k.RateLimitBurst, 1))
+		}
+	})
+
+	return k.limiter
+}
+
+// namespaceLimiter returns the limiter for calls about one namespace, with the same rate as the shared one
+func (k *Kubernetes) namespaceLimiter(ns string) *rate.Limiter {
+	k.nsLimitersMu.Lock()
+	defer k.nsLimitersMu.Unlock()
+
+	if k.nsLimiters == nil {
+		k.nsLimiters = map[string]*rate.Limiter{}
+	}
+
+	limiter, ok := k.nsLimiters[ns]
+	if !ok {
+		if len(k.nsLimiters) >= maxNamespaceLimiters {
+			k.evictNamespaceLimiters()
+		}
+
+		limiter = rate.NewLimiter(rate.Limit(k.RateLimitQPS), max(k.RateLimitBurst, 1))
+		k.nsLimiters[ns] = limiter
+	}
+
+	return limiter
+}
+
+// evictNamespaceLimiters makes room in nsLimiters, the caller must hold nsLimitersMu
+// A limiter whose bucket has refilled behaves the same as a new one, so those are all dropped. When every
+// namespace is busy the one closest to refilled is dropped instead, so the map never grows past the cap
+func (k *Kubernetes) evictNamespaceLimiters() {
+	now := time.Now()
+	burst := float64(max(k.RateLimitBurst, 1))
+	closest, closestTokens := "", math.Inf(-1)
+
+	for ns, limiter := range k.nsLimiters {
+		tokens := limiter.TokensAt(now)
+		if tokens >= burst {
+			delete(k.nsLimiters, ns)
+			continue
+		}
+
+		if tokens > closestTokens {
+			closest, closestTokens = ns, tokens
+		}
+	}
+
+	if len(k.nsLimiters) >= maxNamespaceLimiters {
+		delete(k.nsLimiters, closest)
+	}
+}
+
+// Shutdown stops all the informers watching the cluster, waiting for them to exit or the context to expire
+// It is safe to call more than once
+func (k *Kubernetes) Shutdown(ctx context.Context) error {
+	if k.informers == nil {
+		return nil
+	}
+
+	k.stopOnce.Do(func() {
+		log.Println("🛑 Stopping resource watchers...")
+		close(k.stopInformers)
+	})
+
+	done := make(chan struct{})
+
+	go func() {
+		k.informers.Shutdown()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("stopping resource watchers: %w", ctx.Err())
+	}
+}
+
+// resourcesToWatch works out which resource types to watch, from the configured list of plural names
+// An empty list means watch everything, the endpoint & event APIs are picked based on the cluster
+func resourcesToWatch(watched []string, useEndpointSlices, useEventsV1 bool) []schema.GroupVersionResource {
+	endpoints := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "endpoints"}
+	if useEndpointSlices {
+		endpoints = schema.GroupVersionResource{Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"}
+	}
+
+	candidates := append(slices.Clone(watchableResources), eventsGVR(useEventsV1), endpoints)
+	if len(watched) == 0 {
+		return candidates
+	}
+
+	candidates = append(candidates, leaseGVR)
+
+	resources := make([]schema.GroupVersionResource, 0, len(watched))
+
+	for _, name := range watched {
+		idx := slices.IndexFunc(candidates, func(gvr schema.GroupVersionResource) bool {
+			return gvr.Resource == name
+		})
+
+		if idx < 0 {
+			log.Printf("⚠️ Resource type %s can not be watched, it will be ignored", name)
+			continue
+		}
+
+		resources = append(resources, candidates[idx])
+	}
+
+	return resources
+}
+
+// Kind of each resource type which can be watched, keyed by plural name, for WatchKindDenylist
+var watchableKinds = map[string]string{
+	"pods":                     "Pod",
+	"services":                 "Service",
+	"deployments":              "Deployment",
+	"replicasets":              "ReplicaSet",
+	"statefulsets":             "StatefulSet",
+	"ingresses":                "Ingress",
+	"networkpolicies":          "NetworkPolicy",
+	"jobs":                     "Job",
+	"cronjobs":                 "CronJob",
+	"persistentvolumeclaims":   "PersistentVolumeClaim",
+	"horizontalpodautoscalers": "HorizontalPodAutoscaler",
+	"configmaps":               "ConfigMap",
+	"secrets":                  "Secret",
+	"events":                   "Event",
+	"endpoints":                "Endpoints",
+	"endpointslices":           "EndpointSlice",
+	"leases":                   "Lease",
+}
+
+// withoutDeniedKinds removes resource types of a denied kind, kinds are matched ignoring case
+func withoutDeniedKinds(resources []schema.GroupVersionResource, denied []string) []schema.GroupVersionResource {
+	if len(denied) == 0 {
+		return resources
+	}
+
+	return slices.DeleteFunc(slices.Clone(resources), func(gvr schema.GroupVersionResource) bool {
+		kind := watchableKinds[gvr.Resource]
+
+		if slices.ContainsFunc(denied, func(d string) bool { return strings.EqualFold(d, kind) }) {
+			log.Printf("🙈 Not watching %s, the kind is in the watch denylist", kind)
+			return true
+		}
+
+		return false
+	})
+}
+
+// startInformers sets up an informer for each resource type, sending events to the sender, and starts them
+// Resource types which can't be watched are polled instead, unless pollInterval is zero
+// Every resyncPeriod the informers send each object in their cache again as an update, zero turns this off
+// The informers & pollers run until the stop channel is closed
+func startInformers(stop <-chan struct{}, client dynamic.Interface, namespace string, sender EventSender,
+	resources []schema.GroupVersionResource, pollInterval, resyncPeriod time.Duration,
+) dynamicinformer.DynamicSharedInformerFactory {
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(client, resyncPeriod, namespace, nil)
+
+	// Add listening event handlers for ALL resources we want to track
+	for _, gvr := range resources {
+		if pollInterval > 0 && !canWatch(client, namespace, gvr) {
+			log.Printf("🔁 Not permitted to watch %s, polling every %s instead", gvr.Resource, pollInterval)
+
+			go pollResource(stop, client, namespace, sender, gvr, pollInterval)
+
+			continue
+		}
+
+		_, _ = factory.ForResource(gvr).Informer().AddEventHandler(getHandlerFuncs(sender))
+	}
+
+	factory.Start(stop)
+	factory.WaitForCacheSync(stop)
+
+	return factory
+}
+
+// buildRestConfig works out how to connect to the cluster, returning the config and the connection mode
+// When no kubeconfig path or context is given we use in-cluster config if possible, else the default kubeconfig
+func buildRestConfig(opts Options) (*rest.Config, string, error) {
+	kubeConfig, mode, err := loadRestConfig(opts)
+	if err != nil {
+		return nil, mode, err
+	}
+
+	kubeConfig.UserAgent = cmp.Or(opts.UserAgent, UserAgent(""))
+
+	return kubeConfig, mode, applyTLSOptions(kubeConfig, opts)
+}
+
+// UserAgent returns the default user agent for a version of KubeView, e.g. "kubeview/2.1.0 (linux/amd64)"
+func UserAgent(version string) string {
+	name := "kubeview"
+	if version != "" {
+		name += "/" + version
+	}
+
+	return fmt.Sprintf("%s (%s/%s)", name, runtime.GOOS, runtime.GOARCH)
+}
+
+// loadRestConfig loads the in-cluster config, or the config from a kubeconfig file
+func loadRestConfig(opts Options) (*rest.Config, string, error) {
+	if opts.KubeconfigPath == "" && opts.KubeContext == "" && inCluster() {
+		log.Println("⚓ Running in cluster, will try to use cluster config")
+
+		kubeConfig, err := rest.InClusterConfig()
+
+		return kubeConfig, "in-cluster", err
+	}
+
+	// Default location for kubeconfig file is $HOME/.kube/config
+	kubeconfigFile := filepath.Join(os.Getenv("HOME"), ".kube", "config")
+
+	// If KUBECONFIG environment variable is set, use that instead, and an explicit path trumps both
+	if os.Getenv("KUBECONFIG") != "" {
+		kubeconfigFile = os.Getenv("KUBECONFIG")
+	}
+
+	if opts.KubeconfigPath != "" {
+		kubeconfigFile = opts.KubeconfigPath
+	}
+
+	log.Println("🏠 Running outside cluster, will use config file:", kubeconfigFile)
+
+	overrides := &clientcmd.ConfigOverrides{}
+	if opts.KubeContext != "" {
+		log.Println("🎯 Using kubeconfig context:", opts.KubeContext)
+
+		overrides.CurrentContext = opts.KubeContext
+	}
+
+	kubeConfig, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfigFile}, overrides).ClientConfig()
+
+	return kubeConfig, "out-of-cluster", err
+}
+
+// applyTLSOptions sets the CA bundle or skips verification of the API server, secure verification is the default
+func applyTLSOptions(kubeConfig *rest.Config, opts Options) error {
+	if opts.InsecureSkipTLSVerify {
+		log.Println("⚠️ TLS verification of the API server is disabled, this is insecure")
+
+		// Client-go refuses to skip verification when a CA is also set
+		kubeConfig.Insecure = true
+		kubeConfig.CAFile = ""
+		kubeConfig.CAData = nil
+
+		return nil
+	}
+
+	if opts.CAFile == "" {
+		return nil
+	}
+
+	if _, err := os.Stat(opts.CAFile); err != nil {
+		return fmt.Errorf("reading CA file: %w", err)
+	}
+
+	log.Println("🔏 Using CA file to verify the API server:", opts.CAFile)
+
+	// CAData takes priority over CAFile, so it's cleared to make sure the file is used
+	kubeConfig.CAFile = opts.CAFile
+	kubeConfig.CAData = nil
+
+	return nil
+}
+
 func inCluster() bool {
 	// Check if the application is running inside a Kubernetes cluster
 	// This is a simple check and may not be foolproof
@@ -370,16 +1118,18 @@ func inCluster() bool {
 }
 
 // getHandlerFuncs returns the event handlers for the Kubernetes informers, which send events through the SSE broker
-func getHandlerFuncs(b *sse.Broker[KubeEvent]) cache.ResourceEventHandlerFuncs {
+// Objects are owned by the informer cache, so they are deep copied before we modify them
+func getHandlerFuncs(b EventSender) cache.ResourceEventHandlerFuncs {
 	return cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
-			u := obj.(*unstructured.Unstructured)
+			u := normaliseEvent(obj.(*unstructured.Unstructured).DeepCopy())
 			namespace := u.GetNamespace()
 			if namespace == "" {
 				return
 			}
 
 			u.SetManagedFields(nil)
+			u.Object[healthField] = string(ObjectHealth(u))
 			b.SendToGroup(namespace, KubeEvent{
 				EventType: AddEvent,
 				Object:    u,
@@ -387,13 +1137,14 @@ func getHandlerFuncs(b *sse.Broker[KubeEvent]) cache.ResourceEventHandlerFuncs {
 		},
 
 		UpdateFunc: func(oldObj, newObj interface{}) {
-			u := newObj.(*unstructured.Unstructured)
+			u := normaliseEvent(newObj.(*unstructured.Unstructured).DeepCopy())
 			namespace := u.GetNamespace()
 			if namespace == "" {
 				return
 			}
 
 			u.SetManagedFields(nil)
+			u.Object[healthField] = string(ObjectHealth(u))
 			b.SendToGroup(namespace, KubeEvent{
 				EventType: UpdateEvent,
 				Object:    u,
@@ -401,7 +1152,7 @@ func getHandlerFuncs(b *sse.Broker[KubeEvent]) cache.ResourceEventHandlerFuncs {
 		},
 
 		DeleteFunc: func(obj interface{}) {
-			u := obj.(*unstructured.Unstructured)
+			u := normaliseEvent(obj.(*unstructured.Unstructured).DeepCopy())
 			namespace := u.GetNamespace()
 			if namespace == "" {
 				return
@@ -6,17 +6,27 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/benc-uk/go-rest-api/pkg/sse"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/dynamic/fake"
 	k8sfake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 // mockKubernetes creates a mock Kubernetes service for testing
@@ -27,6 +37,7 @@ func mockKubernetes() *Kubernetes {
 	// Define resource mappings for the fake client
 	gvrToListKind := map[schema.GroupVersionResource]string{
 		{Group: "", Version: "v1", Resource: "namespaces"}:                          "NamespaceList",
+		{Group: "", Version: "v1", Resource: "nodes"}:                               "NodeList",
 		{Group: "", Version: "v1", Resource: "pods"}:                                "PodList",
 		{Group: "", Version: "v1", Resource: "services"}:                            "ServiceList",
 		{Group: "", Version: "v1", Resource: "endpoints"}:                           "EndpointsList",
@@ -34,6 +45,10 @@ func mockKubernetes() *Kubernetes {
 		{Group: "", Version: "v1", Resource: "secrets"}:                             "SecretList",
 		{Group: "", Version: "v1", Resource: "persistentvolumeclaims"}:              "PersistentVolumeClaimList",
 		{Group: "", Version: "v1", Resource: "events"}:                              "EventList",
+		{Group: "", Version: "v1", Resource: "resourcequotas"}:                      "ResourceQuotaList",
+		{Group: "", Version: "v1", Resource: "limitranges"}:                         "LimitRangeList",
+		{Group: "", Version: "v1", Resource: "serviceaccounts"}:                     "ServiceAccountList",
+		{Group: "events.k8s.io", Version: "v1", Resource: "events"}:                 "EventList",
 		{Group: "apps", Version: "v1", Resource: "deployments"}:                     "DeploymentList",
 		{Group: "apps", Version: "v1", Resource: "replicasets"}:                     "ReplicaSetList",
 		{Group: "apps", Version: "v1", Resource: "statefulsets"}:                    "StatefulSetList",
@@ -41,8 +56,19 @@ func mockKubernetes() *Kubernetes {
 		{Group: "batch", Version: "v1", Resource: "jobs"}:                           "JobList",
 		{Group: "batch", Version: "v1", Resource: "cronjobs"}:                       "CronJobList",
 		{Group: "networking.k8s.io", Version: "v1", Resource: "ingresses"}:          "IngressList",
+		{Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies"}:    "NetworkPolicyList",
 		{Group: "autoscaling", Version: "v2", Resource: "horizontalpodautoscalers"}: "HorizontalPodAutoscalerList",
 		{Group: "discovery.k8s.io", Version: "v1", Resource: "endpointslices"}:      "EndpointSliceList",
+		{Group: "coordination.k8s.io", Version: "v1", Resource: "leases"}:           "LeaseList",
+		{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}:             "PodMetricsList",
+
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "roles"}:               "RoleList",
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "rolebindings"}:        "RoleBindingList",
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}:        "ClusterRoleList",
+		{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"}: "ClusterRoleBindingList",
+
+		{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}: "CustomResourceDefinitionList",
+		{Group: "example.com", Version: "v1", Resource: "widgets"}:                            "WidgetList",
 	}
 
 	fakeDynamicClient := fake.NewSimpleDynamicClientWithCustomListKinds(scheme, gvrToListKind)
@@ -57,6 +83,7 @@ func mockKubernetes() *Kubernetes {
 		Mode:              "test",
 		UseEndpointSlices: false,
 		KubeVersion:       "v1.30.0",
+		RedactSecrets:     true,
 	}
 }
 
@@ -95,6 +122,40 @@ func createTestPod(name, namespace string) *unstructured.Unstructured {
 	}
 }
 
+// createTestDeployment creates a test deployment object, with pods labelled app=name
+func createTestDeployment(name, namespace string, replicas int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+				"uid":       name + "-uid",
+			},
+			"spec": map[string]interface{}{
+				"replicas": replicas,
+				"selector": map[string]interface{}{
+					"matchLabels": map[string]interface{}{"app": name},
+				},
+				"template": map[string]interface{}{
+					"metadata": map[string]interface{}{
+						"labels": map[string]interface{}{"app": name},
+					},
+					"spec": map[string]interface{}{
+						"containers": []interface{}{
+							map[string]interface{}{
+								"name":  "app",
+								"image": "nginx:1.27",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 // createTestSecret creates a test secret object
 func createTestSecret(name, namespace string) *unstructured.Unstructured {
 	return &unstructured.Unstructured{
@@ -211,13 +272,223 @@ func TestKubernetes_GetResources(t *testing.T) {
 	}
 }
 
+func TestKubernetes_GetResourcesSorted(t *testing.T) {
+	k := mockKubernetes()
+
+	// Create pods out of name order, with the oldest pod having the last name
+	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
+	now := time.Now()
+
+	for i, name := range []string{"charlie", "alpha", "bravo"} {
+		pod := createTestPod(name, "default")
+		pod.SetCreationTimestamp(metaV1.NewTime(now.Add(-time.Duration(i) * time.Hour)))
+		_, _ = k.dynamicClient.Resource(gvr).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+	}
+
+	testCases := []struct {
+		order    SortOrder
+		expected []string
+	}{
+		{SortByName, []string{"alpha", "bravo", "charlie"}},
+		{SortByCreated, []string{"bravo", "alpha", "charlie"}},
+		{SortByCreatedDesc, []string{"charlie", "alpha", "bravo"}},
+	}
+
+	for _, tc := range testCases {
+		pods, err := k.GetResourcesSorted("default", "", "v1", "pods", tc.order)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		for i, pod := range pods {
+			if pod.GetName() != tc.expected[i] {
+				t.Errorf("Sort %s: expected %s at position %d, got %s", tc.order, tc.expected[i], i, pod.GetName())
+			}
+		}
+	}
+
+	// Default GetResources should be sorted by name
+	pods, _ := k.GetResources("default", "", "v1", "pods")
+	if pods[0].GetName() != "alpha" || pods[2].GetName() != "charlie" {
+		t.Error("Expected GetResources to return pods sorted by name")
+	}
+
+	if _, err := k.GetResourcesSorted("default", "", "v1", "pods", "size"); err == nil {
+		t.Error("Expected error for invalid sort order, got nil")
+	}
+}
+
+func TestKubernetes_GetResources_Timeout(t *testing.T) {
+	k := mockKubernetes()
+	k.RequestTimeout = 50 * time.Millisecond
+
+	// Make the fake API server hang for longer than the timeout when listing pods
+	fakeClient := k.dynamicClient.(*fake.FakeDynamicClient)
+	fakeClient.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		time.Sleep(500 * time.Millisecond)
+		return false, nil, nil
+	})
+
+	start := time.Now()
+
+	_, err := k.GetResources("default", "", "v1", "pods")
+	if err == nil {
+		t.Fatal("Expected timeout error, got nil")
+	}
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded error, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("Expected call to return soon after the timeout, took %s", elapsed)
+	}
+}
+
+func TestKubernetes_RateLimit(t *testing.T) {
+	k := mockKubernetes()
+	k.RateLimitQPS = 20
+	k.RateLimitBurst = 1
+
+	// With a burst of one, every call after the first waits for a token at 20 per second
+	start := time.Now()
+
+	for range 4 {
+		if _, err := k.GetResources("default", "", "v1", "pods"); err != nil {
+			t.Fatalf("Expected calls under the timeout to wait and succeed, got %v", err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 120*time.Millisecond {
+		t.Errorf("Expected calls to be slowed by the rate limit, took %s", elapsed)
+	}
+
+	// A limit so tight the wait would pass the timeout fails straight away
+	k = mockKubernetes()
+	k.RateLimitQPS = 0.1
+	k.RateLimitBurst = 1
+	k.RequestTimeout = 100 * time.Millisecond
+
+	if _, err := k.GetResources("default", "", "v1", "pods"); err != nil {
+		t.Fatalf("Expected first call to use the burst, got %v", err)
+	}
+
+	if _, err := k.GetResources("default", "", "v1", "pods"); err == nil {
+		t.Error("Expected call over the rate limit to fail")
+	}
+}
+
+// slowClient delays listing in one namespace, the fake client's reactors can't be used for this
+// as they run one at a time, so a slow reactor would hold up calls for every namespace
+type slowClient struct {
+	dynamic.Interface
+	ns    string
+	delay time.Duration
+}
+
+type slowResource struct {
+	dynamic.NamespaceableResourceInterface
+	client slowClient
+}
+
+type slowList struct {
+	dynamic.ResourceInterface
+	delay time.Duration
+}
+
+func (c slowClient) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return slowResource{c.Interface.Resource(gvr), c}
+}
+
+func (r slowResource) Namespace(ns string) dynamic.ResourceInterface {
+	if ns != r.client.ns {
+		return r.NamespaceableResourceInterface.Namespace(ns)
+	}
+
+	return slowList{r.NamespaceableResourceInterface.Namespace(ns), r.client.delay}
+}
+
+func (l slowList) List(ctx context.Context, opts metaV1.ListOptions) (*unstructured.UnstructuredList, error) {
+	time.Sleep(l.delay)
+	return l.ResourceInterface.List(ctx, opts)
+}
+
+func TestKubernetes_RateLimit_NamespaceIsolation(t *testing.T) {
+	k := mockKubernetes()
+	k.RateLimitQPS = 20
+	k.RateLimitBurst = 1
+
+	// Every list in namespace a is slow, as if it held huge secrets
+	k.dynamicClient = slowClient{Interface: k.dynamicClient, ns: "a", delay: 200 * time.Millisecond}
+
+	// Namespace a makes a burst of calls, which would take half a second to get through a shared limiter
+	busy := sync.WaitGroup{}
+	for range 10 {
+		busy.Go(func() { _, _ = k.GetResources("a", "", "v1", "pods") })
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := k.GetResources("b", "", "v1", "pods"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("Expected namespace b not to queue behind namespace a, took %s", elapsed)
+	}
+
+	busy.Wait()
+}
+
+func TestKubernetes_NamespaceLimiter_Bounded(t *testing.T) {
+	k := mockKubernetes()
+	k.RateLimitQPS = 0.1
+	k.RateLimitBurst = 1
+
+	// Every limiter has used its token, so none are idle and busy ones have to be dropped
+	for i := range maxNamespaceLimiters * 2 {
+		k.namespaceLimiter(fmt.Sprintf("ns-%d", i)).Allow()
+	}
+
+	if len(k.nsLimiters) > maxNamespaceLimiters {
+		t.Errorf("Expected at most %d namespace limiters, got %d", maxNamespaceLimiters, len(k.nsLimiters))
+	}
+
+	// Limiters that have refilled are all dropped when room is needed
+	k.RateLimitQPS = 1000
+	k.nsLimiters = nil
+
+	for i := range maxNamespaceLimiters {
+		k.namespaceLimiter(fmt.Sprintf("ns-%d", i))
+	}
+
+	k.namespaceLimiter("busy").Allow()
+
+	if len(k.nsLimiters) != 1 {
+		t.Errorf("Expected idle namespace limiters to be evicted, got %d left", len(k.nsLimiters))
+	}
+}
+
+// addTestNamespace creates a namespace in the fake client, fetching a namespace fails if it doesn't exist
+func addTestNamespace(k *Kubernetes, name string) {
+	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
+	_, _ = k.dynamicClient.Resource(gvr).Create(context.TODO(), createTestNamespace(name), metaV1.CreateOptions{})
+}
+
 func TestKubernetes_FetchNamespace(t *testing.T) {
 	k := mockKubernetes()
+	addTestNamespace(k, "default")
 
-	// Test empty namespace
+	// Test empty namespace, which is a different error to a namespace not existing
 	_, err := k.FetchNamespace("")
-	if err == nil {
-		t.Error("Expected error for empty namespace, got nil")
+	if err == nil || errors.Is(err, ErrNamespaceNotFound) {
+		t.Errorf("Expected empty namespace error, got %v", err)
+	}
+
+	_, err = k.FetchNamespace("missing")
+	if !errors.Is(err, ErrNamespaceNotFound) {
+		t.Errorf("Expected ErrNamespaceNotFound for missing namespace, got %v", err)
 	}
 
 	// Create test resources
@@ -261,6 +532,336 @@ func TestKubernetes_FetchNamespace(t *testing.T) {
 	}
 }
 
+func TestKubernetes_StreamNamespace(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	podGvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
+	secretGvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}
+	deployGvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	_, _ = k.dynamicClient.Resource(podGvr).Namespace("default").
+		Create(context.TODO(), createTestPod("test-pod", "default"), metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(secretGvr).Namespace("default").
+		Create(context.TODO(), createTestSecret("test-secret", "default"), metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(deployGvr).Namespace("default").
+		Create(context.TODO(), createTestDeployment("web", "default", 1), metaV1.CreateOptions{})
+
+	chunks := []ResourceChunk{}
+
+	err := k.StreamNamespace("default", FetchOptions{}, func(chunk ResourceChunk) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(chunks) < 3 {
+		t.Fatalf("Expected a chunk per resource type, got %d", len(chunks))
+	}
+
+	// Pods are sent first so they can be drawn straight away, secrets are last
+	if chunks[0].Kind != "pods" || len(chunks[0].Items) != 1 {
+		t.Errorf("Expected first chunk to hold the pod, got %s with %d items", chunks[0].Kind, len(chunks[0].Items))
+	}
+
+	last := chunks[len(chunks)-1]
+	if last.Kind != "secrets" || last.Items[0].Object["data"].(map[string]interface{})["username"] != redactedValue {
+		t.Errorf("Expected last chunk to hold the redacted secret, got %s", last.Kind)
+	}
+
+	seen := map[string]bool{}
+
+	for _, chunk := range chunks {
+		if seen[chunk.Kind] {
+			t.Errorf("Expected one chunk per type, got %s twice", chunk.Kind)
+		}
+
+		seen[chunk.Kind] = true
+	}
+
+	if !seen["deployments"] {
+		t.Error("Expected a chunk for deployments")
+	}
+}
+
+func TestKubernetes_FetchNamespaceWithOptions_Age(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	// Create pods of various ages, plus one with no creation timestamp
+	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
+	now := time.Now()
+	ages := map[string]time.Duration{"new": time.Minute, "recent": 30 * time.Minute, "old": 2 * time.Hour}
+
+	for name, age := range ages {
+		pod := createTestPod(name, "default")
+		pod.SetCreationTimestamp(metaV1.NewTime(now.Add(-age)))
+		_, _ = k.dynamicClient.Resource(gvr).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+	}
+
+	_, _ = k.dynamicClient.Resource(gvr).Namespace("default").
+		Create(context.TODO(), createTestPod("unknown", "default"), metaV1.CreateOptions{})
+
+	testCases := []struct {
+		opts     FetchOptions
+		expected []string
+	}{
+		{FetchOptions{}, []string{"new", "old", "recent", "unknown"}},
+		{FetchOptions{MaxAge: 45 * time.Minute}, []string{"new", "recent", "unknown"}},
+		{FetchOptions{MinAge: 10 * time.Minute, MaxAge: time.Hour}, []string{"recent", "unknown"}},
+		{FetchOptions{MinAge: time.Hour}, []string{"old", "unknown"}},
+	}
+
+	for _, tc := range testCases {
+		data, err := k.FetchNamespaceWithOptions("default", tc.opts)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		names := make([]string, 0, len(data["pods"]))
+		for _, pod := range data["pods"] {
+			names = append(names, pod.GetName())
+		}
+
+		if fmt.Sprint(names) != fmt.Sprint(tc.expected) {
+			t.Errorf("Options %+v: expected pods %v, got %v", tc.opts, tc.expected, names)
+		}
+	}
+}
+
+func TestKubernetes_FetchNamespace_AgeSeconds(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	pod := createTestPod("web", "default")
+	pod.SetCreationTimestamp(metaV1.NewTime(time.Now().Add(-90 * time.Minute)))
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").
+		Create(context.TODO(), createTestPod("unknown", "default"), metaV1.CreateOptions{})
+
+	data, err := k.FetchNamespace("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ages := map[string]any{}
+	for _, p := range data["pods"] {
+		ages[p.GetName()] = p.Object["ageSeconds"]
+	}
+
+	// Allow some slack for the time the fetch takes
+	if age, ok := ages["web"].(int64); !ok || age < 90*60 || age > 90*60+5 {
+		t.Errorf("Expected web to be about 5400 seconds old, got %v", ages["web"])
+	}
+
+	if age, found := ages["unknown"]; !found || age != nil {
+		t.Errorf("Expected a null age without a creation timestamp, got %v", age)
+	}
+
+	// Null is kept when serialised, rather than the field being left out
+	encoded, _ := json.Marshal(data["pods"])
+	if !strings.Contains(string(encoded), `"ageSeconds":null`) {
+		t.Errorf("Expected ageSeconds to be null in JSON, got %s", encoded)
+	}
+}
+
+func TestKubernetes_FetchNamespace_HidesCompletedJobPods(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	jobPod := func(name, phase, ownerKind string) {
+		pod := createTestPod(name, "default")
+		_ = unstructured.SetNestedField(pod.Object, phase, "status", "phase")
+
+		if ownerKind != "" {
+			controller := true
+			pod.SetOwnerReferences([]metaV1.OwnerReference{{Kind: ownerKind, Name: "owner", Controller: &controller}})
+		}
+
+		_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+	}
+
+	jobPod("finished", "Succeeded", "Job")
+	jobPod("running", "Running", "Job")
+	jobPod("failed", "Failed", "Job")
+	jobPod("standalone", "Succeeded", "")
+
+	names := func(pods []unstructured.Unstructured) []string {
+		found := []string{}
+		for _, p := range pods {
+			found = append(found, p.GetName())
+		}
+
+		return found
+	}
+
+	data, err := k.FetchNamespace("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if pods := names(data["pods"]); slices.Contains(pods, "finished") || len(pods) != 3 {
+		t.Errorf("Expected only the succeeded job pod to be hidden, got %v", pods)
+	}
+
+	view, _ := k.FetchNamespaceView("default", FetchOptions{})
+	if view.HiddenPods != 1 {
+		t.Errorf("Expected the view to count 1 hidden pod, got %d", view.HiddenPods)
+	}
+
+	data, _ = k.FetchNamespaceWithOptions("default", FetchOptions{IncludeCompletedJobPods: true})
+	if pods := names(data["pods"]); !slices.Contains(pods, "finished") {
+		t.Errorf("Expected the succeeded job pod when asked for, got %v", pods)
+	}
+}
+
+func TestKubernetes_FetchNamespaceWithOptions_Timing(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	timed := []string{}
+	opts := FetchOptions{Timing: func(kind string, elapsed time.Duration) {
+		if elapsed < 0 {
+			t.Errorf("Expected a positive duration for %s, got %v", kind, elapsed)
+		}
+
+		timed = append(timed, kind)
+	}}
+
+	data, err := k.FetchNamespaceWithOptions("default", opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(timed) != len(data) || timed[0] != "pods" {
+		t.Errorf("Expected every resource type to be timed in fetch order, got %v", timed)
+	}
+}
+
+func TestKubernetes_FetchNamespace_MaxObjects(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+	k.MaxObjects = 3
+
+	// Ages are out of name order, so the newest can't be picked by list order alone
+	now := time.Now()
+	ages := map[string]time.Duration{
+		"a": 5 * time.Hour, "b": time.Minute, "c": 3 * time.Hour, "d": 2 * time.Minute, "e": 4 * time.Hour,
+	}
+
+	for name, age := range ages {
+		pod := createTestPod(name, "default")
+		pod.SetCreationTimestamp(metaV1.NewTime(now.Add(-age)))
+		_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+	}
+
+	// No creation timestamp counts as the oldest
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").
+		Create(context.TODO(), createTestPod("unknown", "default"), metaV1.CreateOptions{})
+
+	truncated := map[string]int{}
+	opts := FetchOptions{Truncated: func(kind string, more int) { truncated[kind] = more }}
+
+	data, err := k.FetchNamespaceWithOptions("default", opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	names := []string{}
+	for _, pod := range data["pods"] {
+		names = append(names, pod.GetName())
+	}
+
+	slices.Sort(names)
+
+	if fmt.Sprint(names) != "[b c d]" {
+		t.Errorf("Expected the 3 newest pods, got %v", names)
+	}
+
+	if len(truncated) != 1 || truncated["pods"] != 3 {
+		t.Errorf("Expected only pods to be truncated with 3 more, got %v", truncated)
+	}
+
+	view, _ := k.FetchNamespaceView("default", FetchOptions{})
+	if len(view.Pods) != 3 || view.Truncated["pods"] != 3 {
+		t.Errorf("Expected the view to have 3 pods & 3 more truncated, got %d & %v", len(view.Pods), view.Truncated)
+	}
+
+	k.MaxObjects = 0
+
+	data, _ = k.FetchNamespace("default")
+	if len(data["pods"]) != 6 {
+		t.Errorf("Expected every pod with no limit, got %d", len(data["pods"]))
+	}
+}
+
+func TestKubernetes_FetchNamespace_SecretTypeDenylist(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+	k.SecretTypeDenylist = []string{"helm.sh/release.v1"}
+
+	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}
+
+	opaque := createTestSecret("db-creds", "default")
+	opaque.Object["type"] = "Opaque"
+
+	release := createTestSecret("sh.helm.release.v1.web.v1", "default")
+	release.Object["type"] = "helm.sh/release.v1"
+
+	_, _ = k.dynamicClient.Resource(gvr).Namespace("default").Create(context.TODO(), opaque, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(gvr).Namespace("default").Create(context.TODO(), release, metaV1.CreateOptions{})
+
+	data, err := k.FetchNamespace("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	secrets := data["secrets"]
+	if len(secrets) != 1 || secrets[0].GetName() != "db-creds" {
+		t.Fatalf("Expected only the opaque secret to be returned, got %d secrets", len(secrets))
+	}
+
+	if password, _, _ := unstructured.NestedString(secrets[0].Object, "data", "password"); password != redactedValue {
+		t.Errorf("Expected the opaque secret to be redacted, got %q", password)
+	}
+}
+
+func TestKubernetes_FetchNamespace_DeepCopy(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	secret := createTestSecret("test-secret", "default")
+	secretGvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}
+	_, _ = k.dynamicClient.Resource(secretGvr).Namespace("default").
+		Create(context.TODO(), secret, metaV1.CreateOptions{})
+
+	// Fetch twice, both results should be redacted once and not interfere with each other
+	for i := 0; i < 2; i++ {
+		data, err := k.FetchNamespace("default")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		secretData, _ := data["secrets"][0].Object["data"].(map[string]interface{})
+		if secretData["username"] != "*REDACTED*" {
+			t.Errorf("Fetch %d: expected secret data to be redacted, got %v", i, secretData["username"])
+		}
+	}
+
+	// The stored canonical object must still hold the original data
+	stored, err := k.dynamicClient.Resource(secretGvr).Namespace("default").
+		Get(context.TODO(), "test-secret", metaV1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	storedData, _ := stored.Object["data"].(map[string]interface{})
+	if storedData["username"] != "dGVzdA==" {
+		t.Errorf("Expected stored secret to retain original data, got %v", storedData["username"])
+	}
+}
+
 func TestKubernetes_GetPodLogs(t *testing.T) {
 	k := mockKubernetes()
 
@@ -310,6 +911,217 @@ func TestInCluster(t *testing.T) {
 	}
 }
 
+// testKubeconfig is a kubeconfig with two clusters & contexts, "alpha" is the current context
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: alpha
+clusters:
+- name: alpha
+  cluster:
+    server: https://alpha.example.com:6443
+- name: beta
+  cluster:
+    server: https://beta.example.com:6443
+contexts:
+- name: alpha
+  context:
+    cluster: alpha
+    user: tester
+- name: beta
+  context:
+    cluster: beta
+    user: tester
+users:
+- name: tester
+  user:
+    token: not-a-real-token
+`
+
+// writeTestKubeconfig writes a kubeconfig file into a temp dir, returning the path
+func writeTestKubeconfig(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write test kubeconfig: %v", err)
+	}
+
+	return path
+}
+
+func TestBuildRestConfig_KubeconfigContext(t *testing.T) {
+	path := writeTestKubeconfig(t, testKubeconfig)
+
+	// With no context set, the current context from the file is used
+	conf, mode, err := buildRestConfig(Options{KubeconfigPath: path})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if conf.Host != "https://alpha.example.com:6443" {
+		t.Errorf("Expected host of the current context, got %s", conf.Host)
+	}
+
+	if mode != "out-of-cluster" {
+		t.Errorf("Expected out-of-cluster mode, got %s", mode)
+	}
+
+	// Select the non-default context
+	conf, _, err = buildRestConfig(Options{KubeconfigPath: path, KubeContext: "beta"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if conf.Host != "https://beta.example.com:6443" {
+		t.Errorf("Expected host of the beta context, got %s", conf.Host)
+	}
+
+	// Unknown contexts should be an error
+	_, _, err = buildRestConfig(Options{KubeconfigPath: path, KubeContext: "gamma"})
+	if err == nil {
+		t.Error("Expected error for unknown context, got nil")
+	}
+}
+
+func TestBuildRestConfig_TLS(t *testing.T) {
+	path := writeTestKubeconfig(t, testKubeconfig)
+
+	// Secure verification with the default CA is used when nothing is set
+	conf, _, err := buildRestConfig(Options{KubeconfigPath: path})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if conf.Insecure || conf.CAFile != "" {
+		t.Errorf("Expected default TLS settings, got insecure %v & CA file %q", conf.Insecure, conf.CAFile)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(caFile, []byte("not-a-real-cert"), 0o600); err != nil {
+		t.Fatalf("Failed to write test CA file: %v", err)
+	}
+
+	conf, _, err = buildRestConfig(Options{KubeconfigPath: path, CAFile: caFile})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if conf.CAFile != caFile || conf.Insecure {
+		t.Errorf("Expected CA file %s to be used, got %q", caFile, conf.CAFile)
+	}
+
+	// A missing CA file should fail early, rather than on the first API call
+	_, _, err = buildRestConfig(Options{KubeconfigPath: path, CAFile: filepath.Join(t.TempDir(), "missing.crt")})
+	if err == nil {
+		t.Error("Expected error for missing CA file, got nil")
+	}
+
+	conf, _, err = buildRestConfig(Options{KubeconfigPath: path, CAFile: caFile, InsecureSkipTLSVerify: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !conf.Insecure || conf.CAFile != "" {
+		t.Errorf("Expected verification to be skipped with no CA, got insecure %v & CA file %q", conf.Insecure, conf.CAFile)
+	}
+}
+
+func TestBuildRestConfig_UserAgent(t *testing.T) {
+	path := writeTestKubeconfig(t, testKubeconfig)
+
+	conf, _, err := buildRestConfig(Options{KubeconfigPath: path})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !strings.HasPrefix(conf.UserAgent, "kubeview (") {
+		t.Errorf("Expected default kubeview user agent, got %q", conf.UserAgent)
+	}
+
+	conf, _, err = buildRestConfig(Options{KubeconfigPath: path, UserAgent: UserAgent("2.1.0")})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !strings.HasPrefix(conf.UserAgent, "kubeview/2.1.0 (") {
+		t.Errorf("Expected user agent with version, got %q", conf.UserAgent)
+	}
+
+	conf, _, err = buildRestConfig(Options{KubeconfigPath: path, UserAgent: "acme-audit/1"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if conf.UserAgent != "acme-audit/1" {
+		t.Errorf("Expected overridden user agent, got %q", conf.UserAgent)
+	}
+}
+
+func TestBuildRestConfig_AuthPlugins(t *testing.T) {
+	// Kubeconfig with one user using an exec credential plugin and one using the oidc auth provider
+	kubeconfig := `apiVersion: v1
+kind: Config
+current-context: exec
+clusters:
+- name: test
+  cluster:
+    server: https://test.example.com:6443
+contexts:
+- name: exec
+  context:
+    cluster: test
+    user: exec-user
+- name: oidc
+  context:
+    cluster: test
+    user: oidc-user
+users:
+- name: exec-user
+  user:
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: kubelogin
+      args: ["get-token", "--login", "azurecli"]
+      interactiveMode: Never
+- name: oidc-user
+  user:
+    auth-provider:
+      name: oidc
+      config:
+        client-id: kubeview
+        id-token: not-a-real-token
+        idp-issuer-url: https://issuer.example.com
+`
+	path := writeTestKubeconfig(t, kubeconfig)
+
+	conf, _, err := buildRestConfig(Options{KubeconfigPath: path})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if conf.ExecProvider == nil {
+		t.Fatal("Expected exec provider to be kept in the config")
+	}
+
+	if conf.ExecProvider.Command != "kubelogin" || len(conf.ExecProvider.Args) != 3 {
+		t.Errorf("Expected exec command and args to be kept, got %s %v", conf.ExecProvider.Command, conf.ExecProvider.Args)
+	}
+
+	conf, _, err = buildRestConfig(Options{KubeconfigPath: path, KubeContext: "oidc"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if conf.AuthProvider == nil || conf.AuthProvider.Name != "oidc" {
+		t.Fatal("Expected oidc auth provider to be kept in the config")
+	}
+
+	// Building a transport fails if the oidc plugin isn't registered
+	if _, err := rest.TransportFor(conf); err != nil {
+		t.Errorf("Expected oidc auth provider plugin to be registered, got %v", err)
+	}
+}
+
 func TestEventTypeEnum(t *testing.T) {
 	// Test that all event types are properly defined
 	testCases := []struct {
@@ -388,6 +1200,16 @@ func TestGetHandlerFuncs(t *testing.T) {
 		handlers.DeleteFunc(pod)
 	}
 
+	// Handlers must not modify the object owned by the informer cache
+	managed := []metaV1.ManagedFieldsEntry{{Manager: "kubectl"}}
+	cachedPod := createTestPod("cached-pod", "default")
+	cachedPod.SetManagedFields(managed)
+	handlers.UpdateFunc(cachedPod, cachedPod)
+
+	if len(cachedPod.GetManagedFields()) != 1 {
+		t.Error("Expected handler to leave the cached object's managed fields untouched")
+	}
+
 	// Test that objects without namespace are ignored
 	clusterResource := &unstructured.Unstructured{
 		Object: map[string]interface{}{
@@ -413,6 +1235,187 @@ func TestGetHandlerFuncs(t *testing.T) {
 	}
 }
 
+// recordingSender is an EventSender which keeps every event sent, for checking in tests
+type recordingSender struct {
+	mu     sync.Mutex
+	events []KubeEvent
+}
+
+func (r *recordingSender) SendToGroup(_ string, message KubeEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, message)
+}
+
+// kinds returns the kind of every object sent so far
+func (r *recordingSender) kinds() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kinds := make([]string, 0, len(r.events))
+	for _, e := range r.events {
+		kinds = append(kinds, e.Object.GetKind())
+	}
+
+	return kinds
+}
+
+func TestResourcesToWatch(t *testing.T) {
+	all := resourcesToWatch(nil, true, false)
+	if len(all) != len(watchableResources)+2 || all[len(all)-1].Resource != "endpointslices" {
+		t.Errorf("Expected all resources plus events & endpointslices to be watched by default, got %v", all)
+	}
+
+	if legacy := resourcesToWatch(nil, false, false); legacy[len(legacy)-1].Resource != "endpoints" {
+		t.Errorf("Expected endpoints to be watched on older clusters, got %v", legacy)
+	}
+
+	// Unknown names are dropped, order follows the configured list
+	picked := resourcesToWatch([]string{"deployments", "widgets", "pods"}, true, false)
+	if len(picked) != 2 || picked[0].Resource != "deployments" || picked[1].Resource != "pods" {
+		t.Errorf("Expected only deployments & pods to be watched, got %v", picked)
+	}
+
+	// Leases are only watched when asked for
+	if slices.Contains(all, leaseGVR) {
+		t.Errorf("Expected leases not to be watched by default, got %v", all)
+	}
+
+	if leases := resourcesToWatch([]string{"leases"}, true, false); len(leases) != 1 || leases[0] != leaseGVR {
+		t.Errorf("Expected leases to be watched when named, got %v", leases)
+	}
+}
+
+func TestStartInformers_Selective(t *testing.T) {
+	k := mockKubernetes()
+
+	podGvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
+	svcGvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "services"}
+
+	svc := createTestPod("web", "default")
+	svc.SetKind("Service")
+
+	_, _ = k.dynamicClient.Resource(podGvr).Namespace("default").
+		Create(context.TODO(), createTestPod("web", "default"), metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(svcGvr).Namespace("default").Create(context.TODO(), svc, metaV1.CreateOptions{})
+
+	sender := &recordingSender{}
+	stop := make(chan struct{})
+	factory := startInformers(stop, k.dynamicClient, "", sender, resourcesToWatch([]string{"pods"}, true, false), 0, 0)
+
+	defer factory.Shutdown()
+	defer close(stop)
+
+	// Handlers are called asynchronously after the cache sync, so wait for the pod to arrive
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sender.kinds()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	kinds := sender.kinds()
+	if len(kinds) != 1 || kinds[0] != "Pod" {
+		t.Errorf("Expected a single event for the pod and none for the service, got %v", kinds)
+	}
+}
+
+func TestStartInformers_Resync(t *testing.T) {
+	k := mockKubernetes()
+
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").
+		Create(context.TODO(), createTestPod("web", "default"), metaV1.CreateOptions{})
+
+	sender := &recordingSender{}
+	stop := make(chan struct{})
+	resources := resourcesToWatch([]string{"pods"}, true, false)
+	factory := startInformers(stop, k.dynamicClient, "", sender, resources, 0, time.Second)
+
+	defer factory.Shutdown()
+	defer close(stop)
+
+	updates := func() int {
+		sender.mu.Lock()
+		defer sender.mu.Unlock()
+
+		count := 0
+
+		for _, e := range sender.events {
+			if e.EventType == UpdateEvent {
+				count++
+			}
+		}
+
+		return count
+	}
+
+	// The pod never changes, so an update can only come from the informer resyncing
+	deadline := time.Now().Add(5 * time.Second)
+	for updates() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if updates() == 0 {
+		t.Errorf("Expected the unchanged pod to be resent by a resync, got events %v", sender.kinds())
+	}
+}
+
+func TestKubernetes_Shutdown(t *testing.T) {
+	k := mockKubernetes()
+
+	// Nothing to stop when no informers have been started
+	if err := k.Shutdown(context.Background()); err != nil {
+		t.Errorf("Expected no error with no informers, got %v", err)
+	}
+
+	k.stopInformers = make(chan struct{})
+	resources := resourcesToWatch(nil, true, false)
+	k.informers = startInformers(k.stopInformers, k.dynamicClient, "", &recordingSender{}, resources, 0, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := k.Shutdown(ctx); err != nil {
+		t.Fatalf("Expected informers to stop, got %v", err)
+	}
+
+	// A second call must not panic closing the stop channel again
+	if err := k.Shutdown(ctx); err != nil {
+		t.Errorf("Expected second shutdown to succeed, got %v", err)
+	}
+}
+
+func TestStartInformers_WatchKindDenylist(t *testing.T) {
+	k := mockKubernetes()
+
+	resources := withoutDeniedKinds(resourcesToWatch(nil, true, false), []string{"event", "Secret"})
+	if len(resources) != len(watchableResources) {
+		t.Errorf("Expected events & secrets to be removed, got %v", resources)
+	}
+
+	stop := make(chan struct{})
+	factory := startInformers(stop, k.dynamicClient, "", &recordingSender{}, resources, 0, 0)
+
+	defer factory.Shutdown()
+	defer close(stop)
+
+	client, _ := k.dynamicClient.(*fake.FakeDynamicClient)
+	watched := map[string]bool{}
+
+	for _, action := range client.Actions() {
+		if action.GetVerb() == "watch" || action.GetVerb() == "list" {
+			watched[action.GetResource().Resource] = true
+		}
+	}
+
+	if watched["events"] || watched["secrets"] {
+		t.Errorf("Expected no informer for a denied kind, got %v", watched)
+	}
+
+	if !watched["pods"] || !watched["endpointslices"] {
+		t.Errorf("Expected informers for kinds not denied, got %v", watched)
+	}
+}
+
 // Benchmark tests
 func BenchmarkGetNamespaces(b *testing.B) {
 	k := mockKubernetes()
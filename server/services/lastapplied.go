@@ -0,0 +1,102 @@
+// ==========================================================================================
+// The last-applied-configuration annotation kubectl apply leaves on objects, decoded so the
+// declared state can be shown alongside the live state. For Secrets it holds the values in
+// plain text, so it is redacted along with the data whenever RedactSecrets is on
+// ==========================================================================================
+
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+)
+
+// Annotation set by `kubectl apply` holding the object as it was last applied, as JSON
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// Fields holding the values of Secrets & ConfigMaps, which are redacted in the last applied config
+var redactedDataFields = []string{"data", "stringData", "binaryData"}
+
+// ObjectDetail is a single object, with the configuration it was last applied with when known
+type ObjectDetail struct {
+	Object *unstructured.Unstructured `json:"object"`
+	// LastApplied is the decoded last-applied-configuration annotation, nil when the object doesn't have one
+	LastApplied map[string]any `json:"lastApplied"`
+}
+
+// GetObjectDetail returns an object by kind & name, with its last applied configuration decoded
+// The apiVersion is only needed for kinds other than the common workload kinds, see resourceForKind
+func (k *Kubernetes) GetObjectDetail(ns, apiVersion, kind, name string) (*ObjectDetail, error) {
+	if ns == "" || kind == "" || name == "" {
+		return nil, errors.New("namespace, kind or name is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	obj, err := k.getByKind(ns, apiVersion, kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	obj = k.cleanObject(obj)
+
+	return &ObjectDetail{Object: obj, LastApplied: lastAppliedConfig(obj)}, nil
+}
+
+// lastAppliedConfig decodes the last-applied-configuration annotation, returning nil if missing or invalid
+func lastAppliedConfig(obj *unstructured.Unstructured) map[string]any {
+	raw, ok := obj.GetAnnotations()[lastAppliedAnnotation]
+	if !ok {
+		return nil
+	}
+
+	// Decoded the same way as unstructured objects, so whole numbers are int64 not float64
+	config := map[string]any{}
+	if err := utiljson.Unmarshal([]byte(raw), &config); err != nil {
+		log.Printf("💥 Failed to decode last applied config of %s %s: %v", obj.GetKind(), obj.GetName(), err)
+		return nil
+	}
+
+	return config
+}
+
+// redactLastApplied redacts the values of Secrets & ConfigMaps held in the last-applied-configuration annotation
+func redactLastApplied(obj *unstructured.Unstructured) {
+	editLastApplied(obj, func(config map[string]any) {
+		for _, field := range redactedDataFields {
+			if data, ok := config[field].(map[string]any); ok {
+				for key := range data {
+					data[key] = redactedValue
+				}
+			}
+		}
+	})
+}
+
+// editLastApplied decodes the last-applied-configuration annotation, changes it with edit, then encodes it again
+// An annotation which can't be decoded is replaced entirely, as there is no telling what is in it
+func editLastApplied(obj *unstructured.Unstructured, edit func(config map[string]any)) {
+	annotations := obj.GetAnnotations()
+	if _, ok := annotations[lastAppliedAnnotation]; !ok {
+		return
+	}
+
+	redacted := redactedValue
+
+	if config := lastAppliedConfig(obj); config != nil {
+		edit(config)
+
+		if encoded, err := json.Marshal(config); err == nil {
+			redacted = string(encoded)
+		}
+	}
+
+	annotations[lastAppliedAnnotation] = redacted
+	obj.SetAnnotations(annotations)
+}
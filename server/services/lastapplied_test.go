@@ -0,0 +1,94 @@
+// ==========================================================================================
+// Unit tests for the last applied configuration
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestKubernetes_GetObjectDetail(t *testing.T) {
+	k := mockKubernetes()
+
+	applied := createTestDeployment("web", "default", 3)
+	applied.SetAnnotations(map[string]string{
+		lastAppliedAnnotation: `{"apiVersion":"apps/v1","kind":"Deployment","spec":{"replicas":2}}`,
+	})
+
+	for _, deploy := range []*unstructured.Unstructured{applied, createTestDeployment("plain", "default", 1)} {
+		_, _ = k.dynamicClient.Resource(deploymentGVR).Namespace("default").
+			Create(context.TODO(), deploy, metaV1.CreateOptions{})
+	}
+
+	detail, err := k.GetObjectDetail("default", "", "Deployment", "web")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if detail.Object.GetName() != "web" {
+		t.Errorf("Expected the live object to be returned, got %s", detail.Object.GetName())
+	}
+
+	// The live object has 3 replicas, while 2 were declared
+	if replicas, _, _ := unstructured.NestedInt64(detail.LastApplied, "spec", "replicas"); replicas != 2 {
+		t.Errorf("Expected the last applied config to declare 2 replicas, got %d", replicas)
+	}
+
+	detail, err = k.GetObjectDetail("default", "", "Deployment", "plain")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if detail.LastApplied != nil {
+		t.Errorf("Expected no last applied config for an object without the annotation, got %v", detail.LastApplied)
+	}
+
+	if _, err := k.GetObjectDetail("default", "", "Deployment", ""); err == nil {
+		t.Error("Expected error for an empty name")
+	}
+}
+
+func TestKubernetes_GetObjectDetail_RedactSecret(t *testing.T) {
+	k := mockKubernetes()
+	setKindDiscovery(k)
+
+	secret := createTestSecret("db-creds", "default")
+	secret.SetAnnotations(map[string]string{
+		lastAppliedAnnotation: `{"apiVersion":"v1","kind":"Secret","data":{"password":"c2VjcmV0"},` +
+			`"stringData":{"token":"plain-text"}}`,
+	})
+
+	secretGVR := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "secrets"}
+	_, _ = k.dynamicClient.Resource(secretGVR).Namespace("default").Create(context.TODO(), secret, metaV1.CreateOptions{})
+
+	detail, err := k.GetObjectDetail("default", "v1", "Secret", "db-creds")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, field := range []string{"data", "stringData"} {
+		values, _, _ := unstructured.NestedStringMap(detail.LastApplied, field)
+		if len(values) != 1 {
+			t.Errorf("Expected one value in %s of the last applied config, got %v", field, values)
+		}
+
+		for key, value := range values {
+			if value != redactedValue {
+				t.Errorf("Expected %s.%s to be redacted, got %q", field, key, value)
+			}
+		}
+	}
+
+	// The raw annotation on the live object mustn't give the values away either
+	raw := detail.Object.GetAnnotations()[lastAppliedAnnotation]
+	if strings.Contains(raw, "c2VjcmV0") || strings.Contains(raw, "plain-text") {
+		t.Errorf("Expected the annotation on the live object to be redacted, got %s", raw)
+	}
+}
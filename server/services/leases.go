@@ -0,0 +1,102 @@
+// ==========================================================================================
+// Lease based leader election status, who holds each Lease and when it expires
+// The number of transitions shows when leadership is flapping between candidates
+// ==========================================================================================
+
+package services
+
+import (
+	"errors"
+	"time"
+
+	coordinationV1 "k8s.io/api/coordination/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// LeaseStatus is the current holder of a Lease, times are RFC3339 and empty when not set
+type LeaseStatus struct {
+	Name string `json:"name"`
+	// Holder is the identity of the leader, empty when no one holds the Lease
+	Holder          string `json:"holder"`
+	DurationSeconds int32  `json:"durationSeconds,omitempty"`
+	AcquireTime     string `json:"acquireTime,omitempty"`
+	RenewTime       string `json:"renewTime,omitempty"`
+	// ExpiryTime is when the Lease can be taken by another candidate, unless renewed before then
+	ExpiryTime string `json:"expiryTime,omitempty"`
+	// Expired is true when the holder hasn't renewed the Lease in time
+	Expired bool `json:"expired"`
+	// Transitions is how many times the Lease has changed holder
+	Transitions int32 `json:"transitions"`
+}
+
+// GetLeaseStatuses returns the holder & expiry of every Lease in a namespace
+func (k *Kubernetes) GetLeaseStatuses(ns string) ([]LeaseStatus, error) {
+	if ns == "" {
+		return nil, errors.New("namespace is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	leases, err := k.GetResources(ns, "coordination.k8s.io", "v1", "leases")
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	statuses := make([]LeaseStatus, 0, len(leases))
+
+	for i := range leases {
+		statuses = append(statuses, leaseStatus(&leases[i], now))
+	}
+
+	return statuses, nil
+}
+
+// leaseStatus works out the holder & expiry of a Lease, it expires a lease duration after it was last renewed
+func leaseStatus(obj *unstructured.Unstructured, now time.Time) LeaseStatus {
+	status := LeaseStatus{Name: obj.GetName()}
+
+	lease := coordinationV1.Lease{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &lease); err != nil {
+		return status
+	}
+
+	spec := lease.Spec
+
+	if spec.HolderIdentity != nil {
+		status.Holder = *spec.HolderIdentity
+	}
+
+	if spec.LeaseDurationSeconds != nil {
+		status.DurationSeconds = *spec.LeaseDurationSeconds
+	}
+
+	if spec.LeaseTransitions != nil {
+		status.Transitions = *spec.LeaseTransitions
+	}
+
+	if spec.AcquireTime != nil {
+		status.AcquireTime = spec.AcquireTime.UTC().Format(time.RFC3339)
+	}
+
+	// A Lease which has never been renewed counts from when it was acquired
+	renewed := spec.RenewTime
+	if renewed == nil {
+		renewed = spec.AcquireTime
+	}
+
+	if spec.RenewTime != nil {
+		status.RenewTime = spec.RenewTime.UTC().Format(time.RFC3339)
+	}
+
+	if renewed != nil && status.DurationSeconds > 0 {
+		expiry := renewed.Add(time.Duration(status.DurationSeconds) * time.Second)
+		status.ExpiryTime = expiry.UTC().Format(time.RFC3339)
+		status.Expired = now.After(expiry)
+	}
+
+	return status
+}
@@ -0,0 +1,75 @@
+// ==========================================================================================
+// Unit tests for Lease leader election status
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func createTestLease(name, ns, holder string, renewed time.Time) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "coordination.k8s.io/v1",
+		"kind":       "Lease",
+		"metadata":   map[string]interface{}{"name": name, "namespace": ns},
+		"spec": map[string]interface{}{
+			"holderIdentity":       holder,
+			"leaseDurationSeconds": int64(15),
+			"leaseTransitions":     int64(3),
+			"acquireTime":          renewed.Add(-time.Hour).UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+			"renewTime":            renewed.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		},
+	}}
+}
+
+func TestKubernetes_GetLeaseStatuses(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "kube-system")
+
+	lease := createTestLease("kube-scheduler", "kube-system", "node-1_abc123", time.Now())
+	_, _ = k.dynamicClient.Resource(leaseGVR).Namespace("kube-system").
+		Create(context.TODO(), lease, metaV1.CreateOptions{})
+
+	statuses, err := k.GetLeaseStatuses("kube-system")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(statuses) != 1 {
+		t.Fatalf("Expected 1 lease, got %+v", statuses)
+	}
+
+	s := statuses[0]
+	if s.Name != "kube-scheduler" || s.Holder != "node-1_abc123" || s.Transitions != 3 || s.DurationSeconds != 15 {
+		t.Errorf("Expected the scheduler lease held by node-1_abc123, got %+v", s)
+	}
+
+	if s.Expired || s.RenewTime == "" || s.ExpiryTime == "" {
+		t.Errorf("Expected a freshly renewed lease not to have expired, got %+v", s)
+	}
+
+	// Leases are fetched with the rest of the namespace
+	data, err := k.FetchNamespace("kube-system")
+	if err != nil || len(data["leases"]) != 1 {
+		t.Errorf("Expected the lease to be fetched, got %d, %v", len(data["leases"]), err)
+	}
+
+	if _, err := k.GetLeaseStatuses(""); err == nil {
+		t.Error("Expected error for empty namespace")
+	}
+}
+
+func TestLeaseStatus_Expired(t *testing.T) {
+	renewed := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := leaseStatus(createTestLease("controller", "default", "node-2", renewed), renewed.Add(time.Minute))
+
+	if !s.Expired || s.ExpiryTime != "2025-01-02T03:04:20Z" || s.AcquireTime != "2025-01-02T02:04:05Z" {
+		t.Errorf("Expected the lease to have expired 15s after renewal, got %+v", s)
+	}
+}
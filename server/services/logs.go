@@ -0,0 +1,226 @@
+// ==========================================================================================
+// Pod logs from every container, merged into a single view
+// ==========================================================================================
+
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Longer lines than this are an error, rather than being split
+const maxLogLineLength = 1024 * 1024
+
+// Lines of logs returned when no count or window of time is given
+const defaultLogLines = 100
+
+// Most lines of logs which can be asked for, when MaxLogLines is not set
+const defaultMaxLogLines = 5000
+
+// ErrInvalidLogOptions is returned when the window of time to get logs for is invalid
+var ErrInvalidLogOptions = errors.New("invalid log options")
+
+// LogOptions limit the logs returned to a window of time, the zero value applies no window
+type LogOptions struct {
+	// SinceSeconds only returns logs from this many seconds ago onwards
+	SinceSeconds int64
+	// SinceTime only returns logs written after this time, it can't be used together with SinceSeconds
+	SinceTime time.Time
+	// Timestamps starts each line with the RFC 3339 time it was written, after the container name when merged
+	Timestamps bool
+}
+
+// logLine is a line of a container's logs, with the time it was written when known
+type logLine struct {
+	time      time.Time
+	container string
+	text      string
+}
+
+// GetPodLogsAllContainers returns the last lines of logs from every container in a pod, including init
+// containers, merged in time order with each line prefixed by its container name e.g. "[app] started"
+func (k *Kubernetes) GetPodLogsAllContainers(ns, podName string, lineCount int) (string, error) {
+	return k.GetPodLogsAllContainersWithOptions(ns, podName, lineCount, LogOptions{})
+}
+
+// GetPodLogsAllContainersWithOptions is GetPodLogsAllContainers limited to a window of time
+func (k *Kubernetes) GetPodLogsAllContainersWithOptions(ns, podName string, lineCount int,
+	opts LogOptions) (string, error) {
+	if ns == "" || podName == "" {
+		return "", errors.New("namespace or pod name is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return "", err
+	}
+
+	lineCount, _ = k.LogLineLimit(lineCount)
+
+	logOpts, err := opts.podLogOptions(lineCount)
+	if err != nil {
+		return "", err
+	}
+
+	var pod *coreV1.Pod
+
+	err = k.callAPI(func(ctx context.Context) (err error) {
+		pod, err = k.clientSet.CoreV1().Pods(ns).Get(ctx, podName, metaV1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	containers := []string{}
+	for _, c := range slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers) {
+		containers = append(containers, c.Name)
+	}
+
+	lines := []logLine{}
+	fetched := 0
+
+	for _, container := range containers {
+		containerLines, err := k.getContainerLogs(ns, podName, container, *logOpts)
+		if err != nil {
+			// Init containers which haven't run yet have no logs, so this isn't treated as a failure
+			log.Printf("⚠️ Failed to get logs for container %s of pod %s in namespace %s: %v", container, podName, ns, err)
+			continue
+		}
+
+		fetched++
+
+		lines = append(lines, containerLines...)
+	}
+
+	if fetched == 0 && len(containers) > 0 {
+		return "", fmt.Errorf("no logs could be fetched from any container of pod %s", podName)
+	}
+
+	return mergeLogLines(lines, opts.Timestamps), nil
+}
+
+// LogLineLimit clamps a requested count of log lines to MaxLogLines, so a huge count can't exhaust memory
+// Returns the count to use, and true when it was clamped
+func (k *Kubernetes) LogLineLimit(lineCount int) (int, bool) {
+	limit := k.MaxLogLines
+	if limit <= 0 {
+		limit = defaultMaxLogLines
+	}
+
+	if lineCount > limit {
+		return limit, true
+	}
+
+	return lineCount, false
+}
+
+// podLogOptions checks the window of time, then converts it to the options for the API
+// The line count is a limit within the window, when there's no window it defaults to defaultLogLines
+func (o LogOptions) podLogOptions(lineCount int) (*coreV1.PodLogOptions, error) {
+	if o.SinceSeconds < 0 {
+		return nil, fmt.Errorf("%w: sinceSeconds can't be negative", ErrInvalidLogOptions)
+	}
+
+	if o.SinceSeconds > 0 && !o.SinceTime.IsZero() {
+		return nil, fmt.Errorf("%w: only one of sinceSeconds or sinceTime can be set", ErrInvalidLogOptions)
+	}
+
+	logOpts := &coreV1.PodLogOptions{Timestamps: o.Timestamps}
+
+	if o.SinceSeconds > 0 {
+		logOpts.SinceSeconds = &o.SinceSeconds
+	}
+
+	if !o.SinceTime.IsZero() {
+		logOpts.SinceTime = &metaV1.Time{Time: o.SinceTime}
+	}
+
+	if lineCount <= 0 && logOpts.SinceSeconds == nil && logOpts.SinceTime == nil {
+		lineCount = defaultLogLines
+	}
+
+	if lineCount > 0 {
+		logOpts.TailLines = &[]int64{int64(lineCount)}[0]
+	}
+
+	return logOpts, nil
+}
+
+// getContainerLogs fetches the logs from a container, with the timestamp of each line
+// Timestamps are always fetched as they are needed to merge containers, the options only decide if they are shown
+func (k *Kubernetes) getContainerLogs(ns, podName, container string, opts coreV1.PodLogOptions) ([]logLine, error) {
+	opts.Container = container
+	opts.Timestamps = true
+
+	req := k.clientSet.CoreV1().Pods(ns).GetLogs(podName, &opts)
+
+	var logs []byte
+
+	err := k.callAPI(func(ctx context.Context) (err error) {
+		logs, err = req.DoRaw(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseLogLines(container, logs)
+}
+
+// parseLogLines splits logs into lines, taking the timestamp from the start of each line
+func parseLogLines(container string, logs []byte) ([]logLine, error) {
+	lines := []logLine{}
+	last := time.Time{}
+	scanner := bufio.NewScanner(bytes.NewReader(logs))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLogLineLength)
+
+	for scanner.Scan() {
+		line := logLine{container: container, text: scanner.Text()}
+
+		// Each line starts with a timestamp then a space, lines without one take the time of the line before
+		if stamp, text, found := strings.Cut(line.text, " "); found {
+			if t, err := time.Parse(time.RFC3339Nano, stamp); err == nil {
+				last = t
+				line.text = text
+			}
+		}
+
+		line.time = last
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// mergeLogLines puts the lines in time order, prefixing each with the container name & optionally the time
+// Lines with no timestamp of their own are shown with the time of the line before, as they are sorted by it
+func mergeLogLines(lines []logLine, timestamps bool) string {
+	// Stable, so lines from a container stay in order when times are the same or unknown
+	slices.SortStableFunc(lines, func(a, b logLine) int {
+		return a.time.Compare(b.time)
+	})
+
+	var merged strings.Builder
+
+	for _, line := range lines {
+		if timestamps && !line.time.IsZero() {
+			fmt.Fprintf(&merged, "[%s] %s %s\n", line.container, line.time.Format(time.RFC3339Nano), line.text)
+			continue
+		}
+
+		fmt.Fprintf(&merged, "[%s] %s\n", line.container, line.text)
+	}
+
+	return merged.String()
+}
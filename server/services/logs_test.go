@@ -0,0 +1,187 @@
+// ==========================================================================================
+// Unit tests for merged pod logs
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestKubernetes_GetPodLogsAllContainers(t *testing.T) {
+	k := mockKubernetes()
+
+	// Test empty namespace
+	_, err := k.GetPodLogsAllContainers("", "test-pod", 100)
+	if err == nil {
+		t.Error("Expected error for empty namespace, got nil")
+	}
+
+	// Test empty pod name
+	_, err = k.GetPodLogsAllContainers("default", "", 100)
+	if err == nil {
+		t.Error("Expected error for empty pod name, got nil")
+	}
+
+	// Test pod which doesn't exist
+	_, err = k.GetPodLogsAllContainers("default", "missing", 100)
+	if err == nil {
+		t.Error("Expected error for missing pod, got nil")
+	}
+
+	pod := &coreV1.Pod{
+		ObjectMeta: metaV1.ObjectMeta{Name: "test-pod", Namespace: "default"},
+		Spec: coreV1.PodSpec{
+			InitContainers: []coreV1.Container{{Name: "setup"}},
+			Containers:     []coreV1.Container{{Name: "app"}, {Name: "sidecar"}},
+		},
+	}
+	_, _ = k.clientSet.CoreV1().Pods("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+
+	// Fake client returns the same untimestamped line for every container, so the order is the container order
+	logs, err := k.GetPodLogsAllContainers("default", "test-pod", 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(logs), "\n")
+	expected := []string{"[setup] ", "[app] ", "[sidecar] "}
+
+	if len(lines) != len(expected) {
+		t.Fatalf("Expected %d lines, got %d: %q", len(expected), len(lines), logs)
+	}
+
+	for i, prefix := range expected {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("Expected line %d to start with %q, got %q", i, prefix, lines[i])
+		}
+	}
+}
+
+func TestMergeLogLines(t *testing.T) {
+	lines := []logLine{}
+
+	for _, c := range []struct{ container, logs string }{
+		{"app", "2024-01-01T10:00:01Z first app\n2024-01-01T10:00:03Z second app\n"},
+		{"sidecar", "2024-01-01T10:00:02Z first sidecar\ncontinued without timestamp\n"},
+	} {
+		parsed, err := parseLogLines(c.container, []byte(c.logs))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		lines = append(lines, parsed...)
+	}
+
+	merged := mergeLogLines(lines, false)
+	expected := "[app] first app\n[sidecar] first sidecar\n[sidecar] continued without timestamp\n[app] second app\n"
+
+	if merged != expected {
+		t.Errorf("Expected lines merged in time order, got %q", merged)
+	}
+
+	// Timestamps go after the container name, a line without one shows the time it was sorted by
+	merged = mergeLogLines(lines, true)
+	expected = "[app] 2024-01-01T10:00:01Z first app\n" +
+		"[sidecar] 2024-01-01T10:00:02Z first sidecar\n" +
+		"[sidecar] 2024-01-01T10:00:02Z continued without timestamp\n" +
+		"[app] 2024-01-01T10:00:03Z second app\n"
+
+	if merged != expected {
+		t.Errorf("Expected lines merged with timestamps, got %q", merged)
+	}
+}
+
+func TestLogOptions_PodLogOptions(t *testing.T) {
+	since := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// Both set is rejected, for pods & for every container
+	k := mockKubernetes()
+	both := LogOptions{SinceSeconds: 300, SinceTime: since}
+
+	if _, err := k.GetPodLogsWithOptions("default", "test-pod", 0, both); !errors.Is(err, ErrInvalidLogOptions) {
+		t.Errorf("Expected ErrInvalidLogOptions with both set, got %v", err)
+	}
+
+	_, err := k.GetPodLogsAllContainersWithOptions("default", "test-pod", 0, both)
+	if !errors.Is(err, ErrInvalidLogOptions) {
+		t.Errorf("Expected ErrInvalidLogOptions for all containers with both set, got %v", err)
+	}
+
+	if _, err := (LogOptions{SinceSeconds: -1}).podLogOptions(0); !errors.Is(err, ErrInvalidLogOptions) {
+		t.Errorf("Expected ErrInvalidLogOptions for negative seconds, got %v", err)
+	}
+
+	// No window keeps the default tail of lines
+	opts, err := LogOptions{}.podLogOptions(0)
+	if err != nil || opts.TailLines == nil || *opts.TailLines != defaultLogLines {
+		t.Errorf("Expected the default tail lines with no window, got %+v, %v", opts, err)
+	}
+
+	// A window with no count returns every line in it
+	opts, err = LogOptions{SinceSeconds: 300}.podLogOptions(0)
+	if err != nil || opts.TailLines != nil || opts.SinceSeconds == nil || *opts.SinceSeconds != 300 {
+		t.Errorf("Expected only sinceSeconds to be set, got %+v, %v", opts, err)
+	}
+
+	opts, err = LogOptions{Timestamps: true}.podLogOptions(0)
+	if err != nil || !opts.Timestamps {
+		t.Errorf("Expected timestamps to be requested, got %+v, %v", opts, err)
+	}
+
+	opts, err = LogOptions{SinceTime: since}.podLogOptions(50)
+	if err != nil || opts.SinceTime == nil || !opts.SinceTime.Time.Equal(since) || *opts.TailLines != 50 ||
+		opts.Timestamps {
+		t.Errorf("Expected sinceTime & the tail lines to be set, got %+v, %v", opts, err)
+	}
+}
+
+func TestKubernetes_LogLineLimit(t *testing.T) {
+	k := mockKubernetes()
+
+	if lines, clamped := k.LogLineLimit(10_000_000); lines != defaultMaxLogLines || !clamped {
+		t.Errorf("Expected a huge count to be clamped to %d, got %d, %v", defaultMaxLogLines, lines, clamped)
+	}
+
+	k.MaxLogLines = 200
+
+	if lines, clamped := k.LogLineLimit(1000); lines != 200 || !clamped {
+		t.Errorf("Expected the count to be clamped to 200, got %d, %v", lines, clamped)
+	}
+
+	// Counts within the limit, and no count at all, are left alone
+	if lines, clamped := k.LogLineLimit(50); lines != 50 || clamped {
+		t.Errorf("Expected 50 lines unclamped, got %d, %v", lines, clamped)
+	}
+
+	if lines, clamped := k.LogLineLimit(0); lines != 0 || clamped {
+		t.Errorf("Expected no count to be left unclamped, got %d, %v", lines, clamped)
+	}
+
+	// The clamped count is what's asked of the API
+	if _, err := k.GetPodLogs("default", "test-pod", 10_000_000); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var opts *coreV1.PodLogOptions
+
+	client, _ := k.clientSet.(*k8sfake.Clientset)
+	for _, action := range client.Actions() {
+		if action.GetSubresource() == "log" {
+			opts, _ = action.(k8stesting.GenericActionImpl).Value.(*coreV1.PodLogOptions)
+		}
+	}
+
+	if opts == nil || opts.TailLines == nil || *opts.TailLines != 200 {
+		t.Errorf("Expected the logs to be requested with a tail of 200, got %+v", opts)
+	}
+}
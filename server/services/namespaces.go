@@ -0,0 +1,216 @@
+// ==========================================================================================
+// Namespace access control, status & summaries, giving an overview of each namespace for the namespace picker
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ErrNamespaceForbidden is returned when accessing a namespace blocked by the allowlist or denylist
+var ErrNamespaceForbidden = errors.New("namespace is not permitted")
+
+// NamespacePermitted checks a namespace against single namespace mode, then the allowlist & denylist
+// The denylist always wins
+func (k *Kubernetes) NamespacePermitted(ns string) bool {
+	if k.SingleNamespace != "" && ns != k.SingleNamespace {
+		return false
+	}
+
+	if slices.Contains(k.NamespaceDenylist, ns) {
+		return false
+	}
+
+	return len(k.NamespaceAllowlist) == 0 || slices.Contains(k.NamespaceAllowlist, ns)
+}
+
+// checkNamespace returns ErrNamespaceForbidden when the namespace is not permitted
+func (k *Kubernetes) checkNamespace(ns string) error {
+	if !k.NamespacePermitted(ns) {
+		return fmt.Errorf("%w: %s", ErrNamespaceForbidden, ns)
+	}
+
+	return nil
+}
+
+// DefaultNamespace picks the namespace to show first, the preferred one when it exists and is permitted
+// Otherwise falls back to "default", then the first permitted namespace, returning empty if there are none
+func (k *Kubernetes) DefaultNamespace(preferred string) string {
+	if preferred != "" {
+		if k.CheckNamespaceExists(preferred) {
+			return preferred
+		}
+
+		log.Printf("⚠️ Default namespace %s does not exist or is not permitted, falling back", preferred)
+	}
+
+	if k.CheckNamespaceExists("default") {
+		return "default"
+	}
+
+	namespaces, err := k.GetNamespaces()
+	if err != nil || len(namespaces) == 0 {
+		return ""
+	}
+
+	slices.Sort(namespaces)
+
+	return namespaces[0]
+}
+
+// NamespaceStatus is the phase of a namespace, and what is holding up its deletion when Terminating
+type NamespaceStatus struct {
+	Name string `json:"name"`
+	// Phase is "Active" or "Terminating"
+	Phase string `json:"phase"`
+	// Finalizers which must be removed before a terminating namespace can be deleted
+	Finalizers []string `json:"finalizers,omitempty"`
+	// Blockers are the messages from conditions explaining why deletion hasn't finished
+	Blockers []string `json:"blockers,omitempty"`
+}
+
+// GetNamespaceStatuses returns the phase of each permitted namespace, detailing any stuck Terminating
+func (k *Kubernetes) GetNamespaceStatuses() ([]NamespaceStatus, error) {
+	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
+
+	var l *unstructured.UnstructuredList
+
+	err := k.callAPI(func(ctx context.Context) (err error) {
+		l, err = k.dynamicClient.Resource(gvr).List(ctx, metaV1.ListOptions{})
+		return err
+	})
+	if err != nil {
+		log.Println("💥 Failed to get namespaces:", err)
+		return nil, err
+	}
+
+	statuses := []NamespaceStatus{}
+
+	// Hide the namespaces which aren't permitted
+	for _, ns := range l.Items {
+		if k.NamespacePermitted(ns.GetName()) {
+			statuses = append(statuses, namespaceStatus(ns))
+		}
+	}
+
+	return statuses, nil
+}
+
+// namespaceStatus gets the phase of a namespace, with finalizers & blocking conditions when Terminating
+func namespaceStatus(ns unstructured.Unstructured) NamespaceStatus {
+	status := NamespaceStatus{Name: ns.GetName()}
+	status.Phase, _, _ = unstructured.NestedString(ns.Object, "status", "phase")
+
+	if status.Phase != "Terminating" {
+		return status
+	}
+
+	// Both the spec finalizers e.g. "kubernetes" and metadata finalizers block deletion
+	specFinalizers, _, _ := unstructured.NestedStringSlice(ns.Object, "spec", "finalizers")
+	status.Finalizers = slices.Concat(specFinalizers, ns.GetFinalizers())
+
+	conditions, _, _ := unstructured.NestedSlice(ns.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, _ := c.(map[string]interface{})
+
+		if condition["status"] == "True" {
+			if message, _ := condition["message"].(string); message != "" {
+				status.Blockers = append(status.Blockers, message)
+			}
+		}
+	}
+
+	return status
+}
+
+// NamespaceSummary is a namespace along with counts of the main resource types in it
+type NamespaceSummary struct {
+	Name string `json:"name"`
+	// Counts is keyed on resource type e.g. "pods", types which failed to be counted are left out
+	Counts map[string]int `json:"counts"`
+}
+
+// The resource types counted for each namespace, kept small as every one is an API call per namespace
+var summaryResources = []schema.GroupVersionResource{
+	{Group: "", Version: "v1", Resource: "pods"},
+	{Group: "apps", Version: "v1", Resource: "deployments"},
+	{Group: "apps", Version: "v1", Resource: "statefulsets"},
+	{Group: "", Version: "v1", Resource: "services"},
+}
+
+// Maximum number of namespaces being counted at once, so we don't flood the API server
+const summaryConcurrency = 5
+
+// GetNamespaceSummaries counts the main resource types in each of the given namespaces
+// Results are returned in the same order as the namespaces passed in
+func (k *Kubernetes) GetNamespaceSummaries(namespaces []string) []NamespaceSummary {
+	summaries := make([]NamespaceSummary, len(namespaces))
+	sem := make(chan struct{}, summaryConcurrency)
+
+	var wg sync.WaitGroup
+
+	for i, ns := range namespaces {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			summaries[i] = NamespaceSummary{Name: ns, Counts: k.countResources(ns)}
+		}()
+	}
+
+	wg.Wait()
+
+	return summaries
+}
+
+// countResources counts each of the summary resource types in a namespace
+func (k *Kubernetes) countResources(ns string) map[string]int {
+	counts := make(map[string]int, len(summaryResources))
+
+	for _, gvr := range summaryResources {
+		count, err := k.countResource(ns, gvr)
+		if err != nil {
+			log.Printf("💥 Failed to count %s in namespace %s: %v", gvr.Resource, ns, err)
+			continue
+		}
+
+		counts[gvr.Resource] = count
+	}
+
+	return counts
+}
+
+// countResource counts a resource type in a namespace, using the remaining item count for big lists
+func (k *Kubernetes) countResource(ns string, gvr schema.GroupVersionResource) (int, error) {
+	count := 0
+
+	err := k.callAPI(func(ctx context.Context) error {
+		l, err := k.dynamicClient.Resource(gvr).Namespace(ns).List(ctx, metaV1.ListOptions{Limit: 500})
+		if err != nil {
+			return err
+		}
+
+		count = len(l.Items)
+		if remaining := l.GetRemainingItemCount(); remaining != nil {
+			count += int(*remaining)
+		}
+
+		return nil
+	})
+
+	return count, err
+}
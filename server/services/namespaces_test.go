@@ -0,0 +1,207 @@
+// ==========================================================================================
+// Unit tests for namespace access control, status & summaries
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestKubernetes_GetNamespaceSummaries(t *testing.T) {
+	k := mockKubernetes()
+
+	podGvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "pods"}
+	deployGvr := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	// Two pods and a deployment in "apps", one pod in "tools", nothing in "empty"
+	for _, pod := range []struct{ name, ns string }{{"web-1", "apps"}, {"web-2", "apps"}, {"debug", "tools"}} {
+		_, _ = k.dynamicClient.Resource(podGvr).Namespace(pod.ns).
+			Create(context.TODO(), createTestPod(pod.name, pod.ns), metaV1.CreateOptions{})
+	}
+
+	_, _ = k.dynamicClient.Resource(deployGvr).Namespace("apps").
+		Create(context.TODO(), createTestDeployment("web", "apps", 2), metaV1.CreateOptions{})
+
+	summaries := k.GetNamespaceSummaries([]string{"apps", "tools", "empty"})
+
+	expected := []struct {
+		name        string
+		pods        int
+		deployments int
+	}{
+		{"apps", 2, 1},
+		{"tools", 1, 0},
+		{"empty", 0, 0},
+	}
+
+	if len(summaries) != len(expected) {
+		t.Fatalf("Expected %d summaries, got %d", len(expected), len(summaries))
+	}
+
+	for i, exp := range expected {
+		s := summaries[i]
+		if s.Name != exp.name {
+			t.Errorf("Expected summary %d to be for %s, got %s", i, exp.name, s.Name)
+		}
+
+		if s.Counts["pods"] != exp.pods || s.Counts["deployments"] != exp.deployments {
+			t.Errorf("Namespace %s: expected %d pods & %d deployments, got %v", exp.name, exp.pods, exp.deployments, s.Counts)
+		}
+	}
+}
+
+func TestKubernetes_GetNamespaceSummaries_Many(t *testing.T) {
+	k := mockKubernetes()
+
+	// More namespaces than the concurrency limit, all should still be counted in order
+	namespaces := make([]string, summaryConcurrency*3)
+	for i := range namespaces {
+		namespaces[i] = fmt.Sprintf("ns-%d", i)
+	}
+
+	summaries := k.GetNamespaceSummaries(namespaces)
+
+	for i, s := range summaries {
+		if s.Name != namespaces[i] {
+			t.Errorf("Expected summary %d to be for %s, got %s", i, namespaces[i], s.Name)
+		}
+
+		if _, ok := s.Counts["pods"]; !ok {
+			t.Errorf("Expected pods to be counted for %s", s.Name)
+		}
+	}
+}
+
+func TestKubernetes_NamespaceAllowDenyLists(t *testing.T) {
+	k := mockKubernetes()
+	k.NamespaceAllowlist = []string{"default", "apps", "kube-system"}
+	k.NamespaceDenylist = []string{"kube-system"}
+
+	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
+	for _, name := range []string{"default", "apps", "kube-system", "other"} {
+		_, _ = k.dynamicClient.Resource(gvr).Create(context.TODO(), createTestNamespace(name), metaV1.CreateOptions{})
+	}
+
+	namespaces, err := k.GetNamespaces()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Denylist wins over the allowlist, and anything not allowed is hidden
+	slices.Sort(namespaces)
+
+	if !slices.Equal(namespaces, []string{"apps", "default"}) {
+		t.Errorf("Expected only apps & default to be listed, got %v", namespaces)
+	}
+
+	if k.CheckNamespaceExists("kube-system") || k.CheckNamespaceExists("other") {
+		t.Error("Expected namespaces which aren't permitted to not exist")
+	}
+
+	if !k.CheckNamespaceExists("default") {
+		t.Error("Expected allowed namespace to exist")
+	}
+
+	if _, err := k.FetchNamespace("kube-system"); !errors.Is(err, ErrNamespaceForbidden) {
+		t.Errorf("Expected fetching a denied namespace to be forbidden, got %v", err)
+	}
+
+	if _, err := k.GetPodLogs("other", "web", 10); !errors.Is(err, ErrNamespaceForbidden) {
+		t.Errorf("Expected pod logs in a namespace not allowed to be forbidden, got %v", err)
+	}
+
+	if _, err := k.FetchNamespace("default"); err != nil {
+		t.Errorf("Expected fetching an allowed namespace to succeed, got %v", err)
+	}
+}
+
+func TestKubernetes_GetNamespaceStatuses(t *testing.T) {
+	k := mockKubernetes()
+	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
+
+	active := createTestNamespace("default")
+	active.Object["status"] = map[string]interface{}{"phase": "Active"}
+
+	stuck := createTestNamespace("stuck")
+	stuck.SetFinalizers([]string{"example.com/cleanup"})
+	stuck.Object["spec"] = map[string]interface{}{"finalizers": []interface{}{"kubernetes"}}
+	stuck.Object["status"] = map[string]interface{}{
+		"phase": "Terminating",
+		"conditions": []interface{}{
+			map[string]interface{}{
+				"type":    "NamespaceFinalizersRemaining",
+				"status":  "True",
+				"message": "Some content in the namespace has finalizers remaining",
+			},
+			map[string]interface{}{"type": "NamespaceDeletionDiscoveryFailure", "status": "False", "message": "ok"},
+		},
+	}
+
+	_, _ = k.dynamicClient.Resource(gvr).Create(context.TODO(), active, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(gvr).Create(context.TODO(), stuck, metaV1.CreateOptions{})
+
+	statuses, err := k.GetNamespaceStatuses()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	byName := map[string]NamespaceStatus{}
+	for _, s := range statuses {
+		byName[s.Name] = s
+	}
+
+	if s := byName["default"]; s.Phase != "Active" || len(s.Finalizers) != 0 || len(s.Blockers) != 0 {
+		t.Errorf("Expected active namespace with no finalizers, got %+v", s)
+	}
+
+	s := byName["stuck"]
+	if s.Phase != "Terminating" {
+		t.Fatalf("Expected stuck namespace to be terminating, got %s", s.Phase)
+	}
+
+	if !slices.Equal(s.Finalizers, []string{"kubernetes", "example.com/cleanup"}) {
+		t.Errorf("Expected both spec & metadata finalizers, got %v", s.Finalizers)
+	}
+
+	if len(s.Blockers) != 1 || s.Blockers[0] != "Some content in the namespace has finalizers remaining" {
+		t.Errorf("Expected only the true condition as a blocker, got %v", s.Blockers)
+	}
+}
+
+func TestKubernetes_DefaultNamespace(t *testing.T) {
+	k := mockKubernetes()
+	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "namespaces"}
+
+	for _, name := range []string{"default", "team-a", "apps"} {
+		_, _ = k.dynamicClient.Resource(gvr).Create(context.TODO(), createTestNamespace(name), metaV1.CreateOptions{})
+	}
+
+	if ns := k.DefaultNamespace("team-a"); ns != "team-a" {
+		t.Errorf("Expected the configured default team-a, got %q", ns)
+	}
+
+	if ns := k.DefaultNamespace("missing"); ns != "default" {
+		t.Errorf("Expected a missing namespace to fall back to default, got %q", ns)
+	}
+
+	// Without access to "default" the first permitted namespace is used
+	k.NamespaceDenylist = []string{"default", "team-a"}
+
+	if ns := k.DefaultNamespace("team-a"); ns != "apps" {
+		t.Errorf("Expected a denied namespace to fall back to apps, got %q", ns)
+	}
+
+	k.NamespaceAllowlist = []string{"other"}
+
+	if ns := k.DefaultNamespace(""); ns != "" {
+		t.Errorf("Expected no default with no permitted namespaces, got %q", ns)
+	}
+}
@@ -0,0 +1,218 @@
+// ==========================================================================================
+// NetworkPolicies, the pods they select and a readable summary of their ingress & egress rules
+// ==========================================================================================
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// NetworkPolicySummary is a NetworkPolicy with the pods it applies to and its rules in short form
+type NetworkPolicySummary struct {
+	Name string `json:"name"`
+	// PolicyTypes are "Ingress" and/or "Egress"
+	PolicyTypes []string `json:"policyTypes"`
+	// Pods are the names of the pods selected by the policy
+	Pods    []string            `json:"pods"`
+	Ingress []NetworkPolicyRule `json:"ingress"`
+	Egress  []NetworkPolicyRule `json:"egress"`
+}
+
+// NetworkPolicyRule is one ingress or egress rule, with peers like "pods app=web" and ports like "TCP/80"
+// Empty peers or ports means all are allowed
+type NetworkPolicyRule struct {
+	Peers []string `json:"peers"`
+	Ports []string `json:"ports"`
+}
+
+// GetNetworkPolicies returns a summary of every NetworkPolicy in a namespace
+func (k *Kubernetes) GetNetworkPolicies(ns string) ([]NetworkPolicySummary, error) {
+	if ns == "" {
+		return nil, errors.New("namespace is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	policies, err := k.GetResources(ns, "networking.k8s.io", "v1", "networkpolicies")
+	if err != nil {
+		return nil, err
+	}
+
+	pods, err := k.GetResources(ns, podGVR.Group, podGVR.Version, podGVR.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make([]NetworkPolicySummary, 0, len(policies))
+
+	for i := range policies {
+		summary := NetworkPolicySummary{
+			Name:        policies[i].GetName(),
+			PolicyTypes: policyTypes(&policies[i]),
+			Pods:        []string{},
+			Ingress:     policyRules(&policies[i], "ingress", "from"),
+			Egress:      policyRules(&policies[i], "egress", "to"),
+		}
+
+		for _, pod := range selectedPods(&policies[i], pods) {
+			summary.Pods = append(summary.Pods, pod.GetName())
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, nil
+}
+
+// selectedPods returns the pods matched by the podSelector of a NetworkPolicy
+// An empty podSelector selects every pod in the namespace, an invalid one selects nothing
+func selectedPods(policy *unstructured.Unstructured, pods []unstructured.Unstructured) []unstructured.Unstructured {
+	podSelector, _, _ := unstructured.NestedMap(policy.Object, "spec", "podSelector")
+
+	selector, err := toSelector(podSelector)
+	if err != nil {
+		return nil
+	}
+
+	selected := []unstructured.Unstructured{}
+
+	for _, pod := range pods {
+		if selector.Matches(labels.Set(pod.GetLabels())) {
+			selected = append(selected, pod)
+		}
+	}
+
+	return selected
+}
+
+// toSelector converts a label selector in unstructured form, supporting both matchLabels & matchExpressions
+func toSelector(obj map[string]interface{}) (labels.Selector, error) {
+	ls := metaV1.LabelSelector{}
+
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj, &ls); err != nil {
+		return nil, err
+	}
+
+	return metaV1.LabelSelectorAsSelector(&ls)
+}
+
+// policyTypes returns the policy types, which default to Ingress plus Egress when there are egress rules
+func policyTypes(policy *unstructured.Unstructured) []string {
+	types, found, _ := unstructured.NestedStringSlice(policy.Object, "spec", "policyTypes")
+	if found {
+		return types
+	}
+
+	types = []string{"Ingress"}
+	if egress, _, _ := unstructured.NestedSlice(policy.Object, "spec", "egress"); len(egress) > 0 {
+		types = append(types, "Egress")
+	}
+
+	return types
+}
+
+// policyRules summarises the ingress or egress rules of a policy, peerField is "from" or "to"
+func policyRules(policy *unstructured.Unstructured, field, peerField string) []NetworkPolicyRule {
+	rules := []NetworkPolicyRule{}
+
+	list, _, _ := unstructured.NestedSlice(policy.Object, "spec", field)
+	for _, r := range list {
+		rule, _ := r.(map[string]interface{})
+		summary := NetworkPolicyRule{Peers: []string{}, Ports: []string{}}
+
+		peers, _, _ := unstructured.NestedSlice(rule, peerField)
+		for _, p := range peers {
+			peer, _ := p.(map[string]interface{})
+			summary.Peers = append(summary.Peers, peerSummary(peer))
+		}
+
+		ports, _, _ := unstructured.NestedSlice(rule, "ports")
+		for _, p := range ports {
+			port, _ := p.(map[string]interface{})
+			summary.Ports = append(summary.Ports, portSummary(port))
+		}
+
+		rules = append(rules, summary)
+	}
+
+	return rules
+}
+
+// peerSummary describes a peer e.g. "pods app=web in namespaces team=a" or "ipBlock 10.0.0.0/8"
+func peerSummary(peer map[string]interface{}) string {
+	if cidr, found, _ := unstructured.NestedString(peer, "ipBlock", "cidr"); found {
+		except, _, _ := unstructured.NestedStringSlice(peer, "ipBlock", "except")
+		if len(except) > 0 {
+			return fmt.Sprintf("ipBlock %s except %s", cidr, strings.Join(except, ", "))
+		}
+
+		return "ipBlock " + cidr
+	}
+
+	parts := []string{}
+
+	if podSelector, found, _ := unstructured.NestedMap(peer, "podSelector"); found {
+		parts = append(parts, "pods "+selectorSummary(podSelector))
+	}
+
+	if nsSelector, found, _ := unstructured.NestedMap(peer, "namespaceSelector"); found {
+		parts = append(parts, "namespaces "+selectorSummary(nsSelector))
+	}
+
+	return strings.Join(parts, " in ")
+}
+
+// selectorSummary describes a label selector, an empty selector matches everything
+func selectorSummary(obj map[string]interface{}) string {
+	selector, err := toSelector(obj)
+	if err != nil {
+		return "(invalid selector)"
+	}
+
+	if selector.Empty() {
+		return "(all)"
+	}
+
+	return selector.String()
+}
+
+// portSummary describes a port e.g. "TCP/80", "UDP/dns" or "TCP/8000-9000"
+func portSummary(port map[string]interface{}) string {
+	protocol, found, _ := unstructured.NestedString(port, "protocol")
+	if !found {
+		protocol = "TCP"
+	}
+
+	value, found, _ := unstructured.NestedFieldNoCopy(port, "port")
+	if !found {
+		return protocol + "/all"
+	}
+
+	summary := fmt.Sprintf("%s/%v", protocol, value)
+	if endPort, found, _ := unstructured.NestedInt64(port, "endPort"); found {
+		summary += fmt.Sprintf("-%d", endPort)
+	}
+
+	return summary
+}
+
+// networkPolicyRelationships links a NetworkPolicy to the pods it applies to
+func networkPolicyRelationships(policy *unstructured.Unstructured, pods []unstructured.Unstructured) []Relationship {
+	rels := []Relationship{}
+
+	for _, pod := range selectedPods(policy, pods) {
+		rels = append(rels, Relationship{From: refOf(policy), To: refOf(&pod), Type: "applies"})
+	}
+
+	return rels
+}
@@ -0,0 +1,119 @@
+// ==========================================================================================
+// Unit tests for NetworkPolicies
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var networkPolicyGVR = schema.GroupVersionResource{
+	Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies",
+}
+
+// createTestNetworkPolicy creates a NetworkPolicy with the given pod selector and spec
+func createTestNetworkPolicy(name string, podSelector, spec map[string]interface{}) *unstructured.Unstructured {
+	spec["podSelector"] = podSelector
+
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "networking.k8s.io/v1",
+			"kind":       "NetworkPolicy",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+			"spec":       spec,
+		},
+	}
+}
+
+func TestKubernetes_GetNetworkPolicies(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	for name, tier := range map[string]string{"web-1": "web", "web-2": "web", "db-1": "db"} {
+		pod := createTestPod(name, "default")
+		pod.SetLabels(map[string]string{"tier": tier})
+		_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+	}
+
+	// Only lets the web tier talk to the database
+	dbPolicy := createTestNetworkPolicy("db-ingress",
+		map[string]interface{}{"matchLabels": map[string]interface{}{"tier": "db"}},
+		map[string]interface{}{
+			"ingress": []interface{}{
+				map[string]interface{}{
+					"from": []interface{}{
+						map[string]interface{}{"podSelector": map[string]interface{}{
+							"matchLabels": map[string]interface{}{"tier": "web"},
+						}},
+						map[string]interface{}{"ipBlock": map[string]interface{}{
+							"cidr": "10.0.0.0/8", "except": []interface{}{"10.1.0.0/16"},
+						}},
+					},
+					"ports": []interface{}{map[string]interface{}{"protocol": "TCP", "port": int64(5432)}},
+				},
+			},
+		})
+
+	// Empty pod selector applies to every pod
+	denyAll := createTestNetworkPolicy("deny-egress", map[string]interface{}{},
+		map[string]interface{}{"policyTypes": []interface{}{"Egress"}})
+
+	for _, np := range []*unstructured.Unstructured{dbPolicy, denyAll} {
+		_, _ = k.dynamicClient.Resource(networkPolicyGVR).Namespace("default").
+			Create(context.TODO(), np, metaV1.CreateOptions{})
+	}
+
+	policies, err := k.GetNetworkPolicies("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	byName := map[string]NetworkPolicySummary{}
+	for _, p := range policies {
+		byName[p.Name] = p
+	}
+
+	db := byName["db-ingress"]
+	if !slices.Equal(db.Pods, []string{"db-1"}) {
+		t.Errorf("Expected db-ingress to select only db-1, got %v", db.Pods)
+	}
+
+	if !slices.Equal(db.PolicyTypes, []string{"Ingress"}) {
+		t.Errorf("Expected policy types to default to Ingress, got %v", db.PolicyTypes)
+	}
+
+	if len(db.Ingress) != 1 ||
+		!slices.Equal(db.Ingress[0].Peers, []string{"pods tier=web", "ipBlock 10.0.0.0/8 except 10.1.0.0/16"}) ||
+		!slices.Equal(db.Ingress[0].Ports, []string{"TCP/5432"}) {
+		t.Errorf("Unexpected ingress rules %+v", db.Ingress)
+	}
+
+	if deny := byName["deny-egress"]; len(deny.Pods) != 3 || len(deny.Egress) != 0 {
+		t.Errorf("Expected deny-egress to select all 3 pods with no egress rules, got %+v", deny)
+	}
+
+	// Policies are also linked to their pods in the namespace view
+	view, err := k.FetchNamespaceView("default", FetchOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	applies := 0
+
+	for _, rel := range view.Relationships {
+		if rel.Type == "applies" {
+			applies++
+		}
+	}
+
+	if len(view.NetworkPolicies) != 2 || applies != 4 {
+		t.Errorf("Expected 2 policies applying to 4 pods in total, got %d & %d", len(view.NetworkPolicies), applies)
+	}
+}
@@ -0,0 +1,223 @@
+// ==========================================================================================
+// Finding resources which look to have been left behind, nothing owns or uses them
+// Only candidates are reported, it's always worth checking before deleting anything
+// ==========================================================================================
+
+package services
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	appsV1 "k8s.io/api/apps/v1"
+	batchV1 "k8s.io/api/batch/v1"
+	coreV1 "k8s.io/api/core/v1"
+	networkingV1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ConfigMap added to every namespace by Kubernetes, it's used by pods without being referenced
+const rootCAConfigMap = "kube-root-ca.crt"
+
+// Types of Secret which are used by Kubernetes or tools, rather than referenced by pods
+var orphanIgnoredSecretTypes = []coreV1.SecretType{
+	coreV1.SecretTypeServiceAccountToken,
+	coreV1.SecretTypeBootstrapToken,
+	"helm.sh/release.v1",
+}
+
+// OrphanReport lists the resources in a namespace which appear to be orphaned, by type
+// Anything owned by another resource is never reported, its owner is responsible for it
+type OrphanReport struct {
+	// ReplicaSets scaled to zero which aren't owned by a Deployment, or anything else
+	ReplicaSets []Orphan `json:"replicaSets"`
+	// ConfigMaps & Secrets which no pod, pod template, ServiceAccount or Ingress refers to
+	ConfigMaps []Orphan `json:"configMaps"`
+	Secrets    []Orphan `json:"secrets"`
+	// PersistentVolumeClaims no pod or pod template mounts, other than those kept for a StatefulSet
+	PersistentVolumeClaims []Orphan `json:"persistentVolumeClaims"`
+}
+
+// Orphan is a resource which appears to be orphaned, and why
+type Orphan struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// GetOrphans looks for orphaned resources in a namespace, see OrphanReport
+func (k *Kubernetes) GetOrphans(ns string) (*OrphanReport, error) {
+	// Completed pods still count as using what they refer to, the Job may be run again
+	data, err := k.FetchNamespaceWithOptions(ns, FetchOptions{IncludeCompletedJobPods: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return NewOrphanReport(data), nil
+}
+
+// NewOrphanReport builds the orphan report from the map of resources returned by FetchNamespace
+// Each list is sorted by name
+func NewOrphanReport(data map[string][]unstructured.Unstructured) *OrphanReport {
+	report := &OrphanReport{
+		ReplicaSets:            []Orphan{},
+		ConfigMaps:             []Orphan{},
+		Secrets:                []Orphan{},
+		PersistentVolumeClaims: []Orphan{},
+	}
+
+	refs := newOrphanRefs(data)
+
+	for _, rs := range decodeAll[appsV1.ReplicaSet](data["replicasets"]) {
+		if len(rs.OwnerReferences) > 0 || rs.Spec.Replicas == nil || *rs.Spec.Replicas != 0 || rs.Status.Replicas != 0 {
+			continue
+		}
+
+		report.ReplicaSets = append(report.ReplicaSets, Orphan{rs.Name, "scaled to zero with no owner"})
+	}
+
+	for _, cm := range decodeAll[coreV1.ConfigMap](data["configmaps"]) {
+		if len(cm.OwnerReferences) > 0 || cm.Name == rootCAConfigMap || refs.configMaps[cm.Name] {
+			continue
+		}
+
+		report.ConfigMaps = append(report.ConfigMaps, Orphan{cm.Name, "not used by any pod or pod template"})
+	}
+
+	for _, secret := range decodeAll[coreV1.Secret](data["secrets"]) {
+		if len(secret.OwnerReferences) > 0 || slices.Contains(orphanIgnoredSecretTypes, secret.Type) ||
+			refs.secrets[secret.Name] {
+			continue
+		}
+
+		report.Secrets = append(report.Secrets, Orphan{
+			secret.Name, "not used by any pod, pod template, service account or ingress",
+		})
+	}
+
+	for _, pvc := range decodeAll[coreV1.PersistentVolumeClaim](data["persistentvolumeclaims"]) {
+		if len(pvc.OwnerReferences) > 0 || refs.claims[pvc.Name] || refs.statefulSetClaim(pvc.Name) {
+			continue
+		}
+
+		report.PersistentVolumeClaims = append(report.PersistentVolumeClaims, Orphan{
+			pvc.Name, "not mounted by any pod or pod template",
+		})
+	}
+
+	for _, list := range []*[]Orphan{
+		&report.ReplicaSets, &report.ConfigMaps, &report.Secrets, &report.PersistentVolumeClaims,
+	} {
+		slices.SortFunc(*list, func(a, b Orphan) int { return cmp.Compare(a.Name, b.Name) })
+	}
+
+	return report
+}
+
+// orphanRefs is everything referred to in a namespace, by name
+type orphanRefs struct {
+	configMaps map[string]bool
+	secrets    map[string]bool
+	claims     map[string]bool
+	// Prefixes of the PVCs a StatefulSet creates from its volume claim templates, "<template>-<statefulset>-"
+	statefulSetClaimPrefixes []string
+}
+
+// newOrphanRefs collects references from pods, the pod templates of workloads, ServiceAccounts & Ingresses
+// Pod templates are included, so a workload scaled to zero still counts as using what it refers to
+func newOrphanRefs(data map[string][]unstructured.Unstructured) *orphanRefs {
+	refs := &orphanRefs{configMaps: map[string]bool{}, secrets: map[string]bool{}, claims: map[string]bool{}}
+	specs := []coreV1.PodSpec{}
+
+	for _, pod := range decodeAll[coreV1.Pod](data["pods"]) {
+		specs = append(specs, pod.Spec)
+	}
+
+	for _, deploy := range decodeAll[appsV1.Deployment](data["deployments"]) {
+		specs = append(specs, deploy.Spec.Template.Spec)
+	}
+
+	for _, rs := range decodeAll[appsV1.ReplicaSet](data["replicasets"]) {
+		specs = append(specs, rs.Spec.Template.Spec)
+	}
+
+	for _, ds := range decodeAll[appsV1.DaemonSet](data["daemonsets"]) {
+		specs = append(specs, ds.Spec.Template.Spec)
+	}
+
+	for _, job := range decodeAll[batchV1.Job](data["jobs"]) {
+		specs = append(specs, job.Spec.Template.Spec)
+	}
+
+	for _, cronJob := range decodeAll[batchV1.CronJob](data["cronjobs"]) {
+		specs = append(specs, cronJob.Spec.JobTemplate.Spec.Template.Spec)
+	}
+
+	for _, sts := range decodeAll[appsV1.StatefulSet](data["statefulsets"]) {
+		specs = append(specs, sts.Spec.Template.Spec)
+
+		for _, tpl := range sts.Spec.VolumeClaimTemplates {
+			refs.statefulSetClaimPrefixes = append(refs.statefulSetClaimPrefixes, tpl.Name+"-"+sts.Name+"-")
+		}
+	}
+
+	for i := range specs {
+		configMaps, secrets := podConfigReferences(&coreV1.Pod{Spec: specs[i]})
+
+		for name := range configMaps {
+			refs.configMaps[name] = true
+		}
+
+		for name := range secrets {
+			refs.secrets[name] = true
+		}
+
+		for _, vol := range specs[i].Volumes {
+			if vol.PersistentVolumeClaim != nil {
+				refs.claims[vol.PersistentVolumeClaim.ClaimName] = true
+			}
+		}
+	}
+
+	for _, sa := range decodeAll[coreV1.ServiceAccount](data["serviceaccounts"]) {
+		for _, s := range sa.Secrets {
+			refs.secrets[s.Name] = true
+		}
+
+		for _, s := range sa.ImagePullSecrets {
+			refs.secrets[s.Name] = true
+		}
+	}
+
+	for _, ing := range decodeAll[networkingV1.Ingress](data["ingresses"]) {
+		for _, tls := range ing.Spec.TLS {
+			refs.secrets[tls.SecretName] = true
+		}
+	}
+
+	return refs
+}
+
+// statefulSetClaim checks if a PVC was created for a StatefulSet, these are kept on purpose when it's scaled down
+func (r *orphanRefs) statefulSetClaim(name string) bool {
+	return slices.ContainsFunc(r.statefulSetClaimPrefixes, func(prefix string) bool {
+		return strings.HasPrefix(name, prefix)
+	})
+}
+
+// decodeAll converts objects to a typed kind, skipping any which can't be converted
+func decodeAll[T any](items []unstructured.Unstructured) []T {
+	typed := make([]T, 0, len(items))
+
+	for i := range items {
+		obj := new(T)
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(items[i].Object, obj); err != nil {
+			continue
+		}
+
+		typed = append(typed, *obj)
+	}
+
+	return typed
+}
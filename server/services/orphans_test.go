@@ -0,0 +1,61 @@
+// ==========================================================================================
+// Unit tests for finding orphaned resources
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestKubernetes_GetOrphans(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	configMap := func(name string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ConfigMap",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		}}
+	}
+
+	for _, name := range []string{"unused", "mounted", "templated", rootCAConfigMap} {
+		_, _ = k.dynamicClient.Resource(configMapGVR).Namespace("default").
+			Create(context.TODO(), configMap(name), metaV1.CreateOptions{})
+	}
+
+	pod := createTestPod("web", "default")
+	pod.Object["spec"] = map[string]interface{}{
+		"volumes": []interface{}{
+			map[string]interface{}{"name": "config", "configMap": map[string]interface{}{"name": "mounted"}},
+		},
+		"containers": []interface{}{map[string]interface{}{"name": "app"}},
+	}
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+
+	// A deployment scaled to zero has no pods, but its template still uses the ConfigMap
+	deploy := createTestDeployment("batch", "default", 0)
+	_ = unstructured.SetNestedSlice(deploy.Object, []interface{}{
+		map[string]interface{}{"name": "config", "configMap": map[string]interface{}{"name": "templated"}},
+	}, "spec", "template", "spec", "volumes")
+	_, _ = k.dynamicClient.Resource(deploymentGVR).Namespace("default").
+		Create(context.TODO(), deploy, metaV1.CreateOptions{})
+
+	report, err := k.GetOrphans("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(report.ConfigMaps) != 1 || report.ConfigMaps[0].Name != "unused" {
+		t.Errorf("Expected only the unused ConfigMap to be orphaned, got %+v", report.ConfigMaps)
+	}
+
+	if len(report.ReplicaSets) != 0 || len(report.Secrets) != 0 || len(report.PersistentVolumeClaims) != 0 {
+		t.Errorf("Expected nothing else to be orphaned, got %+v", report)
+	}
+}
@@ -0,0 +1,127 @@
+// ==========================================================================================
+// Walking ownerReferences upwards, e.g. from a Pod to the Deployment which manages it
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Resource types for the kinds commonly found in owner chains
+var kindResources = map[string]schema.GroupVersionResource{
+	"Pod":         {Group: "", Version: "v1", Resource: "pods"},
+	"ReplicaSet":  {Group: "apps", Version: "v1", Resource: "replicasets"},
+	"Deployment":  {Group: "apps", Version: "v1", Resource: "deployments"},
+	"StatefulSet": {Group: "apps", Version: "v1", Resource: "statefulsets"},
+	"DaemonSet":   {Group: "apps", Version: "v1", Resource: "daemonsets"},
+	"Job":         {Group: "batch", Version: "v1", Resource: "jobs"},
+	"CronJob":     {Group: "batch", Version: "v1", Resource: "cronjobs"},
+}
+
+// Owner chains are never this deep, this stops runaway walks over bad data
+const maxOwnerChainLength = 10
+
+// ResolveOwnerChain returns the object followed by each of its controlling owners, up to the root
+// e.g. Pod → ReplicaSet → Deployment. Owners which no longer exist end the chain early
+func (k *Kubernetes) ResolveOwnerChain(ns, kind, name string) ([]unstructured.Unstructured, error) {
+	if ns == "" || kind == "" || name == "" {
+		return nil, errors.New("namespace, kind or name is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	obj, err := k.getByKind(ns, "", kind, name)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := []unstructured.Unstructured{}
+	visited := map[types.UID]bool{}
+
+	for obj != nil && len(chain) < maxOwnerChainLength {
+		// Guards against a cycle in the owner references
+		if visited[obj.GetUID()] {
+			log.Printf("⚠️ Cycle found in owner chain of %s %s in %s", kind, name, ns)
+			break
+		}
+
+		visited[obj.GetUID()] = true
+
+		chain = append(chain, *k.cleanObject(obj))
+
+		owner := metaV1.GetControllerOf(obj)
+		if owner == nil {
+			break
+		}
+
+		obj, err = k.getByKind(ns, owner.APIVersion, owner.Kind, owner.Name)
+		if apiErrors.IsNotFound(err) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return chain, nil
+}
+
+// getByKind fetches an object by kind, using the API version from an owner reference when given
+func (k *Kubernetes) getByKind(ns, apiVersion, kind, name string) (*unstructured.Unstructured, error) {
+	gvr, err := k.resourceForKind(apiVersion, kind)
+	if err != nil {
+		return nil, err
+	}
+
+	var obj *unstructured.Unstructured
+
+	err = k.callAPI(func(ctx context.Context) (err error) {
+		obj, err = k.dynamicClient.Resource(gvr).Namespace(ns).Get(ctx, name, metaV1.GetOptions{})
+		return err
+	})
+
+	return obj, err
+}
+
+// resourceForKind works out the resource type for a kind, from the cached discovery when the apiVersion is given
+// so every kind the cluster serves is found with its real plural, e.g. ingresses or networkpolicies. The kinds
+// in kindResources are also known without an apiVersion, or when discovery fails
+func (k *Kubernetes) resourceForKind(apiVersion, kind string) (schema.GroupVersionResource, error) {
+	if apiVersion != "" {
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			return schema.GroupVersionResource{}, err
+		}
+
+		resources, err := k.GetAPIResources()
+		if err != nil {
+			log.Printf("⚠️ Unable to discover the resource type of %s, using the known kinds: %v", kind, err)
+		}
+
+		// The plural is the same in every version of a group, so the preferred version's is used
+		idx := slices.IndexFunc(resources, func(r APIResource) bool { return r.Group == gv.Group && r.Kind == kind })
+		if idx >= 0 {
+			return gv.WithResource(resources[idx].Plural), nil
+		}
+	}
+
+	if gvr, ok := kindResources[kind]; ok {
+		return gvr, nil
+	}
+
+	return schema.GroupVersionResource{}, fmt.Errorf("%w: kind %s %s", ErrResourceTypeNotFound, apiVersion, kind)
+}
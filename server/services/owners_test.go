@@ -0,0 +1,148 @@
+// ==========================================================================================
+// Unit tests for resolving owner chains
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+)
+
+// setKindDiscovery gives the fake discovery some kinds whose plural isn't just the kind with an s
+func setKindDiscovery(k *Kubernetes) {
+	verbs := []string{"get", "list", "watch"}
+
+	disc, _ := k.clientSet.Discovery().(*fakediscovery.FakeDiscovery)
+	disc.Resources = []*metaV1.APIResourceList{
+		{GroupVersion: "v1", APIResources: []metaV1.APIResource{
+			{Name: "secrets", Kind: "Secret", Namespaced: true, Verbs: verbs},
+			{Name: "endpoints", Kind: "Endpoints", Namespaced: true, Verbs: verbs},
+		}},
+		{GroupVersion: "networking.k8s.io/v1", APIResources: []metaV1.APIResource{
+			{Name: "ingresses", Kind: "Ingress", Namespaced: true, Verbs: verbs},
+			{Name: "networkpolicies", Kind: "NetworkPolicy", Namespaced: true, Verbs: verbs},
+		}},
+	}
+}
+
+// setController makes owner the controlling owner of obj
+func setController(obj, owner *unstructured.Unstructured) {
+	controller := true
+
+	obj.SetOwnerReferences([]metaV1.OwnerReference{{
+		APIVersion: owner.GetAPIVersion(),
+		Kind:       owner.GetKind(),
+		Name:       owner.GetName(),
+		UID:        owner.GetUID(),
+		Controller: &controller,
+	}})
+}
+
+func TestKubernetes_ResolveOwnerChain(t *testing.T) {
+	k := mockKubernetes()
+
+	deploy := createTestDeployment("web", "default", 1)
+
+	rs := createTestDeployment("web-123", "default", 1)
+	rs.SetKind("ReplicaSet")
+	setController(rs, deploy)
+
+	pod := createTestPod("web-123-abc", "default")
+	pod.SetUID(types.UID("web-123-abc-uid"))
+	setController(pod, rs)
+
+	for _, obj := range []*unstructured.Unstructured{deploy, rs, pod} {
+		gvr := kindResources[obj.GetKind()]
+		_, _ = k.dynamicClient.Resource(gvr).Namespace("default").Create(context.TODO(), obj, metaV1.CreateOptions{})
+	}
+
+	chain, err := k.ResolveOwnerChain("default", "Pod", "web-123-abc")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []string{"Pod/web-123-abc", "ReplicaSet/web-123", "Deployment/web"}
+	if len(chain) != len(expected) {
+		t.Fatalf("Expected chain of %d, got %d", len(expected), len(chain))
+	}
+
+	for i, exp := range expected {
+		if got := chain[i].GetKind() + "/" + chain[i].GetName(); got != exp {
+			t.Errorf("Expected link %d to be %s, got %s", i, exp, got)
+		}
+	}
+}
+
+func TestKubernetes_ResolveOwnerChain_Cycle(t *testing.T) {
+	k := mockKubernetes()
+
+	// Two ReplicaSets which claim to own each other
+	a := createTestDeployment("a", "default", 1)
+	a.SetKind("ReplicaSet")
+
+	b := createTestDeployment("b", "default", 1)
+	b.SetKind("ReplicaSet")
+
+	setController(a, b)
+	setController(b, a)
+
+	gvr := kindResources["ReplicaSet"]
+	_, _ = k.dynamicClient.Resource(gvr).Namespace("default").Create(context.TODO(), a, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(gvr).Namespace("default").Create(context.TODO(), b, metaV1.CreateOptions{})
+
+	chain, err := k.ResolveOwnerChain("default", "ReplicaSet", "a")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(chain) != 2 {
+		t.Errorf("Expected the walk to stop when the cycle is found, got %d links", len(chain))
+	}
+
+	// Missing owner ends the chain rather than failing
+	pod := createTestPod("orphan", "default")
+	setController(pod, createTestDeployment("gone", "default", 1))
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+
+	if chain, err := k.ResolveOwnerChain("default", "Pod", "orphan"); err != nil || len(chain) != 1 {
+		t.Errorf("Expected just the pod when its owner is gone, got %d links & %v", len(chain), err)
+	}
+}
+
+func TestKubernetes_ResourceForKind(t *testing.T) {
+	k := mockKubernetes()
+	setKindDiscovery(k)
+
+	tests := []struct {
+		apiVersion, kind string
+		want             schema.GroupVersionResource
+	}{
+		{"networking.k8s.io/v1", "Ingress", schema.GroupVersionResource{
+			Group: "networking.k8s.io", Version: "v1", Resource: "ingresses",
+		}},
+		{"networking.k8s.io/v1", "NetworkPolicy", schema.GroupVersionResource{
+			Group: "networking.k8s.io", Version: "v1", Resource: "networkpolicies",
+		}},
+		{"v1", "Endpoints", schema.GroupVersionResource{Group: "", Version: "v1", Resource: "endpoints"}},
+		// Known kinds are found without an apiVersion, even though discovery doesn't have them here
+		{"", "Deployment", kindResources["Deployment"]},
+	}
+
+	for _, tt := range tests {
+		if got, err := k.resourceForKind(tt.apiVersion, tt.kind); err != nil || got != tt.want {
+			t.Errorf("Expected %s %s to be %v, got %v, %v", tt.apiVersion, tt.kind, tt.want, got, err)
+		}
+	}
+
+	if _, err := k.resourceForKind("example.com/v1", "Widget"); !errors.Is(err, ErrResourceTypeNotFound) {
+		t.Errorf("Expected ErrResourceTypeNotFound for a kind the cluster doesn't serve, got %v", err)
+	}
+}
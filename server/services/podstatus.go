@@ -0,0 +1,320 @@
+// ==========================================================================================
+// Pod status, including the QoS class & priority which decide scheduling and eviction order
+// and containers stuck pulling their image or crash looping, or why a pod is stuck Pending
+// Init containers are listed in the order they run, with the one holding up the pod's start
+// ==========================================================================================
+
+package services
+
+import (
+	"cmp"
+	"errors"
+	"log"
+	"slices"
+	"time"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Reason of the events the scheduler records each time it fails to find a node for a pod
+const failedSchedulingReason = "FailedScheduling"
+
+// Waiting reasons which mean a container won't start without something being fixed
+var containerProblemReasons = []string{"ImagePullBackOff", "ErrImagePull", "CrashLoopBackOff"}
+
+// ContainerProblem is a container waiting for one of the containerProblemReasons
+type ContainerProblem struct {
+	Container string `json:"container"`
+	Reason    string `json:"reason"`
+	Message   string `json:"message,omitempty"`
+}
+
+// PodStatus is the phase, QoS class & priority of a pod
+type PodStatus struct {
+	Name  string `json:"name"`
+	Phase string `json:"phase"`
+	// QOSClass is "Guaranteed", "Burstable" or "BestEffort", computed from the requests & limits
+	QOSClass          string `json:"qosClass"`
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// Priority is nil when the priority admission controller hasn't set it
+	Priority *int32 `json:"priority"`
+	// Problems lists containers that can't pull their image or keep crashing, empty when there are none
+	Problems []ContainerProblem `json:"problems"`
+	// Pending is why a Pending pod hasn't started, nil for pods in any other phase
+	Pending *PendingReason `json:"pending,omitempty"`
+	// InitContainers are in the order they run, empty when the pod has none
+	InitContainers []InitContainerState `json:"initContainers"`
+	// CurrentInitStep is the step of the init container the pod is waiting on, zero when none is
+	CurrentInitStep int `json:"currentInitStep,omitempty"`
+}
+
+// InitContainerState is the progress of an init container, times are RFC3339 and empty when not known
+type InitContainerState struct {
+	// Step is the position the init container runs in, starting from 1
+	Step int    `json:"step"`
+	Name string `json:"name"`
+	// State is "waiting", "running" or "terminated", waiting includes not yet started
+	State  string `json:"state"`
+	Reason string `json:"reason,omitempty"`
+	// ExitCode is only set once terminated, anything but 0 means the init container will be retried
+	ExitCode   *int32 `json:"exitCode,omitempty"`
+	StartedAt  string `json:"startedAt,omitempty"`
+	FinishedAt string `json:"finishedAt,omitempty"`
+	Restarts   int32  `json:"restarts"`
+	// Sidecar is a native sidecar, which keeps running & lets the next step start once it has started
+	Sidecar bool `json:"sidecar,omitempty"`
+	// Blocking is true for the init container the pod is waiting on
+	Blocking bool `json:"blocking"`
+}
+
+// PendingReason is why a pod is stuck Pending, either it can't be scheduled or its containers haven't started
+type PendingReason struct {
+	// Unschedulable is set when no node can be found for the pod, e.g. not enough CPU or an unmatched taint
+	Unschedulable bool `json:"unschedulable"`
+	// Reason is from the PodScheduled condition, e.g. "Unschedulable", or the reason a container is waiting
+	// e.g. "ContainerCreating". It's "Pending" when there's nothing more to go on
+	Reason string `json:"reason"`
+	// Message is from the latest FailedScheduling event when there is one, as it's kept the most up to date
+	Message string `json:"message,omitempty"`
+}
+
+// GetPodStatuses returns the status of every pod in a namespace
+func (k *Kubernetes) GetPodStatuses(ns string) ([]PodStatus, error) {
+	if ns == "" {
+		return nil, errors.New("namespace is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	pods, err := k.GetResources(ns, podGVR.Group, podGVR.Version, podGVR.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]PodStatus, 0, len(pods))
+
+	var schedulingMessages map[string]string
+
+	for _, podObj := range pods {
+		pod := coreV1.Pod{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(podObj.Object, &pod); err != nil {
+			log.Printf("💥 Failed to decode pod %s: %v", podObj.GetName(), err)
+			continue
+		}
+
+		initContainers, initStep := initContainerStates(&pod)
+
+		statuses = append(statuses, PodStatus{
+			Name:              pod.Name,
+			Phase:             string(pod.Status.Phase),
+			QOSClass:          string(podQOSClass(&pod)),
+			PriorityClassName: pod.Spec.PriorityClassName,
+			Priority:          pod.Spec.Priority,
+			Problems:          containerProblems(&pod),
+			Pending:           podPendingReason(&pod),
+			InitContainers:    initContainers,
+			CurrentInitStep:   initStep,
+		})
+
+		// Events are only listed when a pod can't be scheduled, as most namespaces have none
+		if pending := statuses[len(statuses)-1].Pending; pending != nil && pending.Unschedulable {
+			if schedulingMessages == nil {
+				schedulingMessages = k.failedSchedulingMessages(ns)
+			}
+
+			if msg := schedulingMessages[pod.Name]; msg != "" {
+				pending.Message = msg
+			}
+		}
+	}
+
+	return statuses, nil
+}
+
+// podPendingReason works out why a Pending pod hasn't started, nil when the pod isn't Pending
+// A pod not yet scheduled has the reason from its PodScheduled condition, otherwise the first waiting container
+// gives the reason, init containers first as they run before the rest
+func podPendingReason(pod *coreV1.Pod) *PendingReason {
+	if pod.Status.Phase != coreV1.PodPending {
+		return nil
+	}
+
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == coreV1.PodScheduled && cond.Status == coreV1.ConditionFalse {
+			return &PendingReason{
+				Unschedulable: true,
+				Reason:        cmp.Or(cond.Reason, string(coreV1.PodReasonUnschedulable)),
+				Message:       cond.Message,
+			}
+		}
+	}
+
+	statuses := append(slices.Clone(pod.Status.InitContainerStatuses), pod.Status.ContainerStatuses...)
+
+	for _, cs := range statuses {
+		if waiting := cs.State.Waiting; waiting != nil && waiting.Reason != "" {
+			return &PendingReason{Reason: waiting.Reason, Message: waiting.Message}
+		}
+	}
+
+	return &PendingReason{Reason: string(coreV1.PodPending)}
+}
+
+// initContainerStates lists the init containers in the order they run, from the spec matched to their statuses
+// Returns the step of the first init container which hasn't finished, the one the pod is waiting on
+// A native sidecar is finished once it has started, zero is returned when every step has finished
+func initContainerStates(pod *coreV1.Pod) ([]InitContainerState, int) {
+	states := []InitContainerState{}
+	current := 0
+
+	for i, c := range pod.Spec.InitContainers {
+		state := InitContainerState{
+			Step:    i + 1,
+			Name:    c.Name,
+			State:   "waiting",
+			Sidecar: c.RestartPolicy != nil && *c.RestartPolicy == coreV1.ContainerRestartPolicyAlways,
+		}
+
+		finished := false
+
+		found := slices.IndexFunc(pod.Status.InitContainerStatuses, func(cs coreV1.ContainerStatus) bool {
+			return cs.Name == c.Name
+		})
+		if found >= 0 {
+			cs := pod.Status.InitContainerStatuses[found]
+			state.Restarts = cs.RestartCount
+
+			switch {
+			case cs.State.Terminated != nil:
+				term := cs.State.Terminated
+				state.State = "terminated"
+				state.Reason = term.Reason
+				state.ExitCode = &term.ExitCode
+				state.StartedAt = formatTime(term.StartedAt.Time)
+				state.FinishedAt = formatTime(term.FinishedAt.Time)
+				finished = term.ExitCode == 0
+			case cs.State.Running != nil:
+				state.State = "running"
+				state.StartedAt = formatTime(cs.State.Running.StartedAt.Time)
+				finished = state.Sidecar && cs.Started != nil && *cs.Started
+			case cs.State.Waiting != nil:
+				state.Reason = cs.State.Waiting.Reason
+			}
+		}
+
+		// Init containers have all finished once a pod has succeeded, even if their statuses are gone
+		if current == 0 && !finished && pod.Status.Phase != coreV1.PodSucceeded {
+			current = state.Step
+			state.Blocking = true
+		}
+
+		states = append(states, state)
+	}
+
+	return states, current
+}
+
+// formatTime returns a time as RFC3339, or empty when it's zero
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
+
+// failedSchedulingMessages finds the message of the latest FailedScheduling event for each pod, by pod name
+// Events are only a help, so failing to list them is logged and nothing is returned
+func (k *Kubernetes) failedSchedulingMessages(ns string) map[string]string {
+	messages := map[string]string{}
+
+	events, err := k.getEvents(ns)
+	if err != nil {
+		log.Printf("⚠️ Unable to list events in namespace %s: %v", ns, err)
+		return messages
+	}
+
+	latest := map[string]time.Time{}
+
+	for i := range events {
+		reason, _, _ := unstructured.NestedString(events[i].Object, "reason")
+		kind, _, _ := unstructured.NestedString(events[i].Object, "involvedObject", "kind")
+		name, _, _ := unstructured.NestedString(events[i].Object, "involvedObject", "name")
+
+		if reason != failedSchedulingReason || kind != "Pod" || name == "" {
+			continue
+		}
+
+		_, seen := eventLastSeen(&events[i])
+		if _, found := messages[name]; found && !seen.After(latest[name]) {
+			continue
+		}
+
+		messages[name], _, _ = unstructured.NestedString(events[i].Object, "message")
+		latest[name] = seen
+	}
+
+	return messages
+}
+
+// podQOSClass works out the QoS class the same way the kubelet does, only CPU & memory count
+// Guaranteed needs every container to have limits for both, with requests equal to the limits
+// BestEffort is when no container has any requests or limits, anything else is Burstable
+func podQOSClass(pod *coreV1.Pod) coreV1.PodQOSClass {
+	containers := append([]coreV1.Container{}, pod.Spec.InitContainers...)
+	containers = append(containers, pod.Spec.Containers...)
+
+	anySet := false
+	guaranteed := true
+
+	for _, c := range containers {
+		for _, name := range []coreV1.ResourceName{coreV1.ResourceCPU, coreV1.ResourceMemory} {
+			request, hasRequest := c.Resources.Requests[name]
+			limit, hasLimit := c.Resources.Limits[name]
+
+			if (hasRequest && !request.IsZero()) || (hasLimit && !limit.IsZero()) {
+				anySet = true
+			}
+
+			// A request left unset defaults to the limit, so only a different request breaks Guaranteed
+			if !hasLimit || limit.IsZero() || (hasRequest && request.Cmp(limit) != 0) {
+				guaranteed = false
+			}
+		}
+	}
+
+	switch {
+	case !anySet:
+		return coreV1.PodQOSBestEffort
+	case guaranteed:
+		return coreV1.PodQOSGuaranteed
+	default:
+		return coreV1.PodQOSBurstable
+	}
+}
+
+// containerProblems finds containers, including init containers, waiting for a reason that needs fixing
+func containerProblems(pod *coreV1.Pod) []ContainerProblem {
+	problems := []ContainerProblem{}
+
+	statuses := append(slices.Clone(pod.Status.InitContainerStatuses), pod.Status.ContainerStatuses...)
+
+	for _, cs := range statuses {
+		waiting := cs.State.Waiting
+		if waiting == nil || !slices.Contains(containerProblemReasons, waiting.Reason) {
+			continue
+		}
+
+		problems = append(problems, ContainerProblem{
+			Container: cs.Name,
+			Reason:    waiting.Reason,
+			Message:   waiting.Message,
+		})
+	}
+
+	return problems
+}
@@ -0,0 +1,271 @@
+// ==========================================================================================
+// Unit tests for pod status, QoS class & priority
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestKubernetes_GetPodStatuses(t *testing.T) {
+	k := mockKubernetes()
+
+	guaranteed := createTestPod("guaranteed", "default")
+	resources := map[string]interface{}{
+		"requests": map[string]interface{}{"cpu": "500m", "memory": "128Mi"},
+		"limits":   map[string]interface{}{"cpu": "0.5", "memory": "128Mi"},
+	}
+	_ = unstructured.SetNestedSlice(guaranteed.Object, []interface{}{
+		map[string]interface{}{"name": "app", "image": "nginx", "resources": resources},
+	}, "spec", "containers")
+	_ = unstructured.SetNestedField(guaranteed.Object, "high", "spec", "priorityClassName")
+	_ = unstructured.SetNestedField(guaranteed.Object, int64(1000), "spec", "priority")
+	_ = unstructured.SetNestedField(guaranteed.Object, "Running", "status", "phase")
+
+	burstable := createTestPod("burstable", "default")
+	_ = unstructured.SetNestedSlice(burstable.Object, []interface{}{
+		map[string]interface{}{"name": "app", "image": "nginx", "resources": map[string]interface{}{
+			"requests": map[string]interface{}{"cpu": "100m"},
+		}},
+	}, "spec", "containers")
+
+	bestEffort := createTestPod("best-effort", "default")
+
+	for _, pod := range []*unstructured.Unstructured{guaranteed, burstable, bestEffort} {
+		_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+	}
+
+	statuses, err := k.GetPodStatuses("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	byName := map[string]PodStatus{}
+	for _, s := range statuses {
+		byName[s.Name] = s
+	}
+
+	g := byName["guaranteed"]
+	if g.QOSClass != "Guaranteed" || g.Phase != "Running" {
+		t.Errorf("Expected Running Guaranteed pod, got %+v", g)
+	}
+
+	if g.PriorityClassName != "high" || g.Priority == nil || *g.Priority != 1000 {
+		t.Errorf("Expected priority class high with priority 1000, got %+v", g)
+	}
+
+	if byName["burstable"].QOSClass != "Burstable" {
+		t.Errorf("Expected Burstable, got %s", byName["burstable"].QOSClass)
+	}
+
+	be := byName["best-effort"]
+	if be.QOSClass != "BestEffort" || be.Priority != nil {
+		t.Errorf("Expected BestEffort pod with no priority, got %+v", be)
+	}
+}
+
+func TestKubernetes_GetPodStatuses_Problems(t *testing.T) {
+	k := mockKubernetes()
+
+	pod := createTestPod("bad-image", "default")
+	_ = unstructured.SetNestedSlice(pod.Object, []interface{}{
+		map[string]interface{}{
+			"name":  "app",
+			"ready": false,
+			"state": map[string]interface{}{"waiting": map[string]interface{}{
+				"reason":  "ImagePullBackOff",
+				"message": `Back-off pulling image "nginx:nope"`,
+			}},
+		},
+		map[string]interface{}{
+			"name":  "sidecar",
+			"ready": false,
+			"state": map[string]interface{}{"waiting": map[string]interface{}{"reason": "ContainerCreating"}},
+		},
+	}, "status", "containerStatuses")
+
+	healthy := createTestPod("healthy", "default")
+
+	for _, p := range []*unstructured.Unstructured{pod, healthy} {
+		_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), p, metaV1.CreateOptions{})
+	}
+
+	statuses, err := k.GetPodStatuses("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	byName := map[string]PodStatus{}
+	for _, s := range statuses {
+		byName[s.Name] = s
+	}
+
+	problems := byName["bad-image"].Problems
+	if len(problems) != 1 {
+		t.Fatalf("Expected 1 problem, got %+v", problems)
+	}
+
+	if problems[0].Container != "app" || problems[0].Reason != "ImagePullBackOff" || problems[0].Message == "" {
+		t.Errorf("Expected ImagePullBackOff on app, got %+v", problems[0])
+	}
+
+	if p := byName["healthy"].Problems; p == nil || len(p) != 0 {
+		t.Errorf("Expected empty problems for healthy pod, got %+v", p)
+	}
+}
+
+func TestKubernetes_GetPodStatuses_Pending(t *testing.T) {
+	k := mockKubernetes()
+
+	unschedulable := createTestPod("unschedulable", "default")
+	_ = unstructured.SetNestedField(unschedulable.Object, "Pending", "status", "phase")
+	_ = unstructured.SetNestedSlice(unschedulable.Object, []interface{}{
+		map[string]interface{}{
+			"type":    "PodScheduled",
+			"status":  "False",
+			"reason":  "Unschedulable",
+			"message": "0/3 nodes are available: 3 Insufficient cpu.",
+		},
+	}, "status", "conditions")
+
+	creating := createTestPod("creating", "default")
+	_ = unstructured.SetNestedField(creating.Object, "Pending", "status", "phase")
+	_ = unstructured.SetNestedSlice(creating.Object, []interface{}{
+		map[string]interface{}{
+			"name":  "app",
+			"state": map[string]interface{}{"waiting": map[string]interface{}{"reason": "ContainerCreating"}},
+		},
+	}, "status", "containerStatuses")
+
+	running := createTestPod("running", "default")
+	_ = unstructured.SetNestedField(running.Object, "Running", "status", "phase")
+
+	for _, p := range []*unstructured.Unstructured{unschedulable, creating, running} {
+		_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), p, metaV1.CreateOptions{})
+	}
+
+	// The scheduler keeps retrying, only the latest attempt matters
+	for i, msg := range []string{
+		"0/3 nodes are available: 3 Insufficient memory.", "0/4 nodes are available: 4 Insufficient cpu.",
+	} {
+		event := createTestCoreEvent(fmt.Sprintf("unschedulable.%d", i), "default")
+		event.Object["reason"] = "FailedScheduling"
+		event.Object["message"] = msg
+		event.Object["lastTimestamp"] = fmt.Sprintf("2026-01-01T10:0%d:00Z", i)
+		_ = unstructured.SetNestedField(event.Object, "unschedulable", "involvedObject", "name")
+
+		_, _ = k.dynamicClient.Resource(coreEventGVR).Namespace("default").
+			Create(context.TODO(), event, metaV1.CreateOptions{})
+	}
+
+	statuses, err := k.GetPodStatuses("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	byName := map[string]PodStatus{}
+	for _, s := range statuses {
+		byName[s.Name] = s
+	}
+
+	pending := byName["unschedulable"].Pending
+	if pending == nil || !pending.Unschedulable || pending.Reason != "Unschedulable" {
+		t.Fatalf("Expected the pod to be unschedulable, got %+v", pending)
+	}
+
+	if pending.Message != "0/4 nodes are available: 4 Insufficient cpu." {
+		t.Errorf("Expected the message of the latest FailedScheduling event, got %q", pending.Message)
+	}
+
+	// Pending for a reason other than scheduling
+	if p := byName["creating"].Pending; p == nil || p.Unschedulable || p.Reason != "ContainerCreating" {
+		t.Errorf("Expected the pod to be pending on ContainerCreating, got %+v", p)
+	}
+
+	if p := byName["running"].Pending; p != nil {
+		t.Errorf("Expected no pending reason for a running pod, got %+v", p)
+	}
+}
+
+func TestKubernetes_GetPodStatuses_InitContainers(t *testing.T) {
+	k := mockKubernetes()
+
+	pod := createTestPod("slow-start", "default")
+	_ = unstructured.SetNestedField(pod.Object, "Pending", "status", "phase")
+	_ = unstructured.SetNestedSlice(pod.Object, []interface{}{
+		map[string]interface{}{"name": "migrate", "image": "migrate"},
+		map[string]interface{}{"name": "wait-for-db", "image": "busybox"},
+		map[string]interface{}{"name": "warm-cache", "image": "busybox"},
+	}, "spec", "initContainers")
+	_ = unstructured.SetNestedSlice(pod.Object, []interface{}{
+		map[string]interface{}{
+			"name": "migrate",
+			"state": map[string]interface{}{"terminated": map[string]interface{}{
+				"exitCode":   int64(0),
+				"reason":     "Completed",
+				"startedAt":  "2024-01-01T10:00:00Z",
+				"finishedAt": "2024-01-01T10:00:05Z",
+			}},
+		},
+		map[string]interface{}{
+			"name":         "wait-for-db",
+			"restartCount": int64(1),
+			"state": map[string]interface{}{"running": map[string]interface{}{
+				"startedAt": "2024-01-01T10:00:06Z",
+			}},
+		},
+		map[string]interface{}{
+			"name":  "warm-cache",
+			"state": map[string]interface{}{"waiting": map[string]interface{}{"reason": "PodInitializing"}},
+		},
+	}, "status", "initContainerStatuses")
+
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").
+		Create(context.TODO(), createTestPod("no-init", "default"), metaV1.CreateOptions{})
+
+	statuses, err := k.GetPodStatuses("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	byName := map[string]PodStatus{}
+	for _, s := range statuses {
+		byName[s.Name] = s
+	}
+
+	slow := byName["slow-start"]
+	if slow.CurrentInitStep != 2 {
+		t.Errorf("Expected init step 2 to be current, got %d", slow.CurrentInitStep)
+	}
+
+	summary := []string{}
+	for _, ic := range slow.InitContainers {
+		summary = append(summary, fmt.Sprintf("%d:%s:%s:%v", ic.Step, ic.Name, ic.State, ic.Blocking))
+	}
+
+	expected := "[1:migrate:terminated:false 2:wait-for-db:running:true 3:warm-cache:waiting:false]"
+	if fmt.Sprint(summary) != expected {
+		t.Errorf("Expected init containers %s, got %v", expected, summary)
+	}
+
+	migrate := slow.InitContainers[0]
+	if migrate.ExitCode == nil || *migrate.ExitCode != 0 || migrate.FinishedAt != "2024-01-01T10:00:05Z" {
+		t.Errorf("Expected migrate to have completed with exit code 0, got %+v", migrate)
+	}
+
+	if waitForDB := slow.InitContainers[1]; waitForDB.Restarts != 1 || waitForDB.StartedAt == "" {
+		t.Errorf("Expected wait-for-db to be running after a restart, got %+v", waitForDB)
+	}
+
+	none := byName["no-init"]
+	if none.InitContainers == nil || len(none.InitContainers) != 0 || none.CurrentInitStep != 0 {
+		t.Errorf("Expected no init containers & no current step, got %+v", none)
+	}
+}
@@ -0,0 +1,122 @@
+// ==========================================================================================
+// Polling fallback for resources which can be listed but not watched, as with some restricted
+// ServiceAccounts. Each poll re-lists the resources and sends events for what has changed
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+)
+
+// canWatch checks if a resource type can be watched, by opening a watch and closing it straight away
+// Only a Forbidden error counts, anything else is left to the informer which retries as normal
+func canWatch(client dynamic.Interface, namespace string, gvr schema.GroupVersionResource) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+	defer cancel()
+
+	w, err := client.Resource(gvr).Namespace(namespace).Watch(ctx, metaV1.ListOptions{})
+	if apiErrors.IsForbidden(err) {
+		return false
+	}
+
+	if err == nil {
+		w.Stop()
+	}
+
+	return true
+}
+
+// pollResource lists a resource type every interval until stop is closed, sending events for any changes
+// The first list is only used as the starting point, the same as the initial state loaded by the browser
+func pollResource(stop <-chan struct{}, client dynamic.Interface, namespace string, sender EventSender,
+	gvr schema.GroupVersionResource, interval time.Duration) {
+	handlers := getHandlerFuncs(sender)
+	ticker := time.NewTicker(interval)
+
+	defer ticker.Stop()
+
+	var snapshot map[types.UID]*unstructured.Unstructured
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultRequestTimeout)
+		list, err := client.Resource(gvr).Namespace(namespace).List(ctx, metaV1.ListOptions{})
+
+		cancel()
+
+		if err != nil {
+			log.Printf("💥 Failed to poll %s: %v", gvr.Resource, err)
+		} else {
+			events, next := diffSnapshot(snapshot, list.Items)
+			if snapshot != nil {
+				for _, event := range events {
+					switch event.EventType {
+					case AddEvent:
+						handlers.OnAdd(event.Object, false)
+					case UpdateEvent:
+						handlers.OnUpdate(nil, event.Object)
+					case DeleteEvent:
+						handlers.OnDelete(event.Object)
+					}
+				}
+			}
+
+			snapshot = next
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// diffSnapshot compares a new list of objects with the last snapshot, returning the events between them
+// and the new snapshot. Objects are matched by UID, a changed resource version is an update
+func diffSnapshot(prev map[types.UID]*unstructured.Unstructured,
+	items []unstructured.Unstructured) ([]KubeEvent, map[types.UID]*unstructured.Unstructured) {
+	next := make(map[types.UID]*unstructured.Unstructured, len(items))
+	events := []KubeEvent{}
+
+	for i := range items {
+		obj := &items[i]
+		next[obj.GetUID()] = obj
+
+		old, found := prev[obj.GetUID()]
+
+		switch {
+		case !found:
+			events = append(events, KubeEvent{EventType: AddEvent, Object: obj})
+		case old.GetResourceVersion() != obj.GetResourceVersion():
+			events = append(events, KubeEvent{EventType: UpdateEvent, Object: obj})
+		}
+	}
+
+	deleted := []KubeEvent{}
+
+	for uid, obj := range prev {
+		if _, found := next[uid]; !found {
+			deleted = append(deleted, KubeEvent{EventType: DeleteEvent, Object: obj})
+		}
+	}
+
+	// Map order is random, so deletes are sorted to keep the events stable
+	slices.SortFunc(deleted, func(a, b KubeEvent) int {
+		return strings.Compare(a.Object.GetNamespace()+"/"+a.Object.GetName(),
+			b.Object.GetNamespace()+"/"+b.Object.GetName())
+	})
+
+	return append(events, deleted...), next
+}
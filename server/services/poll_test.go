@@ -0,0 +1,96 @@
+// ==========================================================================================
+// Unit tests for the polling fallback when watching isn't permitted
+// ==========================================================================================
+
+package services
+
+import (
+	"errors"
+	"testing"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic/fake"
+	k8sTesting "k8s.io/client-go/testing"
+)
+
+func pollTestPod(name, uid, resourceVersion string) unstructured.Unstructured {
+	pod := createTestPod(name, "default")
+	pod.SetUID(types.UID(uid))
+	pod.SetResourceVersion(resourceVersion)
+
+	return *pod
+}
+
+func TestDiffSnapshot(t *testing.T) {
+	first := []unstructured.Unstructured{
+		pollTestPod("web", "uid-web", "1"),
+		pollTestPod("db", "uid-db", "1"),
+		pollTestPod("cache", "uid-cache", "1"),
+	}
+
+	events, snapshot := diffSnapshot(nil, first)
+	if len(events) != 3 || len(snapshot) != 3 {
+		t.Fatalf("Expected 3 adds from an empty snapshot, got %d events", len(events))
+	}
+
+	// web changes, db is unchanged, cache is deleted and worker is added
+	second := []unstructured.Unstructured{
+		pollTestPod("web", "uid-web", "2"),
+		pollTestPod("db", "uid-db", "1"),
+		pollTestPod("worker", "uid-worker", "1"),
+	}
+
+	events, snapshot = diffSnapshot(snapshot, second)
+	if len(snapshot) != 3 {
+		t.Errorf("Expected 3 objects in the new snapshot, got %d", len(snapshot))
+	}
+
+	expected := []struct {
+		eventType EventTypeEnum
+		name      string
+	}{
+		{UpdateEvent, "web"},
+		{AddEvent, "worker"},
+		{DeleteEvent, "cache"},
+	}
+
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, got %d", len(expected), len(events))
+	}
+
+	for i, e := range expected {
+		if events[i].EventType != e.eventType || events[i].Object.GetName() != e.name {
+			t.Errorf("Expected %s of %s, got %s of %s", e.eventType, e.name,
+				events[i].EventType, events[i].Object.GetName())
+		}
+	}
+
+	if events[0].Object.GetResourceVersion() != "2" {
+		t.Errorf("Expected the update to hold the latest object, got version %s", events[0].Object.GetResourceVersion())
+	}
+
+	// Polling again with nothing changed sends nothing
+	if events, _ := diffSnapshot(snapshot, second); len(events) != 0 {
+		t.Errorf("Expected no events when nothing changed, got %d", len(events))
+	}
+}
+
+func TestCanWatch(t *testing.T) {
+	k := mockKubernetes()
+
+	if !canWatch(k.dynamicClient, "default", podGVR) {
+		t.Error("Expected pods to be watchable")
+	}
+
+	k.dynamicClient.(*fake.FakeDynamicClient).PrependWatchReactor("pods",
+		func(k8sTesting.Action) (bool, watch.Interface, error) {
+			return true, nil, apiErrors.NewForbidden(podGVR.GroupResource(), "", errors.New("watch not permitted"))
+		})
+
+	if canWatch(k.dynamicClient, "default", podGVR) {
+		t.Error("Expected pods not to be watchable when watch is forbidden")
+	}
+}
@@ -0,0 +1,144 @@
+// ==========================================================================================
+// Port forwarding to a pod, each forwarded connection is its own websocket to the API server
+// Disabled unless turned on explicitly, as it reaches anything the pod's network can
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+
+	"golang.org/x/net/websocket"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ErrPortForwardDisabled is returned when port forwarding hasn't been enabled, see Options.EnablePortForward
+var ErrPortForwardDisabled = errors.New("port forwarding is not enabled")
+
+// Each forwarded port has a data & an error channel, only one port is forwarded per connection
+const (
+	portForwardDataChannel byte = iota
+	portForwardErrorChannel
+)
+
+// PortForward copies data between conn and a port on a pod, until either side closes or ctx is cancelled
+// The port is a number or the name of a port declared by one of the pod's containers
+func (k *Kubernetes) PortForward(ctx context.Context, ns, podName, port string, conn io.ReadWriter) error {
+	if k.ReadOnly {
+		return ErrReadOnly
+	}
+
+	if !k.PortForwardEnabled {
+		return ErrPortForwardDisabled
+	}
+
+	if ns == "" || podName == "" || port == "" {
+		return errors.New("namespace, pod name or port is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return err
+	}
+
+	var pod *coreV1.Pod
+
+	err := k.callAPI(func(ctx context.Context) (err error) {
+		pod, err = k.clientSet.CoreV1().Pods(ns).Get(ctx, podName, metaV1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	portNumber, err := podPort(pod, port)
+	if err != nil {
+		return err
+	}
+
+	if k.restConfig == nil {
+		return errors.New("port forwarding is not available, no connection to the API server")
+	}
+
+	forwardURL := k.clientSet.CoreV1().RESTClient().Post().
+		Namespace(ns).Resource("pods").Name(podName).SubResource("portforward").
+		Param("ports", strconv.Itoa(int(portNumber))).URL()
+
+	ws, err := k.dialAPIWebsocket(forwardURL)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	stop := context.AfterFunc(ctx, func() { _ = ws.Close() })
+	defer stop()
+
+	// The pod side is closed once the caller has finished sending, as websockets can't be half closed
+	go func() {
+		copyToChannel(ws, portForwardDataChannel, conn)
+		_ = ws.Close()
+	}()
+
+	// The first message on each channel is the port number, as two little endian bytes
+	portSent := map[byte]bool{}
+
+	for {
+		var msg []byte
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			if ctx.Err() != nil || errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			return err
+		}
+
+		if len(msg) == 0 {
+			continue
+		}
+
+		channel, data := msg[0], msg[1:]
+		if !portSent[channel] {
+			portSent[channel] = true
+
+			if len(data) < 2 {
+				continue
+			}
+
+			data = data[2:]
+		}
+
+		if len(data) == 0 {
+			continue
+		}
+
+		switch channel {
+		case portForwardDataChannel:
+			if _, err := conn.Write(data); err != nil {
+				return err
+			}
+		case portForwardErrorChannel:
+			return fmt.Errorf("forwarding port %d: %s", portNumber, data)
+		}
+	}
+}
+
+// podPort checks a port is declared by one of the pod's containers, returning its number
+// Ports which aren't declared can still be listened on, but requiring them stops typos reaching the pod
+func podPort(pod *coreV1.Pod, port string) (int32, error) {
+	number, numErr := strconv.ParseInt(port, 10, 32)
+
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			if (numErr == nil && p.ContainerPort == int32(number)) || (p.Name != "" && p.Name == port) {
+				return p.ContainerPort, nil
+			}
+		}
+	}
+
+	return 0, fmt.Errorf("port %s is not declared by any container in pod %s", port, pod.Name)
+}
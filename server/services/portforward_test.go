@@ -0,0 +1,78 @@
+// ==========================================================================================
+// Unit tests for port forwarding
+// ==========================================================================================
+
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	coreV1 "k8s.io/api/core/v1"
+)
+
+func TestKubernetes_PortForward_Disabled(t *testing.T) {
+	k := mockKubernetes()
+	createTestExecPod(k, nil)
+
+	// Off by default, even when not in read-only mode
+	err := k.PortForward(context.TODO(), "default", "web", "80", &bytes.Buffer{})
+	if !errors.Is(err, ErrPortForwardDisabled) {
+		t.Errorf("Expected ErrPortForwardDisabled, got %v", err)
+	}
+
+	// Read-only mode wins, even when enabled
+	k.PortForwardEnabled = true
+	k.ReadOnly = true
+
+	err = k.PortForward(context.TODO(), "default", "web", "80", &bytes.Buffer{})
+	if !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+}
+
+func TestKubernetes_PortForward_Validation(t *testing.T) {
+	k := mockKubernetes()
+	k.PortForwardEnabled = true
+	createTestExecPod(k, nil)
+
+	tests := []struct {
+		name          string
+		pod, port     string
+		expectedError string
+	}{
+		{"empty port", "web", "", "empty"},
+		{"missing pod", "nope", "80", "not found"},
+		{"undeclared port", "web", "8080", "port 8080 is not declared"},
+	}
+
+	for _, test := range tests {
+		err := k.PortForward(context.TODO(), "default", test.pod, test.port, &bytes.Buffer{})
+		if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+			t.Errorf("%s: expected error containing %q, got %v", test.name, test.expectedError, err)
+		}
+	}
+}
+
+func TestPodPort(t *testing.T) {
+	pod := &coreV1.Pod{Spec: coreV1.PodSpec{Containers: []coreV1.Container{
+		{Name: "app", Ports: []coreV1.ContainerPort{{Name: "http", ContainerPort: 8000}}},
+		{Name: "sidecar", Ports: []coreV1.ContainerPort{{ContainerPort: 9901}}},
+	}}}
+
+	tests := map[string]int32{"8000": 8000, "http": 8000, "9901": 9901}
+	for port, expected := range tests {
+		if got, err := podPort(pod, port); err != nil || got != expected {
+			t.Errorf("Expected port %s to be %d, got %d %v", port, expected, got, err)
+		}
+	}
+
+	for _, port := range []string{"80", "metrics"} {
+		if _, err := podPort(pod, port); err == nil {
+			t.Errorf("Expected error for undeclared port %s", port)
+		}
+	}
+}
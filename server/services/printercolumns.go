@@ -0,0 +1,162 @@
+// ==========================================================================================
+// Columns for custom resources, from the additionalPrinterColumns the CRD author defined
+// Used when the API server can't provide a Table, so custom resources still get their columns
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+var crdGVR = schema.GroupVersionResource{
+	Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions",
+}
+
+// printerColumn is a column from a CRD, with its parsed JSONPath
+type printerColumn struct {
+	definition metaV1.TableColumnDefinition
+	path       *jsonpath.JSONPath
+}
+
+// localTable builds a table without the API server's help, using the CRD's columns for custom resources
+func (k *Kubernetes) localTable(grp, ver, res string, items []unstructured.Unstructured) *ResourceTable {
+	if grp != "" {
+		if columns := k.crdPrinterColumns(grp, ver, res); len(columns) > 0 {
+			return printerColumnTable(columns, items, time.Now())
+		}
+	}
+
+	return clientTable(res, items, time.Now())
+}
+
+// crdPrinterColumns reads the additionalPrinterColumns for a version of a custom resource
+// Returns nil when the resource isn't from a CRD, or the CRD defines no columns for the version
+func (k *Kubernetes) crdPrinterColumns(grp, ver, res string) []printerColumn {
+	var crd *unstructured.Unstructured
+
+	// CRDs are always named after the plural & group of the resource they define
+	err := k.callAPI(func(ctx context.Context) (err error) {
+		crd, err = k.dynamicClient.Resource(crdGVR).Get(ctx, res+"."+grp, metaV1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		if !apiErrors.IsNotFound(err) {
+			log.Printf("💥 Failed to get CRD for %s.%s: %v", res, grp, err)
+		}
+
+		return nil
+	}
+
+	versions, _, _ := unstructured.NestedSlice(crd.Object, "spec", "versions")
+
+	for _, v := range versions {
+		version, ok := v.(map[string]any)
+		if !ok || version["name"] != ver {
+			continue
+		}
+
+		defs, _, _ := unstructured.NestedSlice(version, "additionalPrinterColumns")
+
+		return parsePrinterColumns(defs)
+	}
+
+	return nil
+}
+
+// parsePrinterColumns parses the JSONPath of each column, skipping any which are invalid
+func parsePrinterColumns(defs []any) []printerColumn {
+	columns := []printerColumn{}
+
+	for _, d := range defs {
+		def, ok := d.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		name, _, _ := unstructured.NestedString(def, "name")
+		colType, _, _ := unstructured.NestedString(def, "type")
+		format, _, _ := unstructured.NestedString(def, "format")
+		description, _, _ := unstructured.NestedString(def, "description")
+		priority, _, _ := unstructured.NestedInt64(def, "priority")
+		path, _, _ := unstructured.NestedString(def, "jsonPath")
+
+		// Same as kubectl, a column with a missing field is left empty rather than failing
+		parser := jsonpath.New(name).AllowMissingKeys(true)
+		if err := parser.Parse("{" + path + "}"); err != nil {
+			log.Printf("💥 Skipping printer column %s, invalid JSONPath %q: %v", name, path, err)
+			continue
+		}
+
+		columns = append(columns, printerColumn{
+			definition: metaV1.TableColumnDefinition{
+				Name: name, Type: colType, Format: format, Description: description, Priority: int32(priority),
+			},
+			path: parser,
+		})
+	}
+
+	return columns
+}
+
+// printerColumnTable builds a table with a name column followed by the CRD's columns
+func printerColumnTable(columns []printerColumn, items []unstructured.Unstructured, now time.Time) *ResourceTable {
+	definitions := []metaV1.TableColumnDefinition{{Name: "Name", Type: "string", Format: "name"}}
+	for _, col := range columns {
+		definitions = append(definitions, col.definition)
+	}
+
+	table := &ResourceTable{Columns: definitions, Rows: []ResourceTableRow{}}
+
+	for i := range items {
+		cells := []any{items[i].GetName()}
+		for _, col := range columns {
+			cells = append(cells, printerCell(col, &items[i], now))
+		}
+
+		table.Rows = append(table.Rows, ResourceTableRow{Name: items[i].GetName(), Cells: cells})
+	}
+
+	return table
+}
+
+// printerCell evaluates a column against an object, nil when the field is missing
+// Dates are shown as an age like kubectl does, and multiple values are joined with commas
+func printerCell(col printerColumn, obj *unstructured.Unstructured, now time.Time) any {
+	results, err := col.path.FindResults(obj.Object)
+	if err != nil || len(results) == 0 || len(results[0]) == 0 {
+		return nil
+	}
+
+	values := results[0]
+
+	if len(values) > 1 {
+		parts := make([]string, 0, len(values))
+		for _, v := range values {
+			parts = append(parts, fmt.Sprint(v.Interface()))
+		}
+
+		return strings.Join(parts, ",")
+	}
+
+	value := values[0].Interface()
+
+	if date, ok := value.(string); ok && col.definition.Type == "date" {
+		if t, err := time.Parse(time.RFC3339, date); err == nil {
+			return duration.HumanDuration(now.Sub(t))
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,162 @@
+// ==========================================================================================
+// Effective image pull secrets for a pod, from its spec & its ServiceAccount, for debugging
+// registry auth failures. Secrets are checked to exist, but their credentials are never returned
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+
+	coreV1 "k8s.io/api/core/v1"
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var serviceAccountGVR = schema.GroupVersionResource{Group: "", Version: "v1", Resource: "serviceaccounts"}
+
+// PullSecret is an image pull secret used by a pod, and whether it can be used
+type PullSecret struct {
+	Name string `json:"name"`
+	// Sources is where the secret is referenced, "pod" and/or "serviceaccount"
+	Sources []string `json:"sources"`
+	Exists  bool     `json:"exists"`
+	Type    string   `json:"type,omitempty"`
+	// Registries the secret holds credentials for, only the host names are returned
+	Registries []string `json:"registries,omitempty"`
+	// Problem with the secret which would stop images being pulled, e.g. it doesn't exist
+	Warning string `json:"warning,omitempty"`
+}
+
+// PodPullSecrets is every image pull secret a pod uses, the pod's own first then its ServiceAccount's
+type PodPullSecrets struct {
+	ServiceAccount string       `json:"serviceAccount"`
+	Secrets        []PullSecret `json:"secrets"`
+	// Warnings about the ServiceAccount, e.g. when it doesn't exist its pull secrets can't be known
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// GetPodPullSecrets resolves the image pull secrets of a pod, merging those in its spec with those of its
+// ServiceAccount, and flags any which are missing or aren't a registry credential type
+func (k *Kubernetes) GetPodPullSecrets(ns, podName string) (*PodPullSecrets, error) {
+	if ns == "" || podName == "" {
+		return nil, errors.New("namespace or pod name is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	pod := coreV1.Pod{}
+	if err := k.getTyped(podGVR, ns, podName, &pod); err != nil {
+		log.Printf("💥 Failed to get pod %s in namespace %s: %v", podName, ns, err)
+		return nil, err
+	}
+
+	result := &PodPullSecrets{ServiceAccount: pod.Spec.ServiceAccountName, Secrets: []PullSecret{}}
+	if result.ServiceAccount == "" {
+		result.ServiceAccount = "default"
+	}
+
+	add := func(name, source string) {
+		i := slices.IndexFunc(result.Secrets, func(s PullSecret) bool { return s.Name == name })
+		if i < 0 {
+			result.Secrets = append(result.Secrets, PullSecret{Name: name, Sources: []string{source}})
+			return
+		}
+
+		if !slices.Contains(result.Secrets[i].Sources, source) {
+			result.Secrets[i].Sources = append(result.Secrets[i].Sources, source)
+		}
+	}
+
+	for _, ref := range pod.Spec.ImagePullSecrets {
+		add(ref.Name, "pod")
+	}
+
+	sa := coreV1.ServiceAccount{}
+	if err := k.getTyped(serviceAccountGVR, ns, result.ServiceAccount, &sa); err != nil {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("unable to get service account %s: %v",
+			result.ServiceAccount, err))
+	}
+
+	for _, ref := range sa.ImagePullSecrets {
+		add(ref.Name, "serviceaccount")
+	}
+
+	for i := range result.Secrets {
+		k.checkPullSecret(ns, &result.Secrets[i])
+	}
+
+	return result, nil
+}
+
+// checkPullSecret fills in if the secret exists, its type & the registries it's for, without any credentials
+func (k *Kubernetes) checkPullSecret(ns string, ps *PullSecret) {
+	secret := coreV1.Secret{}
+
+	err := k.getTyped(secretGVR, ns, ps.Name, &secret)
+	if apiErrors.IsNotFound(err) {
+		ps.Warning = "secret not found"
+		return
+	}
+
+	if err != nil {
+		ps.Warning = fmt.Sprintf("unable to get secret: %v", err)
+		return
+	}
+
+	ps.Exists = true
+	ps.Type = string(secret.Type)
+
+	var auths map[string]json.RawMessage
+
+	switch secret.Type {
+	case coreV1.SecretTypeDockerConfigJson:
+		config := struct {
+			Auths map[string]json.RawMessage `json:"auths"`
+		}{}
+		err = json.Unmarshal(secret.Data[coreV1.DockerConfigJsonKey], &config)
+		auths = config.Auths
+	case coreV1.SecretTypeDockercfg:
+		err = json.Unmarshal(secret.Data[coreV1.DockerConfigKey], &auths)
+	default:
+		ps.Warning = fmt.Sprintf("secret type %s holds no registry credentials, so is ignored", secret.Type)
+		return
+	}
+
+	if err != nil {
+		ps.Warning = "secret does not hold valid registry credentials"
+		return
+	}
+
+	for registry := range auths {
+		ps.Registries = append(ps.Registries, registry)
+	}
+
+	slices.Sort(ps.Registries)
+}
+
+// getTyped gets an object with the dynamic client, converting it to its typed struct
+// Secret data is decoded from base64 by the conversion, so it must never be returned as is
+func (k *Kubernetes) getTyped(gvr schema.GroupVersionResource, ns, name string, into interface{}) error {
+	var obj *unstructured.Unstructured
+
+	err := k.callNamespaceAPI(ns, func(ctx context.Context) (err error) {
+		obj, err = k.dynamicClient.Resource(gvr).Namespace(ns).Get(ctx, name, metaV1.GetOptions{})
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, into)
+}
@@ -0,0 +1,82 @@
+// ==========================================================================================
+// Unit tests for resolving image pull secrets
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestKubernetes_GetPodPullSecrets(t *testing.T) {
+	k := mockKubernetes()
+	ctx := context.TODO()
+
+	pod := createTestPod("web", "default")
+	_ = unstructured.SetNestedField(pod.Object, "builder", "spec", "serviceAccountName")
+	_ = unstructured.SetNestedSlice(pod.Object, []interface{}{
+		map[string]interface{}{"name": "registry-creds"},
+		map[string]interface{}{"name": "missing-creds"},
+	}, "spec", "imagePullSecrets")
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(ctx, pod, metaV1.CreateOptions{})
+
+	sa := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion":       "v1",
+		"kind":             "ServiceAccount",
+		"metadata":         map[string]interface{}{"name": "builder", "namespace": "default"},
+		"imagePullSecrets": []interface{}{map[string]interface{}{"name": "registry-creds"}},
+	}}
+	_, _ = k.dynamicClient.Resource(serviceAccountGVR).Namespace("default").Create(ctx, sa, metaV1.CreateOptions{})
+
+	config, _ := json.Marshal(map[string]interface{}{
+		"auths": map[string]interface{}{"registry.example.com": map[string]string{"auth": "c2VjcmV0"}},
+	})
+	secret := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "registry-creds", "namespace": "default"},
+		"type":       "kubernetes.io/dockerconfigjson",
+		"data":       map[string]interface{}{".dockerconfigjson": base64.StdEncoding.EncodeToString(config)},
+	}}
+	_, _ = k.dynamicClient.Resource(secretGVR).Namespace("default").Create(ctx, secret, metaV1.CreateOptions{})
+
+	result, err := k.GetPodPullSecrets("default", "web")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if result.ServiceAccount != "builder" || len(result.Warnings) != 0 || len(result.Secrets) != 2 {
+		t.Fatalf("Expected 2 secrets from the builder service account, got %+v", result)
+	}
+
+	// Referenced by both the pod & its ServiceAccount, so it's listed once
+	found := result.Secrets[0]
+	if found.Name != "registry-creds" || !found.Exists || found.Warning != "" ||
+		!slices.Equal(found.Sources, []string{"pod", "serviceaccount"}) ||
+		!slices.Equal(found.Registries, []string{"registry.example.com"}) {
+		t.Errorf("Expected registry-creds to exist for registry.example.com, got %+v", found)
+	}
+
+	missing := result.Secrets[1]
+	if missing.Name != "missing-creds" || missing.Exists || missing.Warning == "" {
+		t.Errorf("Expected missing-creds to be flagged as missing, got %+v", missing)
+	}
+
+	// None of the credentials are returned
+	body, _ := json.Marshal(result)
+	if strings.Contains(string(body), "c2VjcmV0") || strings.Contains(string(body), "auths") {
+		t.Errorf("Expected no secret contents in the result, got %s", body)
+	}
+
+	if _, err := k.GetPodPullSecrets("default", "nope"); err == nil {
+		t.Error("Expected error for a pod which doesn't exist")
+	}
+}
@@ -0,0 +1,129 @@
+// ==========================================================================================
+// ResourceQuotas & LimitRanges, which explain why pods are rejected or given default resources
+// ==========================================================================================
+
+package services
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// QuotaResource is how much of one resource has been used against the quota's hard limit
+type QuotaResource struct {
+	// Resource is the quota key e.g. "pods", "requests.cpu" or "count/deployments.apps"
+	Resource string `json:"resource"`
+	Used     string `json:"used"`
+	Hard     string `json:"hard"`
+}
+
+// QuotaStatus is a ResourceQuota with its used & hard amounts paired up, sorted by resource
+type QuotaStatus struct {
+	Name      string          `json:"name"`
+	Resources []QuotaResource `json:"resources"`
+}
+
+// LimitRangeItem is one entry of a LimitRange, each map is keyed by resource name e.g. "cpu"
+type LimitRangeItem struct {
+	// Type is "Container", "Pod" or "PersistentVolumeClaim"
+	Type           string            `json:"type"`
+	Default        map[string]string `json:"default,omitempty"`
+	DefaultRequest map[string]string `json:"defaultRequest,omitempty"`
+	Min            map[string]string `json:"min,omitempty"`
+	Max            map[string]string `json:"max,omitempty"`
+}
+
+// LimitRangeSummary is a LimitRange and its limits
+type LimitRangeSummary struct {
+	Name   string           `json:"name"`
+	Limits []LimitRangeItem `json:"limits"`
+}
+
+// QuotaReport holds all the ResourceQuotas & LimitRanges in a namespace
+type QuotaReport struct {
+	Quotas      []QuotaStatus       `json:"quotas"`
+	LimitRanges []LimitRangeSummary `json:"limitRanges"`
+}
+
+// GetQuotaReport returns the ResourceQuotas & LimitRanges in a namespace
+func (k *Kubernetes) GetQuotaReport(ns string) (*QuotaReport, error) {
+	if ns == "" {
+		return nil, errors.New("namespace is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	quotas, err := k.GetResources(ns, "", "v1", "resourcequotas")
+	if err != nil {
+		return nil, err
+	}
+
+	limitRanges, err := k.GetResources(ns, "", "v1", "limitranges")
+	if err != nil {
+		return nil, err
+	}
+
+	report := &QuotaReport{
+		Quotas:      make([]QuotaStatus, 0, len(quotas)),
+		LimitRanges: make([]LimitRangeSummary, 0, len(limitRanges)),
+	}
+
+	for i := range quotas {
+		report.Quotas = append(report.Quotas, quotaStatus(&quotas[i]))
+	}
+
+	for i := range limitRanges {
+		report.LimitRanges = append(report.LimitRanges, limitRangeSummary(&limitRanges[i]))
+	}
+
+	return report, nil
+}
+
+// quotaStatus pairs each hard limit of a quota with its usage, usage is empty until the quota controller sets it
+func quotaStatus(quota *unstructured.Unstructured) QuotaStatus {
+	// The status holds the hard limits actually enforced, the spec is used until the status is set
+	hard, found, _ := unstructured.NestedStringMap(quota.Object, "status", "hard")
+	if !found {
+		hard, _, _ = unstructured.NestedStringMap(quota.Object, "spec", "hard")
+	}
+
+	used, _, _ := unstructured.NestedStringMap(quota.Object, "status", "used")
+
+	status := QuotaStatus{Name: quota.GetName(), Resources: []QuotaResource{}}
+
+	for resource, limit := range hard {
+		status.Resources = append(status.Resources, QuotaResource{Resource: resource, Used: used[resource], Hard: limit})
+	}
+
+	slices.SortFunc(status.Resources, func(a, b QuotaResource) int {
+		return cmp.Compare(a.Resource, b.Resource)
+	})
+
+	return status
+}
+
+// limitRangeSummary returns the limits of a LimitRange
+func limitRangeSummary(limitRange *unstructured.Unstructured) LimitRangeSummary {
+	summary := LimitRangeSummary{Name: limitRange.GetName(), Limits: []LimitRangeItem{}}
+
+	limits, _, _ := unstructured.NestedSlice(limitRange.Object, "spec", "limits")
+	for _, l := range limits {
+		limit, _ := l.(map[string]interface{})
+		item := LimitRangeItem{}
+
+		item.Type, _, _ = unstructured.NestedString(limit, "type")
+		item.Default, _, _ = unstructured.NestedStringMap(limit, "default")
+		item.DefaultRequest, _, _ = unstructured.NestedStringMap(limit, "defaultRequest")
+		item.Min, _, _ = unstructured.NestedStringMap(limit, "min")
+		item.Max, _, _ = unstructured.NestedStringMap(limit, "max")
+
+		summary.Limits = append(summary.Limits, item)
+	}
+
+	return summary
+}
@@ -0,0 +1,76 @@
+// ==========================================================================================
+// Unit tests for ResourceQuotas & LimitRanges
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestKubernetes_GetQuotaReport(t *testing.T) {
+	k := mockKubernetes()
+
+	quotaGvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "resourcequotas"}
+	limitGvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "limitranges"}
+
+	quota := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ResourceQuota",
+		"metadata":   map[string]interface{}{"name": "compute", "namespace": "default"},
+		"spec":       map[string]interface{}{"hard": map[string]interface{}{"pods": "10", "requests.cpu": "4"}},
+		"status": map[string]interface{}{
+			"hard": map[string]interface{}{"pods": "10", "requests.cpu": "4"},
+			"used": map[string]interface{}{"pods": "10", "requests.cpu": "2500m"},
+		},
+	}}
+
+	limitRange := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "LimitRange",
+		"metadata":   map[string]interface{}{"name": "defaults", "namespace": "default"},
+		"spec": map[string]interface{}{"limits": []interface{}{
+			map[string]interface{}{
+				"type":           "Container",
+				"default":        map[string]interface{}{"cpu": "500m"},
+				"defaultRequest": map[string]interface{}{"cpu": "100m"},
+			},
+		}},
+	}}
+
+	_, _ = k.dynamicClient.Resource(quotaGvr).Namespace("default").Create(context.TODO(), quota, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(limitGvr).Namespace("default").
+		Create(context.TODO(), limitRange, metaV1.CreateOptions{})
+
+	report, err := k.GetQuotaReport("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(report.Quotas) != 1 || len(report.Quotas[0].Resources) != 2 {
+		t.Fatalf("Expected 1 quota with 2 resources, got %+v", report.Quotas)
+	}
+
+	pods := report.Quotas[0].Resources[0]
+	if pods.Resource != "pods" || pods.Used != "10" || pods.Hard != "10" {
+		t.Errorf("Expected pods used 10 of 10, got %+v", pods)
+	}
+
+	if cpu := report.Quotas[0].Resources[1]; cpu.Used != "2500m" || cpu.Hard != "4" {
+		t.Errorf("Expected cpu used 2500m of 4, got %+v", cpu)
+	}
+
+	if len(report.LimitRanges) != 1 || len(report.LimitRanges[0].Limits) != 1 {
+		t.Fatalf("Expected 1 limit range with 1 limit, got %+v", report.LimitRanges)
+	}
+
+	limit := report.LimitRanges[0].Limits[0]
+	if limit.Type != "Container" || limit.Default["cpu"] != "500m" || limit.DefaultRequest["cpu"] != "100m" {
+		t.Errorf("Expected container defaults, got %+v", limit)
+	}
+}
@@ -0,0 +1,156 @@
+// ==========================================================================================
+// RBAC review, the Roles & ClusterRoles bound to each ServiceAccount in a namespace
+// ==========================================================================================
+
+package services
+
+import (
+	"errors"
+	"log"
+	"slices"
+
+	rbacV1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const rbacGroup = "rbac.authorization.k8s.io"
+
+// BoundRole is a Role or ClusterRole granted to a ServiceAccount, and the binding which grants it
+type BoundRole struct {
+	// Kind is "Role" or "ClusterRole"
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// BindingKind is "RoleBinding" or "ClusterRoleBinding"
+	// A ClusterRole bound by a RoleBinding only grants access within the namespace
+	BindingKind string `json:"bindingKind"`
+	BindingName string `json:"bindingName"`
+	// Rules are left empty if the role couldn't be found, e.g. it was deleted or listing it isn't permitted
+	Rules []rbacV1.PolicyRule `json:"rules"`
+}
+
+// ServiceAccountRoles is a ServiceAccount with all the roles bound to it
+type ServiceAccountRoles struct {
+	Name  string      `json:"name"`
+	Roles []BoundRole `json:"roles"`
+}
+
+// GetServiceAccountRoles resolves the roles bound to each ServiceAccount in a namespace
+// ClusterRoleBindings & ClusterRoles need cluster wide permission, without it only RoleBindings are resolved
+func (k *Kubernetes) GetServiceAccountRoles(ns string) ([]ServiceAccountRoles, error) {
+	if ns == "" {
+		return nil, errors.New("namespace is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	saList, err := k.GetResources(ns, "", "v1", "serviceaccounts")
+	if err != nil {
+		return nil, err
+	}
+
+	roleBindingList, err := k.GetResources(ns, rbacGroup, "v1", "rolebindings")
+	if err != nil {
+		return nil, err
+	}
+
+	roleBindings := decodeRBAC[rbacV1.RoleBinding](roleBindingList)
+	roles := decodeRBAC[rbacV1.Role](k.listRBAC(ns, "roles"))
+	clusterRoles := decodeRBAC[rbacV1.ClusterRole](k.listRBAC("", "clusterroles"))
+	clusterRoleBindings := decodeRBAC[rbacV1.ClusterRoleBinding](k.listRBAC("", "clusterrolebindings"))
+
+	// Rules of every role which could be listed, keyed by kind & name
+	rules := map[rbacV1.RoleRef][]rbacV1.PolicyRule{}
+	for _, r := range roles {
+		rules[rbacV1.RoleRef{APIGroup: rbacGroup, Kind: "Role", Name: r.Name}] = r.Rules
+	}
+
+	for _, r := range clusterRoles {
+		rules[rbacV1.RoleRef{APIGroup: rbacGroup, Kind: "ClusterRole", Name: r.Name}] = r.Rules
+	}
+
+	bound := func(ref rbacV1.RoleRef, bindingKind, bindingName string) BoundRole {
+		role := BoundRole{Kind: ref.Kind, Name: ref.Name, BindingKind: bindingKind, BindingName: bindingName}
+
+		role.Rules = rules[ref]
+		if role.Rules == nil {
+			role.Rules = []rbacV1.PolicyRule{}
+		}
+
+		return role
+	}
+
+	result := make([]ServiceAccountRoles, 0, len(saList))
+
+	for _, sa := range saList {
+		saRoles := ServiceAccountRoles{Name: sa.GetName(), Roles: []BoundRole{}}
+
+		for _, rb := range roleBindings {
+			if subjectsMatch(rb.Subjects, ns, sa.GetName(), ns) {
+				saRoles.Roles = append(saRoles.Roles, bound(rb.RoleRef, "RoleBinding", rb.Name))
+			}
+		}
+
+		for _, crb := range clusterRoleBindings {
+			if subjectsMatch(crb.Subjects, ns, sa.GetName(), "") {
+				saRoles.Roles = append(saRoles.Roles, bound(crb.RoleRef, "ClusterRoleBinding", crb.Name))
+			}
+		}
+
+		result = append(result, saRoles)
+	}
+
+	return result, nil
+}
+
+// listRBAC lists an RBAC resource, returning nothing when it can't be listed so the rest can still be resolved
+func (k *Kubernetes) listRBAC(ns, res string) []unstructured.Unstructured {
+	items, err := k.GetResources(ns, rbacGroup, "v1", res)
+	if err != nil {
+		log.Printf("🔒 Unable to list %s, roles bound with them won't be shown: %v", res, err)
+		return nil
+	}
+
+	return items
+}
+
+// decodeRBAC converts unstructured RBAC objects to their typed form, skipping any which can't be decoded
+func decodeRBAC[T any](items []unstructured.Unstructured) []T {
+	typed := make([]T, 0, len(items))
+
+	for _, obj := range items {
+		var t T
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &t); err != nil {
+			log.Printf("💥 Failed to decode %s %s: %v", obj.GetKind(), obj.GetName(), err)
+			continue
+		}
+
+		typed = append(typed, t)
+	}
+
+	return typed
+}
+
+// subjectsMatch checks if any subject of a binding refers to a ServiceAccount, directly, by its user name
+// or by the groups every ServiceAccount belongs to. In a RoleBinding a subject with no namespace means bindingNs
+func subjectsMatch(subjects []rbacV1.Subject, ns, name, bindingNs string) bool {
+	return slices.ContainsFunc(subjects, func(s rbacV1.Subject) bool {
+		switch s.Kind {
+		case rbacV1.ServiceAccountKind:
+			subjectNs := s.Namespace
+			if subjectNs == "" {
+				subjectNs = bindingNs
+			}
+
+			return s.Name == name && subjectNs == ns
+		case rbacV1.UserKind:
+			return s.Name == "system:serviceaccount:"+ns+":"+name
+		case rbacV1.GroupKind:
+			return s.Name == "system:serviceaccounts" || s.Name == "system:serviceaccounts:"+ns
+		}
+
+		return false
+	})
+}
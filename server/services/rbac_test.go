@@ -0,0 +1,103 @@
+// ==========================================================================================
+// Unit tests for resolving the roles bound to ServiceAccounts
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestKubernetes_GetServiceAccountRoles(t *testing.T) {
+	k := mockKubernetes()
+
+	rbac := func(res string) schema.GroupVersionResource {
+		return schema.GroupVersionResource{Group: rbacGroup, Version: "v1", Resource: res}
+	}
+
+	create := func(res, ns string, obj map[string]interface{}) {
+		_, _ = k.dynamicClient.Resource(rbac(res)).Namespace(ns).
+			Create(context.TODO(), &unstructured.Unstructured{Object: obj}, metaV1.CreateOptions{})
+	}
+
+	saGvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "serviceaccounts"}
+	for _, name := range []string{"builder", "default"} {
+		sa := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "ServiceAccount",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		}}
+		_, _ = k.dynamicClient.Resource(saGvr).Namespace("default").Create(context.TODO(), sa, metaV1.CreateOptions{})
+	}
+
+	create("roles", "default", map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "Role",
+		"metadata":   map[string]interface{}{"name": "pod-reader", "namespace": "default"},
+		"rules": []interface{}{map[string]interface{}{
+			"apiGroups": []interface{}{""}, "resources": []interface{}{"pods"}, "verbs": []interface{}{"get", "list"},
+		}},
+	})
+
+	create("rolebindings", "default", map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "RoleBinding",
+		"metadata":   map[string]interface{}{"name": "builder-reads-pods", "namespace": "default"},
+		"roleRef":    map[string]interface{}{"apiGroup": rbacGroup, "kind": "Role", "name": "pod-reader"},
+		"subjects": []interface{}{
+			map[string]interface{}{"kind": "ServiceAccount", "name": "builder", "namespace": "default"},
+		},
+	})
+
+	create("clusterrolebindings", "", map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "ClusterRoleBinding",
+		"metadata":   map[string]interface{}{"name": "all-view"},
+		"roleRef":    map[string]interface{}{"apiGroup": rbacGroup, "kind": "ClusterRole", "name": "view"},
+		"subjects": []interface{}{
+			map[string]interface{}{"kind": "Group", "name": "system:serviceaccounts:default"},
+		},
+	})
+
+	accounts, err := k.GetServiceAccountRoles("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(accounts) != 2 || accounts[0].Name != "builder" || accounts[1].Name != "default" {
+		t.Fatalf("Expected builder & default ServiceAccounts, got %+v", accounts)
+	}
+
+	builder := accounts[0].Roles
+	if len(builder) != 2 {
+		t.Fatalf("Expected 2 roles bound to builder, got %+v", builder)
+	}
+
+	role := builder[0]
+	if role.Kind != "Role" || role.Name != "pod-reader" || role.BindingName != "builder-reads-pods" {
+		t.Errorf("Expected pod-reader Role from builder-reads-pods, got %+v", role)
+	}
+
+	if len(role.Rules) != 1 || role.Rules[0].Resources[0] != "pods" {
+		t.Errorf("Expected the pod-reader rules, got %+v", role.Rules)
+	}
+
+	// The view ClusterRole doesn't exist in the fake cluster, so it has no rules
+	view := builder[1]
+	if view.Kind != "ClusterRole" || view.BindingKind != "ClusterRoleBinding" || len(view.Rules) != 0 {
+		t.Errorf("Expected view ClusterRole with no rules, got %+v", view)
+	}
+
+	if len(accounts[1].Roles) != 1 || accounts[1].Roles[0].Name != "view" {
+		t.Errorf("Expected only view bound to default, got %+v", accounts[1].Roles)
+	}
+
+	if _, err := k.GetServiceAccountRoles(""); err == nil {
+		t.Error("Expected error for empty namespace")
+	}
+}
@@ -0,0 +1,113 @@
+// ==========================================================================================
+// Redacting values anywhere in an object by key, e.g. annotations or env vars holding tokens,
+// on top of the Secret & ConfigMap data hidden by RedactSecrets
+// ==========================================================================================
+
+package services
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// KeyRedactor is a Trimmer which redacts values whose key matches one of a set of patterns, ignoring case
+// Patterns are globs e.g. "*token*", where * matches anything including a slash, and ? any one character.
+// They're matched against map keys at any depth, such as annotation & label keys, including within the
+// last-applied-configuration annotation.
+// Lists of name & value pairs, like container env vars, are matched on the name with the value redacted.
+// Only strings, numbers & bools are redacted, a key matching a nested object is searched instead
+type KeyRedactor struct {
+	patterns []*regexp.Regexp
+}
+
+// NewKeyRedactor creates a KeyRedactor from glob patterns, empty patterns are ignored
+func NewKeyRedactor(patterns []string) *KeyRedactor {
+	r := &KeyRedactor{}
+
+	for _, p := range patterns {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+
+		expr := regexp.QuoteMeta(p)
+		expr = strings.NewReplacer(`\*`, ".*", `\?`, ".").Replace(expr)
+		r.patterns = append(r.patterns, regexp.MustCompile("(?i)^"+expr+"$"))
+	}
+
+	return r
+}
+
+// Trim redacts the object in place, it must be a copy the caller owns, never an informer's cached object
+// The last-applied-configuration annotation holds a copy of the whole object as JSON, so it's redacted too
+func (r *KeyRedactor) Trim(obj *unstructured.Unstructured) {
+	if len(r.patterns) == 0 {
+		return
+	}
+
+	r.redact(obj.Object)
+
+	editLastApplied(obj, func(config map[string]any) {
+		r.redact(config)
+	})
+}
+
+func (r *KeyRedactor) matches(key string) bool {
+	return slices.ContainsFunc(r.patterns, func(p *regexp.Regexp) bool { return p.MatchString(key) })
+}
+
+func (r *KeyRedactor) redact(value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		// A name & value pair, e.g. an env var, is redacted when the name matches
+		if name, ok := v["name"].(string); ok && isScalar(v["value"]) && r.matches(name) {
+			v["value"] = redactedValue
+		}
+
+		for key, child := range v {
+			if isScalar(child) && r.matches(key) {
+				v[key] = redactedValue
+				continue
+			}
+
+			r.redact(child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			r.redact(child)
+		}
+	}
+}
+
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case string, int64, float64, bool:
+		return true
+	}
+
+	return false
+}
+
+// chainTrimmer applies each Trimmer in turn
+type chainTrimmer []Trimmer
+
+func (c chainTrimmer) Trim(obj *unstructured.Unstructured) {
+	for _, t := range c {
+		t.Trim(obj)
+	}
+}
+
+// withRedaction adds a KeyRedactor after a Trimmer when there are patterns, a nil Trimmer means DefaultTrimmer
+func withRedaction(trimmer Trimmer, patterns []string) Trimmer {
+	if trimmer == nil {
+		trimmer = DefaultTrimmer{}
+	}
+
+	if len(patterns) == 0 {
+		return trimmer
+	}
+
+	return chainTrimmer{trimmer, NewKeyRedactor(patterns)}
+}
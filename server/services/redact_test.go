@@ -0,0 +1,119 @@
+// ==========================================================================================
+// Unit tests for redacting values by key
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestKubernetes_RedactKeys(t *testing.T) {
+	k := mockKubernetes()
+	k.RedactKeys = []string{"*TOKEN*"}
+
+	pod := createTestPod("web", "default")
+	pod.SetAnnotations(map[string]string{"example.com/api-token": "abc123", "example.com/owner": "team-a"})
+	_ = unstructured.SetNestedSlice(pod.Object, []interface{}{
+		map[string]interface{}{"name": "app", "image": "nginx", "env": []interface{}{
+			map[string]interface{}{"name": "GITHUB_TOKEN", "value": "ghp_secret"},
+			map[string]interface{}{"name": "LOG_LEVEL", "value": "debug"},
+		}},
+	}, "spec", "containers")
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+
+	pods, err := k.GetResources("default", "", "v1", "pods")
+	if err != nil || len(pods) != 1 {
+		t.Fatalf("Expected one pod, got %d, %v", len(pods), err)
+	}
+
+	annotations := pods[0].GetAnnotations()
+	if annotations["example.com/api-token"] != redactedValue || annotations["example.com/owner"] != "team-a" {
+		t.Errorf("Expected only the token annotation to be redacted, got %v", annotations)
+	}
+
+	containers, _, _ := unstructured.NestedSlice(pods[0].Object, "spec", "containers")
+	env, _, _ := unstructured.NestedSlice(containers[0].(map[string]interface{}), "env")
+
+	if v := env[0].(map[string]interface{})["value"]; v != redactedValue {
+		t.Errorf("Expected the GITHUB_TOKEN env var to be redacted, got %v", v)
+	}
+
+	if v := env[1].(map[string]interface{})["value"]; v != "debug" {
+		t.Errorf("Expected the LOG_LEVEL env var to be kept, got %v", v)
+	}
+
+	// Single objects are redacted the same as listed ones
+	chain, err := k.ResolveOwnerChain("default", "Pod", "web")
+	if err != nil || len(chain) != 1 {
+		t.Fatalf("Expected an owner chain of just the pod, got %d, %v", len(chain), err)
+	}
+
+	if v := chain[0].GetAnnotations()["example.com/api-token"]; v != redactedValue {
+		t.Errorf("Expected the token annotation to be redacted in the owner chain, got %q", v)
+	}
+
+	// Only the copy returned is redacted, never the object it came from
+	stored, _ := k.dynamicClient.Resource(podGVR).Namespace("default").Get(context.TODO(), "web", metaV1.GetOptions{})
+	if v := stored.GetAnnotations()["example.com/api-token"]; v != "abc123" {
+		t.Errorf("Expected the stored pod to be unchanged, got %q", v)
+	}
+}
+
+func TestKubernetes_RedactKeys_LastApplied(t *testing.T) {
+	k := mockKubernetes()
+	k.RedactKeys = []string{"*token*"}
+
+	deploy := createTestDeployment("web", "default", 1)
+	_ = unstructured.SetNestedSlice(deploy.Object, []interface{}{
+		map[string]interface{}{"name": "app", "image": "nginx", "env": []interface{}{
+			map[string]interface{}{"name": "GITHUB_TOKEN", "value": "ghp_secret"},
+		}},
+	}, "spec", "template", "spec", "containers")
+
+	// kubectl apply leaves the whole object as it was applied in an annotation, env vars and all
+	applied, _ := json.Marshal(deploy.Object)
+	deploy.SetAnnotations(map[string]string{lastAppliedAnnotation: string(applied)})
+	_, _ = k.dynamicClient.Resource(deploymentGVR).Namespace("default").
+		Create(context.TODO(), deploy, metaV1.CreateOptions{})
+
+	deploys, err := k.GetResources("default", "apps", "v1", "deployments")
+	if err != nil || len(deploys) != 1 {
+		t.Fatalf("Expected one deployment, got %d, %v", len(deploys), err)
+	}
+
+	if strings.Contains(deploys[0].GetAnnotations()[lastAppliedAnnotation], "ghp_secret") {
+		t.Errorf("Expected the token to be redacted in the last applied config, got %s",
+			deploys[0].GetAnnotations()[lastAppliedAnnotation])
+	}
+
+	if strings.Contains(string(mustJSON(t, deploys[0].Object)), "ghp_secret") {
+		t.Error("Expected the token to be redacted in the spec")
+	}
+
+	detail, err := k.GetObjectDetail("default", "apps/v1", "Deployment", "web")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if detail.LastApplied == nil || strings.Contains(string(mustJSON(t, detail.LastApplied)), "ghp_secret") {
+		t.Errorf("Expected the decoded last applied config to be redacted, got %v", detail.LastApplied)
+	}
+}
+
+func mustJSON(t *testing.T, value any) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+
+	return data
+}
@@ -0,0 +1,355 @@
+// ==========================================================================================
+// Rollout progress of a Deployment, worked out the same way as `kubectl rollout status`, its history & rolling back
+// ==========================================================================================
+
+package services
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"time"
+
+	appsV1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Set on a Deployment & its ReplicaSets by the deployment controller, counting up with each rollout
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+var deploymentGVR = schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+// ErrRevisionNotFound is returned when rolling back to a revision a Deployment doesn't have
+var ErrRevisionNotFound = errors.New("revision not found")
+
+// Set by users or tools to record why a rollout happened, shown by `kubectl rollout history`
+const changeCauseAnnotation = "kubernetes.io/change-cause"
+
+// RolloutState is the overall state of a rollout
+type RolloutState string
+
+const (
+	// RolloutInProgress is while replicas are being updated, or old ones are still being removed
+	RolloutInProgress RolloutState = "in-progress"
+	// RolloutComplete is when every replica is updated & available
+	RolloutComplete RolloutState = "complete"
+	// RolloutFailed is when the progress deadline has been exceeded, the controller has given up
+	RolloutFailed RolloutState = "failed"
+	// RolloutPaused is a rollout which won't progress until it's resumed
+	RolloutPaused RolloutState = "paused"
+)
+
+// RolloutCondition is one of the Progressing, Available or ReplicaFailure conditions of a Deployment
+type RolloutCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// RolloutStatus is the progress of a Deployment's rollout
+type RolloutStatus struct {
+	Name              string             `json:"name"`
+	State             RolloutState       `json:"state"`
+	Message           string             `json:"message"`
+	Paused            bool               `json:"paused"`
+	Replicas          int32              `json:"replicas"`
+	UpdatedReplicas   int32              `json:"updatedReplicas"`
+	ReadyReplicas     int32              `json:"readyReplicas"`
+	AvailableReplicas int32              `json:"availableReplicas"`
+	Conditions        []RolloutCondition `json:"conditions"`
+}
+
+// GetRolloutStatus returns the rollout progress of a Deployment
+func (k *Kubernetes) GetRolloutStatus(ns, name string) (*RolloutStatus, error) {
+	if ns == "" || name == "" {
+		return nil, errors.New("namespace or deployment name is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	obj, err := k.getByKind(ns, "", "Deployment", name)
+	if err != nil {
+		log.Printf("💥 Failed to get deployment %s in namespace %s: %v", name, ns, err)
+		return nil, err
+	}
+
+	deploy := appsV1.Deployment{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &deploy); err != nil {
+		return nil, err
+	}
+
+	return rolloutStatus(&deploy), nil
+}
+
+// rolloutStatus works out the state of a rollout from the Deployment's status & conditions
+func rolloutStatus(deploy *appsV1.Deployment) *RolloutStatus {
+	// Replicas defaults to one when not set
+	replicas := int32(1)
+	if deploy.Spec.Replicas != nil {
+		replicas = *deploy.Spec.Replicas
+	}
+
+	status := &RolloutStatus{
+		Name:              deploy.Name,
+		Paused:            deploy.Spec.Paused,
+		Replicas:          replicas,
+		UpdatedReplicas:   deploy.Status.UpdatedReplicas,
+		ReadyReplicas:     deploy.Status.ReadyReplicas,
+		AvailableReplicas: deploy.Status.AvailableReplicas,
+		Conditions:        []RolloutCondition{},
+	}
+
+	var progressing *appsV1.DeploymentCondition
+
+	for i, c := range deploy.Status.Conditions {
+		status.Conditions = append(status.Conditions, RolloutCondition{
+			Type:    string(c.Type),
+			Status:  string(c.Status),
+			Reason:  c.Reason,
+			Message: c.Message,
+		})
+
+		if c.Type == appsV1.DeploymentProgressing {
+			progressing = &deploy.Status.Conditions[i]
+		}
+	}
+
+	total := deploy.Status.Replicas
+
+	switch {
+	case deploy.Spec.Paused:
+		status.State = RolloutPaused
+		status.Message = fmt.Sprintf("rollout is paused with %d of %d new replicas updated", status.UpdatedReplicas, replicas)
+	case deploy.Status.ObservedGeneration < deploy.Generation:
+		status.State = RolloutInProgress
+		status.Message = "waiting for the deployment spec update to be observed"
+	case progressing != nil && progressing.Reason == "ProgressDeadlineExceeded":
+		status.State = RolloutFailed
+		status.Message = progressing.Message
+	case status.UpdatedReplicas < replicas:
+		status.State = RolloutInProgress
+		status.Message = fmt.Sprintf("%d of %d new replicas have been updated", status.UpdatedReplicas, replicas)
+	case total > status.UpdatedReplicas:
+		status.State = RolloutInProgress
+		status.Message = fmt.Sprintf("%d old replicas are pending termination", total-status.UpdatedReplicas)
+	case status.AvailableReplicas < status.UpdatedReplicas:
+		status.State = RolloutInProgress
+		status.Message = fmt.Sprintf("%d of %d updated replicas are available", status.AvailableReplicas,
+			status.UpdatedReplicas)
+	default:
+		status.State = RolloutComplete
+		status.Message = "successfully rolled out"
+	}
+
+	return status
+}
+
+// RolloutRevision is one of the ReplicaSets a Deployment has rolled out, old or current
+type RolloutRevision struct {
+	Name string `json:"name"`
+	// Revision is zero when the ReplicaSet has no revision annotation
+	Revision      int64     `json:"revision"`
+	Current       bool      `json:"current"`
+	Replicas      int32     `json:"replicas"`
+	ReadyReplicas int32     `json:"readyReplicas"`
+	Images        []string  `json:"images"`
+	ChangeCause   string    `json:"changeCause,omitempty"`
+	Created       time.Time `json:"created"`
+}
+
+// GetRolloutHistory returns the ReplicaSets owned by a Deployment, oldest revision first like `kubectl rollout history`
+func (k *Kubernetes) GetRolloutHistory(ns, name string) ([]RolloutRevision, error) {
+	if ns == "" || name == "" {
+		return nil, errors.New("namespace or deployment name is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	deploy, err := k.getByKind(ns, "", "Deployment", name)
+	if err != nil {
+		log.Printf("💥 Failed to get deployment %s in namespace %s: %v", name, ns, err)
+		return nil, err
+	}
+
+	replicaSets, err := k.ownedReplicaSets(ns, deploy)
+	if err != nil {
+		return nil, err
+	}
+
+	current := deploy.GetAnnotations()[revisionAnnotation]
+	history := []RolloutRevision{}
+
+	for i := range replicaSets {
+		history = append(history, rolloutRevision(&replicaSets[i], current))
+	}
+
+	// ReplicaSets without a revision go first, then ties are broken by age
+	slices.SortFunc(history, func(a, b RolloutRevision) int {
+		return cmp.Or(cmp.Compare(a.Revision, b.Revision), a.Created.Compare(b.Created), cmp.Compare(a.Name, b.Name))
+	})
+
+	return history, nil
+}
+
+// rolloutRevision describes a ReplicaSet, it's current when its revision matches the Deployment's
+func rolloutRevision(rs *appsV1.ReplicaSet, current string) RolloutRevision {
+	annotation := rs.Annotations[revisionAnnotation]
+
+	// A missing or unparseable revision is left as zero
+	revision, _ := strconv.ParseInt(annotation, 10, 64)
+
+	images := []string{}
+	for _, c := range rs.Spec.Template.Spec.Containers {
+		images = append(images, c.Image)
+	}
+
+	return RolloutRevision{
+		Name:          rs.Name,
+		Revision:      revision,
+		Current:       annotation != "" && annotation == current,
+		Replicas:      rs.Status.Replicas,
+		ReadyReplicas: rs.Status.ReadyReplicas,
+		Images:        images,
+		ChangeCause:   rs.Annotations[changeCauseAnnotation],
+		Created:       rs.CreationTimestamp.Time,
+	}
+}
+
+// ownedReplicaSets lists the ReplicaSets controlled by a Deployment
+func (k *Kubernetes) ownedReplicaSets(ns string, deploy *unstructured.Unstructured) ([]appsV1.ReplicaSet, error) {
+	replicaSets, err := k.GetResources(ns, "apps", "v1", "replicasets")
+	if err != nil {
+		return nil, err
+	}
+
+	owned := []appsV1.ReplicaSet{}
+
+	for _, obj := range replicaSets {
+		owner := metaV1.GetControllerOf(&obj)
+		if owner == nil || owner.Kind != "Deployment" || owner.UID != deploy.GetUID() {
+			continue
+		}
+
+		rs := appsV1.ReplicaSet{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &rs); err != nil {
+			log.Printf("💥 Failed to decode replicaset %s: %v", obj.GetName(), err)
+			continue
+		}
+
+		owned = append(owned, rs)
+	}
+
+	return owned, nil
+}
+
+// RollbackDeployment rolls a Deployment back to the pod template of an earlier revision, as `kubectl rollout undo`
+// A revision of zero rolls back to the revision before the current one, with dryRun it's only validated
+func (k *Kubernetes) RollbackDeployment(ns, name string, revision int, dryRun bool) error {
+	if k.ReadOnly {
+		return ErrReadOnly
+	}
+
+	if ns == "" || name == "" {
+		return errors.New("namespace or deployment name is empty")
+	}
+
+	if revision < 0 {
+		return fmt.Errorf("invalid revision %d", revision)
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return err
+	}
+
+	deploy, err := k.getByKind(ns, "", "Deployment", name)
+	if err != nil {
+		log.Printf("💥 Failed to get deployment %s in namespace %s: %v", name, ns, err)
+		return err
+	}
+
+	if paused, _, _ := unstructured.NestedBool(deploy.Object, "spec", "paused"); paused {
+		return fmt.Errorf("deployment %s is paused, resume it before rolling back", name)
+	}
+
+	replicaSets, err := k.ownedReplicaSets(ns, deploy)
+	if err != nil {
+		return err
+	}
+
+	current, _ := strconv.ParseInt(deploy.GetAnnotations()[revisionAnnotation], 10, 64)
+
+	target := rollbackTarget(replicaSets, current, int64(revision))
+	if target == nil {
+		return fmt.Errorf("%w: deployment %s has no revision %d", ErrRevisionNotFound, name, revision)
+	}
+
+	// The hash label is added by the controller to tell ReplicaSets apart, it must not be copied back
+	template := target.Spec.Template.DeepCopy()
+	delete(template.Labels, appsV1.DefaultDeploymentUniqueLabelKey)
+
+	templateMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(template)
+	if err != nil {
+		return err
+	}
+
+	// Replacing the whole template, rather than merging, so nothing from the current template is left behind
+	patch, err := json.Marshal([]map[string]any{
+		{"op": "replace", "path": "/spec/template", "value": templateMap},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = k.callAPI(func(ctx context.Context) error {
+		_, err := k.dynamicClient.Resource(deploymentGVR).Namespace(ns).
+			Patch(ctx, name, types.JSONPatchType, patch, metaV1.PatchOptions{DryRun: dryRunOption(dryRun)})
+		return err
+	})
+	if err != nil {
+		log.Printf("💥 Failed to roll back deployment %s in namespace %s: %v", name, ns, err)
+		return err
+	}
+
+	log.Printf("⏪ Rolled back deployment %s in namespace %s to %s%s", name, ns, target.Name, dryRunNote(dryRun))
+
+	return nil
+}
+
+// rollbackTarget finds the ReplicaSet with a revision, or the latest before the current one when revision is zero
+func rollbackTarget(replicaSets []appsV1.ReplicaSet, current, revision int64) *appsV1.ReplicaSet {
+	var target *appsV1.ReplicaSet
+
+	targetRevision := int64(0)
+
+	for i, rs := range replicaSets {
+		rsRevision, err := strconv.ParseInt(rs.Annotations[revisionAnnotation], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if revision > 0 && rsRevision == revision {
+			return &replicaSets[i]
+		}
+
+		if revision == 0 && rsRevision < current && rsRevision > targetRevision {
+			target = &replicaSets[i]
+			targetRevision = rsRevision
+		}
+	}
+
+	return target
+}
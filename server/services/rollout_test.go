@@ -0,0 +1,225 @@
+// ==========================================================================================
+// Unit tests for Deployment rollout status & history
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// rolloutTestDeployment creates a deployment of 3 replicas with the given status
+func rolloutTestDeployment(k *Kubernetes, name string, paused bool, status map[string]interface{}) {
+	deploy := createTestDeployment(name, "default", 3)
+	deploy.SetGeneration(2)
+	_ = unstructured.SetNestedField(deploy.Object, paused, "spec", "paused")
+	_ = unstructured.SetNestedField(deploy.Object, status, "status")
+
+	_, _ = k.dynamicClient.Resource(deploymentGVR).Namespace("default").
+		Create(context.TODO(), deploy, metaV1.CreateOptions{})
+}
+
+func TestKubernetes_GetRolloutStatus(t *testing.T) {
+	k := mockKubernetes()
+
+	rolloutTestDeployment(k, "mid-rollout", false, map[string]interface{}{
+		"observedGeneration": int64(2),
+		"replicas":           int64(4),
+		"updatedReplicas":    int64(1),
+		"readyReplicas":      int64(3),
+		"availableReplicas":  int64(3),
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Available", "status": "True", "reason": "MinimumReplicasAvailable"},
+			map[string]interface{}{"type": "Progressing", "status": "True", "reason": "ReplicaSetUpdated"},
+		},
+	})
+
+	rolloutTestDeployment(k, "done", false, map[string]interface{}{
+		"observedGeneration": int64(2),
+		"replicas":           int64(3),
+		"updatedReplicas":    int64(3),
+		"readyReplicas":      int64(3),
+		"availableReplicas":  int64(3),
+	})
+
+	rolloutTestDeployment(k, "stuck", false, map[string]interface{}{
+		"observedGeneration": int64(2),
+		"updatedReplicas":    int64(1),
+		"conditions": []interface{}{
+			map[string]interface{}{
+				"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded",
+				"message": `ReplicaSet "stuck-abc" has timed out progressing.`,
+			},
+		},
+	})
+
+	rolloutTestDeployment(k, "on-hold", true, map[string]interface{}{
+		"observedGeneration": int64(2),
+		"updatedReplicas":    int64(1),
+	})
+
+	status, err := k.GetRolloutStatus("default", "mid-rollout")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if status.State != RolloutInProgress || status.Message != "1 of 3 new replicas have been updated" {
+		t.Errorf("Expected rollout in progress with 1 of 3 updated, got %s: %s", status.State, status.Message)
+	}
+
+	if status.Replicas != 3 || status.UpdatedReplicas != 1 || status.ReadyReplicas != 3 || status.AvailableReplicas != 3 {
+		t.Errorf("Unexpected replica counts %+v", status)
+	}
+
+	if len(status.Conditions) != 2 || status.Conditions[1].Type != "Progressing" {
+		t.Errorf("Expected Available & Progressing conditions, got %+v", status.Conditions)
+	}
+
+	for name, expected := range map[string]RolloutState{
+		"done":    RolloutComplete,
+		"stuck":   RolloutFailed,
+		"on-hold": RolloutPaused,
+	} {
+		status, err := k.GetRolloutStatus("default", name)
+		if err != nil {
+			t.Fatalf("Expected no error for %s, got %v", name, err)
+		}
+
+		if status.State != expected {
+			t.Errorf("Expected %s to be %s, got %s: %s", name, expected, status.State, status.Message)
+		}
+	}
+
+	if _, err := k.GetRolloutStatus("default", "missing"); err == nil {
+		t.Error("Expected error for a missing deployment")
+	}
+}
+
+// rolloutTestReplicaSet creates a replicaset controlled by the owner UID, with an optional revision annotation
+func rolloutTestReplicaSet(k *Kubernetes, name, ownerUID, revision, image string, replicas int64) {
+	annotations := map[string]interface{}{}
+	if revision != "" {
+		annotations[revisionAnnotation] = revision
+	}
+
+	rs := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "ReplicaSet",
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"namespace":   "default",
+			"annotations": annotations,
+			"labels":      map[string]interface{}{"app": "web", "pod-template-hash": name},
+			"ownerReferences": []interface{}{map[string]interface{}{
+				"apiVersion": "apps/v1", "kind": "Deployment", "name": "web", "uid": ownerUID, "controller": true,
+			}},
+		},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{"app": "web", "pod-template-hash": name},
+				},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "app", "image": image}},
+				},
+			},
+		},
+		"status": map[string]interface{}{"replicas": replicas, "readyReplicas": replicas},
+	}}
+
+	_, _ = k.dynamicClient.Resource(schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "replicasets"}).
+		Namespace("default").Create(context.TODO(), rs, metaV1.CreateOptions{})
+}
+
+func TestKubernetes_GetRolloutHistory(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	deploy := createTestDeployment("web", "default", 3)
+	deploy.SetAnnotations(map[string]string{revisionAnnotation: "2"})
+	_, _ = k.dynamicClient.Resource(deploymentGVR).Namespace("default").
+		Create(context.TODO(), deploy, metaV1.CreateOptions{})
+
+	// Created out of order, and one owned by another deployment of the same name which must be ignored
+	rolloutTestReplicaSet(k, "web-new", "web-uid", "2", "nginx:1.27", 3)
+	rolloutTestReplicaSet(k, "web-old", "web-uid", "1", "nginx:1.26", 0)
+	rolloutTestReplicaSet(k, "web-other", "other-uid", "3", "nginx:1.28", 1)
+
+	history, err := k.GetRolloutHistory("default", "web")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(history) != 2 || history[0].Name != "web-old" || history[1].Name != "web-new" {
+		t.Fatalf("Expected web-old then web-new, got %+v", history)
+	}
+
+	if history[0].Revision != 1 || history[0].Current || history[0].Replicas != 0 || history[0].Images[0] != "nginx:1.26" {
+		t.Errorf("Unexpected old revision %+v", history[0])
+	}
+
+	if history[1].Revision != 2 || !history[1].Current || history[1].Replicas != 3 || history[1].ReadyReplicas != 3 {
+		t.Errorf("Unexpected current revision %+v", history[1])
+	}
+
+	// A replicaset with no revision annotation is kept, with a revision of zero
+	rolloutTestReplicaSet(k, "web-unknown", "web-uid", "", "nginx:1.25", 0)
+
+	history, _ = k.GetRolloutHistory("default", "web")
+	if len(history) != 3 || history[0].Name != "web-unknown" || history[0].Revision != 0 || history[0].Current {
+		t.Errorf("Expected the replicaset without a revision first, got %+v", history)
+	}
+
+	if _, err := k.GetRolloutHistory("default", "missing"); err == nil {
+		t.Error("Expected error for a missing deployment")
+	}
+}
+
+func TestKubernetes_RollbackDeployment(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	deploy := createTestDeployment("web", "default", 3)
+	deploy.SetAnnotations(map[string]string{revisionAnnotation: "2"})
+	_, _ = k.dynamicClient.Resource(deploymentGVR).Namespace("default").
+		Create(context.TODO(), deploy, metaV1.CreateOptions{})
+
+	rolloutTestReplicaSet(k, "web-old", "web-uid", "1", "nginx:1.26", 0)
+	rolloutTestReplicaSet(k, "web-new", "web-uid", "2", "nginx:1.27", 3)
+
+	k.ReadOnly = true
+
+	if err := k.RollbackDeployment("default", "web", 1, false); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+
+	k.ReadOnly = false
+
+	if err := k.RollbackDeployment("default", "web", 5, false); !errors.Is(err, ErrRevisionNotFound) {
+		t.Errorf("Expected ErrRevisionNotFound, got %v", err)
+	}
+
+	// Zero is the revision before the current one
+	if err := k.RollbackDeployment("default", "web", 0, false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	rolledBack, _ := k.dynamicClient.Resource(deploymentGVR).Namespace("default").
+		Get(context.TODO(), "web", metaV1.GetOptions{})
+
+	containers, _, _ := unstructured.NestedSlice(rolledBack.Object, "spec", "template", "spec", "containers")
+	if len(containers) != 1 || containers[0].(map[string]interface{})["image"] != "nginx:1.26" {
+		t.Errorf("Expected the template of revision 1, got %v", containers)
+	}
+
+	if _, found, _ := unstructured.NestedFieldNoCopy(rolledBack.Object, "spec", "template", "metadata", "labels",
+		"pod-template-hash"); found {
+		t.Error("Expected the pod-template-hash label not to be copied to the deployment")
+	}
+}
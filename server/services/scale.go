@@ -0,0 +1,64 @@
+// ==========================================================================================
+// Scaling workloads through the scale subresource, the same as `kubectl scale`
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ScaleWorkload sets the replica count of a Deployment, StatefulSet, ReplicaSet or any custom resource with
+// a scale subresource, returning the resulting autoscaling/v1 Scale. With dryRun the Scale is what it
+// would be, but the replica count isn't changed
+func (k *Kubernetes) ScaleWorkload(ns, grp, ver, res, name string, replicas int64,
+	dryRun bool,
+) (*unstructured.Unstructured, error) {
+	if k.ReadOnly {
+		return nil, ErrReadOnly
+	}
+
+	if ns == "" || ver == "" || res == "" || name == "" {
+		return nil, errors.New("namespace, version, resource or name is empty")
+	}
+
+	if replicas < 0 {
+		return nil, fmt.Errorf("invalid replica count %d", replicas)
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	patch, err := json.Marshal(map[string]any{"spec": map[string]any{"replicas": replicas}})
+	if err != nil {
+		return nil, err
+	}
+
+	gvr := schema.GroupVersionResource{Group: grp, Version: ver, Resource: res}
+
+	var scale *unstructured.Unstructured
+
+	err = k.callAPI(func(ctx context.Context) (err error) {
+		scale, err = k.dynamicClient.Resource(gvr).Namespace(ns).
+			Patch(ctx, name, types.MergePatchType, patch, metaV1.PatchOptions{DryRun: dryRunOption(dryRun)}, "scale")
+		return err
+	})
+	if err != nil {
+		log.Printf("💥 Failed to scale %s %s in namespace %s: %v", res, name, ns, err)
+		return nil, err
+	}
+
+	log.Printf("📏 Scaled %s %s in namespace %s to %d replicas%s", res, name, ns, replicas, dryRunNote(dryRun))
+
+	return scale, nil
+}
@@ -0,0 +1,114 @@
+// ==========================================================================================
+// Unit tests for scaling workloads
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// dryRunPatches makes the fake client honour dry run for merge patches, as the API server does, by returning
+// the patched object without storing it. Only maps are merged, which is enough for these tests
+func dryRunPatches(k *Kubernetes) {
+	client, _ := k.dynamicClient.(*fake.FakeDynamicClient)
+
+	client.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch, _ := action.(k8stesting.PatchActionImpl)
+		if !slices.Contains(patch.PatchOptions.DryRun, metaV1.DryRunAll) {
+			return false, nil, nil
+		}
+
+		stored, err := client.Tracker().Get(action.GetResource(), action.GetNamespace(), patch.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+
+		changes := map[string]interface{}{}
+		if err := json.Unmarshal(patch.GetPatch(), &changes); err != nil {
+			return true, nil, err
+		}
+
+		obj, _ := stored.DeepCopyObject().(*unstructured.Unstructured)
+		mergeMaps(obj.Object, changes)
+
+		return true, obj, nil
+	})
+}
+
+func mergeMaps(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, isMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+
+		if isMap && dstIsMap {
+			mergeMaps(dstMap, srcMap)
+		} else {
+			dst[key] = value
+		}
+	}
+}
+
+func storedReplicas(t *testing.T, k *Kubernetes) int64 {
+	t.Helper()
+
+	deploy, err := k.dynamicClient.Resource(deploymentGVR).Namespace("default").
+		Get(context.TODO(), "web", metaV1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	replicas, _, _ := unstructured.NestedInt64(deploy.Object, "spec", "replicas")
+
+	return replicas
+}
+
+func TestKubernetes_ScaleWorkload(t *testing.T) {
+	k := mockKubernetes()
+	dryRunPatches(k)
+
+	deploy := createTestDeployment("web", "default", 2)
+	_, _ = k.dynamicClient.Resource(deploymentGVR).Namespace("default").
+		Create(context.TODO(), deploy, metaV1.CreateOptions{})
+
+	// A dry run returns the intended replica count, without changing the stored one
+	scale, err := k.ScaleWorkload("default", "apps", "v1", "deployments", "web", 5, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if replicas, _, _ := unstructured.NestedInt64(scale.Object, "spec", "replicas"); replicas != 5 {
+		t.Errorf("Expected the dry run to return 5 replicas, got %d", replicas)
+	}
+
+	if replicas := storedReplicas(t, k); replicas != 2 {
+		t.Errorf("Expected a dry run to leave 2 replicas, got %d", replicas)
+	}
+
+	if _, err := k.ScaleWorkload("default", "apps", "v1", "deployments", "web", 5, false); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if replicas := storedReplicas(t, k); replicas != 5 {
+		t.Errorf("Expected 5 replicas after scaling, got %d", replicas)
+	}
+
+	if _, err := k.ScaleWorkload("default", "apps", "v1", "deployments", "web", -1, false); err == nil {
+		t.Error("Expected error for a negative replica count")
+	}
+
+	k.ReadOnly = true
+	if _, err := k.ScaleWorkload("default", "apps", "v1", "deployments", "web", 1, true); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+}
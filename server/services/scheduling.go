@@ -0,0 +1,213 @@
+// ==========================================================================================
+// Scheduling constraints of workloads, the node selector, affinity & topology spread of the
+// pod template summarised into short readable rules, e.g. "zone in (a,b)" or "app=web on hostname"
+// ==========================================================================================
+
+package services
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"strings"
+
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// AffinityRule is one node or pod affinity term, required rules must be met for a pod to be scheduled
+type AffinityRule struct {
+	Required bool `json:"required"`
+	// Weight of a preferred rule, from 1 to 100
+	Weight int32  `json:"weight,omitempty"`
+	Rule   string `json:"rule"`
+}
+
+// SpreadConstraint is a topology spread constraint, with its label selector as a string
+type SpreadConstraint struct {
+	TopologyKey string `json:"topologyKey"`
+	MaxSkew     int32  `json:"maxSkew"`
+	// WhenUnsatisfiable is DoNotSchedule or ScheduleAnyway
+	WhenUnsatisfiable string `json:"whenUnsatisfiable"`
+	Selector          string `json:"selector,omitempty"`
+}
+
+// SchedulingSummary is where a workload's pods can be scheduled, fields are left out when not set
+type SchedulingSummary struct {
+	Kind            string             `json:"kind"`
+	Name            string             `json:"name"`
+	NodeSelector    map[string]string  `json:"nodeSelector,omitempty"`
+	NodeAffinity    []AffinityRule     `json:"nodeAffinity,omitempty"`
+	PodAffinity     []AffinityRule     `json:"podAffinity,omitempty"`
+	PodAntiAffinity []AffinityRule     `json:"podAntiAffinity,omitempty"`
+	TopologySpread  []SpreadConstraint `json:"topologySpread,omitempty"`
+}
+
+// Workload types summarised, in the order they are returned
+var schedulingSources = []struct {
+	resource string
+	kind     string
+}{
+	{resource: "deployments", kind: "Deployment"},
+	{resource: "statefulsets", kind: "StatefulSet"},
+}
+
+// GetSchedulingSummaries returns the scheduling constraints of every Deployment & StatefulSet in a namespace
+func (k *Kubernetes) GetSchedulingSummaries(ns string) ([]SchedulingSummary, error) {
+	if ns == "" {
+		return nil, errors.New("namespace is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	summaries := []SchedulingSummary{}
+
+	for _, source := range schedulingSources {
+		items, err := k.GetResources(ns, "apps", "v1", source.resource)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range items {
+			summaries = append(summaries, schedulingSummary(&items[i], source.kind))
+		}
+	}
+
+	return summaries, nil
+}
+
+// schedulingSummary pulls the scheduling constraints out of a workload's pod template
+func schedulingSummary(obj *unstructured.Unstructured, kind string) SchedulingSummary {
+	summary := SchedulingSummary{Kind: kind, Name: obj.GetName()}
+
+	specMap, _, _ := unstructured.NestedMap(obj.Object, "spec", "template", "spec")
+
+	spec := coreV1.PodSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(specMap, &spec); err != nil {
+		return summary
+	}
+
+	if len(spec.NodeSelector) > 0 {
+		summary.NodeSelector = spec.NodeSelector
+	}
+
+	for _, c := range spec.TopologySpreadConstraints {
+		summary.TopologySpread = append(summary.TopologySpread, SpreadConstraint{
+			TopologyKey:       c.TopologyKey,
+			MaxSkew:           c.MaxSkew,
+			WhenUnsatisfiable: string(c.WhenUnsatisfiable),
+			Selector:          formatSelector(c.LabelSelector),
+		})
+	}
+
+	if spec.Affinity == nil {
+		return summary
+	}
+
+	if na := spec.Affinity.NodeAffinity; na != nil {
+		// Required node selector terms are ORed, so each is a separate rule
+		if na.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+			for _, term := range na.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+				summary.NodeAffinity = append(summary.NodeAffinity,
+					AffinityRule{Required: true, Rule: nodeSelectorTermRule(term)})
+			}
+		}
+
+		for _, pref := range na.PreferredDuringSchedulingIgnoredDuringExecution {
+			summary.NodeAffinity = append(summary.NodeAffinity,
+				AffinityRule{Weight: pref.Weight, Rule: nodeSelectorTermRule(pref.Preference)})
+		}
+	}
+
+	if pa := spec.Affinity.PodAffinity; pa != nil {
+		summary.PodAffinity = podAffinityRules(pa.RequiredDuringSchedulingIgnoredDuringExecution,
+			pa.PreferredDuringSchedulingIgnoredDuringExecution)
+	}
+
+	if paa := spec.Affinity.PodAntiAffinity; paa != nil {
+		summary.PodAntiAffinity = podAffinityRules(paa.RequiredDuringSchedulingIgnoredDuringExecution,
+			paa.PreferredDuringSchedulingIgnoredDuringExecution)
+	}
+
+	return summary
+}
+
+// podAffinityRules summarises required & preferred pod affinity terms, e.g. "app=web on kubernetes.io/hostname"
+func podAffinityRules(required []coreV1.PodAffinityTerm, preferred []coreV1.WeightedPodAffinityTerm) []AffinityRule {
+	rules := []AffinityRule{}
+
+	for _, term := range required {
+		rules = append(rules, AffinityRule{Required: true, Rule: podAffinityTermRule(term)})
+	}
+
+	for _, pref := range preferred {
+		rules = append(rules, AffinityRule{Weight: pref.Weight, Rule: podAffinityTermRule(pref.PodAffinityTerm)})
+	}
+
+	return rules
+}
+
+// A nil selector matches no pods, while an empty one matches every pod
+func podAffinityTermRule(term coreV1.PodAffinityTerm) string {
+	pods := "no pods"
+	if term.LabelSelector != nil {
+		pods = cmp.Or(formatSelector(term.LabelSelector), "all pods")
+	}
+
+	rule := pods + " on " + term.TopologyKey
+
+	if len(term.Namespaces) > 0 {
+		rule += " in " + strings.Join(term.Namespaces, ",")
+	}
+
+	return rule
+}
+
+// nodeSelectorTermRule joins the requirements of a term, which must all be met, in kubectl's selector syntax
+func nodeSelectorTermRule(term coreV1.NodeSelectorTerm) string {
+	parts := []string{}
+
+	for _, req := range term.MatchExpressions {
+		parts = append(parts, nodeRequirement(req))
+	}
+
+	for _, req := range term.MatchFields {
+		parts = append(parts, nodeRequirement(req))
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func nodeRequirement(req coreV1.NodeSelectorRequirement) string {
+	values := strings.Join(req.Values, ",")
+
+	switch req.Operator {
+	case coreV1.NodeSelectorOpIn:
+		return fmt.Sprintf("%s in (%s)", req.Key, values)
+	case coreV1.NodeSelectorOpNotIn:
+		return fmt.Sprintf("%s notin (%s)", req.Key, values)
+	case coreV1.NodeSelectorOpExists:
+		return req.Key
+	case coreV1.NodeSelectorOpDoesNotExist:
+		return "!" + req.Key
+	case coreV1.NodeSelectorOpGt:
+		return req.Key + ">" + values
+	case coreV1.NodeSelectorOpLt:
+		return req.Key + "<" + values
+	default:
+		return fmt.Sprintf("%s %s (%s)", req.Key, req.Operator, values)
+	}
+}
+
+// formatSelector returns a label selector as a string e.g. "app=web", empty when it is nil or empty
+func formatSelector(sel *metaV1.LabelSelector) string {
+	if s := metaV1.FormatLabelSelector(sel); s != "<none>" {
+		return s
+	}
+
+	return ""
+}
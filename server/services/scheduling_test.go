@@ -0,0 +1,101 @@
+// ==========================================================================================
+// Unit tests for workload scheduling summaries
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestKubernetes_GetSchedulingSummaries(t *testing.T) {
+	k := mockKubernetes()
+
+	deploy := createTestDeployment("web", "default", 3)
+	_ = unstructured.SetNestedStringMap(deploy.Object, map[string]string{"disktype": "ssd"},
+		"spec", "template", "spec", "nodeSelector")
+	_ = unstructured.SetNestedSlice(deploy.Object, []interface{}{
+		map[string]interface{}{
+			"maxSkew":           int64(1),
+			"topologyKey":       "topology.kubernetes.io/zone",
+			"whenUnsatisfiable": "DoNotSchedule",
+			"labelSelector":     map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+		},
+	}, "spec", "template", "spec", "topologySpreadConstraints")
+	_ = unstructured.SetNestedField(deploy.Object, map[string]interface{}{
+		"podAntiAffinity": map[string]interface{}{
+			"preferredDuringSchedulingIgnoredDuringExecution": []interface{}{
+				map[string]interface{}{"weight": int64(50), "podAffinityTerm": map[string]interface{}{
+					"topologyKey":   "kubernetes.io/hostname",
+					"labelSelector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+				}},
+			},
+		},
+	}, "spec", "template", "spec", "affinity")
+	_, _ = k.dynamicClient.Resource(deploymentGVR).Namespace("default").
+		Create(context.TODO(), deploy, metaV1.CreateOptions{})
+
+	// A workload with no constraints is still listed
+	plain := createTestDeployment("plain", "default", 1)
+	_, _ = k.dynamicClient.Resource(deploymentGVR).Namespace("default").
+		Create(context.TODO(), plain, metaV1.CreateOptions{})
+
+	summaries, err := k.GetSchedulingSummaries("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	byName := map[string]SchedulingSummary{}
+	for _, s := range summaries {
+		byName[s.Name] = s
+	}
+
+	web, ok := byName["web"]
+	if !ok || web.Kind != "Deployment" || web.NodeSelector["disktype"] != "ssd" {
+		t.Fatalf("Expected web to have the disktype=ssd node selector, got %+v", summaries)
+	}
+
+	spread := SpreadConstraint{
+		TopologyKey: "topology.kubernetes.io/zone", MaxSkew: 1, WhenUnsatisfiable: "DoNotSchedule", Selector: "app=web",
+	}
+	if len(web.TopologySpread) != 1 || web.TopologySpread[0] != spread {
+		t.Errorf("Expected a zone spread constraint, got %+v", web.TopologySpread)
+	}
+
+	anti := AffinityRule{Weight: 50, Rule: "app=web on kubernetes.io/hostname"}
+	if len(web.PodAntiAffinity) != 1 || web.PodAntiAffinity[0] != anti {
+		t.Errorf("Expected a preferred anti-affinity rule, got %+v", web.PodAntiAffinity)
+	}
+
+	none := byName["plain"]
+	if none.NodeSelector != nil || none.TopologySpread != nil || none.NodeAffinity != nil || none.PodAffinity != nil {
+		t.Errorf("Expected plain to have no constraints, got %+v", none)
+	}
+}
+
+func TestSchedulingSummary_NodeAffinity(t *testing.T) {
+	deploy := createTestDeployment("zoned", "default", 1)
+	_ = unstructured.SetNestedField(deploy.Object, map[string]interface{}{
+		"nodeAffinity": map[string]interface{}{
+			"requiredDuringSchedulingIgnoredDuringExecution": map[string]interface{}{
+				"nodeSelectorTerms": []interface{}{
+					map[string]interface{}{"matchExpressions": []interface{}{
+						map[string]interface{}{"key": "zone", "operator": "In", "values": []interface{}{"a", "b"}},
+						map[string]interface{}{"key": "gpu", "operator": "DoesNotExist"},
+					}},
+				},
+			},
+		},
+	}, "spec", "template", "spec", "affinity")
+
+	summary := schedulingSummary(deploy, "Deployment")
+
+	expected := AffinityRule{Required: true, Rule: "zone in (a,b), !gpu"}
+	if len(summary.NodeAffinity) != 1 || summary.NodeAffinity[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, summary.NodeAffinity)
+	}
+}
@@ -0,0 +1,176 @@
+// ==========================================================================================
+// Searching the resources in a namespace by name or label value, or every namespace by a
+// label selector
+// ==========================================================================================
+
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ErrInvalidSelector is returned when a label selector is empty or can't be parsed
+var ErrInvalidSelector = errors.New("invalid label selector")
+
+// Objects fetched in each page of a cluster wide list, so only one page is held by the API client at a time
+const selectPageSize = 500
+
+// Most objects returned by SelectAcrossNamespaces, any more and the result is marked as truncated
+const maxSelectResults = 5000
+
+// SelectResult is the objects matching a label selector in every namespace, grouped by namespace
+type SelectResult struct {
+	Namespaces map[string][]unstructured.Unstructured `json:"namespaces"`
+	// Truncated is set when more objects matched than are returned, see maxSelectResults
+	Truncated bool `json:"truncated"`
+}
+
+// SearchResult is a resource which matched a search
+type SearchResult struct {
+	// Type is the plural resource type e.g. "pods", as used by FetchNamespace
+	Type string `json:"type"`
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Match is what matched, either "name" or "label:" followed by the label key
+	Match  string                    `json:"match"`
+	Object unstructured.Unstructured `json:"object"`
+}
+
+// SearchResources finds the resources in a namespace whose name or a label value contains the query
+// Matching is case-insensitive, the resources are fetched with FetchNamespace so are redacted the same way
+func (k *Kubernetes) SearchResources(ns, query string) ([]SearchResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, errors.New("search query is empty")
+	}
+
+	data, err := k.FetchNamespace(ns)
+	if err != nil {
+		return nil, err
+	}
+
+	return searchData(data, query), nil
+}
+
+// searchData searches resources already fetched, results are sorted by type then name
+func searchData(data map[string][]unstructured.Unstructured, query string) []SearchResult {
+	query = strings.ToLower(strings.TrimSpace(query))
+	results := []SearchResult{}
+
+	for resType, items := range data {
+		// Events are named after the object they're about, so would only repeat the real matches
+		if resType == "events" {
+			continue
+		}
+
+		for _, item := range items {
+			if match, ok := matchResource(item, query); ok {
+				results = append(results, SearchResult{
+					Type: resType, Kind: item.GetKind(), Name: item.GetName(), Match: match, Object: item,
+				})
+			}
+		}
+	}
+
+	slices.SortFunc(results, func(a, b SearchResult) int {
+		return cmp.Or(cmp.Compare(a.Type, b.Type), cmp.Compare(a.Name, b.Name))
+	})
+
+	return results
+}
+
+// matchResource checks the name then the label values of a resource against a lower case query
+func matchResource(item unstructured.Unstructured, query string) (string, bool) {
+	if strings.Contains(strings.ToLower(item.GetName()), query) {
+		return "name", true
+	}
+
+	// Sorted so the same label is reported each time when several match
+	labels := item.GetLabels()
+	for _, key := range slices.Sorted(maps.Keys(labels)) {
+		if strings.Contains(strings.ToLower(labels[key]), query) {
+			return "label:" + key, true
+		}
+	}
+
+	return "", false
+}
+
+// SelectAcrossNamespaces lists a namespaced resource type in every namespace with a label selector, e.g.
+// "release=v2", grouped by namespace and sorted by name. Namespaces which aren't permitted are left out
+// The list is fetched a page at a time, and stops once maxSelectResults objects have matched
+func (k *Kubernetes) SelectAcrossNamespaces(grp, ver, res, selector string) (*SelectResult, error) {
+	if ver == "" || res == "" {
+		return nil, errors.New("version or resource is empty")
+	}
+
+	if strings.TrimSpace(selector) == "" {
+		return nil, fmt.Errorf("%w: a selector is needed, rather than listing everything", ErrInvalidSelector)
+	}
+
+	if _, err := labels.Parse(selector); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSelector, err)
+	}
+
+	gvr := schema.GroupVersionResource{Group: grp, Version: ver, Resource: res}
+	result := &SelectResult{Namespaces: map[string][]unstructured.Unstructured{}}
+	trimmer := k.trimmer()
+	count := 0
+	next := ""
+
+	for {
+		var page *unstructured.UnstructuredList
+
+		err := k.callAPI(func(ctx context.Context) (err error) {
+			page, err = k.dynamicClient.Resource(gvr).List(ctx, metaV1.ListOptions{
+				LabelSelector: selector, Limit: selectPageSize, Continue: next,
+			})
+			return err
+		})
+		if err != nil {
+			log.Printf("💥 Failed to list %s with selector %q: %v", res, selector, err)
+			return nil, err
+		}
+
+		for i := range page.Items {
+			ns := page.Items[i].GetNamespace()
+			if ns == "" || !k.NamespacePermitted(ns) {
+				continue
+			}
+
+			if count == maxSelectResults {
+				result.Truncated = true
+				break
+			}
+
+			item := *page.Items[i].DeepCopy()
+			trimmer.Trim(&item)
+			result.Namespaces[ns] = append(result.Namespaces[ns], item)
+			count++
+		}
+
+		next = page.GetContinue()
+		if next == "" || result.Truncated {
+			break
+		}
+	}
+
+	for ns := range result.Namespaces {
+		k.cleanResources(result.Namespaces[ns])
+		setHealth(result.Namespaces[ns])
+		sortResources(result.Namespaces[ns], SortByName)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,113 @@
+// ==========================================================================================
+// Unit tests for searching resources & selecting across namespaces
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestKubernetes_SearchResources(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	svcGvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "services"}
+
+	pod := createTestPod("checkout-abc", "default")
+	other := createTestPod("worker", "default")
+	other.SetLabels(map[string]string{"app": "Checkout"})
+	unrelated := createTestPod("db", "default")
+
+	svc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"name": "checkout-svc", "namespace": "default"},
+	}}
+
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), other, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), unrelated, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(kindResources["Deployment"]).Namespace("default").
+		Create(context.TODO(), createTestDeployment("CHECKOUT", "default", 1), metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(svcGvr).Namespace("default").
+		Create(context.TODO(), svc, metaV1.CreateOptions{})
+
+	results, err := k.SearchResources("default", "checkout")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []struct{ resType, name, match string }{
+		{"deployments", "CHECKOUT", "name"},
+		{"pods", "checkout-abc", "name"},
+		{"pods", "worker", "label:app"},
+		{"services", "checkout-svc", "name"},
+	}
+
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d: %+v", len(expected), len(results), results)
+	}
+
+	for i, exp := range expected {
+		r := results[i]
+		if r.Type != exp.resType || r.Name != exp.name || r.Match != exp.match || r.Kind == "" {
+			t.Errorf("Expected result %d to be %+v, got %s %s %s %s", i, exp, r.Type, r.Kind, r.Name, r.Match)
+		}
+	}
+
+	if _, err := k.SearchResources("default", "  "); err == nil {
+		t.Error("Expected error for empty query, got nil")
+	}
+}
+
+func TestKubernetes_SelectAcrossNamespaces(t *testing.T) {
+	k := mockKubernetes()
+	k.NamespaceDenylist = []string{"kube-system"}
+
+	for _, p := range []struct{ name, ns, release string }{
+		{"web", "default", "v2"},
+		{"api", "default", "v2"},
+		{"old", "default", "v1"},
+		{"worker", "team-a", "v2"},
+		{"dns", "kube-system", "v2"},
+	} {
+		pod := createTestPod(p.name, p.ns)
+		pod.SetLabels(map[string]string{"release": p.release})
+		_, _ = k.dynamicClient.Resource(podGVR).Namespace(p.ns).Create(context.TODO(), pod, metaV1.CreateOptions{})
+	}
+
+	result, err := k.SelectAcrossNamespaces("", "v1", "pods", "release=v2")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	names := map[string][]string{}
+	for ns, items := range result.Namespaces {
+		for _, item := range items {
+			names[ns] = append(names[ns], item.GetName())
+		}
+	}
+
+	if len(names) != 2 || !slices.Equal(names["default"], []string{"api", "web"}) ||
+		!slices.Equal(names["team-a"], []string{"worker"}) {
+		t.Errorf("Expected the v2 pods in default & team-a only, got %v", names)
+	}
+
+	if result.Truncated {
+		t.Error("Expected the result not to be truncated")
+	}
+
+	for _, selector := range []string{"", "release in (v2"} {
+		if _, err := k.SelectAcrossNamespaces("", "v1", "pods", selector); !errors.Is(err, ErrInvalidSelector) {
+			t.Errorf("Expected ErrInvalidSelector for %q, got %v", selector, err)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// ==========================================================================================
+// Diffing a manifest against the cluster, like kubectl diff, using a server-side apply dry run
+// so defaults, admission webhooks & other managers' fields are all accounted for
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ManifestDiff is what applying one document from a manifest would change
+type ManifestDiff struct {
+	ApplyResult
+	// New is set when the object doesn't exist yet, so every field is a change
+	New     bool          `json:"new"`
+	Changes []FieldChange `json:"changes"`
+}
+
+// ServerDiff works out what applying a manifest would change, returning a diff for each document
+// Each document is applied as a dry run, and the result compared with the live object. Documents are handled
+// the same as ApplyManifest, a document which couldn't be applied has an error and no changes. When
+// RedactSecrets is set, changed values in Secrets & ConfigMaps are redacted, but the changed fields are kept.
+// RedactKeys are applied to both the live & dry run objects, so neither side of a change shows a redacted key
+func (k *Kubernetes) ServerDiff(manifest []byte) ([]ManifestDiff, error) {
+	if k.ReadOnly {
+		return nil, ErrReadOnly
+	}
+
+	objs, err := manifestObjects(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]ManifestDiff, 0, len(objs))
+	resources := map[string]*metaV1.APIResourceList{}
+
+	for i, obj := range objs {
+		diff := ManifestDiff{ApplyResult: newApplyResult(i, obj), Changes: []FieldChange{}}
+
+		live, err := k.liveObject(obj, resources)
+		if err != nil {
+			diff.Error = err.Error()
+			diffs = append(diffs, diff)
+
+			continue
+		}
+
+		applied, err := k.applyObject(obj, resources, true)
+		if err != nil {
+			diff.Error = err.Error()
+			diffs = append(diffs, diff)
+
+			continue
+		}
+
+		// Both sides are trimmed the same as any object returned, so RedactKeys hide values before & after
+		trimmer := k.trimmer()
+		if live != nil {
+			trimmer.Trim(live)
+		}
+
+		trimmer.Trim(applied)
+
+		diff.New = live == nil
+		diff.Changes = ComputeDiff(live, applied)
+
+		if k.RedactSecrets && (obj.GetKind() == "Secret" || obj.GetKind() == "ConfigMap") {
+			redactChanges(diff.Changes)
+		}
+
+		diffs = append(diffs, diff)
+	}
+
+	return diffs, nil
+}
+
+// liveObject gets the object a manifest document would change, nil when it doesn't exist yet
+func (k *Kubernetes) liveObject(obj *unstructured.Unstructured,
+	resources map[string]*metaV1.APIResourceList,
+) (*unstructured.Unstructured, error) {
+	gvr, err := k.resourceForObject(obj, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	var live *unstructured.Unstructured
+
+	err = k.callNamespaceAPI(obj.GetNamespace(), func(ctx context.Context) (err error) {
+		live, err = k.dynamicClient.Resource(gvr).Namespace(obj.GetNamespace()).
+			Get(ctx, obj.GetName(), metaV1.GetOptions{})
+		return err
+	})
+	if apiErrors.IsNotFound(err) {
+		return nil, nil
+	}
+
+	return live, err
+}
+
+// redactChanges hides the values of changed data fields, and the data copied into the last-applied annotation
+// A change to the whole metadata or annotations, e.g. for a new object, has the annotation redacted within it
+func redactChanges(changes []FieldChange) {
+	for i := range changes {
+		changes[i].Before = redactChange(changes[i].Path, changes[i].Before)
+		changes[i].After = redactChange(changes[i].Path, changes[i].After)
+	}
+}
+
+func redactChange(path string, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	if strings.Contains(path, lastAppliedAnnotation) || slices.ContainsFunc(redactedDataFields, func(field string) bool {
+		return path == field || strings.HasPrefix(path, field+".") || strings.HasPrefix(path, field+"[")
+	}) {
+		return redactedValue
+	}
+
+	// Wrapped in an object, so it can be redacted the same as any other
+	var obj *unstructured.Unstructured
+
+	switch path {
+	case "metadata":
+		obj = &unstructured.Unstructured{Object: map[string]interface{}{"metadata": value}}
+	case "metadata.annotations":
+		obj = &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"annotations": value},
+		}}
+	default:
+		return value
+	}
+
+	obj = obj.DeepCopy()
+	redactLastApplied(obj)
+
+	if path == "metadata" {
+		return obj.Object["metadata"]
+	}
+
+	annotations, _, _ := unstructured.NestedFieldNoCopy(obj.Object, "metadata", "annotations")
+
+	return annotations
+}
@@ -0,0 +1,212 @@
+// ==========================================================================================
+// Table output, the columns kubectl shows (READY, STATUS, RESTARTS, AGE...) computed by the
+// API server so every kind gets the right columns. Falls back to basic columns worked out here,
+// or the additionalPrinterColumns of the CRD for custom resources, see printercolumns.go
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+// Asks for a Table, servers which can't produce one send the plain list instead
+const tableAcceptHeader = "application/json;as=Table;v=v1;g=meta.k8s.io,application/json"
+
+// ResourceTable is a list of resources as rows of printable cells, one cell per column
+type ResourceTable struct {
+	Columns []metaV1.TableColumnDefinition `json:"columns"`
+	Rows    []ResourceTableRow             `json:"rows"`
+	// ServerSide is false when the columns were worked out by KubeView, as the server couldn't provide a Table
+	ServerSide bool `json:"serverSide"`
+}
+
+// ResourceTableRow is one resource in a table
+type ResourceTableRow struct {
+	Name  string `json:"name"`
+	Cells []any  `json:"cells"`
+}
+
+// GetResourceTable lists resources in a namespace as a table, with the same columns as kubectl get
+func (k *Kubernetes) GetResourceTable(ns, grp, ver, res string) (*ResourceTable, error) {
+	if ns == "" || ver == "" || res == "" {
+		return nil, errors.New("namespace, version or resource is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	if k.restConfig != nil {
+		table, items, err := k.requestTable(ns, grp, ver, res)
+
+		switch {
+		case table != nil:
+			return table, nil
+		case items != nil:
+			// The server sent the plain list, so it's used rather than fetching again
+			return k.localTable(grp, ver, res, items), nil
+		case !apiErrors.IsNotAcceptable(err) && !apiErrors.IsUnsupportedMediaType(err):
+			return nil, err
+		}
+	}
+
+	items, err := k.GetResources(ns, grp, ver, res)
+	if err != nil {
+		return nil, err
+	}
+
+	return k.localTable(grp, ver, res, items), nil
+}
+
+// requestTable asks the API server for a Table, returning the list instead if that's what the server sent
+func (k *Kubernetes) requestTable(ns, grp, ver, res string) (*ResourceTable, []unstructured.Unstructured, error) {
+	config := rest.CopyConfig(k.restConfig)
+	config.NegotiatedSerializer = scheme.Codecs.WithoutConversion()
+
+	client, err := rest.UnversionedRESTClientFor(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	path := fmt.Sprintf("/apis/%s/%s/namespaces/%s/%s", grp, ver, ns, res)
+	if grp == "" {
+		path = fmt.Sprintf("/api/%s/namespaces/%s/%s", ver, ns, res)
+	}
+
+	var body []byte
+
+	err = k.callAPI(func(ctx context.Context) (err error) {
+		body, err = client.Get().AbsPath(path).SetHeader("Accept", tableAcceptHeader).Do(ctx).Raw()
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	table := metaV1.Table{}
+	if err := json.Unmarshal(body, &table); err != nil {
+		return nil, nil, err
+	}
+
+	if table.Kind != "Table" {
+		list := unstructured.UnstructuredList{}
+		if err := list.UnmarshalJSON(body); err != nil {
+			return nil, nil, err
+		}
+
+		return nil, append([]unstructured.Unstructured{}, list.Items...), nil
+	}
+
+	result := &ResourceTable{Columns: table.ColumnDefinitions, Rows: []ResourceTableRow{}, ServerSide: true}
+
+	for _, row := range table.Rows {
+		// Each row holds the object's metadata, which is where the name comes from
+		meta := metaV1.PartialObjectMetadata{}
+		_ = json.Unmarshal(row.Object.Raw, &meta)
+
+		result.Rows = append(result.Rows, ResourceTableRow{Name: meta.Name, Cells: row.Cells})
+	}
+
+	return result, nil, nil
+}
+
+// clientTable builds a table with name & age columns, plus ready, status & restarts for pods
+func clientTable(res string, items []unstructured.Unstructured, now time.Time) *ResourceTable {
+	isPods := res == "pods"
+
+	columns := []metaV1.TableColumnDefinition{{Name: "Name", Type: "string", Format: "name"}}
+	if isPods {
+		columns = append(columns,
+			metaV1.TableColumnDefinition{Name: "Ready", Type: "string"},
+			metaV1.TableColumnDefinition{Name: "Status", Type: "string"},
+			metaV1.TableColumnDefinition{Name: "Restarts", Type: "integer"},
+		)
+	}
+
+	columns = append(columns, metaV1.TableColumnDefinition{Name: "Age", Type: "string"})
+
+	table := &ResourceTable{Columns: columns, Rows: []ResourceTableRow{}}
+
+	for i := range items {
+		item := &items[i]
+		cells := []any{item.GetName()}
+
+		if isPods {
+			ready, total, restarts := podContainerCounts(item)
+			cells = append(cells, fmt.Sprintf("%d/%d", ready, total), podDisplayStatus(item), restarts)
+		}
+
+		cells = append(cells, duration.HumanDuration(now.Sub(item.GetCreationTimestamp().Time)))
+
+		table.Rows = append(table.Rows, ResourceTableRow{Name: item.GetName(), Cells: cells})
+	}
+
+	return table
+}
+
+// podContainerCounts counts the ready containers, all containers & the total restarts of a pod
+func podContainerCounts(pod *unstructured.Unstructured) (int, int, int64) {
+	containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", "containers")
+	statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", "containerStatuses")
+
+	ready := 0
+	restarts := int64(0)
+
+	for _, s := range statuses {
+		status, ok := s.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		if isReady, _, _ := unstructured.NestedBool(status, "ready"); isReady {
+			ready++
+		}
+
+		count, _, _ := unstructured.NestedInt64(status, "restartCount")
+		restarts += count
+	}
+
+	return ready, len(containers), restarts
+}
+
+// podDisplayStatus is a simpler version of the STATUS column kubectl shows for pods
+// A container waiting or terminated with a reason, e.g. CrashLoopBackOff, is shown over the phase
+func podDisplayStatus(pod *unstructured.Unstructured) string {
+	if pod.GetDeletionTimestamp() != nil {
+		return "Terminating"
+	}
+
+	if reason, _, _ := unstructured.NestedString(pod.Object, "status", "reason"); reason != "" {
+		return reason
+	}
+
+	statuses, _, _ := unstructured.NestedSlice(pod.Object, "status", "containerStatuses")
+	for _, s := range statuses {
+		status, ok := s.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		for _, state := range []string{"waiting", "terminated"} {
+			if reason, _, _ := unstructured.NestedString(status, "state", state, "reason"); reason != "" {
+				return reason
+			}
+		}
+	}
+
+	phase, _, _ := unstructured.NestedString(pod.Object, "status", "phase")
+
+	return phase
+}
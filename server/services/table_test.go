@@ -0,0 +1,187 @@
+// ==========================================================================================
+// Unit tests for Table output
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+)
+
+const testTable = `{
+	"kind": "Table",
+	"apiVersion": "meta.k8s.io/v1",
+	"columnDefinitions": [
+		{"name": "Name", "type": "string", "format": "name"},
+		{"name": "Ready", "type": "string"},
+		{"name": "Status", "type": "string"},
+		{"name": "Restarts", "type": "string"},
+		{"name": "Age", "type": "string"}
+	],
+	"rows": [
+		{"cells": ["web", "1/1", "Running", "0", "5d"], "object": {"metadata": {"name": "web"}}}
+	]
+}`
+
+func TestKubernetes_GetResourceTable(t *testing.T) {
+	var accept, path string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept, path = r.Header.Get("Accept"), r.URL.Path
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(testTable))
+	}))
+	defer server.Close()
+
+	k := mockKubernetes()
+	k.restConfig = &rest.Config{Host: server.URL}
+
+	table, err := k.GetResourceTable("default", "", "v1", "pods")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if accept != tableAcceptHeader {
+		t.Errorf("Expected Accept header %q, got %q", tableAcceptHeader, accept)
+	}
+
+	if path != "/api/v1/namespaces/default/pods" {
+		t.Errorf("Expected the core API path, got %s", path)
+	}
+
+	if !table.ServerSide || len(table.Columns) != 5 || table.Columns[2].Name != "Status" {
+		t.Errorf("Expected the server's 5 columns, got %+v", table.Columns)
+	}
+
+	if len(table.Rows) != 1 || table.Rows[0].Name != "web" || table.Rows[0].Cells[2] != "Running" {
+		t.Errorf("Expected a row for web, got %+v", table.Rows)
+	}
+
+	_, err = k.GetResourceTable("default", "apps", "v1", "deployments")
+	if err != nil || path != "/apis/apps/v1/namespaces/default/deployments" {
+		t.Errorf("Expected the apps group API path, got %s %v", path, err)
+	}
+}
+
+func TestKubernetes_GetResourceTable_Fallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"kind": "WidgetList", "apiVersion": "example.com/v1", "metadata": {},
+			"items": [{"kind": "Widget", "apiVersion": "example.com/v1", "metadata": {"name": "gizmo"}}]}`))
+	}))
+	defer server.Close()
+
+	k := mockKubernetes()
+	k.restConfig = &rest.Config{Host: server.URL}
+
+	table, err := k.GetResourceTable("default", "example.com", "v1", "widgets")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if table.ServerSide || len(table.Columns) != 2 || len(table.Rows) != 1 || table.Rows[0].Name != "gizmo" {
+		t.Errorf("Expected a name & age table for the plain list, got %+v", table)
+	}
+
+	// With no connection for Table requests, the columns are worked out from the listed pods
+	k.restConfig = nil
+
+	pod := createTestPod("web", "default")
+	pod.SetCreationTimestamp(metaV1.NewTime(time.Now().Add(-2 * time.Hour)))
+	_ = unstructured.SetNestedSlice(pod.Object, []interface{}{
+		map[string]interface{}{"name": "app", "ready": true, "restartCount": int64(2)},
+	}, "status", "containerStatuses")
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+
+	table, err = k.GetResourceTable("default", "", "v1", "pods")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(table.Columns) != 5 || len(table.Rows) != 1 {
+		t.Fatalf("Expected 5 columns & 1 row, got %+v", table)
+	}
+
+	cells := table.Rows[0].Cells
+	if cells[0] != "web" || cells[1] != "1/1" || cells[3] != int64(2) || cells[4] != "120m" {
+		t.Errorf("Unexpected pod cells %v", cells)
+	}
+}
+
+func TestKubernetes_GetResourceTable_PrinterColumns(t *testing.T) {
+	k := mockKubernetes()
+
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": "widgets.example.com"},
+		"spec": map[string]interface{}{
+			"group": "example.com",
+			"versions": []interface{}{
+				map[string]interface{}{
+					"name": "v1",
+					"additionalPrinterColumns": []interface{}{
+						map[string]interface{}{"name": "Colour", "type": "string", "jsonPath": ".spec.colour"},
+					},
+				},
+			},
+		},
+	}}
+	_, _ = k.dynamicClient.Resource(crdGVR).Create(context.TODO(), crd, metaV1.CreateOptions{})
+
+	widgetGVR := schema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+	for name, colour := range map[string]interface{}{"gizmo": "blue", "gadget": nil} {
+		widget := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+			"spec":       map[string]interface{}{"colour": colour},
+		}}
+
+		if colour == nil {
+			delete(widget.Object, "spec")
+		}
+
+		_, _ = k.dynamicClient.Resource(widgetGVR).Namespace("default").
+			Create(context.TODO(), widget, metaV1.CreateOptions{})
+	}
+
+	table, err := k.GetResourceTable("default", "example.com", "v1", "widgets")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(table.Columns) != 2 || table.Columns[1].Name != "Colour" {
+		t.Fatalf("Expected name & colour columns, got %+v", table.Columns)
+	}
+
+	cells := map[string][]any{}
+	for _, row := range table.Rows {
+		cells[row.Name] = row.Cells
+	}
+
+	if len(cells["gizmo"]) != 2 || cells["gizmo"][1] != "blue" {
+		t.Errorf("Expected gizmo to be blue, got %v", cells["gizmo"])
+	}
+
+	// A missing field is an empty cell rather than an error
+	if len(cells["gadget"]) != 2 || cells["gadget"][1] != nil {
+		t.Errorf("Expected gadget to have no colour, got %v", cells["gadget"])
+	}
+
+	// A version the CRD has no columns for gets none, so the table falls back to name & age
+	if columns := k.crdPrinterColumns("example.com", "v2", "widgets"); columns != nil {
+		t.Errorf("Expected no columns for a version not in the CRD, got %v", columns)
+	}
+}
@@ -0,0 +1,78 @@
+// ==========================================================================================
+// Trimming objects before they're returned or sent in events, so the payload shape can be customised
+// ==========================================================================================
+
+package services
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Trimmer changes each object before it's returned, e.g. to drop fields the UI doesn't need
+// It's applied to everything listed by GetResources, which FetchNamespace and the reports are built on, and to
+// single objects returned, see cleanObject. Removing a field a report relies on, such as status, affects it too
+type Trimmer interface {
+	Trim(obj *unstructured.Unstructured)
+}
+
+// TrimmerFunc lets an ordinary function be used as a Trimmer
+type TrimmerFunc func(obj *unstructured.Unstructured)
+
+func (f TrimmerFunc) Trim(obj *unstructured.Unstructured) {
+	f(obj)
+}
+
+// DefaultTrimmer removes managed fields, which are large and only of use to server-side apply
+// Custom trimmers will usually want to call it first, so managed fields are still removed
+type DefaultTrimmer struct{}
+
+func (DefaultTrimmer) Trim(obj *unstructured.Unstructured) {
+	obj.SetManagedFields(nil)
+}
+
+// trimmer returns the Trimmer to use, the default when none has been set, followed by redaction of RedactKeys
+func (k *Kubernetes) trimmer() Trimmer {
+	return withRedaction(k.Trimmer, k.RedactKeys)
+}
+
+// trimmingSender trims the objects in add & update events before passing them on, deleted objects are cut
+// down to just what identifies them, as that's all a client needs to remove them
+type trimmingSender struct {
+	next    EventSender
+	trimmer Trimmer
+}
+
+// newTrimmingSender wraps an EventSender, when trimmer is nil DefaultTrimmer is used
+func newTrimmingSender(next EventSender, trimmer Trimmer) *trimmingSender {
+	if trimmer == nil {
+		trimmer = DefaultTrimmer{}
+	}
+
+	return &trimmingSender{next: next, trimmer: trimmer}
+}
+
+// SendToGroup trims the event's object in place, events hold their own copy so nothing else is affected
+func (t *trimmingSender) SendToGroup(group string, message KubeEvent) {
+	if message.Object != nil {
+		if message.EventType == DeleteEvent {
+			message.Object = objectIdentity(message.Object)
+		} else {
+			t.trimmer.Trim(message.Object)
+		}
+	}
+
+	t.next.SendToGroup(group, message)
+}
+
+// objectIdentity is a copy of an object with only the fields which identify it
+func objectIdentity(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	identity := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	identity.SetAPIVersion(obj.GetAPIVersion())
+	identity.SetKind(obj.GetKind())
+	identity.SetName(obj.GetName())
+	identity.SetNamespace(obj.GetNamespace())
+	identity.SetUID(obj.GetUID())
+	identity.SetResourceVersion(obj.GetResourceVersion())
+
+	return identity
+}
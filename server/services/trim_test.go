@@ -0,0 +1,96 @@
+// ==========================================================================================
+// Unit tests for trimming objects & events
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestKubernetes_Trimmer(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	pod := createTestPod("web", "default")
+	pod.SetManagedFields([]metaV1.ManagedFieldsEntry{{Manager: "kubectl"}})
+	_ = unstructured.SetNestedField(pod.Object, "Running", "status", "phase")
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+
+	// The default trimmer only removes managed fields
+	pods, err := k.GetResources("default", "", "v1", "pods")
+	if err != nil || len(pods) != 1 {
+		t.Fatalf("Expected 1 pod, got %d %v", len(pods), err)
+	}
+
+	if pods[0].GetManagedFields() != nil {
+		t.Error("Expected managed fields to be removed by default")
+	}
+
+	if _, found, _ := unstructured.NestedString(pods[0].Object, "status", "phase"); !found {
+		t.Error("Expected status to be kept by default")
+	}
+
+	// A custom trimmer removing status, as well as doing the default trimming
+	k.Trimmer = TrimmerFunc(func(obj *unstructured.Unstructured) {
+		DefaultTrimmer{}.Trim(obj)
+		unstructured.RemoveNestedField(obj.Object, "status")
+	})
+
+	data, err := k.FetchNamespace("default")
+	if err != nil || len(data["pods"]) != 1 {
+		t.Fatalf("Expected 1 pod from fetch, got %d %v", len(data["pods"]), err)
+	}
+
+	fetched := data["pods"][0]
+	if _, found := fetched.Object["status"]; found {
+		t.Error("Expected status to be removed by the custom trimmer")
+	}
+
+	if fetched.GetManagedFields() != nil {
+		t.Error("Expected managed fields to still be removed")
+	}
+}
+
+func TestTrimmingSender(t *testing.T) {
+	recorder := &recordingSender{}
+
+	// Keep only what the UI draws, dropping the spec
+	sender := newTrimmingSender(recorder, TrimmerFunc(func(obj *unstructured.Unstructured) {
+		unstructured.RemoveNestedField(obj.Object, "spec")
+	}))
+
+	pod := createTestPod("web", "default")
+	pod.SetUID("web-uid")
+	pod.SetLabels(map[string]string{"app": "web"})
+	_ = unstructured.SetNestedField(pod.Object, "Running", "status", "phase")
+
+	sender.SendToGroup("default", KubeEvent{EventType: UpdateEvent, Object: pod.DeepCopy()})
+	sender.SendToGroup("default", KubeEvent{EventType: DeleteEvent, Object: pod.DeepCopy()})
+
+	if len(recorder.events) != 2 {
+		t.Fatalf("Expected 2 events to be passed on, got %d", len(recorder.events))
+	}
+
+	updated := recorder.events[0].Object
+	if _, found := updated.Object["spec"]; found {
+		t.Error("Expected the spec to be trimmed from the update")
+	}
+
+	if phase, _, _ := unstructured.NestedString(updated.Object, "status", "phase"); phase != "Running" {
+		t.Errorf("Expected the rest of the update to be kept, got %v", updated.Object)
+	}
+
+	deleted := recorder.events[1].Object
+	if deleted.GetUID() != "web-uid" || deleted.GetName() != "web" || deleted.GetKind() != "Pod" {
+		t.Errorf("Expected the delete to identify the pod, got %v", deleted.Object)
+	}
+
+	if _, found := deleted.Object["status"]; found || deleted.GetLabels() != nil {
+		t.Errorf("Expected the delete to hold only identifying metadata, got %v", deleted.Object)
+	}
+}
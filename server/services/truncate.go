@@ -0,0 +1,73 @@
+// ==========================================================================================
+// Truncating the data held in huge ConfigMaps & Secrets, so one object can't swamp the browser
+// ==========================================================================================
+
+package services
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// How much of a truncated value is kept, followed by the marker
+const truncatedKeep = 256
+
+// Added to the end of every truncated value, along with the original size
+const truncatedMarker = "...*TRUNCATED*"
+
+// dataValue is a value in the data or binaryData of an object
+type dataValue struct {
+	data map[string]interface{}
+	key  string
+	size int
+}
+
+// truncateData shortens the largest data & binaryData values of an object until it's within maxBytes
+// The serialised size is estimated from the change in length of each value, rather than re-encoding each time
+func truncateData(obj *unstructured.Unstructured, maxBytes int) {
+	encoded, err := json.Marshal(obj.Object)
+	if err != nil || len(encoded) <= maxBytes {
+		return
+	}
+
+	size := len(encoded)
+	values := []dataValue{}
+
+	for _, field := range []string{"data", "binaryData"} {
+		data, ok := obj.Object[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for key, value := range data {
+			if s, ok := value.(string); ok && len(s) > truncatedKeep {
+				values = append(values, dataValue{data: data, key: key, size: len(s)})
+			}
+		}
+	}
+
+	// Largest first, so as few values as possible are touched
+	slices.SortFunc(values, func(a, b dataValue) int {
+		return cmp.Or(cmp.Compare(b.size, a.size), cmp.Compare(a.key, b.key))
+	})
+
+	for _, v := range values {
+		if size <= maxBytes {
+			break
+		}
+
+		value, _ := v.data[v.key].(string)
+		kept := strings.ToValidUTF8(value[:truncatedKeep], "")
+		truncated := fmt.Sprintf("%s%s (%d bytes)", kept, truncatedMarker, len(value))
+		v.data[v.key] = truncated
+		size -= len(value) - len(truncated)
+	}
+
+	log.Printf("✂️ Truncated data of %s %s, it was %d bytes", obj.GetKind(), obj.GetName(), len(encoded))
+}
@@ -0,0 +1,71 @@
+// ==========================================================================================
+// Unit tests for truncating huge ConfigMaps & Secrets
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestKubernetes_FetchNamespace_MaxObjectBytes(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+	k.RedactSecrets = false
+	k.MaxObjectBytes = 4096
+
+	cmGvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+
+	huge := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "huge", "namespace": "default"},
+		"data": map[string]interface{}{
+			"big.json":   strings.Repeat("x", 10000),
+			"small.conf": "keep=me",
+		},
+	}}
+
+	small := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "small", "namespace": "default"},
+		"data":       map[string]interface{}{"value": strings.Repeat("y", 1000)},
+	}}
+
+	_, _ = k.dynamicClient.Resource(cmGvr).Namespace("default").Create(context.TODO(), huge, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(cmGvr).Namespace("default").Create(context.TODO(), small, metaV1.CreateOptions{})
+
+	data, err := k.FetchNamespace("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	byName := map[string]map[string]interface{}{}
+	for _, cm := range data["configmaps"] {
+		byName[cm.GetName()], _ = cm.Object["data"].(map[string]interface{})
+	}
+
+	big, _ := byName["huge"]["big.json"].(string)
+	if !strings.Contains(big, truncatedMarker) || len(big) > truncatedKeep+100 {
+		t.Errorf("Expected big value to be truncated, got %d bytes", len(big))
+	}
+
+	if !strings.HasSuffix(big, "(10000 bytes)") {
+		t.Errorf("Expected truncated value to give the original size, got %q", big[truncatedKeep:])
+	}
+
+	if byName["huge"]["small.conf"] != "keep=me" {
+		t.Errorf("Expected small value in a huge ConfigMap to be left alone, got %v", byName["huge"]["small.conf"])
+	}
+
+	if value, _ := byName["small"]["value"].(string); len(value) != 1000 {
+		t.Errorf("Expected ConfigMap under the limit to be left alone, got %d bytes", len(value))
+	}
+}
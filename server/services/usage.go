@@ -0,0 +1,164 @@
+// ==========================================================================================
+// Pod resource requests & limits compared with current usage from the metrics API
+// Usage needs metrics-server (or another metrics.k8s.io provider) installed in the cluster
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var podMetricsGVR = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+
+// ResourceAmounts holds CPU & memory amounts, either is nil when not set
+type ResourceAmounts struct {
+	CPUMilli    *int64 `json:"cpuMilli"`
+	MemoryBytes *int64 `json:"memoryBytes"`
+}
+
+// ContainerResources compares the requests & limits of a container with what it is using
+type ContainerResources struct {
+	Name     string          `json:"name"`
+	Requests ResourceAmounts `json:"requests"`
+	Limits   ResourceAmounts `json:"limits"`
+	// Usage is nil when there are no metrics for the container
+	Usage *ResourceAmounts `json:"usage"`
+}
+
+// PodResources is the resources of every container in a pod
+type PodResources struct {
+	Name       string               `json:"name"`
+	Containers []ContainerResources `json:"containers"`
+	// HasMetrics is false when the metrics API has nothing for this pod, e.g. it's just started
+	HasMetrics bool `json:"hasMetrics"`
+}
+
+// GetPodResources returns the requests, limits & current usage for every pod in a namespace
+// When the metrics API is unavailable pods are still returned, with no usage
+func (k *Kubernetes) GetPodResources(ns string) ([]PodResources, error) {
+	if ns == "" {
+		return nil, errors.New("namespace is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	pods, err := k.GetResources(ns, podGVR.Group, podGVR.Version, podGVR.Resource)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := k.getPodUsage(ns)
+	result := make([]PodResources, 0, len(pods))
+
+	for _, podObj := range pods {
+		pod := coreV1.Pod{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(podObj.Object, &pod); err != nil {
+			log.Printf("💥 Failed to decode pod %s: %v", podObj.GetName(), err)
+			continue
+		}
+
+		podUsage, hasMetrics := usage[pod.Name]
+		res := PodResources{Name: pod.Name, Containers: []ContainerResources{}, HasMetrics: hasMetrics}
+
+		for _, c := range pod.Spec.Containers {
+			res.Containers = append(res.Containers, ContainerResources{
+				Name:     c.Name,
+				Requests: amountsFromList(c.Resources.Requests),
+				Limits:   amountsFromList(c.Resources.Limits),
+				Usage:    podUsage[c.Name],
+			})
+		}
+
+		result = append(result, res)
+	}
+
+	return result, nil
+}
+
+// getPodUsage fetches current usage from the metrics API, keyed by pod then container name
+// An empty map is returned when metrics are not available
+func (k *Kubernetes) getPodUsage(ns string) map[string]map[string]*ResourceAmounts {
+	usage := map[string]map[string]*ResourceAmounts{}
+
+	var l *unstructured.UnstructuredList
+
+	err := k.callAPI(func(ctx context.Context) (err error) {
+		l, err = k.dynamicClient.Resource(podMetricsGVR).Namespace(ns).List(ctx, metaV1.ListOptions{})
+		return err
+	})
+	if err != nil {
+		log.Printf("⚠️ Pod metrics not available in namespace %s: %v", ns, err)
+		return usage
+	}
+
+	for _, podMetrics := range l.Items {
+		containers := map[string]*ResourceAmounts{}
+
+		list, _, _ := unstructured.NestedSlice(podMetrics.Object, "containers")
+		for _, c := range list {
+			cMap, _ := c.(map[string]interface{})
+			name, _, _ := unstructured.NestedString(cMap, "name")
+			cpu, _, _ := unstructured.NestedString(cMap, "usage", "cpu")
+			memory, _, _ := unstructured.NestedString(cMap, "usage", "memory")
+
+			containers[name] = &ResourceAmounts{CPUMilli: parseMilli(cpu), MemoryBytes: parseValue(memory)}
+		}
+
+		usage[podMetrics.GetName()] = containers
+	}
+
+	return usage
+}
+
+// amountsFromList picks out CPU & memory from a container's requests or limits
+func amountsFromList(list coreV1.ResourceList) ResourceAmounts {
+	amounts := ResourceAmounts{}
+
+	if cpu, ok := list[coreV1.ResourceCPU]; ok {
+		milli := cpu.MilliValue()
+		amounts.CPUMilli = &milli
+	}
+
+	if memory, ok := list[coreV1.ResourceMemory]; ok {
+		bytes := memory.Value()
+		amounts.MemoryBytes = &bytes
+	}
+
+	return amounts
+}
+
+// parseMilli parses a quantity string as thousandths, nil when empty or invalid
+func parseMilli(s string) *int64 {
+	q, err := resource.ParseQuantity(s)
+	if s == "" || err != nil {
+		return nil
+	}
+
+	milli := q.MilliValue()
+
+	return &milli
+}
+
+// parseValue parses a quantity string as a whole value, nil when empty or invalid
+func parseValue(s string) *int64 {
+	q, err := resource.ParseQuantity(s)
+	if s == "" || err != nil {
+		return nil
+	}
+
+	value := q.Value()
+
+	return &value
+}
@@ -0,0 +1,91 @@
+// ==========================================================================================
+// Unit tests for pod resource requests, limits & usage
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// createTestPodMetrics creates a PodMetrics object for a single container
+func createTestPodMetrics(name, namespace, container, cpu, memory string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "metrics.k8s.io/v1beta1",
+			"kind":       "PodMetrics",
+			"metadata": map[string]interface{}{
+				"name":      name,
+				"namespace": namespace,
+			},
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name":  container,
+					"usage": map[string]interface{}{"cpu": cpu, "memory": memory},
+				},
+			},
+		},
+	}
+}
+
+func TestKubernetes_GetPodResources(t *testing.T) {
+	k := mockKubernetes()
+
+	// Container with requests but no limits
+	pod := createTestPod("web", "default")
+	containers, _, _ := unstructured.NestedSlice(pod.Object, "spec", "containers")
+	containers[0].(map[string]interface{})["resources"] = map[string]interface{}{
+		"requests": map[string]interface{}{"cpu": "250m", "memory": "64Mi"},
+	}
+	_ = unstructured.SetNestedSlice(pod.Object, containers, "spec", "containers")
+
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").
+		Create(context.TODO(), createTestPod("new", "default"), metaV1.CreateOptions{})
+
+	metrics := createTestPodMetrics("web", "default", "test-container", "100m", "32Mi")
+	_, _ = k.dynamicClient.Resource(podMetricsGVR).Namespace("default").
+		Create(context.TODO(), metrics, metaV1.CreateOptions{})
+
+	result, err := k.GetPodResources("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	byName := map[string]PodResources{}
+	for _, p := range result {
+		byName[p.Name] = p
+	}
+
+	web := byName["web"]
+	if !web.HasMetrics || len(web.Containers) != 1 {
+		t.Fatalf("Expected web pod with metrics & one container, got %+v", web)
+	}
+
+	c := web.Containers[0]
+	if c.Requests.CPUMilli == nil || *c.Requests.CPUMilli != 250 {
+		t.Errorf("Expected CPU request of 250m, got %v", c.Requests.CPUMilli)
+	}
+
+	if c.Requests.MemoryBytes == nil || *c.Requests.MemoryBytes != 64*1024*1024 {
+		t.Errorf("Expected memory request of 64Mi, got %v", c.Requests.MemoryBytes)
+	}
+
+	if c.Limits.CPUMilli != nil || c.Limits.MemoryBytes != nil {
+		t.Error("Expected no limits for a container without limits")
+	}
+
+	if c.Usage == nil || *c.Usage.CPUMilli != 100 || *c.Usage.MemoryBytes != 32*1024*1024 {
+		t.Errorf("Expected usage of 100m & 32Mi, got %+v", c.Usage)
+	}
+
+	// Pod without metrics still returned, with no usage
+	fresh := byName["new"]
+	if fresh.HasMetrics || len(fresh.Containers) != 1 || fresh.Containers[0].Usage != nil {
+		t.Errorf("Expected pod without metrics to have nil usage, got %+v", fresh)
+	}
+}
@@ -0,0 +1,155 @@
+// ==========================================================================================
+// Which pods use each ConfigMap & Secret, so it's clear what an edit will affect before it's made
+// ==========================================================================================
+
+package services
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// How a pod refers to a ConfigMap or Secret
+const (
+	UsedByVolume          = "volume"
+	UsedByEnvFrom         = "envFrom"
+	UsedByEnv             = "env"
+	UsedByImagePullSecret = "imagePullSecret"
+)
+
+// ConfigUsage lists the pods using each ConfigMap & Secret in a namespace, keyed by name
+// ConfigMaps & Secrets no pod refers to are left out, as are pods which don't refer to any
+type ConfigUsage struct {
+	ConfigMaps map[string][]PodReference `json:"configMaps"`
+	Secrets    map[string][]PodReference `json:"secrets"`
+}
+
+// PodReference is a pod using a ConfigMap or Secret, and every way it does so
+type PodReference struct {
+	Pod string `json:"pod"`
+	// Via is one or more of "volume", "envFrom", "env" or "imagePullSecret"
+	Via []string `json:"via"`
+}
+
+// GetConfigUsage finds the pods using each ConfigMap & Secret in a namespace
+// References can be to ConfigMaps or Secrets which don't exist, these are included too
+func (k *Kubernetes) GetConfigUsage(ns string) (*ConfigUsage, error) {
+	if ns == "" {
+		return nil, errors.New("namespace is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return nil, err
+	}
+
+	pods, err := k.GetResources(ns, "", "v1", "pods")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewConfigUsage(pods), nil
+}
+
+// NewConfigUsage works out the ConfigMaps & Secrets used by a set of pods, with each list sorted by pod name
+func NewConfigUsage(pods []unstructured.Unstructured) *ConfigUsage {
+	usage := &ConfigUsage{ConfigMaps: map[string][]PodReference{}, Secrets: map[string][]PodReference{}}
+
+	for i := range pods {
+		pod := coreV1.Pod{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(pods[i].Object, &pod); err != nil {
+			continue
+		}
+
+		configMaps, secrets := podConfigReferences(&pod)
+
+		for name, via := range configMaps {
+			usage.ConfigMaps[name] = append(usage.ConfigMaps[name], PodReference{Pod: pod.Name, Via: via})
+		}
+
+		for name, via := range secrets {
+			usage.Secrets[name] = append(usage.Secrets[name], PodReference{Pod: pod.Name, Via: via})
+		}
+	}
+
+	for _, refs := range []map[string][]PodReference{usage.ConfigMaps, usage.Secrets} {
+		for _, podRefs := range refs {
+			slices.SortFunc(podRefs, func(a, b PodReference) int { return cmp.Compare(a.Pod, b.Pod) })
+		}
+	}
+
+	return usage
+}
+
+// podConfigReferences finds the ConfigMaps & Secrets a pod refers to, each with the ways it's referred to
+func podConfigReferences(pod *coreV1.Pod) (map[string][]string, map[string][]string) {
+	configMaps := map[string][]string{}
+	secrets := map[string][]string{}
+
+	add := func(refs map[string][]string, name, via string) {
+		if name != "" && !slices.Contains(refs[name], via) {
+			refs[name] = append(refs[name], via)
+		}
+	}
+
+	for _, vol := range pod.Spec.Volumes {
+		if vol.ConfigMap != nil {
+			add(configMaps, vol.ConfigMap.Name, UsedByVolume)
+		}
+
+		if vol.Secret != nil {
+			add(secrets, vol.Secret.SecretName, UsedByVolume)
+		}
+
+		// Projected volumes can combine any number of ConfigMaps & Secrets
+		if vol.Projected != nil {
+			for _, src := range vol.Projected.Sources {
+				if src.ConfigMap != nil {
+					add(configMaps, src.ConfigMap.Name, UsedByVolume)
+				}
+
+				if src.Secret != nil {
+					add(secrets, src.Secret.Name, UsedByVolume)
+				}
+			}
+		}
+	}
+
+	containers := slices.Concat(pod.Spec.InitContainers, pod.Spec.Containers)
+
+	for _, c := range containers {
+		for _, from := range c.EnvFrom {
+			if from.ConfigMapRef != nil {
+				add(configMaps, from.ConfigMapRef.Name, UsedByEnvFrom)
+			}
+
+			if from.SecretRef != nil {
+				add(secrets, from.SecretRef.Name, UsedByEnvFrom)
+			}
+		}
+
+		for _, env := range c.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+
+			if env.ValueFrom.ConfigMapKeyRef != nil {
+				add(configMaps, env.ValueFrom.ConfigMapKeyRef.Name, UsedByEnv)
+			}
+
+			if env.ValueFrom.SecretKeyRef != nil {
+				add(secrets, env.ValueFrom.SecretKeyRef.Name, UsedByEnv)
+			}
+		}
+	}
+
+	for _, pullSecret := range pod.Spec.ImagePullSecrets {
+		add(secrets, pullSecret.Name, UsedByImagePullSecret)
+	}
+
+	return configMaps, secrets
+}
@@ -0,0 +1,76 @@
+// ==========================================================================================
+// Unit tests for ConfigMap & Secret usage
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestKubernetes_GetConfigUsage(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	mounted := createTestPod("web", "default")
+	mounted.Object["spec"] = map[string]interface{}{
+		"volumes": []interface{}{
+			map[string]interface{}{"name": "config", "configMap": map[string]interface{}{"name": "web-config"}},
+		},
+		"containers": []interface{}{map[string]interface{}{
+			"name": "app",
+			"env": []interface{}{map[string]interface{}{
+				"name": "MODE",
+				"valueFrom": map[string]interface{}{
+					"configMapKeyRef": map[string]interface{}{"name": "web-config", "key": "mode"},
+				},
+			}},
+			"envFrom": []interface{}{map[string]interface{}{"secretRef": map[string]interface{}{"name": "db-creds"}}},
+		}},
+		"imagePullSecrets": []interface{}{map[string]interface{}{"name": "registry"}},
+	}
+
+	other := createTestPod("api", "default")
+	other.Object["spec"] = map[string]interface{}{
+		"volumes": []interface{}{
+			map[string]interface{}{"name": "config", "configMap": map[string]interface{}{"name": "web-config"}},
+		},
+		"containers": []interface{}{map[string]interface{}{"name": "app"}},
+	}
+
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), mounted, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), other, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").
+		Create(context.TODO(), createTestPod("unused", "default"), metaV1.CreateOptions{})
+
+	usage, err := k.GetConfigUsage("default")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	refs := usage.ConfigMaps["web-config"]
+	if len(refs) != 2 || refs[0].Pod != "api" || refs[1].Pod != "web" {
+		t.Fatalf("Expected the api & web pods to use web-config, got %+v", refs)
+	}
+
+	if !slices.Equal(refs[0].Via, []string{UsedByVolume}) ||
+		!slices.Equal(refs[1].Via, []string{UsedByVolume, UsedByEnv}) {
+		t.Errorf("Expected web-config to be mounted by both, and used in env by web, got %+v", refs)
+	}
+
+	if refs := usage.Secrets["db-creds"]; len(refs) != 1 || !slices.Equal(refs[0].Via, []string{UsedByEnvFrom}) {
+		t.Errorf("Expected db-creds to be used by web through envFrom, got %+v", refs)
+	}
+
+	if refs := usage.Secrets["registry"]; len(refs) != 1 || refs[0].Via[0] != UsedByImagePullSecret {
+		t.Errorf("Expected registry to be used by web as an image pull secret, got %+v", refs)
+	}
+
+	if len(usage.ConfigMaps) != 1 || len(usage.Secrets) != 2 {
+		t.Errorf("Expected nothing else to be in use, got %+v", usage)
+	}
+}
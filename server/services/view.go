@@ -0,0 +1,325 @@
+// ==========================================================================================
+// Typed view of a namespace, an alternative to the map returned by FetchNamespace
+// Each resource type has a named field, and the links between resources are worked out here
+// ==========================================================================================
+
+package services
+
+import (
+	"cmp"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// NamespaceView holds all the resources in a namespace, with the relationships between them
+// Every field is always present when serialised, empty types are empty arrays rather than null
+type NamespaceView struct {
+	Namespace                string                      `json:"namespace"`
+	Pods                     []unstructured.Unstructured `json:"pods"`
+	Services                 []unstructured.Unstructured `json:"services"`
+	Deployments              []unstructured.Unstructured `json:"deployments"`
+	ReplicaSets              []unstructured.Unstructured `json:"replicaSets"`
+	StatefulSets             []unstructured.Unstructured `json:"statefulSets"`
+	DaemonSets               []unstructured.Unstructured `json:"daemonSets"`
+	Jobs                     []unstructured.Unstructured `json:"jobs"`
+	CronJobs                 []unstructured.Unstructured `json:"cronJobs"`
+	Ingresses                []unstructured.Unstructured `json:"ingresses"`
+	NetworkPolicies          []unstructured.Unstructured `json:"networkPolicies"`
+	ConfigMaps               []unstructured.Unstructured `json:"configMaps"`
+	Secrets                  []unstructured.Unstructured `json:"secrets"`
+	PersistentVolumeClaims   []unstructured.Unstructured `json:"persistentVolumeClaims"`
+	Events                   []unstructured.Unstructured `json:"events"`
+	HorizontalPodAutoscalers []unstructured.Unstructured `json:"horizontalPodAutoscalers"`
+	ResourceQuotas           []unstructured.Unstructured `json:"resourceQuotas"`
+	LimitRanges              []unstructured.Unstructured `json:"limitRanges"`
+	ServiceAccounts          []unstructured.Unstructured `json:"serviceAccounts"`
+	Leases                   []unstructured.Unstructured `json:"leases"`
+	// Only one of these is filled, depending on the cluster version
+	Endpoints      []unstructured.Unstructured `json:"endpoints"`
+	EndpointSlices []unstructured.Unstructured `json:"endpointSlices"`
+	// Nodes the pods are scheduled on, these are cluster wide so empty when nodes can't be listed
+	Nodes []unstructured.Unstructured `json:"nodes"`
+	// HiddenPods is how many pods of completed Jobs were left out, see FetchOptions.IncludeCompletedJobPods
+	HiddenPods int `json:"hiddenPods"`
+	// Truncated is how many more resources there are of each type cut down to MaxObjects, by resource name
+	Truncated map[string]int `json:"truncated"`
+
+	Relationships []Relationship `json:"relationships"`
+}
+
+// ResourceRef identifies a resource within the namespace
+type ResourceRef struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// Relationship is a link from one resource to another, e.g. a Service selecting a Pod
+type Relationship struct {
+	From ResourceRef `json:"from"`
+	To   ResourceRef `json:"to"`
+	// Type is one of "owns", "selects", "routes", "mounts", "binds", "scales", "applies" or "runs"
+	Type string `json:"type"`
+}
+
+// FetchNamespaceView fetches all resources in a namespace as a typed view
+func (k *Kubernetes) FetchNamespaceView(ns string, opts FetchOptions) (*NamespaceView, error) {
+	data := make(map[string][]unstructured.Unstructured)
+	hiddenPods := 0
+	truncated := map[string]int{}
+
+	err := k.StreamNamespace(ns, opts, func(chunk ResourceChunk) {
+		data[chunk.Kind] = chunk.Items
+
+		if chunk.Kind == "pods" {
+			hiddenPods = chunk.Hidden
+		}
+
+		if chunk.Truncated > 0 {
+			truncated[chunk.Kind] = chunk.Truncated
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data["nodes"] = k.podNodes(data["pods"])
+
+	view := NewNamespaceView(ns, data)
+	view.HiddenPods = hiddenPods
+	view.Truncated = truncated
+
+	return view, nil
+}
+
+// podNodes lists the nodes the pods are scheduled on, from the cluster wide list of nodes
+// A namespace scoped install can't list nodes, so they are left out, but pods still link to them by name
+func (k *Kubernetes) podNodes(pods []unstructured.Unstructured) []unstructured.Unstructured {
+	names := map[string]bool{}
+
+	for i := range pods {
+		if node, _, _ := unstructured.NestedString(pods[i].Object, "spec", "nodeName"); node != "" {
+			names[node] = true
+		}
+	}
+
+	if len(names) == 0 {
+		return []unstructured.Unstructured{}
+	}
+
+	nodes, err := k.GetResources("", nodeGVR.Group, nodeGVR.Version, nodeGVR.Resource)
+	if err != nil {
+		return []unstructured.Unstructured{}
+	}
+
+	return slices.DeleteFunc(nodes, func(node unstructured.Unstructured) bool {
+		return !names[node.GetName()]
+	})
+}
+
+// NewNamespaceView builds a typed view from the map of resources returned by FetchNamespace
+func NewNamespaceView(ns string, data map[string][]unstructured.Unstructured) *NamespaceView {
+	items := func(kind string) []unstructured.Unstructured {
+		if data[kind] == nil {
+			return []unstructured.Unstructured{}
+		}
+
+		return data[kind]
+	}
+
+	view := &NamespaceView{
+		Namespace:                ns,
+		Pods:                     items("pods"),
+		Services:                 items("services"),
+		Deployments:              items("deployments"),
+		ReplicaSets:              items("replicasets"),
+		StatefulSets:             items("statefulsets"),
+		DaemonSets:               items("daemonsets"),
+		Jobs:                     items("jobs"),
+		CronJobs:                 items("cronjobs"),
+		Ingresses:                items("ingresses"),
+		NetworkPolicies:          items("networkpolicies"),
+		ConfigMaps:               items("configmaps"),
+		Secrets:                  items("secrets"),
+		PersistentVolumeClaims:   items("persistentvolumeclaims"),
+		Events:                   items("events"),
+		HorizontalPodAutoscalers: items("horizontalpodautoscalers"),
+		ResourceQuotas:           items("resourcequotas"),
+		LimitRanges:              items("limitranges"),
+		ServiceAccounts:          items("serviceaccounts"),
+		Leases:                   items("leases"),
+		Endpoints:                items("endpoints"),
+		EndpointSlices:           items("endpointslices"),
+		Nodes:                    items("nodes"),
+		Truncated:                map[string]int{},
+	}
+
+	view.Relationships = findRelationships(view)
+
+	return view
+}
+
+// findRelationships works out the links between the resources in a view, sorted so output is stable
+func findRelationships(view *NamespaceView) []Relationship {
+	rels := []Relationship{}
+
+	for _, list := range [][]unstructured.Unstructured{
+		view.Pods, view.ReplicaSets, view.Deployments, view.StatefulSets, view.DaemonSets, view.Jobs,
+	} {
+		for i := range list {
+			rels = append(rels, ownerRelationships(&list[i])...)
+		}
+	}
+
+	for i := range view.Services {
+		rels = append(rels, selectorRelationships(&view.Services[i], view.Pods)...)
+	}
+
+	for i := range view.Ingresses {
+		rels = append(rels, ingressRelationships(&view.Ingresses[i])...)
+	}
+
+	for i := range view.NetworkPolicies {
+		rels = append(rels, networkPolicyRelationships(&view.NetworkPolicies[i], view.Pods)...)
+	}
+
+	for i := range view.Pods {
+		rels = append(rels, volumeRelationships(&view.Pods[i])...)
+	}
+
+	// Pods run on the node they're scheduled to, pending pods have no node yet
+	for i := range view.Pods {
+		pod := &view.Pods[i]
+
+		if node, _, _ := unstructured.NestedString(pod.Object, "spec", "nodeName"); node != "" {
+			rels = append(rels, Relationship{From: refOf(pod), To: ResourceRef{"Node", node}, Type: "runs"})
+		}
+	}
+
+	// Bound claims link to their PersistentVolume, PVs are cluster wide so aren't part of the view itself
+	for i := range view.PersistentVolumeClaims {
+		pvc := &view.PersistentVolumeClaims[i]
+
+		if volume, _, _ := unstructured.NestedString(pvc.Object, "spec", "volumeName"); volume != "" {
+			rels = append(rels, Relationship{From: refOf(pvc), To: ResourceRef{"PersistentVolume", volume}, Type: "binds"})
+		}
+	}
+
+	for i := range view.HorizontalPodAutoscalers {
+		hpa := &view.HorizontalPodAutoscalers[i]
+
+		kind, _, _ := unstructured.NestedString(hpa.Object, "spec", "scaleTargetRef", "kind")
+		name, _, _ := unstructured.NestedString(hpa.Object, "spec", "scaleTargetRef", "name")
+
+		if kind != "" && name != "" {
+			rels = append(rels, Relationship{From: refOf(hpa), To: ResourceRef{kind, name}, Type: "scales"})
+		}
+	}
+
+	slices.SortFunc(rels, func(a, b Relationship) int {
+		return cmp.Or(
+			cmp.Compare(a.Type, b.Type),
+			cmp.Compare(a.From.Kind, b.From.Kind), cmp.Compare(a.From.Name, b.From.Name),
+			cmp.Compare(a.To.Kind, b.To.Kind), cmp.Compare(a.To.Name, b.To.Name),
+		)
+	})
+
+	return slices.Compact(rels)
+}
+
+// ownerRelationships links the owner of a resource to it, e.g. a ReplicaSet owns its Pods
+func ownerRelationships(obj *unstructured.Unstructured) []Relationship {
+	rels := []Relationship{}
+
+	for _, owner := range obj.GetOwnerReferences() {
+		rels = append(rels, Relationship{From: ResourceRef{owner.Kind, owner.Name}, To: refOf(obj), Type: "owns"})
+	}
+
+	return rels
+}
+
+// selectorRelationships links a Service to the Pods matched by its selector
+func selectorRelationships(svc *unstructured.Unstructured, pods []unstructured.Unstructured) []Relationship {
+	rels := []Relationship{}
+
+	selector, _, _ := unstructured.NestedStringMap(svc.Object, "spec", "selector")
+	if len(selector) == 0 {
+		return rels
+	}
+
+	for i := range pods {
+		if labelsMatch(selector, pods[i].GetLabels()) {
+			rels = append(rels, Relationship{From: refOf(svc), To: refOf(&pods[i]), Type: "selects"})
+		}
+	}
+
+	return rels
+}
+
+// ingressRelationships links an Ingress to the Services it routes to
+func ingressRelationships(ing *unstructured.Unstructured) []Relationship {
+	rels := []Relationship{}
+	addBackend := func(backend map[string]interface{}) {
+		if name, found, _ := unstructured.NestedString(backend, "service", "name"); found {
+			rels = append(rels, Relationship{From: refOf(ing), To: ResourceRef{"Service", name}, Type: "routes"})
+		}
+	}
+
+	if backend, found, _ := unstructured.NestedMap(ing.Object, "spec", "defaultBackend"); found {
+		addBackend(backend)
+	}
+
+	rules, _, _ := unstructured.NestedSlice(ing.Object, "spec", "rules")
+	for _, rule := range rules {
+		ruleMap, _ := rule.(map[string]interface{})
+		paths, _, _ := unstructured.NestedSlice(ruleMap, "http", "paths")
+
+		for _, path := range paths {
+			pathMap, _ := path.(map[string]interface{})
+			if backend, found, _ := unstructured.NestedMap(pathMap, "backend"); found {
+				addBackend(backend)
+			}
+		}
+	}
+
+	return rels
+}
+
+// volumeRelationships links a Pod to the PVCs, ConfigMaps & Secrets it mounts as volumes
+func volumeRelationships(pod *unstructured.Unstructured) []Relationship {
+	rels := []Relationship{}
+	sources := []struct{ field, nameField, kind string }{
+		{"persistentVolumeClaim", "claimName", "PersistentVolumeClaim"},
+		{"configMap", "name", "ConfigMap"},
+		{"secret", "secretName", "Secret"},
+	}
+
+	volumes, _, _ := unstructured.NestedSlice(pod.Object, "spec", "volumes")
+	for _, vol := range volumes {
+		volMap, _ := vol.(map[string]interface{})
+
+		for _, src := range sources {
+			if name, found, _ := unstructured.NestedString(volMap, src.field, src.nameField); found {
+				rels = append(rels, Relationship{From: refOf(pod), To: ResourceRef{src.kind, name}, Type: "mounts"})
+			}
+		}
+	}
+
+	return rels
+}
+
+// labelsMatch checks every label in the selector is set to the same value in the labels
+func labelsMatch(selector, labels map[string]string) bool {
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// refOf returns a reference to a resource
+func refOf(obj *unstructured.Unstructured) ResourceRef {
+	return ResourceRef{Kind: obj.GetKind(), Name: obj.GetName()}
+}
@@ -0,0 +1,194 @@
+// ==========================================================================================
+// Unit tests for the typed namespace view
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestNamespaceView_JSON(t *testing.T) {
+	view := NewNamespaceView("default", map[string][]unstructured.Unstructured{
+		"pods": {*createTestPod("web", "default")},
+	})
+
+	out, err := json.Marshal(view)
+	if err != nil {
+		t.Fatalf("Expected view to marshal, got %v", err)
+	}
+
+	var decoded map[string]json.RawMessage
+	if err := json.Unmarshal(out, &decoded); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+
+	expectedKeys := []string{
+		"namespace", "pods", "services", "deployments", "replicaSets", "statefulSets", "daemonSets", "jobs",
+		"cronJobs", "ingresses", "networkPolicies", "configMaps", "secrets", "persistentVolumeClaims", "events",
+		"horizontalPodAutoscalers", "resourceQuotas", "limitRanges", "serviceAccounts", "leases",
+		"endpoints", "endpointSlices", "nodes", "hiddenPods", "truncated", "relationships",
+	}
+
+	if len(decoded) != len(expectedKeys) {
+		t.Errorf("Expected %d keys, got %d", len(expectedKeys), len(decoded))
+	}
+
+	for _, key := range expectedKeys {
+		value, ok := decoded[key]
+		if !ok {
+			t.Errorf("Expected key %s in view JSON", key)
+			continue
+		}
+
+		// Empty types must be arrays, so typed clients never see null
+		if string(value) == "null" {
+			t.Errorf("Expected key %s to not be null", key)
+		}
+	}
+}
+
+func TestKubernetes_FetchNamespaceView_Relationships(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	pod := createTestPod("web-abc", "default")
+	pod.SetLabels(map[string]string{"app": "web"})
+	pod.SetOwnerReferences([]metaV1.OwnerReference{{Kind: "ReplicaSet", Name: "web-123"}})
+	_ = unstructured.SetNestedSlice(pod.Object, []interface{}{
+		map[string]interface{}{"name": "data", "persistentVolumeClaim": map[string]interface{}{"claimName": "web-data"}},
+	}, "spec", "volumes")
+
+	svc := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default"},
+		"spec":       map[string]interface{}{"selector": map[string]interface{}{"app": "web"}},
+	}}
+
+	svcGvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "services"}
+
+	_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+	_, _ = k.dynamicClient.Resource(svcGvr).Namespace("default").Create(context.TODO(), svc, metaV1.CreateOptions{})
+
+	view, err := k.FetchNamespaceView("default", FetchOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(view.Pods) != 1 || len(view.Services) != 1 {
+		t.Fatalf("Expected 1 pod & 1 service, got %d & %d", len(view.Pods), len(view.Services))
+	}
+
+	expected := []Relationship{
+		{From: ResourceRef{"Pod", "web-abc"}, To: ResourceRef{"PersistentVolumeClaim", "web-data"}, Type: "mounts"},
+		{From: ResourceRef{"ReplicaSet", "web-123"}, To: ResourceRef{"Pod", "web-abc"}, Type: "owns"},
+		{From: ResourceRef{"Service", "web"}, To: ResourceRef{"Pod", "web-abc"}, Type: "selects"},
+	}
+
+	if len(view.Relationships) != len(expected) {
+		t.Fatalf("Expected %d relationships, got %v", len(expected), view.Relationships)
+	}
+
+	for i, rel := range expected {
+		if view.Relationships[i] != rel {
+			t.Errorf("Expected relationship %d to be %v, got %v", i, rel, view.Relationships[i])
+		}
+	}
+}
+
+func TestNewNamespaceView_Storage(t *testing.T) {
+	pod := createTestPod("db-0", "default")
+	_ = unstructured.SetNestedSlice(pod.Object, []interface{}{
+		map[string]interface{}{"name": "data", "persistentVolumeClaim": map[string]interface{}{"claimName": "db-data"}},
+	}, "spec", "volumes")
+
+	pvc := func(name, volume string) unstructured.Unstructured {
+		obj := unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "PersistentVolumeClaim",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+			"spec":       map[string]interface{}{},
+		}}
+
+		if volume != "" {
+			_ = unstructured.SetNestedField(obj.Object, volume, "spec", "volumeName")
+		}
+
+		return obj
+	}
+
+	// The pending claim isn't bound to a volume yet, so has no PV to link to
+	view := NewNamespaceView("default", map[string][]unstructured.Unstructured{
+		"pods":                   {*pod},
+		"persistentvolumeclaims": {pvc("db-data", "pv-0001"), pvc("pending", "")},
+	})
+
+	expected := []Relationship{
+		{
+			From: ResourceRef{"PersistentVolumeClaim", "db-data"},
+			To:   ResourceRef{"PersistentVolume", "pv-0001"}, Type: "binds",
+		},
+		{From: ResourceRef{"Pod", "db-0"}, To: ResourceRef{"PersistentVolumeClaim", "db-data"}, Type: "mounts"},
+	}
+
+	if len(view.Relationships) != len(expected) {
+		t.Fatalf("Expected %d relationships, got %v", len(expected), view.Relationships)
+	}
+
+	for i, rel := range expected {
+		if view.Relationships[i] != rel {
+			t.Errorf("Expected relationship %d to be %v, got %v", i, rel, view.Relationships[i])
+		}
+	}
+}
+
+func TestKubernetes_FetchNamespaceView_Nodes(t *testing.T) {
+	k := mockKubernetes()
+	addTestNamespace(k, "default")
+
+	createTestNode(k, "node-a", nil)
+	createTestNode(k, "node-idle", nil)
+
+	scheduled := createTestPod("web", "default")
+	_ = unstructured.SetNestedField(scheduled.Object, "node-a", "spec", "nodeName")
+
+	// A node which can't be listed is still linked to by name
+	hidden := createTestPod("api", "default")
+	_ = unstructured.SetNestedField(hidden.Object, "node-gone", "spec", "nodeName")
+
+	for _, pod := range []*unstructured.Unstructured{scheduled, hidden, createTestPod("pending", "default")} {
+		_, _ = k.dynamicClient.Resource(podGVR).Namespace("default").Create(context.TODO(), pod, metaV1.CreateOptions{})
+	}
+
+	view, err := k.FetchNamespaceView("default", FetchOptions{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(view.Nodes) != 1 || view.Nodes[0].GetName() != "node-a" {
+		t.Errorf("Expected only the node running a pod, got %d nodes", len(view.Nodes))
+	}
+
+	runs := map[string]string{}
+
+	for _, rel := range view.Relationships {
+		if rel.Type == "runs" {
+			runs[rel.From.Name] = rel.To.Name
+		}
+	}
+
+	if len(runs) != 2 || runs["web"] != "node-a" || runs["api"] != "node-gone" {
+		t.Errorf("Expected web & api to run on their nodes, got %v", runs)
+	}
+
+	if _, found := runs["pending"]; found {
+		t.Error("Expected no node for the pending pod")
+	}
+}
@@ -0,0 +1,122 @@
+// ==========================================================================================
+// Watching a single object, for live updates to a detail view without the whole namespace
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// Watch events mapped to the events sent to clients, bookmarks & errors aren't sent
+var watchEventTypes = map[watch.EventType]EventTypeEnum{
+	watch.Added:    AddEvent,
+	watch.Modified: UpdateEvent,
+	watch.Deleted:  DeleteEvent,
+}
+
+// ObjectGroup is the group events for a single object are sent to, see WatchObject
+func ObjectGroup(ns, kind, name string) string {
+	return "object/" + ns + "/" + kind + "/" + name
+}
+
+// WatchObject watches one object, sending its events to the sender's ObjectGroup until ctx is cancelled
+// The apiVersion is only needed for kinds other than the common workload kinds, see resourceForKind
+// The watch is opened before returning, so an error such as an unknown kind is returned straight away
+func (k *Kubernetes) WatchObject(ctx context.Context, ns, apiVersion, kind, name string, sender EventSender) error {
+	if ns == "" || kind == "" || name == "" {
+		return errors.New("namespace, kind or name is empty")
+	}
+
+	if err := k.checkNamespace(ns); err != nil {
+		return err
+	}
+
+	gvr, err := k.resourceForKind(apiVersion, kind)
+	if err != nil {
+		return err
+	}
+
+	w, err := k.watchByName(ctx, ns, gvr, name, "")
+	if err != nil {
+		return err
+	}
+
+	go k.sendObjectEvents(ctx, w, ns, gvr, kind, name, sender)
+
+	return nil
+}
+
+// watchByName opens a watch on a single object using a field selector
+// Watches run until cancelled, so they don't go through callAPI & its timeout
+func (k *Kubernetes) watchByName(ctx context.Context, ns string, gvr schema.GroupVersionResource,
+	name, resourceVersion string) (watch.Interface, error) {
+	return k.dynamicClient.Resource(gvr).Namespace(ns).Watch(ctx, metaV1.ListOptions{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", name).String(),
+		ResourceVersion: resourceVersion,
+	})
+}
+
+// sendObjectEvents sends events from the watch until ctx is cancelled
+// The API server ends watches after a while, when that happens it's reopened from the last version seen
+func (k *Kubernetes) sendObjectEvents(ctx context.Context, w watch.Interface, ns string,
+	gvr schema.GroupVersionResource, kind, name string, sender EventSender) {
+	group := ObjectGroup(ns, kind, name)
+	resourceVersion := ""
+
+	defer func() { w.Stop() }()
+
+	for {
+		var event watch.Event
+
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-w.ResultChan():
+			if !ok {
+				var err error
+				if w, err = k.watchByName(ctx, ns, gvr, name, resourceVersion); err != nil {
+					if ctx.Err() == nil {
+						log.Printf("💥 Failed to reopen watch on %s %s in %s: %v", kind, name, ns, err)
+					}
+
+					return
+				}
+
+				continue
+			}
+
+			event = e
+		}
+
+		// An error is usually an expired version, so the next watch starts over from the current state
+		if event.Type == watch.Error {
+			resourceVersion = ""
+			continue
+		}
+
+		eventType, sent := watchEventTypes[event.Type]
+		obj, isObj := event.Object.(*unstructured.Unstructured)
+
+		// The name is checked here too, in case the field selector wasn't applied
+		if !sent || !isObj || obj.GetName() != name {
+			continue
+		}
+
+		resourceVersion = obj.GetResourceVersion()
+
+		// Cleaned the same as objects streamed with the namespace, so Secret & ConfigMap data stays redacted
+		items := []unstructured.Unstructured{*k.cleanObject(obj)}
+		setHealth(items)
+
+		sender.SendToGroup(group, KubeEvent{EventType: eventType, Object: normaliseEvent(&items[0])})
+	}
+}
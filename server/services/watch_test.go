@@ -0,0 +1,126 @@
+// ==========================================================================================
+// Unit tests for watching a single object
+// ==========================================================================================
+
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// groupSender records the group each event was sent to
+type groupSender struct {
+	mu     sync.Mutex
+	groups []string
+	events []KubeEvent
+}
+
+func (g *groupSender) SendToGroup(group string, message KubeEvent) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.groups = append(g.groups, group)
+	g.events = append(g.events, message)
+}
+
+func (g *groupSender) sent() ([]string, []KubeEvent) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return append([]string{}, g.groups...), append([]KubeEvent{}, g.events...)
+}
+
+func TestKubernetes_WatchObject(t *testing.T) {
+	k := mockKubernetes()
+	pods := k.dynamicClient.Resource(podGVR).Namespace("default")
+
+	for _, name := range []string{"web", "other"} {
+		_, _ = pods.Create(context.TODO(), createTestPod(name, "default"), metaV1.CreateOptions{})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sender := &groupSender{}
+	if err := k.WatchObject(ctx, "default", "", "Pod", "web", sender); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for _, name := range []string{"other", "web"} {
+		pod, _ := pods.Get(context.TODO(), name, metaV1.GetOptions{})
+		pod.SetLabels(map[string]string{"updated": "true"})
+		_, _ = pods.Update(context.TODO(), pod, metaV1.UpdateOptions{})
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for _, events := sender.sent(); len(events) == 0 && time.Now().Before(deadline); _, events = sender.sent() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	groups, events := sender.sent()
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event for the watched pod only, got %d", len(events))
+	}
+
+	if events[0].EventType != UpdateEvent || events[0].Object.GetName() != "web" {
+		t.Errorf("Expected an update to web, got %s of %s", events[0].EventType, events[0].Object.GetName())
+	}
+
+	if events[0].Object.GetLabels()["updated"] != "true" {
+		t.Error("Expected the event to hold the updated pod")
+	}
+
+	if groups[0] != ObjectGroup("default", "Pod", "web") {
+		t.Errorf("Expected the event to be sent to the object's group, got %s", groups[0])
+	}
+
+	if err := k.WatchObject(ctx, "default", "", "Widget", "web", sender); err == nil {
+		t.Error("Expected error for an unknown kind with no apiVersion")
+	}
+}
+
+func TestKubernetes_WatchObject_Secret(t *testing.T) {
+	k := mockKubernetes()
+	k.RedactSecrets = true
+	setKindDiscovery(k)
+	secrets := k.dynamicClient.Resource(secretGVR).Namespace("default")
+
+	_, _ = secrets.Create(context.TODO(), createTestSecret("db-creds", "default"), metaV1.CreateOptions{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sender := &groupSender{}
+	if err := k.WatchObject(ctx, "default", "v1", "Secret", "db-creds", sender); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	secret, _ := secrets.Get(context.TODO(), "db-creds", metaV1.GetOptions{})
+	secret.SetLabels(map[string]string{"updated": "true"})
+	_, _ = secrets.Update(context.TODO(), secret, metaV1.UpdateOptions{})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for _, events := sender.sent(); len(events) == 0 && time.Now().Before(deadline); _, events = sender.sent() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_, events := sender.sent()
+	if len(events) != 1 {
+		t.Fatalf("Expected 1 event for the watched secret, got %d", len(events))
+	}
+
+	data, _, _ := unstructured.NestedStringMap(events[0].Object.Object, "data")
+	if data["password"] != redactedValue {
+		t.Errorf("Expected the secret data to be redacted, got %v", data)
+	}
+
+	if _, ok := events[0].Object.Object[healthField]; !ok {
+		t.Error("Expected the event to have a health field, the same as a streamed namespace")
+	}
+}
@@ -2,14 +2,29 @@
 // SSE broker for Kubernetes events, this is very intertwined with the Kubernetes service
 //   See services/kubernetes.go for how events are generated and sent to the broker
 // - Handles client connections and disconnections
-// - Broadcasts Kubernetes events to connected clients
+// - Broadcasts Kubernetes events to connected clients, optionally only the kinds they asked for
+// - Shares watches on single objects between the clients viewing them, stopping each with its last viewer
+// - Ends all streams on shutdown, telling clients to back off before reconnecting
+// - Refuses new streams beyond a configured limit, each one holds a goroutine & its client's watches
+// - Streams are written here rather than by the underlying broker, whose stream loop never returns
+// - Also sends events to gRPC streams, see grpc.go
 // ==========================================================================================
 
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/benc-uk/go-rest-api/pkg/sse"
@@ -19,15 +34,419 @@ import (
 // Wraps the SSE broker to handle Kubernetes events
 type KubeEventBroker struct {
 	*sse.Broker[services.KubeEvent]
+	// Authorize is called before a client is subscribed to a namespace, when nil all subscriptions are allowed
+	Authorize AuthorizeFunc
+	// Open streams, shared by every copy of the broker
+	streams *streamTracker
+	// Kinds each client wants events for, clients not in here get every kind
+	kinds *kindFilters
+	// Watches on single objects, shared by every client subscribed to the same object
+	objects *objectWatches
+	// gRPC streams, which get the same events as SSE clients in their namespace
+	grpc *grpcStreams
+	// Event channel of each connected client, used in place of the underlying broker's clients
+	clients *sseClients
+}
+
+// sseClients holds the events waiting to be written to each connected client
+type sseClients struct {
+	mu     sync.RWMutex
+	events map[string]chan services.KubeEvent
+	// Guards the underlying broker's groups, which it doesn't lock itself
+	groupsMu sync.RWMutex
+}
+
+// objectWatches tracks the watch behind each object group, and the one object group each client is in
+type objectWatches struct {
+	mu      sync.Mutex
+	watches map[string]*objectWatch
+	clients map[string]string
+}
+
+// objectWatch is a running watch on one object, stopped when its last client leaves
+type objectWatch struct {
+	cancel  context.CancelFunc
+	clients map[string]bool
+}
+
+// kindFilters holds the kinds of object each client has asked for, by lower case kind e.g. "pod"
+type kindFilters struct {
+	mu      sync.RWMutex
+	clients map[string][]string
+}
+
+// Events held for each client while its stream is writing, more than this and events are dropped
+const sseClientBuffer = 100
+
+// Seconds a client refused for being over the stream limit is asked to wait before trying again
+const streamRetryAfter = 10
+
+var (
+	errShuttingDown   = errors.New("server is shutting down")
+	errTooManyStreams = errors.New("too many open event streams")
+)
+
+// streamTracker counts the open SSE streams, and signals them all to end when shutting down
+type streamTracker struct {
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+	closing  bool
+	shutdown chan struct{}
+	// Streams currently open, and the most allowed at once with zero meaning no limit
+	active atomic.Int64
+	max    int64
+}
+
+// AuthorizeFunc decides if the client making a request may subscribe to events for a namespace
+type AuthorizeFunc func(r *http.Request, namespace string) bool
+
+// Subscribe moves a client into the group for a namespace, so it receives ONLY events for that namespace
+// Returns false when the subscription is denied, in which case the client is left in no namespace group
+func (b KubeEventBroker) Subscribe(r *http.Request, clientID string, namespace string) bool {
+	b.UnsubscribeObject(clientID)
+	b.RemoveFromAllGroups(clientID)
+
+	if b.Authorize != nil && !b.Authorize(r, namespace) {
+		log.Printf("⛔ Client %s denied subscription to namespace %s", clientID, namespace)
+		return false
+	}
+
+	b.AddToGroup(clientID, namespace)
+
+	return true
+}
+
+// SubscribeObject adds a client to the group for a single object, replacing any object it was watching
+// The first client in a group calls start to begin the watch, which runs until the context is cancelled
+// The client stays in its namespace group, the namespace must already have been checked by the caller
+func (b KubeEventBroker) SubscribeObject(clientID, group string, start func(ctx context.Context) error) error {
+	b.UnsubscribeObject(clientID)
+
+	b.objects.mu.Lock()
+	defer b.objects.mu.Unlock()
+
+	watch, running := b.objects.watches[group]
+	if !running {
+		ctx, cancel := context.WithCancel(context.Background())
+		if err := start(ctx); err != nil {
+			cancel()
+			return err
+		}
+
+		watch = &objectWatch{cancel: cancel, clients: map[string]bool{}}
+		b.objects.watches[group] = watch
+	}
+
+	watch.clients[clientID] = true
+	b.objects.clients[clientID] = group
+	b.AddToGroup(clientID, group)
+
+	return nil
+}
+
+// UnsubscribeObject removes a client from its object group, stopping the watch if no other client is in it
+func (b KubeEventBroker) UnsubscribeObject(clientID string) {
+	b.objects.mu.Lock()
+	defer b.objects.mu.Unlock()
+
+	group, ok := b.objects.clients[clientID]
+	if !ok {
+		return
+	}
+
+	delete(b.objects.clients, clientID)
+	b.RemoveFromGroup(clientID, group)
+
+	watch := b.objects.watches[group]
+	delete(watch.clients, clientID)
+
+	if len(watch.clients) == 0 {
+		watch.cancel()
+		delete(b.objects.watches, group)
+	}
+}
+
+// SetKinds limits the events sent to a client to objects of these kinds e.g. "Pod", empty means every kind
+// Kinds are matched ignoring case, events with no object such as pings are always sent
+func (b KubeEventBroker) SetKinds(clientID string, kinds []string) {
+	b.kinds.mu.Lock()
+	defer b.kinds.mu.Unlock()
+
+	if len(kinds) == 0 {
+		delete(b.kinds.clients, clientID)
+		return
+	}
+
+	lower := make([]string, 0, len(kinds))
+	for _, kind := range kinds {
+		lower = append(lower, strings.ToLower(kind))
+	}
+
+	b.kinds.clients[clientID] = lower
+}
+
+// SendToGroup sends an event to the clients in a group, skipping those which haven't asked for its kind
+// This replaces the underlying broker's SendToGroup, so filtered events never use any bandwidth
+// gRPC streams subscribed to the group's namespace are sent the event too
+func (b KubeEventBroker) SendToGroup(group string, message services.KubeEvent) {
+	for _, clientID := range b.GetGroupClients(group) {
+		if b.wantsEvent(clientID, message) {
+			b.SendToClient(clientID, message)
+		}
+	}
+
+	b.grpc.send(group, message)
+}
+
+// wantsEvent checks a client's kind filter against the object in an event
+func (b KubeEventBroker) wantsEvent(clientID string, message services.KubeEvent) bool {
+	if message.Object == nil {
+		return true
+	}
+
+	b.kinds.mu.RLock()
+	defer b.kinds.mu.RUnlock()
+
+	kinds, filtered := b.kinds.clients[clientID]
+
+	return !filtered || slices.Contains(kinds, strings.ToLower(message.Object.GetKind()))
+}
+
+// Stream sends events to a client until it disconnects or the broker is shut down
+func (b KubeEventBroker) Stream(clientID string, w http.ResponseWriter, r *http.Request) {
+	if err := b.streams.open(); err != nil {
+		if errors.Is(err, errTooManyStreams) {
+			log.Printf("⛔ Client %s refused, already at the limit of %d streams", clientID, b.streams.max)
+			w.Header().Set("Retry-After", strconv.Itoa(streamRetryAfter))
+		}
+
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+
+		return
+	}
+
+	defer b.streams.done()
+	defer b.SetKinds(clientID, nil)
+	defer b.UnsubscribeObject(clientID)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	events := b.clients.add(clientID)
+	b.ClientConnectedHandler(clientID)
+
+	defer func() {
+		b.clients.remove(clientID)
+		b.RemoveFromAllGroups(clientID)
+		b.ClientDisconnectedHandler(clientID)
+	}()
+
+	send := func(event services.KubeEvent) {
+		msg := b.MessageAdapter(event, clientID)
+		msg.Write(w)
+		flusher.Flush()
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-b.streams.shutdown:
+			send(services.KubeEvent{EventType: services.ShutdownEvent})
+			return
+		case event := <-events:
+			send(event)
+		}
+	}
+}
+
+// SendToClient queues an event for a client, without waiting on slow clients
+// This replaces the underlying broker's SendToClient, which blocks forever once a client has gone
+func (b KubeEventBroker) SendToClient(clientID string, message services.KubeEvent) {
+	b.clients.mu.RLock()
+	defer b.clients.mu.RUnlock()
+
+	b.clients.send(clientID, message)
+}
+
+// SendToAll queues an event for every connected client
+func (b KubeEventBroker) SendToAll(message services.KubeEvent) {
+	b.clients.sendAll(message)
+}
+
+// AddToGroup adds a client to a group, the group methods wrap the underlying broker's with a lock
+func (b KubeEventBroker) AddToGroup(clientID, group string) {
+	b.clients.groupsMu.Lock()
+	defer b.clients.groupsMu.Unlock()
+
+	b.Broker.AddToGroup(clientID, group)
+}
+
+// RemoveFromGroup removes a client from a group
+func (b KubeEventBroker) RemoveFromGroup(clientID, group string) {
+	b.clients.groupsMu.Lock()
+	defer b.clients.groupsMu.Unlock()
+
+	b.Broker.RemoveFromGroup(clientID, group)
+}
+
+// RemoveFromAllGroups removes a client from every group it is in
+func (b KubeEventBroker) RemoveFromAllGroups(clientID string) {
+	b.clients.groupsMu.Lock()
+	defer b.clients.groupsMu.Unlock()
+
+	b.Broker.RemoveFromAllGroups(clientID)
+}
+
+// GetGroupClients returns a copy of the clients in a group, safe to use while the group changes
+func (b KubeEventBroker) GetGroupClients(group string) []string {
+	b.clients.groupsMu.RLock()
+	defer b.clients.groupsMu.RUnlock()
+
+	return slices.Clone(b.Broker.GetGroupClients(group))
+}
+
+// GetClientCount returns how many clients have a stream open
+func (b KubeEventBroker) GetClientCount() int {
+	b.clients.mu.RLock()
+	defer b.clients.mu.RUnlock()
+
+	return len(b.clients.events)
+}
+
+// Shutdown ends every open stream, sending each client a final shutdown event
+// Waits for all the streams to end or the context to expire, new streams are refused
+func (b KubeEventBroker) Shutdown(ctx context.Context) error {
+	b.streams.close()
+
+	done := make(chan struct{})
+
+	go func() {
+		b.streams.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("ending event streams: %w", ctx.Err())
+	}
+}
+
+// open registers a new stream, failing when shutting down or at the limit of open streams
+func (t *streamTracker) open() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.closing {
+		return errShuttingDown
+	}
+
+	if t.max > 0 && t.active.Load() >= t.max {
+		return errTooManyStreams
+	}
+
+	t.active.Add(1)
+	t.wg.Add(1)
+
+	return nil
+}
+
+// done unregisters a stream once it has ended
+func (t *streamTracker) done() {
+	t.active.Add(-1)
+	t.wg.Done()
+}
+
+// close signals all the streams to end, it is safe to call more than once
+func (t *streamTracker) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.closing {
+		t.closing = true
+		close(t.shutdown)
+	}
+}
+
+// add registers a client's stream, replacing any earlier stream with the same ID
+func (c *sseClients) add(clientID string) chan services.KubeEvent {
+	events := make(chan services.KubeEvent, sseClientBuffer)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.events[clientID] = events
+
+	return events
+}
+
+// remove unregisters a client once its stream has ended
+func (c *sseClients) remove(clientID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.events, clientID)
+}
+
+// ids lists the connected clients
+func (c *sseClients) ids() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return slices.Collect(maps.Keys(c.events))
+}
+
+// sendAll queues an event for every client
+func (c *sseClients) sendAll(message services.KubeEvent) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for clientID := range c.events {
+		c.send(clientID, message)
+	}
+}
+
+// send queues an event for a client, dropping it when the client's buffer is full, the lock must be held
+func (c *sseClients) send(clientID string, message services.KubeEvent) {
+	events, ok := c.events[clientID]
+	if !ok {
+		return
+	}
+
+	select {
+	case events <- message:
+	default:
+		log.Printf("⚠️ Client %s is too slow, dropped a %s event", clientID, message.EventType)
+	}
 }
 
 func newKubeEventBroker(conf Config) KubeEventBroker {
 	// This is the underlying SSE broker that will handle streaming events to connected clients
 	broker := sse.NewBroker[services.KubeEvent]()
 
+	// Clients are held here, as the underlying broker's own streams never end
+	clients := &sseClients{events: map[string]chan services.KubeEvent{}}
+
 	// Customise the broker with specific handlers and message adapters
 	broker.MessageAdapter = func(ke services.KubeEvent, clientID string) sse.SSE {
-		json, err := json.Marshal(ke.Object)
+		var payload any = ke.Object
+
+		// Events merged from several clusters say which one they're from, the object is then nested
+		if ke.Cluster != "" {
+			payload = map[string]any{"cluster": ke.Cluster, "object": ke.Object}
+		}
+
+		json, err := json.Marshal(payload)
 		if err != nil {
 			log.Printf("💥 Error marshalling object: %v", err)
 
@@ -56,30 +475,48 @@ func newKubeEventBroker(conf Config) KubeEventBroker {
 
 		// Debug all groups and clients
 		if conf.Debug {
+			clients.groupsMu.RLock()
+			defer clients.groupsMu.RUnlock()
+
 			allGroups := broker.GetGroups()
 
 			log.Printf("🔍 Debug: Current groups in SSE broker: %v", allGroups)
-			log.Printf("🔍 Debug: Current clients in SSE broker: %v", broker.GetClients())
+			log.Printf("🔍 Debug: Current clients in SSE broker: %v", clients.ids())
 
 			for _, group := range allGroups {
-				clients := broker.GetGroupClients(group)
-				log.Printf("🔍 Debug: Group '%s' has clients: %v", group, clients)
+				members := broker.GetGroupClients(group)
+				log.Printf("🔍 Debug: Group '%s' has clients: %v", group, members)
 			}
 		}
 	}
 
-	// Start a SSE heartbeat to keep the connection alive, sent to all clients
+	streams := &streamTracker{shutdown: make(chan struct{}), max: int64(conf.MaxSubscribers)}
+
+	// Start a SSE heartbeat to keep the connection alive, sent to all clients until shutdown
 	go func() {
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+
 		for {
-			broker.SendToAll(services.KubeEvent{
+			clients.sendAll(services.KubeEvent{
 				EventType: services.PingEvent,
 				Object:    nil,
 			})
-			time.Sleep(10 * time.Second)
+
+			select {
+			case <-ticker.C:
+			case <-streams.shutdown:
+				return
+			}
 		}
 	}()
 
 	return KubeEventBroker{
-		broker,
+		Broker:  broker,
+		streams: streams,
+		kinds:   &kindFilters{clients: map[string][]string{}},
+		objects: &objectWatches{watches: map[string]*objectWatch{}, clients: map[string]string{}},
+		grpc:    &grpcStreams{streams: map[string]map[*grpcStream]bool{}},
+		clients: clients,
 	}
 }
@@ -0,0 +1,334 @@
+// ==========================================================================================
+// Unit tests for the SSE broker wrapper
+// ==========================================================================================
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benc-uk/kubeview/server/services"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// denyNamespace returns an authorise hook which blocks a single namespace
+func denyNamespace(denied string) AuthorizeFunc {
+	return func(r *http.Request, namespace string) bool {
+		return namespace != denied
+	}
+}
+
+func TestKubeEventBroker_Subscribe(t *testing.T) {
+	broker := newKubeEventBroker(Config{})
+	broker.Authorize = denyNamespace("kube-system")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/fetch/default", nil)
+
+	if !broker.Subscribe(req, "client-1", "default") {
+		t.Fatal("Expected subscription to default to be allowed")
+	}
+
+	if !slices.Contains(broker.GetGroupClients("default"), "client-1") {
+		t.Error("Expected client to be in the default group")
+	}
+
+	// Denied subscription also removes the client from the namespace it was in
+	if broker.Subscribe(req, "client-1", "kube-system") {
+		t.Fatal("Expected subscription to kube-system to be denied")
+	}
+
+	if slices.Contains(broker.GetGroupClients("kube-system"), "client-1") {
+		t.Error("Expected client not to be in the kube-system group")
+	}
+
+	if slices.Contains(broker.GetGroupClients("default"), "client-1") {
+		t.Error("Expected client to be removed from the default group")
+	}
+}
+
+func TestKubeEventBroker_SubscribeDeniedHandler(t *testing.T) {
+	broker := newKubeEventBroker(Config{})
+	broker.Authorize = denyNamespace("kube-system")
+
+	h := newTestServerWithBroker(Config{}, broker)
+
+	res := doRequest(t, h, "/api/fetch/kube-system?clientID=client-1", nil)
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 for denied namespace, got %d", res.StatusCode)
+	}
+
+	if len(broker.GetGroupClients("kube-system")) != 0 {
+		t.Error("Expected no clients subscribed to the denied namespace")
+	}
+}
+
+func TestKubeEventBroker_Shutdown(t *testing.T) {
+	broker := newKubeEventBroker(Config{})
+	goroutines := runtime.NumGoroutine()
+
+	req := httptest.NewRequest(http.MethodGet, "/updates?clientID=client-1", nil)
+	rec := httptest.NewRecorder()
+	ended := make(chan struct{})
+
+	go func() {
+		broker.Stream("client-1", rec, req)
+		close(ended)
+	}()
+
+	// A client which goes away before shutdown, its stream must end too
+	gone, disconnect := context.WithCancel(context.Background())
+	goneEnded := make(chan struct{})
+
+	go func() {
+		broker.Stream("client-gone", httptest.NewRecorder(), req.WithContext(gone))
+		close(goneEnded)
+	}()
+
+	// Wait for the clients to be connected
+	deadline := time.Now().Add(2 * time.Second)
+	for broker.GetClientCount() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	disconnect()
+
+	select {
+	case <-goneEnded:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the stream to end when the client disconnected")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := broker.Shutdown(ctx); err != nil {
+		t.Fatalf("Expected shutdown to succeed, got %v", err)
+	}
+
+	select {
+	case <-ended:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the open stream to be ended by shutdown")
+	}
+
+	if !strings.Contains(rec.Body.String(), "event: shutdown") {
+		t.Errorf("Expected a final shutdown event to be sent, got %q", rec.Body.String())
+	}
+
+	// Every goroutine started for the streams has gone, the heartbeat stops on shutdown too
+	deadline = time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if leaked := runtime.NumGoroutine() - goroutines; leaked > 0 {
+		t.Errorf("Expected no goroutines left behind by the streams, %d leaked", leaked)
+	}
+
+	if broker.GetClientCount() != 0 {
+		t.Errorf("Expected no clients once the streams ended, got %d", broker.GetClientCount())
+	}
+
+	// No new streams once shutting down
+	rec = httptest.NewRecorder()
+	broker.Stream("client-2", rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected new stream to be refused with 503, got %d", rec.Code)
+	}
+}
+
+func TestKubeEventBroker_MaxSubscribers(t *testing.T) {
+	broker := newKubeEventBroker(Config{MaxSubscribers: 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ended := sync.WaitGroup{}
+
+	for _, clientID := range []string{"client-1", "client-2"} {
+		req := httptest.NewRequest(http.MethodGet, "/updates?clientID="+clientID, nil).WithContext(ctx)
+
+		ended.Go(func() { broker.Stream(clientID, &lockedRecorder{header: http.Header{}}, req) })
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for broker.GetClientCount() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/updates?clientID=client-3", nil)
+	rec := httptest.NewRecorder()
+	broker.Stream("client-3", rec, req)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected the stream over the limit to be refused with 503, got %d", rec.Code)
+	}
+
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("Expected a Retry-After header telling the client when to try again")
+	}
+
+	// Once the streams have ended there is room again
+	cancel()
+	ended.Wait()
+
+	if err := broker.streams.open(); err != nil {
+		t.Errorf("Expected a stream to be allowed once the others ended, got %v", err)
+	}
+}
+
+// lockedRecorder is a response recorder which can be read while the stream is still writing
+type lockedRecorder struct {
+	mu     sync.Mutex
+	header http.Header
+	body   strings.Builder
+}
+
+func (l *lockedRecorder) Header() http.Header { return l.header }
+func (l *lockedRecorder) WriteHeader(int)     {}
+func (l *lockedRecorder) Flush()              {}
+
+func (l *lockedRecorder) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.body.Write(p)
+}
+
+func (l *lockedRecorder) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.body.String()
+}
+
+func TestKubeEventBroker_KindFilter(t *testing.T) {
+	broker := newKubeEventBroker(Config{})
+	broker.SetKinds("pods-only", []string{"pod"})
+
+	recorders := map[string]*lockedRecorder{}
+
+	for _, clientID := range []string{"everything", "pods-only"} {
+		rec := &lockedRecorder{header: http.Header{}}
+		recorders[clientID] = rec
+
+		req := httptest.NewRequest(http.MethodGet, "/updates?clientID="+clientID, nil)
+		go broker.Stream(clientID, rec, req)
+	}
+
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+
+		_ = broker.Shutdown(ctx)
+	}()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for broker.GetClientCount() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	broker.AddToGroup("everything", "default")
+	broker.AddToGroup("pods-only", "default")
+
+	svc := &unstructured.Unstructured{}
+	svc.SetKind("Service")
+	svc.SetName("web-svc")
+
+	pod := &unstructured.Unstructured{}
+	pod.SetKind("Pod")
+	pod.SetName("web-pod")
+
+	// Events are sent in order, so once the pod arrives the service has been sent or skipped
+	broker.SendToGroup("default", services.KubeEvent{EventType: services.AddEvent, Object: svc})
+	broker.SendToGroup("default", services.KubeEvent{EventType: services.AddEvent, Object: pod})
+
+	for time.Now().Before(deadline) {
+		if strings.Contains(recorders["everything"].String(), "web-pod") &&
+			strings.Contains(recorders["pods-only"].String(), "web-pod") {
+			break
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if body := recorders["everything"].String(); !strings.Contains(body, "web-svc") || !strings.Contains(body, "web-pod") {
+		t.Errorf("Expected an unfiltered client to get both events, got %q", body)
+	}
+
+	body := recorders["pods-only"].String()
+	if !strings.Contains(body, "web-pod") {
+		t.Errorf("Expected the pod event to be sent to the pods only client, got %q", body)
+	}
+
+	if strings.Contains(body, "web-svc") {
+		t.Errorf("Expected the service event not to be sent to the pods only client, got %q", body)
+	}
+}
+
+func TestKubeEventBroker_SubscribeObject(t *testing.T) {
+	broker := newKubeEventBroker(Config{})
+	group := services.ObjectGroup("default", "Pod", "web")
+
+	starts := 0
+
+	var watchCtx context.Context
+
+	start := func(ctx context.Context) error {
+		starts++
+		watchCtx = ctx
+
+		return nil
+	}
+
+	for _, clientID := range []string{"client-1", "client-2"} {
+		if err := broker.SubscribeObject(clientID, group, start); err != nil {
+			t.Fatalf("Expected no error subscribing %s, got %v", clientID, err)
+		}
+	}
+
+	if starts != 1 {
+		t.Fatalf("Expected the watch to be started once for both clients, got %d", starts)
+	}
+
+	if clients := broker.GetGroupClients(group); len(clients) != 2 {
+		t.Errorf("Expected both clients in the object group, got %v", clients)
+	}
+
+	broker.UnsubscribeObject("client-1")
+
+	if watchCtx.Err() != nil {
+		t.Error("Expected the watch to keep running while a client is still subscribed")
+	}
+
+	// Moving to another namespace drops the object subscription too
+	req := httptest.NewRequest(http.MethodGet, "/api/fetch/other", nil)
+	broker.Subscribe(req, "client-2", "other")
+
+	if watchCtx.Err() == nil {
+		t.Error("Expected the watch to be stopped once the last client left")
+	}
+
+	if clients := broker.GetGroupClients(group); len(clients) != 0 {
+		t.Errorf("Expected no clients left in the object group, got %v", clients)
+	}
+
+	// A watch which fails to start leaves the client out of the group
+	failed := broker.SubscribeObject("client-1", group, func(context.Context) error { return context.Canceled })
+	if failed == nil {
+		t.Error("Expected the start error to be returned")
+	}
+
+	if clients := broker.GetGroupClients(group); len(clients) != 0 {
+		t.Errorf("Expected no clients in the object group after a failed start, got %v", clients)
+	}
+}
@@ -0,0 +1,156 @@
+// ==========================================================================================
+// Serving of the static frontend files embedded in the binary, with cache validation
+// and gzip compression. Files can never change while running, so all the work of hashing
+// and compressing is done once at startup
+// ==========================================================================================
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Static file names are not content hashed, so browsers must always revalidate, using the ETag
+const staticCacheControl = "no-cache"
+
+// Only text based files are worth compressing, images & fonts are already compressed
+var compressibleExtensions = map[string]bool{
+	".html": true, ".js": true, ".mjs": true, ".css": true, ".svg": true, ".json": true, ".md": true, ".ttf": true,
+}
+
+// staticFile is a single file held in memory, ready to be served
+type staticFile struct {
+	content []byte
+	// Gzipped copy of the content, nil when the file isn't worth compressing
+	gzipped     []byte
+	etag        string
+	contentType string
+}
+
+// staticFiles holds all the frontend files keyed by path, and serves them over HTTP
+type staticFiles map[string]*staticFile
+
+// loadStaticFiles reads every file in the filesystem into memory, hashing & compressing them
+func loadStaticFiles(fsys fs.FS) (staticFiles, error) {
+	files := staticFiles{}
+
+	err := fs.WalkDir(fsys, ".", func(filePath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		content, err := fs.ReadFile(fsys, filePath)
+		if err != nil {
+			return err
+		}
+
+		files[filePath] = newStaticFile(filePath, content)
+
+		return nil
+	})
+
+	return files, err
+}
+
+// newStaticFile prepares a file for serving, computing the ETag and compressing it if worthwhile
+func newStaticFile(name string, content []byte) *staticFile {
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(content)
+	}
+
+	file := &staticFile{
+		content:     content,
+		etag:        contentETag(content),
+		contentType: contentType,
+	}
+
+	if compressibleExtensions[path.Ext(name)] {
+		var buf bytes.Buffer
+
+		gz, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		_, _ = gz.Write(content)
+		_ = gz.Close()
+
+		// Tiny files can end up bigger once compressed
+		if buf.Len() < len(content) {
+			file.gzipped = buf.Bytes()
+		}
+	}
+
+	return file
+}
+
+// contentETag returns a strong ETag for some content, based on a truncated SHA-256 hash
+func contentETag(content []byte) string {
+	hash := sha256.Sum256(content)
+	return `"` + hex.EncodeToString(hash[:16]) + `"`
+}
+
+// ServeHTTP serves the file matching the request path
+func (files staticFiles) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	files.serveFile(w, r, strings.TrimPrefix(r.URL.Path, "/"))
+}
+
+// serveFile serves a named file, gzipped when the client accepts it
+// http.ServeContent handles the If-None-Match check against our ETag, sending a 304 when it matches
+func (files staticFiles) serveFile(w http.ResponseWriter, r *http.Request, name string) {
+	file, ok := files[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	content := file.content
+	etag := file.etag
+
+	w.Header().Set("Cache-Control", staticCacheControl)
+
+	if file.gzipped != nil {
+		w.Header().Set("Vary", "Accept-Encoding")
+
+		if acceptsGzip(r) {
+			// Different bytes are a different representation, so need a different ETag
+			content = file.gzipped
+			etag = strings.TrimSuffix(etag, `"`) + `-gz"`
+
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+	}
+
+	// Always set the content type, so ServeContent never tries to sniff it from gzipped bytes
+	w.Header().Set("Content-Type", file.contentType)
+	w.Header().Set("ETag", etag)
+
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(content))
+}
+
+// acceptsGzip checks the Accept-Encoding header, honouring an explicit q=0 refusal
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(enc), ";")
+		if strings.TrimSpace(name) != "gzip" {
+			continue
+		}
+
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			if weight, err := strconv.ParseFloat(q, 64); err == nil && weight == 0 {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
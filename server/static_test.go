@@ -0,0 +1,90 @@
+// ==========================================================================================
+// Unit tests for serving the static frontend files
+// ==========================================================================================
+
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"net/http"
+	"testing"
+
+	kubeview "github.com/benc-uk/kubeview"
+)
+
+func TestStatic_ETag(t *testing.T) {
+	h := newTestServer(Config{})
+
+	for _, path := range []string{"/public/js/main.js", "/"} {
+		res := doRequest(t, h, path, nil)
+		res.Body.Close()
+
+		etag := res.Header.Get("ETag")
+		if res.StatusCode != http.StatusOK || etag == "" {
+			t.Fatalf("Expected 200 with an ETag for %s, got %d %q", path, res.StatusCode, etag)
+		}
+
+		if res.Header.Get("Cache-Control") != staticCacheControl {
+			t.Errorf("Expected Cache-Control %s for %s, got %s", staticCacheControl, path, res.Header.Get("Cache-Control"))
+		}
+
+		// Second request with a matching ETag should not resend the content
+		res = doRequest(t, h, path, map[string]string{"If-None-Match": etag})
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusNotModified {
+			t.Errorf("Expected 304 for %s with matching If-None-Match, got %d", path, res.StatusCode)
+		}
+
+		// A stale ETag gets the full content
+		res = doRequest(t, h, path, map[string]string{"If-None-Match": `"stale"`})
+		res.Body.Close()
+
+		if res.StatusCode != http.StatusOK {
+			t.Errorf("Expected 200 for %s with stale If-None-Match, got %d", path, res.StatusCode)
+		}
+	}
+}
+
+func TestStatic_Gzip(t *testing.T) {
+	h := newTestServer(Config{})
+
+	expected, err := fs.ReadFile(kubeview.FrontendFS, "frontend/js/graph.js")
+	if err != nil {
+		t.Fatalf("Failed to read embedded asset: %v", err)
+	}
+
+	res := doRequest(t, h, "/public/js/graph.js", map[string]string{"Accept-Encoding": "br, gzip;q=0.8"})
+	defer res.Body.Close()
+
+	if res.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected gzip content encoding, got %q", res.Header.Get("Content-Encoding"))
+	}
+
+	if res.Header.Get("Content-Type") != "text/javascript; charset=utf-8" {
+		t.Errorf("Expected javascript content type, got %q", res.Header.Get("Content-Type"))
+	}
+
+	gz, err := gzip.NewReader(res.Body)
+	if err != nil {
+		t.Fatalf("Expected valid gzip body, got %v", err)
+	}
+
+	body, _ := io.ReadAll(gz)
+	if string(body) != string(expected) {
+		t.Error("Expected decompressed body to match the embedded file")
+	}
+
+	// Without gzip support, or with it refused, the raw bytes are sent
+	for _, accept := range []string{"", "gzip;q=0"} {
+		res = doRequest(t, h, "/public/js/graph.js", map[string]string{"Accept-Encoding": accept})
+		body, _ = io.ReadAll(res.Body)
+		res.Body.Close()
+
+		if res.Header.Get("Content-Encoding") != "" || string(body) != string(expected) {
+			t.Errorf("Expected raw content for Accept-Encoding %q", accept)
+		}
+	}
+}
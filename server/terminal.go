@@ -0,0 +1,173 @@
+// ==========================================================================================
+// Web terminal, bridging a websocket from the browser to an exec session in a container
+// - Messages both ways are JSON, see terminalMessage
+// - Denied in read-only mode, as a shell can change anything the container can reach
+// ==========================================================================================
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"unicode/utf8"
+
+	"github.com/benc-uk/kubeview/server/services"
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/net/websocket"
+)
+
+// Command run when none is given, sh is present in far more images than bash
+var defaultExecCommand = []string{"/bin/sh"}
+
+// terminalMessage is sent over the websocket in both directions
+// - Browser to server: "stdin" with data, or "resize" with cols & rows
+// - Server to browser: "stdout" with data, then "exit" with data holding any error once the command ends
+type terminalMessage struct {
+	Type string `json:"type"`
+	Data string `json:"data,omitempty"`
+	Cols uint16 `json:"cols,omitempty"`
+	Rows uint16 `json:"rows,omitempty"`
+}
+
+// Open a terminal to a container, the container & command are optional query parameters
+// The command can be repeated to pass arguments e.g. ?command=/bin/bash&command=-l
+func (s *KubeviewAPI) handleExec(w http.ResponseWriter, r *http.Request) {
+	ns := chi.URLParam(r, "namespace")
+	podName := chi.URLParam(r, "podname")
+
+	if !s.checkNamespacePermitted(w, r, ns) {
+		return
+	}
+
+	// Checked before upgrading, so the browser gets a proper error response
+	if s.kubeService.ReadOnly {
+		sendOperationError(w, r, "exec", services.ErrReadOnly)
+		return
+	}
+
+	opts := services.ExecOptions{
+		Container: r.URL.Query().Get("container"),
+		Command:   r.URL.Query()["command"],
+		TTY:       true,
+	}
+
+	if len(opts.Command) == 0 {
+		opts.Command = defaultExecCommand
+	}
+
+	server := websocket.Server{
+		Handshake: checkSameOrigin,
+		Handler: func(ws *websocket.Conn) {
+			s.runTerminal(ws, ns, podName, opts)
+		},
+	}
+
+	server.ServeHTTP(w, r)
+}
+
+// runTerminal runs the exec session, until the command exits or the browser disconnects
+func (s *KubeviewAPI) runTerminal(ws *websocket.Conn, ns, podName string, opts services.ExecOptions) {
+	defer ws.Close()
+
+	ctx, cancel := context.WithCancel(ws.Request().Context())
+	defer cancel()
+
+	stdinReader, stdinWriter := io.Pipe()
+	resize := make(chan services.TerminalSize, 1)
+
+	// Read from the browser until it goes away, which also ends the exec session
+	go func() {
+		defer cancel()
+		defer stdinWriter.Close()
+
+		for {
+			msg := terminalMessage{}
+			if err := websocket.JSON.Receive(ws, &msg); err != nil {
+				return
+			}
+
+			switch msg.Type {
+			case "stdin":
+				if _, err := stdinWriter.Write([]byte(msg.Data)); err != nil {
+					return
+				}
+			case "resize":
+				// Only the latest size matters, so an unsent one is replaced
+				select {
+				case <-resize:
+				default:
+				}
+
+				resize <- services.TerminalSize{Width: msg.Cols, Height: msg.Rows}
+			}
+		}
+	}()
+
+	out := &terminalWriter{ws: ws}
+
+	err := s.kubeService.ExecInPod(ctx, ns, podName, opts, services.ExecStreams{
+		Stdin:  stdinReader,
+		Stdout: out,
+		Stderr: out,
+		Resize: resize,
+	})
+
+	exit := terminalMessage{Type: "exit"}
+
+	if err != nil && !errors.Is(err, context.Canceled) {
+		log.Printf("💥 Exec into pod %s in namespace %s ended with error: %v", podName, ns, err)
+
+		exit.Data = err.Error()
+	}
+
+	_ = websocket.JSON.Send(ws, exit)
+}
+
+// terminalWriter sends output to the browser as stdout messages
+// A character split across writes is held back until the rest arrives, so it isn't mangled into JSON
+type terminalWriter struct {
+	ws      *websocket.Conn
+	partial []byte
+}
+
+func (t *terminalWriter) Write(p []byte) (int, error) {
+	data := append(t.partial, p...)
+
+	// Find the start of the last character, and hold it back if it's incomplete
+	end := len(data)
+	for i := max(0, len(data)-utf8.UTFMax+1); i < len(data); i++ {
+		if utf8.RuneStart(data[i]) && !utf8.FullRune(data[i:]) {
+			end = i
+		}
+	}
+
+	t.partial = slices.Clone(data[end:])
+
+	if end == 0 {
+		return len(p), nil
+	}
+
+	if err := websocket.JSON.Send(t.ws, terminalMessage{Type: "stdout", Data: string(data[:end])}); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// checkSameOrigin stops other sites opening a terminal using the browser's access to KubeView
+func checkSameOrigin(config *websocket.Config, r *http.Request) error {
+	origin, err := url.Parse(r.Header.Get("Origin"))
+	if err != nil || origin.Host != r.Host {
+		return fmt.Errorf("origin %q not allowed", r.Header.Get("Origin"))
+	}
+
+	config.Origin = origin
+
+	return nil
+}
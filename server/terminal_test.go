@@ -0,0 +1,83 @@
+// ==========================================================================================
+// Unit tests for the web terminal
+// ==========================================================================================
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/benc-uk/go-rest-api/pkg/api"
+	"github.com/benc-uk/kubeview/server/services"
+	"github.com/go-chi/chi/v5"
+	"golang.org/x/net/websocket"
+)
+
+func TestExec_ReadOnly(t *testing.T) {
+	conf := Config{ReadOnly: true}
+	s := &KubeviewAPI{
+		Base:        api.NewBase("kubeview", "test", "test", true),
+		kubeService: &services.Kubernetes{ReadOnly: true},
+		config:      conf,
+		eventBroker: newKubeEventBroker(conf),
+	}
+
+	r := chi.NewRouter()
+	s.AddRoutes(r)
+
+	res := doRequest(t, r, "/api/exec/default/web", nil)
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status 403 in read-only mode, got %d", res.StatusCode)
+	}
+}
+
+func TestCheckSameOrigin(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://kubeview.local/api/exec/default/web", nil)
+
+	req.Header.Set("Origin", "http://kubeview.local")
+	if err := checkSameOrigin(&websocket.Config{}, req); err != nil {
+		t.Errorf("Expected same origin to be allowed, got %v", err)
+	}
+
+	req.Header.Set("Origin", "http://evil.example")
+	if err := checkSameOrigin(&websocket.Config{}, req); err == nil {
+		t.Error("Expected other origin to be rejected")
+	}
+}
+
+func TestTerminalWriter_SplitCharacter(t *testing.T) {
+	server := httptest.NewServer(websocket.Handler(func(ws *websocket.Conn) {
+		out := &terminalWriter{ws: ws}
+		snowman := []byte("☃")
+
+		_, _ = out.Write(append([]byte("hi "), snowman[:1]...))
+		_, _ = out.Write(snowman[1:])
+	}))
+	defer server.Close()
+
+	ws, err := websocket.Dial(strings.Replace(server.URL, "http", "ws", 1), "", server.URL)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer ws.Close()
+
+	got := ""
+
+	for range 2 {
+		msg := terminalMessage{}
+		if err := websocket.JSON.Receive(ws, &msg); err != nil {
+			t.Fatalf("Failed to receive: %v", err)
+		}
+
+		got += msg.Data
+	}
+
+	if got != "hi ☃" {
+		t.Errorf("Expected split character to be joined, got %q", got)
+	}
+}